@@ -0,0 +1,160 @@
+// Command dlq inspects the dead-letter topic of a Kafka topic and replays its messages back to the topic.
+//
+//	dlq list -topic trip.event.created
+//	dlq replay -topic trip.event.created [-partition 0 -offset 12] [-dry-run]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/env"
+	"github.com/cprakhar/uber-clone/shared/messaging"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+)
+
+const usage = `usage: dlq <command> [flags]
+
+commands:
+  list    print the messages in the dead-letter topic of -topic
+  replay  publish the messages in the dead-letter topic of -topic back to it
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+	if command != "list" && command != "replay" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	brokers := flags.String("brokers", env.GetString("KAFKA_BROKERS", "localhost:9092"), "comma-separated broker list")
+	topic := flags.String("topic", "", "topic whose dead letters are read, e.g. trip.event.created")
+	timeout := flags.Duration("timeout", 30*time.Second, "time allowed to read the dead-letter topic")
+	partition := flags.Int("partition", -1, "replay only the dead letters in this partition of the dead-letter topic")
+	offset := flags.Int64("offset", -1, "replay only the dead letter at this offset of -partition")
+	dryRun := flags.Bool("dry-run", false, "print the dead letters replay would publish without publishing them")
+	valueLimit := flags.Int("value-limit", 200, "longest message value list prints, 0 prints it all")
+	flags.Parse(os.Args[2:])
+
+	if *topic == "" {
+		log.Fatal("-topic is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	readCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	letters, err := kafka.ReadDeadLetters(readCtx, strings.Split(*brokers, ","), *topic)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", kafka.DeadLetterTopic(*topic), err)
+	}
+
+	switch command {
+	case "list":
+		for _, l := range letters {
+			printLetter(l, *valueLimit)
+		}
+		fmt.Printf("%d dead letters in %s\n", len(letters), kafka.DeadLetterTopic(*topic))
+	case "replay":
+		selected := selectLetters(letters, *partition, *offset)
+		if err := replay(ctx, strings.Split(*brokers, ","), selected, *dryRun); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// selectLetters returns the dead letters at the given partition and offset, where -1 matches any
+func selectLetters(letters []*kafka.DeadLetter, partition int, offset int64) []*kafka.DeadLetter {
+	var selected []*kafka.DeadLetter
+	for _, l := range letters {
+		if partition >= 0 && l.Partition != int32(partition) {
+			continue
+		}
+		if offset >= 0 && l.Offset != offset {
+			continue
+		}
+		selected = append(selected, l)
+	}
+	return selected
+}
+
+// replay publishes the dead letters back to their topics. Every run publishes them again, as
+// dead letters stay in their topic.
+func replay(ctx context.Context, brokers []string, letters []*kafka.DeadLetter, dryRun bool) error {
+	if dryRun {
+		for _, l := range letters {
+			fmt.Printf("would replay %d/%d to %s\n", l.Partition, l.Offset, l.Topic)
+		}
+		return nil
+	}
+
+	producer, err := kafka.NewProducer(brokers)
+	if err != nil {
+		return fmt.Errorf("failed to create producer: %w", err)
+	}
+	defer producer.Close()
+
+	for _, l := range letters {
+		if err := producer.Replay(ctx, l, 10*time.Second); err != nil {
+			return fmt.Errorf("failed to replay %d/%d: %w", l.Partition, l.Offset, err)
+		}
+		fmt.Printf("replayed %d/%d to %s\n", l.Partition, l.Offset, l.Topic)
+	}
+	fmt.Printf("%d dead letters replayed\n", len(letters))
+	return nil
+}
+
+func printLetter(l *kafka.DeadLetter, valueLimit int) {
+	value := readableValue(l)
+	if valueLimit > 0 && len(value) > valueLimit {
+		value = value[:valueLimit] + "..."
+	}
+
+	fmt.Printf("%d/%d from %s [%s] offset %s\n", l.Partition, l.Offset, l.Topic, l.OriginalPartition, l.OriginalOffset)
+	fmt.Printf("  group:    %s\n", l.ConsumerGroup)
+	fmt.Printf("  attempts: %d, failed at %s\n", l.Attempts, l.FailedAt.Format(time.RFC3339))
+	fmt.Printf("  error:    %s\n", l.Error)
+	fmt.Printf("  key:      %s\n", l.Key)
+	fmt.Printf("  content:  %s\n", l.ContentType)
+	fmt.Printf("  value:    %s\n", value)
+}
+
+// readableValue returns the dead letter's value as JSON, converting a protobuf envelope and its payload
+func readableValue(l *kafka.DeadLetter) string {
+	if l.ContentType == contracts.ContentTypeJSON {
+		return string(l.Value)
+	}
+
+	s, err := kafka.SerializerFor(l.ContentType)
+	if err != nil {
+		return fmt.Sprintf("%q", l.Value)
+	}
+	envelope, err := s.UnmarshalEnvelope(l.Value)
+	if err != nil {
+		return fmt.Sprintf("%q (%v)", l.Value, err)
+	}
+	if envelope.Payload, err = messaging.PayloadJSON(envelope); err != nil {
+		return fmt.Sprintf("%q (%v)", l.Value, err)
+	}
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Sprintf("%q (%v)", l.Value, err)
+	}
+	return string(value)
+}
@@ -0,0 +1,78 @@
+// Command schemas checks the protobuf payloads of the events in the messaging catalog against the
+// schema registry and registers their new versions.
+//
+//	schemas check [-dir schemas]
+//	schemas register [-dir schemas]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cprakhar/uber-clone/shared/messaging"
+	"github.com/cprakhar/uber-clone/shared/messaging/schema"
+)
+
+const usage = `usage: schemas <command> [flags]
+
+commands:
+  check     fail when an event's payload changed without a version bump, is not backward
+            compatible with its previous version, or has a version that is not registered
+  register  save the new versions of the event payloads that pass check
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+	if command != "check" && command != "register" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dir := flags.String("dir", "schemas", "directory of the schema registry")
+	flags.Parse(os.Args[2:])
+
+	registry := schema.NewRegistry(*dir)
+	failed := 0
+	for _, p := range messaging.PayloadSchemas() {
+		s := schema.FromDescriptor(p.Type, p.Version, p.Message)
+
+		switch command {
+		case "check":
+			err := registry.Check(s)
+			switch {
+			case err == nil:
+				fmt.Printf("ok         %s v%d\n", s.Subject, s.Version)
+			case errors.Is(err, schema.ErrNotRegistered):
+				fmt.Printf("new        %s v%d is not registered, run schemas register\n", s.Subject, s.Version)
+				failed++
+			default:
+				fmt.Printf("failed     %v\n", err)
+				failed++
+			}
+		case "register":
+			written, err := registry.Register(s)
+			switch {
+			case err != nil:
+				fmt.Printf("failed     %v\n", err)
+				failed++
+			case written:
+				fmt.Printf("registered %s v%d\n", s.Subject, s.Version)
+			default:
+				fmt.Printf("ok         %s v%d\n", s.Subject, s.Version)
+			}
+		}
+	}
+
+	if failed > 0 {
+		log.Fatalf("%d event schemas failed %s", failed, command)
+	}
+}
@@ -0,0 +1,30 @@
+package grpcclient
+
+import (
+	"github.com/cprakhar/uber-clone/shared/env"
+	pb "github.com/cprakhar/uber-clone/shared/proto/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type paymentServiceClient struct {
+	conn   *grpc.ClientConn
+	Client pb.PaymentServiceClient
+}
+
+// NewPaymentServiceClient creates a new gRPC client for the Payment Service.
+func NewPaymentServiceClient() (*paymentServiceClient, error) {
+	paymentServiceURL := env.GetString("PAYMENT_SERVICE_URL", "payment-service:9200")
+	conn, err := grpc.NewClient(paymentServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	client := pb.NewPaymentServiceClient(conn)
+	return &paymentServiceClient{Client: client, conn: conn}, nil
+}
+
+// Close closes the gRPC connection.
+func (c *paymentServiceClient) Close() error {
+	return c.conn.Close()
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -8,12 +9,15 @@ import (
 	"github.com/cprakhar/uber-clone/services/api-gateway/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
 	"github.com/cprakhar/uber-clone/shared/messaging"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	pbp "github.com/cprakhar/uber-clone/shared/proto/payment"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // NewHTTPHandler initializes the HTTP handler with routes and middleware
-func NewHTTPHandler(kfc *kafka.KafkaClient, connMgr *messaging.ConnectionManager) *gin.Engine {
+func NewHTTPHandler(publisher *messaging.Publisher, connMgr *messaging.ConnectionManager) *gin.Engine {
 	r := gin.Default()
 
 	r.GET("/health", healthHandler)
@@ -21,11 +25,16 @@ func NewHTTPHandler(kfc *kafka.KafkaClient, connMgr *messaging.ConnectionManager
 
 	r.POST("/trip/preview", enableCORS, previewTripHandler)
 	r.POST("/trip/start", enableCORS, tripStartHandler)
+	r.POST("/trip/cancel", enableCORS, tripCancelHandler)
+	r.GET("/payments/trip/:tripID", enableCORS, tripPaymentHandler)
+	r.GET("/payments/rider/:riderID", enableCORS, riderPaymentsHandler)
+	r.GET("/payments/driver/:driverID/earnings", enableCORS, driverEarningsHandler)
+	r.GET("/payments/driver/:driverID/payouts", enableCORS, driverPayoutsHandler)
 	r.GET("/ws/riders", func(ctx *gin.Context) {
-		RidersWSHandler(ctx, kfc, connMgr)
+		RidersWSHandler(ctx, publisher, connMgr)
 	})
 	r.GET("/ws/drivers", func(ctx *gin.Context) {
-		DriversWSHandler(ctx, kfc, connMgr)
+		DriversWSHandler(ctx, publisher, connMgr)
 	})
 
 	return r
@@ -61,9 +70,9 @@ func tripStartHandler(ctx *gin.Context) {
 	}
 	defer tripService.Close()
 
-	trip, err := tripService.Client.CreateTrip(ctx, payload.ToProto())
+	trip, err := tripService.Client.CreateTrip(idempotentContext(ctx), payload.ToProto())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start trip"})
+		grpcError(ctx, err)
 		return
 	}
 
@@ -71,6 +80,174 @@ func tripStartHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// tripCancelHandler handles trip cancellation requests from riders
+func tripCancelHandler(ctx *gin.Context) {
+	var payload types.TripCancelRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, contracts.APIResponse{
+			Error: &contracts.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "invalid request payload",
+			},
+		})
+		return
+	}
+
+	tripService, err := grpcclient.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tripService.Close()
+
+	cancellation, err := tripService.Client.CancelTrip(idempotentContext(ctx), payload.ToProto())
+	if err != nil {
+		grpcError(ctx, err)
+		return
+	}
+
+	res := contracts.APIResponse{Data: cancellation}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// tripPaymentHandler returns the latest payment collected for a trip
+func tripPaymentHandler(ctx *gin.Context) {
+	paymentService, err := grpcclient.NewPaymentServiceClient()
+	if err != nil {
+		log.Printf("Failed to create payment service client: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+	defer paymentService.Close()
+
+	payment, err := paymentService.Client.GetPaymentByTrip(ctx, &pbp.GetPaymentByTripRequest{
+		TripID: ctx.Param("tripID"),
+	})
+	if err != nil {
+		grpcError(ctx, err)
+		return
+	}
+
+	res := contracts.APIResponse{Data: payment.Payment}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// riderPaymentsHandler lists a rider's payments, newest first.
+// The "limit" and "before" (Unix milliseconds) query parameters page through older payments.
+func riderPaymentsHandler(ctx *gin.Context) {
+	var query types.RiderPaymentsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, contracts.APIResponse{
+			Error: &contracts.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "invalid query parameters",
+			},
+		})
+		return
+	}
+
+	paymentService, err := grpcclient.NewPaymentServiceClient()
+	if err != nil {
+		log.Printf("Failed to create payment service client: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payments"})
+		return
+	}
+	defer paymentService.Close()
+
+	payments, err := paymentService.Client.ListPaymentsByRider(ctx, query.ToProto(ctx.Param("riderID")))
+	if err != nil {
+		grpcError(ctx, err)
+		return
+	}
+
+	res := contracts.APIResponse{Data: payments.Payments}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// driverEarningsHandler sums a driver's earnings by day, week or trip, oldest first.
+// The "period", "from" and "to" (Unix milliseconds) query parameters default to days of the last 30 days.
+func driverEarningsHandler(ctx *gin.Context) {
+	var query types.DriverEarningsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, contracts.APIResponse{
+			Error: &contracts.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "invalid query parameters",
+			},
+		})
+		return
+	}
+
+	paymentService, err := grpcclient.NewPaymentServiceClient()
+	if err != nil {
+		log.Printf("Failed to create payment service client: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get earnings"})
+		return
+	}
+	defer paymentService.Close()
+
+	earnings, err := paymentService.Client.GetDriverEarnings(ctx, query.ToProto(ctx.Param("driverID")))
+	if err != nil {
+		grpcError(ctx, err)
+		return
+	}
+
+	res := contracts.APIResponse{Data: earnings.Earnings}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// driverPayoutsHandler lists a driver's payouts, newest first.
+// The "limit" and "before" (Unix milliseconds) query parameters page through older payouts.
+func driverPayoutsHandler(ctx *gin.Context) {
+	var query types.DriverPayoutsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, contracts.APIResponse{
+			Error: &contracts.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "invalid query parameters",
+			},
+		})
+		return
+	}
+
+	paymentService, err := grpcclient.NewPaymentServiceClient()
+	if err != nil {
+		log.Printf("Failed to create payment service client: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payouts"})
+		return
+	}
+	defer paymentService.Close()
+
+	payouts, err := paymentService.Client.ListDriverPayouts(ctx, query.ToProto(ctx.Param("driverID")))
+	if err != nil {
+		grpcError(ctx, err)
+		return
+	}
+
+	res := contracts.APIResponse{Data: payouts.Payouts}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// grpcError writes the HTTP error matching the error of a gRPC call to a service
+func grpcError(ctx *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	switch status.Code(err) {
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.FailedPrecondition, codes.Aborted:
+		code = http.StatusConflict
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	}
+	ctx.JSON(code, contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    code,
+			Message: status.Convert(err).Message(),
+		},
+	})
+}
+
 // previewTripHandler handles trip preview requests
 func previewTripHandler(ctx *gin.Context) {
 	var payload types.PreviewTripRequest
@@ -95,9 +272,10 @@ func previewTripHandler(ctx *gin.Context) {
 	}
 	defer tripService.Close()
 
+	// Previews only read, so they need no idempotency key
 	tripPreview, err := tripService.Client.PreviewTrip(ctx, payload.ToProto())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview trip"})
+		grpcError(ctx, err)
 		return
 	}
 
@@ -105,11 +283,21 @@ func previewTripHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// idempotentContext forwards the Idempotency-Key header of the request to the gRPC call, so a
+// retried POST returns the first result instead of being carried out again
+func idempotentContext(ctx *gin.Context) context.Context {
+	key := ctx.GetHeader(contracts.IdempotencyKeyHeader)
+	if key == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, contracts.IdempotencyKeyMetadata, key)
+}
+
 // enableCORS is a middleware to handle CORS requests
 func enableCORS(ctx *gin.Context) {
 	ctx.Header("Access-Control-Allow-Origin", "*")
 	ctx.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	ctx.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	ctx.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+contracts.IdempotencyKeyHeader)
 
 	if ctx.Request.Method == "OPTIONS" {
 		ctx.Writer.WriteHeader(http.StatusOK)
@@ -1,19 +1,24 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	grpcclient "github.com/cprakhar/uber-clone/services/api-gateway/grpc-client"
+	"github.com/cprakhar/uber-clone/services/api-gateway/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
 	"github.com/cprakhar/uber-clone/shared/messaging"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 	"github.com/cprakhar/uber-clone/shared/proto/driver"
+	"github.com/cprakhar/uber-clone/shared/proto/trip"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // RidersWSHandler handles WebSocket connections for riders
-func RidersWSHandler(ctx *gin.Context, kfc *kafka.KafkaClient, connManager *messaging.ConnectionManager) {
+func RidersWSHandler(ctx *gin.Context, publisher *messaging.Publisher, connManager *messaging.ConnectionManager) {
 	conn, err := connManager.Upgrade(ctx.Writer, ctx.Request)
 	if err != nil {
 		log.Printf("Websocket upgrade failed: %v", err)
@@ -42,7 +47,7 @@ func RidersWSHandler(ctx *gin.Context, kfc *kafka.KafkaClient, connManager *mess
 }
 
 // DriversWSHandler handles WebSocket connections for drivers
-func DriversWSHandler(ctx *gin.Context, kfc *kafka.KafkaClient, connManager *messaging.ConnectionManager) {
+func DriversWSHandler(ctx *gin.Context, publisher *messaging.Publisher, connManager *messaging.ConnectionManager) {
 	conn, err := connManager.Upgrade(ctx.Writer, ctx.Request)
 	if err != nil {
 		log.Printf("Websocket upgrade failed: %v", err)
@@ -73,13 +78,17 @@ func DriversWSHandler(ctx *gin.Context, kfc *kafka.KafkaClient, connManager *mes
 	defer func() {
 		connManager.Remove(driverID)
 
-		driverService.Client.UnregisterDriver(ctx, &driver.RegisterDriverRequest{
+		// Drivers on a trip stay registered, so they are not matched again when they reconnect
+		if _, err := driverService.Client.UnregisterDriver(ctx, &driver.RegisterDriverRequest{
 			DriverID:    driverID,
 			PackageSlug: packageSlug,
-		})
+		}); err != nil {
+			log.Printf("Failed to unregister driver %s: %v", driverID, err)
+		} else {
+			log.Printf("Driver unregistered %s: ", driverID)
+		}
 
 		driverService.Close()
-		log.Printf("Driver unregistered %s: ", driverID)
 	}()
 
 	driver, err := driverService.Client.RegisterDriver(ctx, &driver.RegisterDriverRequest{
@@ -121,18 +130,140 @@ func DriversWSHandler(ctx *gin.Context, kfc *kafka.KafkaClient, connManager *mes
 
 		switch dm.Type {
 		case contracts.DriverCmdLocation:
-			// Update driver location in the system
-			continue
+			handleDriverLocation(ctx, driverService.Client, driverID, dm.Data)
 		case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline:
 			// Notify trip service about trip acceptance/decline
-			if err := kfc.Producer.SendMessage(dm.Type, &contracts.KafkaMessage{
-				EntityID: driverID,
-				Data:     dm.Data,
-			}); err != nil {
-				log.Printf("Failed to send message to trip service: %v", err)
-			}
+			handleDriverTripResponse(ctx, publisher, driverID, dm.Type, dm.Data)
+		case contracts.DriverCmdAvailability:
+			handleDriverAvailability(ctx, connManager, driverService.Client, driverID, dm.Data)
+		case contracts.DriverCmdTripCancel:
+			handleDriverTripCancel(ctx, driverID, dm.Data)
+		case contracts.DriverCmdTripArrived, contracts.DriverCmdTripStart, contracts.DriverCmdTripComplete:
+			handleDriverTripAction(ctx, connManager, driverID, dm.Type, dm.Data)
 		default:
 			log.Printf("Unknown message type from driver %s: %s", driverID, dm.Type)
 		}
 	}
 }
+
+// handleDriverLocation validates a location message from a driver and forwards it to the driver service
+func handleDriverLocation(ctx context.Context, client driver.DriverServiceClient, driverID string, data json.RawMessage) {
+	var msg types.DriverLocationMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("Invalid location message from driver %s: %v", driverID, err)
+		return
+	}
+	if err := msg.Validate(); err != nil {
+		log.Printf("Invalid location message from driver %s: %v", driverID, err)
+		return
+	}
+
+	_, err := client.UpdateLocation(ctx, msg.ToProto(driverID, time.Now()))
+	if status.Code(err) == codes.ResourceExhausted {
+		// Drivers send updates faster than they are accepted, dropping the extra ones is expected
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update location of driver %s: %v", driverID, err)
+	}
+}
+
+// handleDriverAvailability changes whether the driver is offered trips, e.g. going on a break,
+// and sends the updated driver back to them
+func handleDriverAvailability(ctx context.Context, connManager *messaging.ConnectionManager, client driver.DriverServiceClient, driverID string, data json.RawMessage) {
+	var msg types.DriverAvailabilityMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Availability == "" {
+		log.Printf("Invalid availability message from driver %s: %s", driverID, data)
+		return
+	}
+
+	res, err := client.SetAvailability(ctx, msg.ToProto(driverID))
+	if err != nil {
+		log.Printf("Failed to set availability of driver %s to %s: %v", driverID, msg.Availability, err)
+		return
+	}
+
+	if err := connManager.SendMessage(driverID, contracts.WSMessage{Type: contracts.DriverCmdAvailability, Data: res.Driver}); err != nil {
+		log.Printf("Failed to send availability to driver %s: %v", driverID, err)
+	}
+}
+
+// driverTripResponses maps the driver's answers to a trip offer to the commands sent to the trip service
+var driverTripResponses = map[string]messaging.Event[messaging.DriverTripResponseData]{
+	contracts.DriverCmdTripAccept:  messaging.DriverTripAccept,
+	contracts.DriverCmdTripDecline: messaging.DriverTripDecline,
+}
+
+// handleDriverTripResponse forwards the driver's answer to a trip offer to the trip service
+func handleDriverTripResponse(ctx context.Context, publisher *messaging.Publisher, driverID, msgType string, data json.RawMessage) {
+	var msg messaging.DriverTripResponseData
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("Invalid %s message from driver %s: %s", msgType, driverID, data)
+		return
+	}
+
+	if err := messaging.Publish(ctx, publisher, driverTripResponses[msgType], driverID, msg); err != nil {
+		log.Printf("Failed to send message to trip service: %v", err)
+	}
+}
+
+// handleDriverTripCancel cancels the driver's trip through the trip service
+func handleDriverTripCancel(ctx context.Context, driverID string, data json.RawMessage) {
+	var msg types.DriverTripCancelMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.TripID == "" {
+		log.Printf("Invalid trip cancel message from driver %s: %s", driverID, data)
+		return
+	}
+
+	tripService, err := grpcclient.NewTripServiceClient()
+	if err != nil {
+		log.Printf("Failed to create trip service client: %v", err)
+		return
+	}
+	defer tripService.Close()
+
+	if _, err := tripService.Client.CancelTrip(ctx, msg.ToProto(driverID)); err != nil {
+		log.Printf("Failed to cancel trip %s for driver %s: %v", msg.TripID, driverID, err)
+	}
+}
+
+// handleDriverTripAction moves the driver's trip through pickup, start and drop-off,
+// and sends the resulting trip event back to the driver
+func handleDriverTripAction(ctx context.Context, connManager *messaging.ConnectionManager, driverID, msgType string, data json.RawMessage) {
+	var msg types.DriverTripActionMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.TripID == "" {
+		log.Printf("Invalid %s message from driver %s: %s", msgType, driverID, data)
+		return
+	}
+
+	tripService, err := grpcclient.NewTripServiceClient()
+	if err != nil {
+		log.Printf("Failed to create trip service client: %v", err)
+		return
+	}
+	defer tripService.Close()
+
+	var (
+		res   *trip.TripDriverActionResponse
+		event string
+	)
+	switch msgType {
+	case contracts.DriverCmdTripArrived:
+		res, err = tripService.Client.DriverArrived(ctx, msg.ToProto(driverID))
+		event = contracts.TripEventDriverArrived
+	case contracts.DriverCmdTripStart:
+		res, err = tripService.Client.StartTrip(ctx, msg.ToProto(driverID))
+		event = contracts.TripEventStarted
+	case contracts.DriverCmdTripComplete:
+		res, err = tripService.Client.CompleteTrip(ctx, msg.ToProto(driverID))
+		event = contracts.TripEventCompleted
+	}
+	if err != nil {
+		log.Printf("Failed to handle %s for trip %s from driver %s: %v", msgType, msg.TripID, driverID, err)
+		return
+	}
+
+	if err := connManager.SendMessage(driverID, contracts.WSMessage{Type: event, Data: res.Trip}); err != nil {
+		log.Printf("Failed to send %s to driver %s: %v", event, driverID, err)
+	}
+}
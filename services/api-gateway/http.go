@@ -9,24 +9,23 @@ import (
 
 	"github.com/cprakhar/uber-clone/services/api-gateway/handler"
 	"github.com/cprakhar/uber-clone/shared/messaging"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 )
 
 type httpServer struct {
 	addr        string
-	kfClient    *kafka.KafkaClient
+	publisher   *messaging.Publisher
 	connManager *messaging.ConnectionManager
 }
 
 // NewhttpServer creates a new http server instance
-func NewhttpServer(addr string, kfc *kafka.KafkaClient, connMgr *messaging.ConnectionManager) *httpServer {
-	return &httpServer{addr: addr, kfClient: kfc, connManager: connMgr}
+func NewhttpServer(addr string, publisher *messaging.Publisher, connMgr *messaging.ConnectionManager) *httpServer {
+	return &httpServer{addr: addr, publisher: publisher, connManager: connMgr}
 }
 
 // run starts the http server
 func (s *httpServer) run(ctx context.Context) error {
 	// http server setup
-	h := handler.NewHTTPHandler(s.kfClient, s.connManager)
+	h := handler.NewHTTPHandler(s.publisher, s.connManager)
 	srv := &http.Server{
 		Addr:    s.addr,
 		Handler: h,
@@ -14,14 +14,24 @@ import (
 )
 
 var (
-	httpAddr = env.GetString("HTTP_ADDR", ":8080")
-	brokers  = []string{"kafka:9092"}
-	groupID  = "api-gateway-group"
-	topics   = []string{
+	httpAddr    = env.GetString("HTTP_ADDR", ":8080")
+	brokers     = []string{"kafka:9092"}
+	groupID     = "api-gateway-group"
+	serviceName = "api-gateway"
+	topics      = []string{
+		contracts.TripEventCreated,
 		contracts.TripEventNoDriversFound,
 		contracts.TripEventDriverAssigned,
+		contracts.TripEventDriverArrived,
+		contracts.TripEventStarted,
+		contracts.TripEventCompleted,
+		contracts.TripEventCancelled,
+		contracts.TripEventPaymentFailed,
+		contracts.TripEventPaid,
 		contracts.DriverCmdTripRequest,
+		contracts.DriverCmdLocation,
 		contracts.PaymentEventSessionCreated,
+		contracts.PaymentEventRefunded,
 	}
 
 	connManager = messaging.NewConnectionManager()
@@ -40,7 +50,9 @@ func main() {
 	log.Println("Kafka client connected")
 
 	topicConsumer := messaging.NewTopicConsumer(kfClient, connManager, topics)
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := topicConsumer.Consume(ctx); err != nil && ctx.Err() == nil {
 			log.Printf("Error consuming topics: %v", err)
 		}
@@ -48,7 +60,7 @@ func main() {
 	}()
 
 	// Start http server
-	httpServer := NewhttpServer(httpAddr, kfClient, connManager)
+	httpServer := NewhttpServer(httpAddr, messaging.NewPublisher(kfClient.Producer, serviceName), connManager)
 	go func() {
 		if err := httpServer.run(ctx); err != nil && ctx.Err() == nil {
 			log.Printf("http server error: %v", err)
@@ -59,4 +71,6 @@ func main() {
 	// Wait for shutdown signal
 	<-ctx.Done()
 	log.Println("Shutdown signal received, exiting...")
+	// Let the consumer finish the messages it is handling before the Kafka client closes
+	<-consumerDone
 }
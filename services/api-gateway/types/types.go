@@ -1,6 +1,13 @@
 package types
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	pbp "github.com/cprakhar/uber-clone/shared/proto/payment"
 	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
 	"github.com/cprakhar/uber-clone/shared/types"
 )
@@ -38,3 +45,138 @@ func (tsr *TripStartRequest) ToProto() *pb.CreateTripRequest {
 		RideFareID: tsr.FareID,
 	}
 }
+
+type DriverLocationMessage struct {
+	Location  *types.Coordinate `json:"location"`
+	Timestamp int64             `json:"timestamp"` // Unix milliseconds, defaults to the time the message was received
+}
+
+// Validate checks that the message carries a real coordinate
+func (dlm *DriverLocationMessage) Validate() error {
+	if dlm.Location == nil {
+		return errors.New("location is required")
+	}
+	lat, lng := dlm.Location.Latitude, dlm.Location.Longitude
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return fmt.Errorf("location (%f, %f) is out of range", lat, lng)
+	}
+	if dlm.Timestamp < 0 {
+		return fmt.Errorf("invalid timestamp %d", dlm.Timestamp)
+	}
+	return nil
+}
+
+// ToProto converts DriverLocationMessage to its protobuf representation
+func (dlm *DriverLocationMessage) ToProto(driverID string, receivedAt time.Time) *pbd.UpdateLocationRequest {
+	timestamp := dlm.Timestamp
+	if timestamp == 0 {
+		timestamp = receivedAt.UnixMilli()
+	}
+	return &pbd.UpdateLocationRequest{
+		DriverID: driverID,
+		Location: &pbd.Location{
+			Latitude:  dlm.Location.Latitude,
+			Longitude: dlm.Location.Longitude,
+		},
+		Timestamp: timestamp,
+	}
+}
+
+type TripCancelRequest struct {
+	TripID  string `json:"tripID" binding:"required"`
+	RiderID string `json:"riderID" binding:"required"`
+	Reason  string `json:"reason"`
+}
+
+// ToProto converts TripCancelRequest to its protobuf representation
+func (tcr *TripCancelRequest) ToProto() *pb.CancelTripRequest {
+	return &pb.CancelTripRequest{
+		TripID:      tcr.TripID,
+		CancelledBy: "rider",
+		RequesterID: tcr.RiderID,
+		Reason:      tcr.Reason,
+	}
+}
+
+type DriverTripCancelMessage struct {
+	TripID string `json:"tripID"`
+	Reason string `json:"reason"`
+}
+
+// ToProto converts DriverTripCancelMessage to its protobuf representation
+func (dtcm *DriverTripCancelMessage) ToProto(driverID string) *pb.CancelTripRequest {
+	return &pb.CancelTripRequest{
+		TripID:      dtcm.TripID,
+		CancelledBy: "driver",
+		RequesterID: driverID,
+		Reason:      dtcm.Reason,
+	}
+}
+
+type DriverTripActionMessage struct {
+	TripID string `json:"tripID"`
+}
+
+// ToProto converts DriverTripActionMessage to its protobuf representation
+func (dtam *DriverTripActionMessage) ToProto(driverID string) *pb.TripDriverActionRequest {
+	return &pb.TripDriverActionRequest{
+		TripID:   dtam.TripID,
+		DriverID: driverID,
+	}
+}
+
+type DriverAvailabilityMessage struct {
+	Availability string `json:"availability"`
+}
+
+// ToProto converts DriverAvailabilityMessage to its protobuf representation
+func (dam *DriverAvailabilityMessage) ToProto(driverID string) *pbd.SetAvailabilityRequest {
+	return &pbd.SetAvailabilityRequest{
+		DriverID:     driverID,
+		Availability: dam.Availability,
+	}
+}
+
+type RiderPaymentsQuery struct {
+	Limit  int32 `form:"limit"`
+	Before int64 `form:"before"` // Unix milliseconds
+}
+
+// ToProto converts RiderPaymentsQuery to its protobuf representation
+func (rpq *RiderPaymentsQuery) ToProto(riderID string) *pbp.ListPaymentsByRiderRequest {
+	return &pbp.ListPaymentsByRiderRequest{
+		RiderID: riderID,
+		Limit:   rpq.Limit,
+		Before:  rpq.Before,
+	}
+}
+
+type DriverEarningsQuery struct {
+	Period string `form:"period"` // "day", "week" or "trip"
+	From   int64  `form:"from"`   // Unix milliseconds
+	To     int64  `form:"to"`     // Unix milliseconds
+}
+
+// ToProto converts DriverEarningsQuery to its protobuf representation
+func (deq *DriverEarningsQuery) ToProto(driverID string) *pbp.GetDriverEarningsRequest {
+	return &pbp.GetDriverEarningsRequest{
+		DriverID: driverID,
+		Period:   deq.Period,
+		From:     deq.From,
+		To:       deq.To,
+	}
+}
+
+type DriverPayoutsQuery struct {
+	Limit  int32 `form:"limit"`
+	Before int64 `form:"before"` // Unix milliseconds
+}
+
+// ToProto converts DriverPayoutsQuery to its protobuf representation
+func (dpq *DriverPayoutsQuery) ToProto(driverID string) *pbp.ListDriverPayoutsRequest {
+	return &pbp.ListDriverPayoutsRequest{
+		DriverID: driverID,
+		Limit:    dpq.Limit,
+		Before:   dpq.Before,
+	}
+}
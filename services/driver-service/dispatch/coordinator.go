@@ -0,0 +1,274 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+)
+
+// DriverPool finds the drivers that can be offered a trip, closest first, and reserves them while they hold an offer
+type DriverPool interface {
+	FindAvailableDrivers(ctx context.Context, packageSlug string, pickup *pbd.Location) []*types.DriverCandidate
+	OfferTrip(ctx context.Context, driverID string) error
+	ReleaseOffer(ctx context.Context, driverID string) error
+}
+
+// Publisher notifies drivers and other services about the progress of a dispatch
+type Publisher interface {
+	PublishTripRequest(ctx context.Context, driverID string, trip *pb.Trip) error
+	PublishNoDriversFound(ctx context.Context, trip *pb.Trip) error
+}
+
+// ErrNotRunning is returned when a trip is handed to a coordinator that was not started or was stopped
+var ErrNotRunning = errors.New("dispatch coordinator is not running")
+
+// tripDispatch is the state of a single trip being offered to drivers
+type tripDispatch struct {
+	trip     *pb.Trip
+	tried    map[string]struct{} // drivers who declined or let the offer expire
+	attempts int
+	deadline time.Time
+	current  string      // driver holding the outstanding offer
+	timer    *time.Timer // expires the outstanding offer, or looks for candidates again while there is none
+}
+
+// Coordinator offers trips to ranked candidates one at a time.
+// A driver has OfferTTL to respond before the next candidate is offered the trip. Drivers who
+// declined or timed out are not offered the same trip again, and a driver only holds one offer at
+// a time. While no driver can be offered the trip, candidates are looked for again every RetryInterval.
+// Dispatch gives up after MaxAttempts offers or once the Deadline has passed.
+//
+// Trips are dispatched between Start and Stop, under the context given to Start.
+type Coordinator struct {
+	mu        sync.Mutex
+	ctx       context.Context // the coordinator's lifecycle, nil until it is started
+	cancel    context.CancelFunc
+	cfg       *types.DispatchConfig
+	drivers   DriverPool
+	publisher Publisher
+	trips     map[string]*tripDispatch // trip ID -> dispatch state
+	offers    map[string]string        // driver ID -> trip ID of the outstanding offer
+}
+
+// NewCoordinator creates a new Coordinator
+func NewCoordinator(cfg *types.DispatchConfig, drivers DriverPool, publisher Publisher) *Coordinator {
+	return &Coordinator{
+		cfg:       cfg,
+		drivers:   drivers,
+		publisher: publisher,
+		trips:     make(map[string]*tripDispatch),
+		offers:    make(map[string]string),
+	}
+}
+
+// Start lets the coordinator dispatch trips until Stop is called. Offers and their expiry outlive
+// the messages that started them, so they run under ctx rather than the context of a message.
+func (c *Coordinator) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx, c.cancel = context.WithCancel(ctx)
+}
+
+// Stop ends every dispatch in progress, releasing the drivers holding an offer, and stops taking trips
+func (c *Coordinator) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx == nil {
+		return
+	}
+
+	for tripID, d := range c.trips {
+		c.endOffer(d)
+		delete(c.trips, tripID)
+	}
+	c.cancel()
+}
+
+// Dispatch starts offering the trip to drivers. Redelivered trips already being dispatched are ignored.
+// It returns ErrNotRunning outside of Start and Stop.
+func (c *Coordinator) Dispatch(trip *pb.Trip) error {
+	c.mu.Lock()
+	if c.ctx == nil || c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return ErrNotRunning
+	}
+	if _, ok := c.trips[trip.Id]; ok {
+		c.mu.Unlock()
+		log.Printf("Trip %s is already being dispatched", trip.Id)
+		return nil
+	}
+	c.trips[trip.Id] = &tripDispatch{
+		trip:     trip,
+		tried:    make(map[string]struct{}),
+		deadline: time.Now().Add(c.cfg.Deadline),
+	}
+	c.mu.Unlock()
+
+	c.offerNext(trip.Id)
+	return nil
+}
+
+// Declined moves the trip on to the next candidate after a driver declined it
+func (c *Coordinator) Declined(tripID, driverID string) {
+	c.mu.Lock()
+	d, ok := c.trips[tripID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	d.tried[driverID] = struct{}{}
+	if d.current != driverID {
+		// A stale decline for an offer that already moved on
+		c.mu.Unlock()
+		return
+	}
+	c.endOffer(d)
+	c.mu.Unlock()
+
+	log.Printf("Driver %s declined trip %s", driverID, tripID)
+	c.offerNext(tripID)
+}
+
+// Withdraw moves a trip offered to the driver on to the next candidate, e.g. when the driver disconnects
+func (c *Coordinator) Withdraw(driverID string) {
+	c.mu.Lock()
+	tripID, ok := c.offers[driverID]
+	c.mu.Unlock()
+
+	if ok {
+		c.Declined(tripID, driverID)
+	}
+}
+
+// StopDispatch ends the dispatch of a trip, e.g. once a driver is assigned or the trip is cancelled
+func (c *Coordinator) StopDispatch(tripID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.trips[tripID]
+	if !ok {
+		return
+	}
+	c.endOffer(d)
+	delete(c.trips, tripID)
+}
+
+// expire moves the trip on to the next candidate when a driver did not respond in time
+func (c *Coordinator) expire(tripID, driverID string) {
+	c.mu.Lock()
+	d, ok := c.trips[tripID]
+	if !ok || d.current != driverID {
+		c.mu.Unlock()
+		return
+	}
+	d.tried[driverID] = struct{}{}
+	c.endOffer(d)
+	c.mu.Unlock()
+
+	log.Printf("Offer of trip %s to driver %s expired", tripID, driverID)
+	c.offerNext(tripID)
+}
+
+// offerNext offers the trip to the closest candidate who has not been tried yet, looks again later
+// when there is none, or gives up on the trip when the attempts or the deadline are exhausted.
+func (c *Coordinator) offerNext(tripID string) {
+	c.mu.Lock()
+	d, ok := c.trips[tripID]
+	if !ok || d.current != "" {
+		c.mu.Unlock()
+		return
+	}
+
+	remaining := time.Until(d.deadline)
+	exhausted := d.attempts >= c.cfg.MaxAttempts || remaining <= 0
+	var driverID string
+	if !exhausted {
+		driverID = c.nextCandidate(d)
+	}
+
+	if driverID == "" && !exhausted {
+		// Every candidate was tried or holds another offer for now
+		wait := min(c.cfg.RetryInterval, remaining)
+		d.timer = time.AfterFunc(wait, func() {
+			c.offerNext(tripID)
+		})
+		c.mu.Unlock()
+
+		log.Printf("No driver available for trip %s yet, looking again in %s", tripID, wait)
+		return
+	}
+
+	ctx := c.ctx
+	if driverID == "" {
+		delete(c.trips, tripID)
+		c.mu.Unlock()
+
+		log.Printf("No drivers found for trip %s after %d offers", tripID, d.attempts)
+		if err := c.publisher.PublishNoDriversFound(ctx, d.trip); err != nil {
+			log.Printf("Failed to publish no drivers found for trip %s: %v", tripID, err)
+		}
+		return
+	}
+
+	d.attempts++
+	attempt := d.attempts
+	d.current = driverID
+	c.offers[driverID] = tripID
+	d.timer = time.AfterFunc(min(c.cfg.OfferTTL, remaining), func() {
+		c.expire(tripID, driverID)
+	})
+	c.mu.Unlock()
+
+	log.Printf("Offering trip %s to driver %s (attempt %d)", tripID, driverID, attempt)
+	if err := c.publisher.PublishTripRequest(ctx, driverID, d.trip); err != nil {
+		log.Printf("Failed to offer trip %s to driver %s: %v", tripID, driverID, err)
+	}
+}
+
+// nextCandidate reserves and returns the closest driver who was not tried for the trip and holds no other offer.
+// The caller must hold the lock.
+func (c *Coordinator) nextCandidate(d *tripDispatch) string {
+	var pickup *pbd.Location
+	if p := d.trip.GetPickup(); p != nil {
+		pickup = &pbd.Location{Latitude: p.Latitude, Longitude: p.Longitude}
+	}
+
+	candidates := c.drivers.FindAvailableDrivers(c.ctx, d.trip.GetSelectedFare().GetPackageSlug(), pickup)
+	for _, candidate := range candidates {
+		id := candidate.Driver.Id
+		if _, tried := d.tried[id]; tried {
+			continue
+		}
+		if _, offered := c.offers[id]; offered {
+			continue
+		}
+		if err := c.drivers.OfferTrip(c.ctx, id); err != nil {
+			// The driver became unavailable since they were found
+			log.Printf("Skipping driver %s for trip %s: %v", id, d.trip.Id, err)
+			continue
+		}
+		return id
+	}
+	return ""
+}
+
+// endOffer clears the outstanding offer of a trip. The caller must hold the lock.
+func (c *Coordinator) endOffer(d *tripDispatch) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.current != "" {
+		if err := c.drivers.ReleaseOffer(c.ctx, d.current); err != nil {
+			log.Printf("Failed to release offer of driver %s: %v", d.current, err)
+		}
+		delete(c.offers, d.current)
+		d.current = ""
+	}
+}
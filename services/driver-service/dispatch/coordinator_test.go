@@ -0,0 +1,317 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+)
+
+// noDrivers is the driver ID of the offers channel for a "no drivers found" event
+const noDrivers = "none"
+
+type offer struct {
+	tripID   string
+	driverID string
+}
+
+func TestCoordinator(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         types.DispatchConfig
+		drivers     []string // available, closest first
+		trips       int      // dispatched in turn, trip-0 first
+		respond     func(c *Coordinator, o offer)
+		want        []offer
+		stillOffers []string // drivers holding an offer at the end
+	}{
+		{
+			name:    "declined offers move to the next closest driver",
+			cfg:     types.DispatchConfig{MaxAttempts: 3},
+			drivers: []string{"d1", "d2", "d3"},
+			trips:   1,
+			respond: func(c *Coordinator, o offer) { c.Declined(o.tripID, o.driverID) },
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", "d2"}, {"trip-0", "d3"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "expired offers move to the next closest driver",
+			cfg:     types.DispatchConfig{OfferTTL: 10 * time.Millisecond, MaxAttempts: 2},
+			drivers: []string{"d1", "d2"},
+			trips:   1,
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", "d2"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "withdrawn offers move to the next closest driver",
+			cfg:     types.DispatchConfig{MaxAttempts: 2},
+			drivers: []string{"d1", "d2"},
+			trips:   1,
+			respond: func(c *Coordinator, o offer) { c.Withdraw(o.driverID) },
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", "d2"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "gives up after max attempts",
+			cfg:     types.DispatchConfig{MaxAttempts: 2},
+			drivers: []string{"d1", "d2", "d3"},
+			trips:   1,
+			respond: func(c *Coordinator, o offer) { c.Declined(o.tripID, o.driverID) },
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", "d2"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "gives up once the deadline passed",
+			cfg:     types.DispatchConfig{OfferTTL: time.Minute, Deadline: 10 * time.Millisecond},
+			drivers: []string{"d1", "d2"},
+			trips:   1,
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "keeps looking until the deadline once every driver was tried",
+			cfg:     types.DispatchConfig{Deadline: 50 * time.Millisecond},
+			drivers: []string{"d1"},
+			trips:   1,
+			respond: func(c *Coordinator, o offer) { c.Declined(o.tripID, o.driverID) },
+			want:    []offer{{"trip-0", "d1"}, {"trip-0", noDrivers}},
+		},
+		{
+			name:    "a driver holds one offer at a time",
+			drivers: []string{"d1", "d2"},
+			trips:   3,
+			respond: func(c *Coordinator, o offer) {
+				// The driver of trip-0 becomes free for the waiting trip-2
+				if o.tripID == "trip-0" {
+					c.StopDispatch(o.tripID)
+				}
+			},
+			want:        []offer{{"trip-0", "d1"}, {"trip-1", "d2"}, {"trip-2", "d1"}},
+			stillOffers: []string{"d1", "d2"},
+		},
+		{
+			name:        "stopped dispatches release the driver",
+			drivers:     []string{"d1", "d2"},
+			trips:       1,
+			respond:     func(c *Coordinator, o offer) { c.StopDispatch(o.tripID) },
+			want:        []offer{{"trip-0", "d1"}},
+			stillOffers: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.DispatchConfig{OfferTTL: time.Minute, MaxAttempts: 5, Deadline: time.Minute, RetryInterval: 5 * time.Millisecond}
+			if tt.cfg.OfferTTL > 0 {
+				cfg.OfferTTL = tt.cfg.OfferTTL
+			}
+			if tt.cfg.MaxAttempts > 0 {
+				cfg.MaxAttempts = tt.cfg.MaxAttempts
+			}
+			if tt.cfg.Deadline > 0 {
+				cfg.Deadline = tt.cfg.Deadline
+			}
+			pool := newFakePool(tt.drivers...)
+			publisher := newFakePublisher()
+			c := NewCoordinator(cfg, pool, publisher)
+			c.Start(t.Context())
+			defer c.Stop()
+
+			for i := range tt.trips {
+				if err := c.Dispatch(newTrip(i)); err != nil {
+					t.Fatalf("Dispatch: %v", err)
+				}
+			}
+
+			var got []offer
+			for len(got) < len(tt.want) {
+				o := publisher.next(t)
+				got = append(got, o)
+				if tt.respond != nil && o.driverID != noDrivers {
+					tt.respond(c, o)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("offers = %v, want %v", got, tt.want)
+			}
+			publisher.none(t)
+
+			if tt.stillOffers != nil {
+				if offered := pool.offered(); !slices.Equal(offered, tt.stillOffers) {
+					t.Errorf("drivers holding an offer = %v, want %v", offered, tt.stillOffers)
+				}
+			}
+		})
+	}
+}
+
+func TestCoordinatorRedelivery(t *testing.T) {
+	publisher := newFakePublisher()
+	c := NewCoordinator(&types.DispatchConfig{OfferTTL: time.Minute, MaxAttempts: 5, Deadline: time.Minute, RetryInterval: 5 * time.Millisecond}, newFakePool("d1", "d2"), publisher)
+	c.Start(t.Context())
+	defer c.Stop()
+
+	trip := newTrip(0)
+	for range 2 {
+		if err := c.Dispatch(trip); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+	if got := publisher.next(t); got != (offer{"trip-0", "d1"}) {
+		t.Errorf("offer = %v, want trip-0 to d1", got)
+	}
+	publisher.none(t)
+}
+
+func TestCoordinatorLifecycle(t *testing.T) {
+	pool := newFakePool("d1", "d2")
+	publisher := newFakePublisher()
+	c := NewCoordinator(&types.DispatchConfig{OfferTTL: 10 * time.Millisecond, MaxAttempts: 5, Deadline: time.Minute, RetryInterval: 5 * time.Millisecond}, pool, publisher)
+
+	if err := c.Dispatch(newTrip(0)); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Dispatch before Start: got %v, want ErrNotRunning", err)
+	}
+
+	c.Start(context.Background())
+	if err := c.Dispatch(newTrip(0)); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	publisher.next(t)
+	if got := publisher.next(t); got != (offer{"trip-0", "d2"}) {
+		t.Fatalf("offer after the first expired = %v, want trip-0 to d2", got)
+	}
+
+	c.Stop()
+	if offered := pool.offered(); len(offered) != 0 {
+		t.Errorf("drivers holding an offer after Stop = %v, want none", offered)
+	}
+	if err := publisher.lastContext().Err(); err == nil {
+		t.Error("publisher's context not cancelled after Stop")
+	}
+	// The expiry of the last offer does not move the stopped dispatch on
+	publisher.none(t)
+
+	if err := c.Dispatch(newTrip(1)); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Dispatch after Stop: got %v, want ErrNotRunning", err)
+	}
+}
+
+func newTrip(i int) *pb.Trip {
+	return &pb.Trip{
+		Id:           fmt.Sprintf("trip-%d", i),
+		Pickup:       &pb.Coordinate{Latitude: 12.97, Longitude: 77.59},
+		SelectedFare: &pb.RideFare{PackageSlug: "sedan"},
+	}
+}
+
+// fakePool offers trips to a fixed list of drivers, reserving each while they hold an offer
+type fakePool struct {
+	mu       sync.Mutex
+	drivers  []string
+	reserved map[string]bool
+}
+
+func newFakePool(drivers ...string) *fakePool {
+	return &fakePool{drivers: drivers, reserved: make(map[string]bool)}
+}
+
+func (p *fakePool) FindAvailableDrivers(ctx context.Context, packageSlug string, pickup *pbd.Location) []*types.DriverCandidate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*types.DriverCandidate
+	for i, id := range p.drivers {
+		if !p.reserved[id] {
+			candidates = append(candidates, &types.DriverCandidate{Driver: &pbd.Driver{Id: id}, DistanceMeters: float64(i)})
+		}
+	}
+	return candidates
+}
+
+func (p *fakePool) OfferTrip(ctx context.Context, driverID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.reserved[driverID] {
+		return fmt.Errorf("driver %s already holds an offer", driverID)
+	}
+	p.reserved[driverID] = true
+	return nil
+}
+
+func (p *fakePool) ReleaseOffer(ctx context.Context, driverID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.reserved, driverID)
+	return nil
+}
+
+// offered returns the drivers holding an offer, sorted
+func (p *fakePool) offered() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	offered := []string{}
+	for id := range p.reserved {
+		offered = append(offered, id)
+	}
+	slices.Sort(offered)
+	return offered
+}
+
+// fakePublisher passes on the offers and "no drivers found" events it is asked to publish
+type fakePublisher struct {
+	mu     sync.Mutex
+	offers chan offer
+	ctx    context.Context
+}
+
+func newFakePublisher() *fakePublisher {
+	return &fakePublisher{offers: make(chan offer, 16)}
+}
+
+func (p *fakePublisher) PublishTripRequest(ctx context.Context, driverID string, trip *pb.Trip) error {
+	p.record(ctx)
+	p.offers <- offer{trip.Id, driverID}
+	return nil
+}
+
+func (p *fakePublisher) PublishNoDriversFound(ctx context.Context, trip *pb.Trip) error {
+	p.record(ctx)
+	p.offers <- offer{trip.Id, noDrivers}
+	return nil
+}
+
+func (p *fakePublisher) record(ctx context.Context) {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+}
+
+func (p *fakePublisher) lastContext() context.Context {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ctx
+}
+
+func (p *fakePublisher) next(t *testing.T) offer {
+	t.Helper()
+	select {
+	case o := <-p.offers:
+		return o
+	case <-time.After(time.Second):
+		t.Fatal("nothing published after a second")
+		return offer{}
+	}
+}
+
+// none fails if anything is published within a few offer expiries of the fast configs
+func (p *fakePublisher) none(t *testing.T) {
+	t.Helper()
+	select {
+	case o := <-p.offers:
+		t.Errorf("published %v, want nothing more", o)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
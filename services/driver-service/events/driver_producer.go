@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/messaging"
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	pbt "github.com/cprakhar/uber-clone/shared/proto/trip"
+)
+
+type DriverEventProducer struct {
+	publisher *messaging.Publisher
+}
+
+// NewDriverEventProducer creates a new DriverEventProducer with the given publisher.
+func NewDriverEventProducer(publisher *messaging.Publisher) *DriverEventProducer {
+	return &DriverEventProducer{publisher: publisher}
+}
+
+// PublishLocationUpdated publishes a "driver.event.location_updated" event with the driver's
+// current location, when it was recorded and whether they can take trips.
+func (dep *DriverEventProducer) PublishLocationUpdated(ctx context.Context, driver *pb.Driver, available bool, recordedAt time.Time) error {
+	return messaging.Publish(ctx, dep.publisher, messaging.DriverLocationUpdated, driver.Id, messaging.DriverLocationEventData{
+		Driver:     driver,
+		Available:  available,
+		RecordedAt: recordedAt,
+	})
+}
+
+// PublishTripRequest offers the trip to the driver with a "driver.cmd.trip_request" command.
+func (dep *DriverEventProducer) PublishTripRequest(ctx context.Context, driverID string, trip *pbt.Trip) error {
+	return messaging.Publish(ctx, dep.publisher, messaging.DriverTripRequest, driverID, messaging.TripEventData{Trip: trip})
+}
+
+// PublishNoDriversFound publishes a "trip.event.no_drivers_found" event keyed by the rider ID.
+func (dep *DriverEventProducer) PublishNoDriversFound(ctx context.Context, trip *pbt.Trip) error {
+	return messaging.Publish(ctx, dep.publisher, messaging.TripNoDriversFound, trip.RiderID, messaging.TripEventData{Trip: trip})
+}
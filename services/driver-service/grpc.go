@@ -5,20 +5,22 @@ import (
 	"log"
 	"net"
 
+	"github.com/cprakhar/uber-clone/services/driver-service/dispatch"
+	"github.com/cprakhar/uber-clone/services/driver-service/events"
 	"github.com/cprakhar/uber-clone/services/driver-service/handler"
 	"github.com/cprakhar/uber-clone/services/driver-service/service"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 	"google.golang.org/grpc"
 )
 
 type gRPCServer struct {
 	addr          string
-	kfClient      *kafka.KafkaClient
+	producer      *events.DriverEventProducer
 	driverService service.DriverService
+	dispatcher    *dispatch.Coordinator
 }
 
-func NewgRPCServer(addr string, kfc *kafka.KafkaClient, svc service.DriverService) *gRPCServer {
-	return &gRPCServer{addr: addr, kfClient: kfc, driverService: svc}
+func NewgRPCServer(addr string, producer *events.DriverEventProducer, svc service.DriverService, dispatcher *dispatch.Coordinator) *gRPCServer {
+	return &gRPCServer{addr: addr, producer: producer, driverService: svc, dispatcher: dispatcher}
 }
 
 func (s *gRPCServer) run(ctx context.Context) error {
@@ -31,7 +33,7 @@ func (s *gRPCServer) run(ctx context.Context) error {
 
 	// gRPC server setup
 	srv := grpc.NewServer()
-	handler.NewgRPCHandler(srv, s.driverService)
+	handler.NewgRPCHandler(srv, s.driverService, s.producer, s.dispatcher)
 
 	// Graceful shutdown on context cancellation
 	go func() {
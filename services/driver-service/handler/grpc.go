@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
+	"github.com/cprakhar/uber-clone/services/driver-service/dispatch"
+	"github.com/cprakhar/uber-clone/services/driver-service/events"
+	"github.com/cprakhar/uber-clone/services/driver-service/repo"
 	"github.com/cprakhar/uber-clone/services/driver-service/service"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
 	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -13,11 +19,13 @@ import (
 
 type gRPCHandler struct {
 	pb.UnimplementedDriverServiceServer
-	svc service.DriverService
+	svc        service.DriverService
+	producer   *events.DriverEventProducer
+	dispatcher *dispatch.Coordinator
 }
 
-func NewgRPCHandler(srv *grpc.Server, svc service.DriverService) {
-	handler := &gRPCHandler{svc: svc}
+func NewgRPCHandler(srv *grpc.Server, svc service.DriverService, producer *events.DriverEventProducer, dispatcher *dispatch.Coordinator) {
+	handler := &gRPCHandler{svc: svc, producer: producer, dispatcher: dispatcher}
 	pb.RegisterDriverServiceServer(srv, handler)
 }
 
@@ -30,12 +38,105 @@ func (h *gRPCHandler) RegisterDriver(ctx context.Context, req *pb.RegisterDriver
 		return nil, status.Errorf(codes.Internal, "failed to register driver: %v", err)
 	}
 	log.Printf("Driver registered: %s", driver.Id)
+
+	// Count the driver towards the supply in their area
+	if err := h.producer.PublishLocationUpdated(ctx, driver, h.svc.IsAvailable(ctx, driver.Id), time.Now()); err != nil {
+		log.Printf("Failed to publish location of driver %s: %v", driver.Id, err)
+	}
+
 	return &pb.RegisterDriverResponse{
 		Driver: driver,
 	}, nil
 }
 
 func (h *gRPCHandler) UnregisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
-	// Implement the logic to unregister a driver
-	return nil, nil
+	driverID := req.GetDriverID()
+	driver, err := h.svc.GetDriver(ctx, driverID)
+	if errors.Is(err, repo.ErrDriverNotFound) {
+		return nil, status.Errorf(codes.NotFound, "driver %s not found", driverID)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get driver: %v", err)
+	}
+
+	// Offer a trip the driver was holding to the next candidate
+	h.dispatcher.Withdraw(driverID)
+
+	if err := h.svc.UnregisterDriver(ctx, driverID); err != nil {
+		if errors.Is(err, repo.ErrDriverOnTrip) {
+			return nil, status.Errorf(codes.FailedPrecondition, "driver %s is on a trip", driverID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to unregister driver: %v", err)
+	}
+	log.Printf("Driver unregistered: %s", driverID)
+
+	// Stop counting the driver towards the supply in their area
+	driver.Availability = string(types.AvailabilityOffline)
+	if err := h.producer.PublishLocationUpdated(ctx, driver, false, time.Now()); err != nil {
+		log.Printf("Failed to publish location of driver %s: %v", driverID, err)
+	}
+
+	return &pb.RegisterDriverResponse{
+		Driver: driver,
+	}, nil
+}
+
+func (h *gRPCHandler) SetAvailability(ctx context.Context, req *pb.SetAvailabilityRequest) (*pb.SetAvailabilityResponse, error) {
+	driverID := req.GetDriverID()
+	driver, err := h.svc.SetAvailability(ctx, driverID, types.Availability(req.GetAvailability()))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrDriverNotFound):
+			return nil, status.Errorf(codes.NotFound, "driver %s not found", driverID)
+		case errors.Is(err, types.ErrInvalidAvailability):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to set availability: %v", err)
+	}
+	log.Printf("Driver %s is now %s", driverID, driver.Availability)
+
+	if err := h.producer.PublishLocationUpdated(ctx, driver, h.svc.IsAvailable(ctx, driverID), time.Now()); err != nil {
+		log.Printf("Failed to publish location of driver %s: %v", driverID, err)
+	}
+
+	return &pb.SetAvailabilityResponse{
+		Driver: driver,
+	}, nil
+}
+
+func (h *gRPCHandler) UpdateLocation(ctx context.Context, req *pb.UpdateLocationRequest) (*pb.UpdateLocationResponse, error) {
+	driverID := req.GetDriverID()
+	if driverID == "" {
+		return nil, status.Error(codes.InvalidArgument, "driverID is required")
+	}
+
+	at := time.UnixMilli(req.GetTimestamp())
+	driver, err := h.svc.UpdateLocation(ctx, driverID, req.GetLocation(), at)
+	if err != nil {
+		return nil, locationError(err)
+	}
+
+	// Keep matching and surge pricing in the driver's area up to date
+	if err := h.producer.PublishLocationUpdated(ctx, driver, h.svc.IsAvailable(ctx, driverID), at); err != nil {
+		log.Printf("Failed to publish location of driver %s: %v", driverID, err)
+	}
+
+	return &pb.UpdateLocationResponse{
+		Driver: driver,
+	}, nil
+}
+
+// locationError maps a rejected location update to a gRPC status
+func locationError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidLocation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrStaleLocation), errors.Is(err, repo.ErrOutOfOrderLocation):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrRateLimited):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, repo.ErrDriverNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to update location: %v", err)
 }
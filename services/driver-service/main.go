@@ -6,18 +6,44 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/cprakhar/uber-clone/services/driver-service/dispatch"
 	"github.com/cprakhar/uber-clone/services/driver-service/events"
 	"github.com/cprakhar/uber-clone/services/driver-service/repo"
 	"github.com/cprakhar/uber-clone/services/driver-service/service"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/env"
+	"github.com/cprakhar/uber-clone/shared/messaging"
 	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 )
 
 var (
-	brokers = []string{"kafka:9092"}
-	groupID = "driver-service-group"
-	topics  = []string{contracts.TripEventCreated, contracts.TripEventDriverNotInterested}
+	brokers     = []string{"kafka:9092"}
+	groupID     = "driver-service-group"
+	serviceName = "driver-service"
+	topics      = []string{
+		contracts.TripEventCreated,
+		contracts.TripEventDriverNotInterested,
+		contracts.TripEventDriverAssigned,
+		contracts.TripEventCompleted,
+		contracts.TripEventCancelled,
+	}
+
+	matchStartPrecision = env.GetInt("MATCH_START_PRECISION", 6)
+	matchMinPrecision   = env.GetInt("MATCH_MIN_PRECISION", 4)
+	matchMaxCandidates  = env.GetInt("MATCH_MAX_CANDIDATES", 5)
+	matchAvgSpeedKmh    = env.GetFloat("MATCH_AVG_SPEED_KMH", 25)
+
+	locationMinInterval  = env.GetDuration("LOCATION_MIN_INTERVAL", time.Second)
+	locationMaxAge       = env.GetDuration("LOCATION_MAX_AGE", 30*time.Second)
+	locationMaxClockSkew = env.GetDuration("LOCATION_MAX_CLOCK_SKEW", 5*time.Second)
+
+	dispatchOfferTTL      = env.GetDuration("DISPATCH_OFFER_TTL", 15*time.Second)
+	dispatchMaxAttempts   = env.GetInt("DISPATCH_MAX_ATTEMPTS", 5)
+	dispatchDeadline      = env.GetDuration("DISPATCH_DEADLINE", 2*time.Minute)
+	dispatchRetryInterval = env.GetDuration("DISPATCH_RETRY_INTERVAL", 5*time.Second)
 )
 
 func main() {
@@ -34,18 +60,44 @@ func main() {
 
 	// Initialize repositories and services
 	driverRepo := repo.NewDriverRepository()
-	driverService := service.NewDriverService(driverRepo)
+	driverProducer := events.NewDriverEventProducer(messaging.NewPublisher(kfClient.Producer, serviceName))
+	matchingCfg := &types.MatchingConfig{
+		StartPrecision: uint(matchStartPrecision),
+		MinPrecision:   uint(matchMinPrecision),
+		MaxCandidates:  matchMaxCandidates,
+		AvgSpeedKmh:    matchAvgSpeedKmh,
+	}
+	if err := matchingCfg.Validate(); err != nil {
+		log.Fatalf("invalid driver matching config: %v", err)
+	}
+	driverService := service.NewDriverService(driverRepo, matchingCfg, &types.LocationConfig{
+		MinInterval:  locationMinInterval,
+		MaxAge:       locationMaxAge,
+		MaxClockSkew: locationMaxClockSkew,
+	})
+
+	// Offer trips to the nearest drivers one at a time
+	dispatcher := dispatch.NewCoordinator(&types.DispatchConfig{
+		OfferTTL:      dispatchOfferTTL,
+		MaxAttempts:   dispatchMaxAttempts,
+		Deadline:      dispatchDeadline,
+		RetryInterval: dispatchRetryInterval,
+	}, driverService, driverProducer)
+	// Stopped once the consumer has drained, so the trips it still hands over are not failed
+	dispatcher.Start(context.WithoutCancel(ctx))
 
 	// Start consuming trip events
-	tripConsumer := events.NewTripConsumer(kfClient, driverService)
+	tripConsumer := events.NewTripConsumer(kfClient, driverService, dispatcher)
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := tripConsumer.Consume(ctx, topics); err != nil {
 			log.Printf("Error consuming trip topics: %v", err)
 		}
 	}()
 
 	// Start gRPC server
-	grpcServer := NewgRPCServer(":9100", kfClient, driverService)
+	grpcServer := NewgRPCServer(":9100", driverProducer, driverService, dispatcher)
 	go func() {
 		if err := grpcServer.run(ctx); err != nil && ctx.Err() == nil {
 			log.Printf("gRPC server error: %v", err)
@@ -55,4 +107,7 @@ func main() {
 
 	<-ctx.Done()
 	log.Println("Shutdown signal received, exiting...")
+	// Let the consumer finish the messages it is handling before the Kafka client closes
+	<-consumerDone
+	dispatcher.Stop()
 }
@@ -1,47 +1,199 @@
 package repo
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
 	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrDriverNotFound      = errors.New("driver not found")
+	ErrOutOfOrderLocation  = errors.New("location is older than the last recorded location")
+	ErrDriverOnTrip        = errors.New("driver is on a trip")
+	ErrAvailabilityChanged = errors.New("driver availability changed")
 )
 
 type inMemoRepo struct {
 	sync.RWMutex
-	drivers []*pb.Driver
+	drivers           []*pb.Driver
+	activeTrips       map[string]string    // driver ID -> trip ID
+	locationUpdatedAt map[string]time.Time // driver ID -> timestamp of the last location
 }
 
 type DriverRepo interface {
 	Create(driver *pb.Driver) (*pb.Driver, error)
 	Delete(driverID string) error
 	GetAll() []*pb.Driver
+	GetByID(driverID string) (*pb.Driver, error)
+	SetAvailability(driverID string, availability types.Availability, tripID string) (*pb.Driver, error)
+	SwapAvailability(driverID string, from, to types.Availability, tripID string) (*pb.Driver, error)
+	GetActiveTrip(driverID string) (string, bool)
+	UpdateLocation(driverID string, location *pb.Location, geohash string, at time.Time) (*pb.Driver, error)
 }
 
 func NewDriverRepository() *inMemoRepo {
 	return &inMemoRepo{
-		drivers: []*pb.Driver{},
+		drivers:           []*pb.Driver{},
+		activeTrips:       make(map[string]string),
+		locationUpdatedAt: make(map[string]time.Time),
 	}
 }
 
+// Create stores the driver, replacing an earlier registration with the same ID.
+// A driver registering again during a trip stays on the trip, at their last known location.
 func (r *inMemoRepo) Create(driver *pb.Driver) (*pb.Driver, error) {
 	r.Lock()
+	defer r.Unlock()
+	for i, d := range r.drivers {
+		if d.Id != driver.Id {
+			continue
+		}
+
+		if _, onTrip := r.activeTrips[driver.Id]; onTrip {
+			driver.Availability = d.Availability
+			driver.Location = d.Location
+			driver.Geohash = d.Geohash
+		} else {
+			delete(r.locationUpdatedAt, driver.Id)
+		}
+		r.drivers[i] = driver
+		return driver, nil
+	}
 	r.drivers = append(r.drivers, driver)
-	r.Unlock()
 	return driver, nil
 }
 
+// Delete removes the driver. Drivers on a trip are kept until the trip ended, with ErrDriverOnTrip.
 func (r *inMemoRepo) Delete(driverID string) error {
 	r.Lock()
+	defer r.Unlock()
+	if _, onTrip := r.activeTrips[driverID]; onTrip {
+		return ErrDriverOnTrip
+	}
 	for i, d := range r.drivers {
 		if d.Id == driverID {
 			r.drivers = append(r.drivers[:i], r.drivers[i+1:]...)
 			break
 		}
 	}
-	r.Unlock()
+	delete(r.locationUpdatedAt, driverID)
 	return nil
 }
 
 func (r *inMemoRepo) GetAll() []*pb.Driver {
-	return r.drivers
+	r.RLock()
+	defer r.RUnlock()
+	drivers := make([]*pb.Driver, len(r.drivers))
+	copy(drivers, r.drivers)
+	return drivers
+}
+
+// GetByID returns the registered driver with the given ID
+func (r *inMemoRepo) GetByID(driverID string) (*pb.Driver, error) {
+	r.RLock()
+	defer r.RUnlock()
+	for _, d := range r.drivers {
+		if d.Id == driverID {
+			return d, nil
+		}
+	}
+	return nil, ErrDriverNotFound
+}
+
+// SetAvailability moves the driver to the given availability if the transition is allowed.
+// The trip ID is recorded as the driver's active trip when they go on a trip.
+func (r *inMemoRepo) SetAvailability(driverID string, availability types.Availability, tripID string) (*pb.Driver, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.update(driverID, func(d *pb.Driver) error {
+		if err := types.Availability(d.Availability).ValidateTransition(availability); err != nil {
+			return err
+		}
+		d.Availability = string(availability)
+
+		if availability == types.AvailabilityOnTrip {
+			r.activeTrips[driverID] = tripID
+		} else {
+			delete(r.activeTrips, driverID)
+		}
+		return nil
+	})
+}
+
+// SwapAvailability moves the driver from one availability to another like SetAvailability, but only while
+// they are still in from and, when from is on_trip, still on the trip with ID tripID.
+// It returns ErrAvailabilityChanged when the driver has moved on.
+func (r *inMemoRepo) SwapAvailability(driverID string, from, to types.Availability, tripID string) (*pb.Driver, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.update(driverID, func(d *pb.Driver) error {
+		if types.Availability(d.Availability) != from {
+			return fmt.Errorf("%w: driver is %s, not %s", ErrAvailabilityChanged, d.Availability, from)
+		}
+		if from == types.AvailabilityOnTrip && r.activeTrips[driverID] != tripID {
+			return fmt.Errorf("%w: driver is on trip %s, not %s", ErrAvailabilityChanged, r.activeTrips[driverID], tripID)
+		}
+		if err := from.ValidateTransition(to); err != nil {
+			return err
+		}
+		d.Availability = string(to)
+
+		if to == types.AvailabilityOnTrip {
+			r.activeTrips[driverID] = tripID
+		} else {
+			delete(r.activeTrips, driverID)
+		}
+		return nil
+	})
+}
+
+// GetActiveTrip returns the trip the driver is currently on, if any
+func (r *inMemoRepo) GetActiveTrip(driverID string) (string, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	tripID, ok := r.activeTrips[driverID]
+	return tripID, ok
+}
+
+// UpdateLocation replaces the driver's location and geohash.
+// Locations recorded at or before the last accepted one are rejected with ErrOutOfOrderLocation.
+func (r *inMemoRepo) UpdateLocation(driverID string, location *pb.Location, geohash string, at time.Time) (*pb.Driver, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.update(driverID, func(d *pb.Driver) error {
+		if last, ok := r.locationUpdatedAt[driverID]; ok && !at.After(last) {
+			return ErrOutOfOrderLocation
+		}
+		d.Location = location
+		d.Geohash = geohash
+		r.locationUpdatedAt[driverID] = at
+		return nil
+	})
+}
+
+// update applies fn to a copy of the driver and stores the copy if fn succeeds.
+// Drivers are replaced instead of mutated, as callers may still hold the old value.
+// The caller must hold the lock.
+func (r *inMemoRepo) update(driverID string, fn func(d *pb.Driver) error) (*pb.Driver, error) {
+	for i, d := range r.drivers {
+		if d.Id != driverID {
+			continue
+		}
+
+		updated := proto.Clone(d).(*pb.Driver)
+		if err := fn(updated); err != nil {
+			return nil, err
+		}
+		r.drivers[i] = updated
+		return updated, nil
+	}
+	return nil, ErrDriverNotFound
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/repo"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+)
+
+// SetAvailability changes the availability of the driver at their own request.
+// Drivers can only switch between available, on break and offline; offers and trips drive the rest.
+func (s *driverService) SetAvailability(ctx context.Context, driverID string, availability types.Availability) (*pb.Driver, error) {
+	if !availability.IsDriverSettable() {
+		return nil, fmt.Errorf("%w: drivers cannot set themselves %q", types.ErrInvalidAvailability, availability)
+	}
+	return s.repo.SetAvailability(driverID, availability, "")
+}
+
+// IsAvailable reports whether the driver can be offered trips
+func (s *driverService) IsAvailable(ctx context.Context, driverID string) bool {
+	driver, err := s.repo.GetByID(driverID)
+	return err == nil && driver.Availability == string(types.AvailabilityAvailable)
+}
+
+// OfferTrip reserves an available driver while they are offered a trip, so they are not matched again
+func (s *driverService) OfferTrip(ctx context.Context, driverID string) error {
+	_, err := s.repo.SetAvailability(driverID, types.AvailabilityOffered, "")
+	return err
+}
+
+// ReleaseOffer makes a driver whose offer ended available again.
+// Drivers who already moved on, e.g. by accepting the trip, are left as they are.
+func (s *driverService) ReleaseOffer(ctx context.Context, driverID string) error {
+	_, err := s.repo.SwapAvailability(driverID, types.AvailabilityOffered, types.AvailabilityAvailable, "")
+	if errors.Is(err, repo.ErrAvailabilityChanged) {
+		return nil
+	}
+	return err
+}
+
+// AssignTrip marks the driver as on the trip so they are not matched again
+func (s *driverService) AssignTrip(ctx context.Context, driverID, tripID string) error {
+	_, err := s.repo.SetAvailability(driverID, types.AvailabilityOnTrip, tripID)
+	return err
+}
+
+// ReleaseTrip makes the driver available again once the trip ended.
+// It does nothing when the driver is no longer on that trip.
+func (s *driverService) ReleaseTrip(ctx context.Context, driverID, tripID string) error {
+	_, err := s.repo.SwapAvailability(driverID, types.AvailabilityOnTrip, types.AvailabilityAvailable, tripID)
+	if errors.Is(err, repo.ErrAvailabilityChanged) || errors.Is(err, repo.ErrDriverNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/repo"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+)
+
+func TestAvailability(t *testing.T) {
+	var (
+		offer        = func(s *driverService) error { return s.OfferTrip(t.Context(), "driver") }
+		releaseOffer = func(s *driverService) error { return s.ReleaseOffer(t.Context(), "driver") }
+		accept       = func(s *driverService) error { return s.AssignTrip(t.Context(), "driver", "trip-1") }
+		releaseTrip  = func(tripID string) func(s *driverService) error {
+			return func(s *driverService) error { return s.ReleaseTrip(t.Context(), "driver", tripID) }
+		}
+		set = func(availability types.Availability) func(s *driverService) error {
+			return func(s *driverService) error {
+				_, err := s.SetAvailability(t.Context(), "driver", availability)
+				return err
+			}
+		}
+	)
+
+	tests := []struct {
+		name     string
+		steps    []func(s *driverService) error // applied in turn to an available driver
+		wantErr  error                          // of the last step
+		want     types.Availability
+		wantTrip string
+	}{
+		{name: "offer released", steps: []func(s *driverService) error{offer, releaseOffer}, want: types.AvailabilityAvailable},
+		{name: "offer accepted", steps: []func(s *driverService) error{offer, accept}, want: types.AvailabilityOnTrip, wantTrip: "trip-1"},
+		{name: "offer released after it was accepted", steps: []func(s *driverService) error{offer, accept, releaseOffer}, want: types.AvailabilityOnTrip, wantTrip: "trip-1"},
+		{name: "offer released twice", steps: []func(s *driverService) error{offer, releaseOffer, releaseOffer}, want: types.AvailabilityAvailable},
+		{name: "offered twice", steps: []func(s *driverService) error{offer, offer}, wantErr: types.ErrInvalidAvailability, want: types.AvailabilityOffered},
+		{name: "offered on a break", steps: []func(s *driverService) error{set(types.AvailabilityOnBreak), offer}, wantErr: types.ErrInvalidAvailability, want: types.AvailabilityOnBreak},
+		{name: "trip released", steps: []func(s *driverService) error{accept, releaseTrip("trip-1")}, want: types.AvailabilityAvailable},
+		{name: "another trip released", steps: []func(s *driverService) error{accept, releaseTrip("trip-2")}, want: types.AvailabilityOnTrip, wantTrip: "trip-1"},
+		{name: "trip released while not on it", steps: []func(s *driverService) error{set(types.AvailabilityOnBreak), releaseTrip("trip-1")}, want: types.AvailabilityOnBreak},
+		{name: "break taken and ended", steps: []func(s *driverService) error{set(types.AvailabilityOnBreak), set(types.AvailabilityAvailable)}, want: types.AvailabilityAvailable},
+		{name: "driver sets themselves on a trip", steps: []func(s *driverService) error{set(types.AvailabilityOnTrip)}, wantErr: types.ErrInvalidAvailability, want: types.AvailabilityAvailable},
+		{name: "driver goes offline during a trip", steps: []func(s *driverService) error{accept, set(types.AvailabilityOffline)}, wantErr: types.ErrInvalidAvailability, want: types.AvailabilityOnTrip, wantTrip: "trip-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newAvailabilityService(t)
+
+			var err error
+			for i, step := range tt.steps {
+				if err = step(svc); err != nil && i < len(tt.steps)-1 {
+					t.Fatalf("step %d: %v", i+1, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("last step = %v, want %v", err, tt.wantErr)
+			}
+			assertAvailability(t, svc, tt.want, tt.wantTrip)
+		})
+	}
+
+	if err := newAvailabilityService(t).ReleaseTrip(t.Context(), "unknown", "trip-1"); err != nil {
+		t.Errorf("ReleaseTrip() of an unknown driver = %v, want nil", err)
+	}
+}
+
+func TestUnregisterDriver(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepare     func(s *driverService) error
+		wantErr     error
+		want        types.Availability // after registering again
+		wantTrip    string
+		wantMatched bool // found for a trip after registering again
+	}{
+		{name: "available", prepare: func(s *driverService) error { return nil }, want: types.AvailabilityAvailable, wantMatched: true},
+		{name: "on a break", prepare: func(s *driverService) error {
+			_, err := s.SetAvailability(t.Context(), "driver", types.AvailabilityOnBreak)
+			return err
+		}, want: types.AvailabilityAvailable, wantMatched: true},
+		{name: "on a trip", prepare: func(s *driverService) error {
+			return s.AssignTrip(t.Context(), "driver", "trip-1")
+		}, wantErr: repo.ErrDriverOnTrip, want: types.AvailabilityOnTrip, wantTrip: "trip-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newAvailabilityService(t)
+			if err := tt.prepare(svc); err != nil {
+				t.Fatal(err)
+			}
+			before, err := svc.GetDriver(t.Context(), "driver")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = svc.UnregisterDriver(t.Context(), "driver")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnregisterDriver() = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := svc.GetDriver(t.Context(), "driver"); (err == nil) != (tt.wantErr != nil) {
+				t.Errorf("GetDriver() after UnregisterDriver = %v", err)
+			}
+
+			// The driver reconnects
+			driver, err := svc.RegisterDriver(t.Context(), "driver", "sedan")
+			if err != nil {
+				t.Fatalf("RegisterDriver() = %v", err)
+			}
+			assertAvailability(t, svc, tt.want, tt.wantTrip)
+			if tt.wantTrip != "" && driver.Geohash != before.Geohash {
+				t.Errorf("driver on a trip moved to %s when registering again, want %s", driver.Geohash, before.Geohash)
+			}
+
+			matched := len(svc.FindAvailableDrivers(t.Context(), "sedan", driver.Location)) > 0
+			if matched != tt.wantMatched {
+				t.Errorf("driver found for a trip = %v, want %v", matched, tt.wantMatched)
+			}
+		})
+	}
+}
+
+// newAvailabilityService returns a service with an available sedan driver with ID "driver"
+func newAvailabilityService(t *testing.T) *driverService {
+	t.Helper()
+	svc := NewDriverService(repo.NewDriverRepository(), &types.MatchingConfig{
+		StartPrecision: 6,
+		MinPrecision:   4,
+		MaxCandidates:  5,
+		AvgSpeedKmh:    25,
+	}, &types.LocationConfig{MinInterval: time.Second, MaxAge: time.Minute, MaxClockSkew: time.Second})
+	if _, err := svc.RegisterDriver(t.Context(), "driver", "sedan"); err != nil {
+		t.Fatalf("RegisterDriver: %v", err)
+	}
+	return svc
+}
+
+func assertAvailability(t *testing.T, svc *driverService, want types.Availability, wantTrip string) {
+	t.Helper()
+	driver, err := svc.GetDriver(t.Context(), "driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if driver.Availability != string(want) {
+		t.Errorf("availability = %s, want %s", driver.Availability, want)
+	}
+	if tripID, _ := svc.repo.GetActiveTrip("driver"); tripID != wantTrip {
+		t.Errorf("active trip = %q, want %q", tripID, wantTrip)
+	}
+}
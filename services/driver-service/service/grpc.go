@@ -4,26 +4,47 @@ import (
 	"context"
 	"log"
 	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/cprakhar/uber-clone/services/driver-service/repo"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
 	"github.com/cprakhar/uber-clone/services/driver-service/util"
 	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
 	sharedUtil "github.com/cprakhar/uber-clone/shared/util"
 	"github.com/mmcloughlin/geohash"
 )
 
 type driverService struct {
-	repo repo.DriverRepo
+	repo     repo.DriverRepo
+	matching *types.MatchingConfig
+	location *types.LocationConfig
+	limiter  *rateLimiter
 }
 
 type DriverService interface {
 	RegisterDriver(ctx context.Context, driverID, packageSlug string) (*pb.Driver, error)
 	UnregisterDriver(ctx context.Context, driverID string) error
-	FindAvailableDrivers(ctx context.Context, packageSlug string) []string
+	GetDriver(ctx context.Context, driverID string) (*pb.Driver, error)
+	FindAvailableDrivers(ctx context.Context, packageSlug string, pickup *pb.Location) []*types.DriverCandidate
+	AssignTrip(ctx context.Context, driverID, tripID string) error
+	ReleaseTrip(ctx context.Context, driverID, tripID string) error
+	OfferTrip(ctx context.Context, driverID string) error
+	ReleaseOffer(ctx context.Context, driverID string) error
+	SetAvailability(ctx context.Context, driverID string, availability types.Availability) (*pb.Driver, error)
+	UpdateLocation(ctx context.Context, driverID string, location *pb.Location, at time.Time) (*pb.Driver, error)
+	IsAvailable(ctx context.Context, driverID string) bool
 }
 
-func NewDriverService(repo repo.DriverRepo) *driverService {
-	return &driverService{repo: repo}
+func NewDriverService(repo repo.DriverRepo, matching *types.MatchingConfig, location *types.LocationConfig) *driverService {
+	return &driverService{
+		repo:     repo,
+		matching: matching,
+		location: location,
+		limiter:  newRateLimiter(location.MinInterval),
+	}
 }
 
 func (s *driverService) RegisterDriver(ctx context.Context, driverID, packageSlug string) (*pb.Driver, error) {
@@ -35,12 +56,13 @@ func (s *driverService) RegisterDriver(ctx context.Context, driverID, packageSlu
 	geohash := geohash.Encode(randomRoute[0][0], randomRoute[0][1])
 
 	driver := &pb.Driver{
-		Id:          driverID,
-		Name:        "Prakhar Chhalotre",
-		ProfilePic:  profilePic,
-		CarPlate:    carPlate,
-		PackageSlug: packageSlug,
-		Geohash:     geohash,
+		Id:           driverID,
+		Name:         "Prakhar Chhalotre",
+		ProfilePic:   profilePic,
+		CarPlate:     carPlate,
+		PackageSlug:  packageSlug,
+		Geohash:      geohash,
+		Availability: string(types.AvailabilityAvailable),
 		Location: &pb.Location{
 			Latitude:  randomRoute[0][0],
 			Longitude: randomRoute[0][1],
@@ -56,16 +78,86 @@ func (s *driverService) RegisterDriver(ctx context.Context, driverID, packageSlu
 }
 
 func (s *driverService) UnregisterDriver(ctx context.Context, driverID string) error {
+	s.limiter.Forget(driverID)
 	return s.repo.Delete(driverID)
 }
 
-func (s *driverService) FindAvailableDrivers(ctx context.Context, packageSlug string) []string {
-	matchingDrivers := []string{}
+// GetDriver returns the registered driver with the given ID
+func (s *driverService) GetDriver(ctx context.Context, driverID string) (*pb.Driver, error) {
+	return s.repo.GetByID(driverID)
+}
+
+// FindAvailableDrivers returns available drivers of the package, ranked by distance to the pickup.
+// The search starts with the pickup's geohash cell and its neighbours and widens to larger cells
+// until at least one driver is found.
+func (s *driverService) FindAvailableDrivers(ctx context.Context, packageSlug string, pickup *pb.Location) []*types.DriverCandidate {
+	drivers := []*pb.Driver{}
 	for _, d := range s.repo.GetAll() {
-		if d.PackageSlug == packageSlug {
-			matchingDrivers = append(matchingDrivers, d.Id)
+		if d.PackageSlug != packageSlug || d.Availability != string(types.AvailabilityAvailable) {
+			continue
 		}
+		drivers = append(drivers, d)
 	}
 
-	return matchingDrivers
+	if pickup == nil || len(drivers) == 0 {
+		return nil
+	}
+
+	var nearby []*pb.Driver
+	for precision := s.matching.StartPrecision; ; precision-- {
+		nearby = driversInCells(drivers, searchCells(pickup, precision))
+		if len(nearby) > 0 || precision <= s.matching.MinPrecision {
+			break
+		}
+	}
+
+	return s.rankCandidates(nearby, pickup)
+}
+
+// rankCandidates orders drivers by their distance to the pickup and keeps the closest ones
+func (s *driverService) rankCandidates(drivers []*pb.Driver, pickup *pb.Location) []*types.DriverCandidate {
+	pickupCoord := &sharedtypes.Coordinate{Latitude: pickup.Latitude, Longitude: pickup.Longitude}
+	speedMps := s.matching.AvgSpeedKmh * 1000 / 3600
+
+	candidates := make([]*types.DriverCandidate, 0, len(drivers))
+	for _, d := range drivers {
+		distance := sharedUtil.HaversineDistance(pickupCoord, &sharedtypes.Coordinate{
+			Latitude:  d.GetLocation().GetLatitude(),
+			Longitude: d.GetLocation().GetLongitude(),
+		})
+		candidates = append(candidates, &types.DriverCandidate{
+			Driver:         d,
+			DistanceMeters: distance,
+			ETASeconds:     distance / speedMps,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].DistanceMeters < candidates[j].DistanceMeters
+	})
+
+	if len(candidates) > s.matching.MaxCandidates {
+		candidates = candidates[:s.matching.MaxCandidates]
+	}
+	return candidates
+}
+
+// searchCells returns the pickup's geohash cell at the given precision together with its neighbours
+func searchCells(pickup *pb.Location, precision uint) []string {
+	center := geohash.EncodeWithPrecision(pickup.Latitude, pickup.Longitude, precision)
+	return append(geohash.Neighbors(center), center)
+}
+
+// driversInCells returns the drivers whose geohash falls inside one of the cells
+func driversInCells(drivers []*pb.Driver, cells []string) []*pb.Driver {
+	var matched []*pb.Driver
+	for _, d := range drivers {
+		for _, cell := range cells {
+			if strings.HasPrefix(d.Geohash, cell) {
+				matched = append(matched, d)
+				break
+			}
+		}
+	}
+	return matched
 }
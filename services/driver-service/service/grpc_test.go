@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/repo"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	"github.com/mmcloughlin/geohash"
+)
+
+func TestFindAvailableDrivers(t *testing.T) {
+	pickup := &pb.Location{Latitude: 12.9716, Longitude: 77.5946}
+
+	tests := []struct {
+		name     string
+		start    uint
+		min      uint
+		driverAt *pb.Location
+		want     bool
+	}{
+		{name: "in the pickup's cell", start: 6, min: 4, driverAt: &pb.Location{Latitude: 12.9717, Longitude: 77.5947}, want: true},
+		{name: "found by widening the search", start: 6, min: 3, driverAt: &pb.Location{Latitude: 13.05, Longitude: 77.65}, want: true},
+		{name: "beyond the widest search", start: 6, min: 4, driverAt: &pb.Location{Latitude: 13.8, Longitude: 78.5}},
+		{name: "widest search at precision 1", start: 3, min: 1, driverAt: &pb.Location{Latitude: 28.6139, Longitude: 77.209}, want: true},
+		{name: "single precision", start: 5, min: 5, driverAt: &pb.Location{Latitude: 28.6139, Longitude: 77.209}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverRepo := repo.NewDriverRepository()
+			if _, err := driverRepo.Create(&pb.Driver{
+				Id:           "driver",
+				PackageSlug:  "sedan",
+				Availability: string(types.AvailabilityAvailable),
+				Location:     tt.driverAt,
+				Geohash:      geohash.Encode(tt.driverAt.Latitude, tt.driverAt.Longitude),
+			}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			svc := NewDriverService(driverRepo, &types.MatchingConfig{
+				StartPrecision: tt.start,
+				MinPrecision:   tt.min,
+				MaxCandidates:  5,
+				AvgSpeedKmh:    25,
+			}, &types.LocationConfig{MinInterval: time.Second, MaxAge: time.Minute, MaxClockSkew: time.Second})
+
+			got := svc.FindAvailableDrivers(t.Context(), "sedan", pickup)
+			if found := len(got) == 1; found != tt.want {
+				t.Errorf("FindAvailableDrivers() found %d drivers, want found %v", len(got), tt.want)
+			}
+		})
+	}
+}
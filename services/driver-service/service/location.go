@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	"github.com/mmcloughlin/geohash"
+)
+
+var (
+	ErrInvalidLocation = errors.New("invalid location")
+	ErrStaleLocation   = errors.New("location is too old")
+	ErrRateLimited     = errors.New("location updates are too frequent")
+)
+
+// UpdateLocation records the driver's location at the given time and recomputes their geohash.
+// Updates that are invalid, stale, out of order or arrive faster than the configured interval are rejected.
+func (s *driverService) UpdateLocation(ctx context.Context, driverID string, location *pb.Location, at time.Time) (*pb.Driver, error) {
+	if err := validateLocation(location); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if at.After(now.Add(s.location.MaxClockSkew)) {
+		return nil, fmt.Errorf("%w: timestamp %s is in the future", ErrInvalidLocation, at.Format(time.RFC3339Nano))
+	}
+	if now.Sub(at) > s.location.MaxAge {
+		return nil, ErrStaleLocation
+	}
+	if !s.limiter.Allow(driverID, now) {
+		return nil, ErrRateLimited
+	}
+
+	hash := geohash.Encode(location.Latitude, location.Longitude)
+	return s.repo.UpdateLocation(driverID, location, hash, at)
+}
+
+// validateLocation checks that the location is a real coordinate
+func validateLocation(location *pb.Location) error {
+	if location == nil {
+		return fmt.Errorf("%w: missing location", ErrInvalidLocation)
+	}
+	lat, lng := location.Latitude, location.Longitude
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return fmt.Errorf("%w: (%f, %f) is out of range", ErrInvalidLocation, lat, lng)
+	}
+	return nil
+}
+
+// rateLimiter allows one event per key within the interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval, last: make(map[string]time.Time)}
+}
+
+// Allow reports whether an event for the key may happen now, and records it if so
+func (l *rateLimiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.last[key]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+	l.last[key] = now
+	return true
+}
+
+// Forget drops the state kept for the key
+func (l *rateLimiter) Forget(key string) {
+	l.mu.Lock()
+	delete(l.last, key)
+	l.mu.Unlock()
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/driver-service/repo"
+	"github.com/cprakhar/uber-clone/services/driver-service/types"
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
+	"github.com/mmcloughlin/geohash"
+)
+
+func TestUpdateLocation(t *testing.T) {
+	bangalore := &pb.Location{Latitude: 12.9716, Longitude: 77.5946}
+
+	tests := []struct {
+		name     string
+		interval time.Duration // between accepted updates of a driver
+		previous time.Duration // age of an update accepted before, none if 0
+		location *pb.Location
+		age      time.Duration // of the update, negative for the future
+		driverID string
+		wantErr  error
+	}{
+		{name: "accepted", interval: time.Second, location: bangalore},
+		{name: "slightly old", interval: time.Second, location: bangalore, age: 30 * time.Second},
+		{name: "within the clock skew", interval: time.Second, location: bangalore, age: -time.Second},
+		{name: "edge of the world", interval: time.Second, location: &pb.Location{Latitude: -90, Longitude: 180}},
+		{name: "missing location", interval: time.Second, wantErr: ErrInvalidLocation},
+		{name: "latitude out of range", interval: time.Second, location: &pb.Location{Latitude: 91, Longitude: 77}, wantErr: ErrInvalidLocation},
+		{name: "longitude out of range", interval: time.Second, location: &pb.Location{Latitude: 12, Longitude: -181}, wantErr: ErrInvalidLocation},
+		{name: "not a number", interval: time.Second, location: &pb.Location{Latitude: math.NaN(), Longitude: 77}, wantErr: ErrInvalidLocation},
+		{name: "in the future", interval: time.Second, location: bangalore, age: -time.Minute, wantErr: ErrInvalidLocation},
+		{name: "stale", interval: time.Second, location: bangalore, age: 2 * time.Minute, wantErr: ErrStaleLocation},
+		{name: "too frequent", interval: time.Hour, previous: 10 * time.Second, location: bangalore, wantErr: ErrRateLimited},
+		{name: "out of order", previous: time.Second, location: bangalore, age: 5 * time.Second, wantErr: repo.ErrOutOfOrderLocation},
+		{name: "after a previous update", previous: 5 * time.Second, location: bangalore, age: time.Second},
+		{name: "unknown driver", interval: time.Second, location: bangalore, driverID: "unknown", wantErr: repo.ErrDriverNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverRepo := repo.NewDriverRepository()
+			start := &pb.Location{Latitude: 28.6139, Longitude: 77.2090}
+			if _, err := driverRepo.Create(&pb.Driver{Id: "driver", Location: start, Geohash: geohash.Encode(start.Latitude, start.Longitude)}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			svc := NewDriverService(driverRepo, &types.MatchingConfig{}, &types.LocationConfig{
+				MinInterval:  tt.interval,
+				MaxAge:       time.Minute,
+				MaxClockSkew: 2 * time.Second,
+			})
+
+			now := time.Now()
+			if tt.previous > 0 {
+				if _, err := svc.UpdateLocation(t.Context(), "driver", start, now.Add(-tt.previous)); err != nil {
+					t.Fatalf("previous update: %v", err)
+				}
+			}
+
+			driverID := tt.driverID
+			if driverID == "" {
+				driverID = "driver"
+			}
+			driver, err := svc.UpdateLocation(t.Context(), driverID, tt.location, now.Add(-tt.age))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateLocation() = %v, want %v", err, tt.wantErr)
+				}
+				if stored, _ := driverRepo.GetByID("driver"); stored.Location != start {
+					t.Errorf("rejected update moved the driver to %v", stored.Location)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateLocation() = %v", err)
+			}
+
+			wantHash := geohash.Encode(tt.location.Latitude, tt.location.Longitude)
+			if driver.Location != tt.location || driver.Geohash != wantHash {
+				t.Errorf("driver at %v in cell %s, want %v in %s", driver.Location, driver.Geohash, tt.location, wantHash)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		key  string
+		at   time.Duration // after start
+		want bool
+	}{
+		{name: "first event", key: "d1", want: true},
+		{name: "too soon", key: "d1", at: 500 * time.Millisecond},
+		{name: "other key", key: "d2", at: 500 * time.Millisecond, want: true},
+		{name: "after the interval", key: "d1", at: time.Second, want: true},
+		{name: "too soon after the last allowed", key: "d1", at: 1500 * time.Millisecond},
+	}
+
+	l := newRateLimiter(time.Second)
+	for _, tt := range tests {
+		if got := l.Allow(tt.key, start.Add(tt.at)); got != tt.want {
+			t.Errorf("%s: Allow(%s, +%s) = %v, want %v", tt.name, tt.key, tt.at, got, tt.want)
+		}
+	}
+
+	l.Forget("d1")
+	if !l.Allow("d1", start.Add(1600*time.Millisecond)) {
+		t.Error("Allow() after Forget = false, want true")
+	}
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidAvailability = errors.New("invalid driver availability change")
+
+// Availability is whether a driver can currently be offered trips
+type Availability string
+
+const (
+	AvailabilityOffline   Availability = "offline"
+	AvailabilityAvailable Availability = "available"
+	AvailabilityOffered   Availability = "offered"
+	AvailabilityOnTrip    Availability = "on_trip"
+	AvailabilityOnBreak   Availability = "on_break"
+)
+
+// availabilityTransitions lists the availabilities a driver may move to from each availability.
+// A driver may still accept a trip after their offer expired, so available drivers can go on a trip directly.
+var availabilityTransitions = map[Availability][]Availability{
+	AvailabilityOffline:   {AvailabilityAvailable},
+	AvailabilityAvailable: {AvailabilityOffered, AvailabilityOnTrip, AvailabilityOnBreak, AvailabilityOffline},
+	AvailabilityOffered:   {AvailabilityAvailable, AvailabilityOnTrip},
+	AvailabilityOnTrip:    {AvailabilityAvailable},
+	AvailabilityOnBreak:   {AvailabilityAvailable, AvailabilityOffline},
+}
+
+// CanTransitionTo reports whether a driver in availability a may move to next
+func (a Availability) CanTransitionTo(next Availability) bool {
+	for _, allowed := range availabilityTransitions[a] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an ErrInvalidAvailability when a driver in availability a may not move to next
+func (a Availability) ValidateTransition(next Availability) error {
+	if !a.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidAvailability, a, next)
+	}
+	return nil
+}
+
+// IsDriverSettable reports whether drivers may choose the availability themselves.
+// The other availabilities follow from trip offers and trip events.
+func (a Availability) IsDriverSettable() bool {
+	return a == AvailabilityAvailable || a == AvailabilityOnBreak || a == AvailabilityOffline
+}
@@ -1,6 +1,10 @@
 package types
 
 import (
+	"fmt"
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/driver"
 	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
 )
 
@@ -13,3 +17,48 @@ type DriverModel struct {
 	Geohash     string                 `json:"geohash"`
 	Location    sharedtypes.Coordinate `json:"location"`
 }
+
+// DriverCandidate is a driver ranked for a trip by their distance to the pickup
+type DriverCandidate struct {
+	Driver         *pb.Driver
+	DistanceMeters float64
+	ETASeconds     float64
+}
+
+// MatchingConfig controls how drivers are searched for around a pickup
+type MatchingConfig struct {
+	StartPrecision uint    // geohash precision of the first, narrowest search
+	MinPrecision   uint    // geohash precision of the widest search
+	MaxCandidates  int     // maximum number of ranked candidates returned
+	AvgSpeedKmh    float64 // speed used to estimate a driver's ETA to the pickup
+}
+
+// maxGeohashPrecision is the length of the geohash drivers report
+const maxGeohashPrecision = 12
+
+// Validate checks that the search narrows from StartPrecision down to MinPrecision within the
+// geohash lengths drivers report
+func (c *MatchingConfig) Validate() error {
+	if c.MinPrecision < 1 || c.MinPrecision > c.StartPrecision || c.StartPrecision > maxGeohashPrecision {
+		return fmt.Errorf("matching config: precisions must satisfy 1 <= min (%d) <= start (%d) <= %d", c.MinPrecision, c.StartPrecision, maxGeohashPrecision)
+	}
+	if c.MaxCandidates < 1 {
+		return fmt.Errorf("matching config: max candidates %d must be positive", c.MaxCandidates)
+	}
+	return nil
+}
+
+// DispatchConfig controls how a trip is offered to candidate drivers
+type DispatchConfig struct {
+	OfferTTL      time.Duration // how long a driver has to respond to an offer
+	MaxAttempts   int           // maximum number of drivers offered the trip
+	Deadline      time.Duration // total time allowed to find a driver
+	RetryInterval time.Duration // how long to wait before looking again when no driver can be offered the trip
+}
+
+// LocationConfig controls which driver location updates are accepted
+type LocationConfig struct {
+	MinInterval  time.Duration // minimum time between two accepted updates of a driver
+	MaxAge       time.Duration // updates recorded longer ago than this are stale
+	MaxClockSkew time.Duration // how far in the future an update's timestamp may be
+}
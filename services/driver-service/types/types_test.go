@@ -0,0 +1,29 @@
+package types
+
+import "testing"
+
+func TestMatchingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   uint
+		min     uint
+		wantErr bool
+	}{
+		{name: "narrowing search", start: 6, min: 4},
+		{name: "single precision", start: 5, min: 5},
+		{name: "widest and narrowest geohash", start: 12, min: 1},
+		{name: "zero min precision", start: 6, min: 0, wantErr: true},
+		{name: "min above start", start: 4, min: 6, wantErr: true},
+		{name: "start beyond the geohash length", start: 13, min: 4, wantErr: true},
+		{name: "negative env value wrapped around", start: 6, min: uint(1<<64 - 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &MatchingConfig{StartPrecision: tt.start, MinPrecision: tt.min, MaxCandidates: 5, AvgSpeedKmh: 25}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
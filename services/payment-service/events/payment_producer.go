@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/cprakhar/uber-clone/shared/messaging"
+)
+
+// publishTimeout bounds the wait for Kafka to acknowledge an event, well within the time Stripe
+// waits for a webhook response
+const publishTimeout = 10 * time.Second
+
+// paymentStatusEvents maps each final payment status to the event published when a payment enters it
+var paymentStatusEvents = map[types.PaymentStatus]messaging.Event[messaging.PaymentStatusUpdateData]{
+	types.PaymentStatusSuccess:   messaging.PaymentSucceeded,
+	types.PaymentStatusFailed:    messaging.PaymentFailed,
+	types.PaymentStatusCancelled: messaging.PaymentCancelled,
+}
+
+type PaymentEventProducer struct {
+	publisher *messaging.Publisher
+}
+
+// NewPaymentEventProducer creates a new PaymentEventProducer with the given publisher.
+func NewPaymentEventProducer(publisher *messaging.Publisher) *PaymentEventProducer {
+	return &PaymentEventProducer{publisher: publisher}
+}
+
+// PublishPaymentStatus publishes the "payment.event.*" event matching the payment's current status, keyed by the rider ID,
+// and waits for Kafka to acknowledge it so a failed publish fails the webhook and Stripe delivers it again.
+func (pep *PaymentEventProducer) PublishPaymentStatus(ctx context.Context, payment *types.Payment) error {
+	event, ok := paymentStatusEvents[payment.Status]
+	if !ok {
+		return fmt.Errorf("no event topic for payment status %q", payment.Status)
+	}
+
+	msg := messaging.PaymentStatusUpdateData{
+		TripID:    payment.TripID,
+		RiderID:   payment.RiderID,
+		DriverID:  payment.DriverID,
+		SessionID: payment.StripeSessionID,
+		Purpose:   string(payment.Purpose),
+		Status:    string(payment.Status),
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+	}
+
+	return messaging.PublishAndWait(ctx, pep.publisher, event, payment.RiderID, msg, publishTimeout)
+}
+
+// PublishRefund publishes a "payment.event.refunded" event for a refund the processor completed, keyed by the rider ID,
+// and waits for Kafka to acknowledge it.
+func (pep *PaymentEventProducer) PublishRefund(ctx context.Context, refund *types.Refund, payment *types.Payment) error {
+	msg := messaging.PaymentRefundedData{
+		TripID:         payment.TripID,
+		RiderID:        payment.RiderID,
+		DriverID:       payment.DriverID,
+		PaymentID:      payment.ID,
+		RefundID:       refund.ID,
+		Purpose:        string(payment.Purpose),
+		Reason:         string(refund.Reason),
+		Amount:         refund.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		Currency:       refund.Currency,
+		PaymentStatus:  string(payment.Status),
+	}
+
+	return messaging.PublishAndWait(ctx, pep.publisher, messaging.PaymentRefunded, payment.RiderID, msg, publishTimeout)
+}
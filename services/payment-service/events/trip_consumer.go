@@ -2,61 +2,61 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"time"
 
-	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
 	"github.com/cprakhar/uber-clone/shared/messaging"
 	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 )
 
 type TripConsumer struct {
-	kfClient *kafka.KafkaClient
-	svc      repo.Service
+	kfClient  *kafka.KafkaClient
+	publisher *messaging.Publisher
+	svc       repo.Service
 }
 
-func NewTripConsumer(kfClient *kafka.KafkaClient, svc repo.Service) *TripConsumer {
-	return &TripConsumer{kfClient: kfClient, svc: svc}
+func NewTripConsumer(kfClient *kafka.KafkaClient, publisher *messaging.Publisher, svc repo.Service) *TripConsumer {
+	return &TripConsumer{kfClient: kfClient, publisher: publisher, svc: svc}
 }
 
 func (tc *TripConsumer) Consume(ctx context.Context, topics []string) error {
-	return tc.kfClient.Consumer.SubscribeAndConsume(ctx, topics,
-		func(ctx context.Context, m *ckafka.Message) error {
-			var kafkaMsg contracts.KafkaMessage
-			if err := json.Unmarshal(m.Value, &kafkaMsg); err != nil {
-				log.Printf("Failed to unmarshal message: %v", err)
-			}
+	router := messaging.NewRouter()
+	messaging.Handle(router, messaging.PaymentCreateSession, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.PaymentTripResponseData) error {
+		if err := tc.handleTripAccepted(ctx, payload); err != nil {
+			log.Printf("Failed to handle trip accepted: %v", err)
+			return err
+		}
+		return nil
+	})
+	messaging.Handle(router, messaging.PaymentChargeCancellationFee, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.PaymentTripResponseData) error {
+		if err := tc.createSession(ctx, payload, types.PaymentPurposeCancellationFee); err != nil {
+			log.Printf("Failed to handle cancellation fee: %v", err)
+			return err
+		}
+		return nil
+	})
 
-			var payload messaging.PaymentTripResponseData
-			if kafkaMsg.Data != nil {
-				if err := json.Unmarshal(kafkaMsg.Data, &payload); err != nil {
-					log.Printf("Failed to unmarshal payload: %v", err)
-				}
-			}
-
-			switch *m.TopicPartition.Topic {
-			case contracts.PaymentCmdCreateSession:
-				if err := tc.handleTripAccepted(ctx, payload); err != nil {
-					log.Printf("Failed to handle trip accepted: %v", err)
-					return err
-				}
-			}
-			return nil
-		},
-	)
+	return tc.kfClient.Consumer.SubscribeAndConsume(ctx, topics, router.HandleMessage)
 }
 
 func (tc *TripConsumer) handleTripAccepted(ctx context.Context, payload messaging.PaymentTripResponseData) error {
-	log.Printf("Processing payment for trip %s with amount %.2f", payload.TripID, payload.Amount)
+	return tc.createSession(ctx, payload, types.PaymentPurposeRide)
+}
+
+// createSession creates a checkout session for the rider and sends it to them
+func (tc *TripConsumer) createSession(ctx context.Context, payload messaging.PaymentTripResponseData, purpose types.PaymentPurpose) error {
+	log.Printf("Processing %s payment for trip %s with amount %d", purpose, payload.TripID, payload.Amount)
 
 	paymentSession, err := tc.svc.CreatePaymentSession(ctx,
 		payload.TripID,
 		payload.RiderID,
 		payload.DriverID,
-		int64(payload.Amount),
+		payload.PackageSlug,
+		purpose,
+		payload.Amount,
 		payload.Currency,
 	)
 
@@ -72,21 +72,10 @@ func (tc *TripConsumer) handleTripAccepted(ctx context.Context, payload messagin
 		SessionID: paymentSession.StripeSessionID,
 		Amount:    float64(payload.Amount) / 100, // converting paise to rupees
 		Currency:  payload.Currency,
+		Purpose:   string(purpose),
 	}
 
-	data, err := json.Marshal(paymentPayload)
-	if err != nil {
-		log.Printf("Failed to marshal payload: %v", err)
-		return err
-	}
-
-	if err := tc.kfClient.Producer.SendMessageAndWait(ctx, contracts.PaymentEventSessionCreated,
-		&contracts.KafkaMessage{
-			EntityID: payload.RiderID,
-			Data:     data,
-		},
-		30*time.Second,
-	); err != nil {
+	if err := messaging.PublishAndWait(ctx, tc.publisher, messaging.PaymentSessionCreated, payload.RiderID, paymentPayload, 30*time.Second); err != nil {
 		log.Printf("Failed to send payment session created message: %v", err)
 		return err
 	}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/handler"
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"google.golang.org/grpc"
+)
+
+type gRPCServer struct {
+	addr           string
+	paymentService repo.Service
+	publisher      handler.Publisher
+}
+
+func NewgRPCServer(addr string, svc repo.Service, publisher handler.Publisher) *gRPCServer {
+	return &gRPCServer{addr: addr, paymentService: svc, publisher: publisher}
+}
+
+func (s *gRPCServer) run(ctx context.Context) error {
+	// Start listening on the specified address
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		log.Printf("Failed to listen on %s: %v", s.addr, err)
+		return err
+	}
+
+	// gRPC server setup
+	srv := grpc.NewServer()
+	handler.NewgRPCHandler(srv, s.paymentService, s.publisher)
+
+	// Graceful shutdown on context cancellation
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	// Start serving
+	log.Printf("gRPC server running on %s", s.addr)
+	if err := srv.Serve(lis); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to serve gRPC server: %v", err)
+		return err
+	}
+	return nil
+}
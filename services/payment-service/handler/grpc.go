@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/service"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	pb "github.com/cprakhar/uber-clone/shared/proto/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type gRPCHandler struct {
+	pb.UnimplementedPaymentServiceServer
+	svc       repo.Service
+	publisher Publisher
+}
+
+func NewgRPCHandler(srv *grpc.Server, svc repo.Service, publisher Publisher) {
+	handler := &gRPCHandler{svc: svc, publisher: publisher}
+	pb.RegisterPaymentServiceServer(srv, handler)
+}
+
+func (h *gRPCHandler) GetPaymentByTrip(ctx context.Context, req *pb.GetPaymentByTripRequest) (*pb.GetPaymentByTripResponse, error) {
+	tripID := req.GetTripID()
+	if tripID == "" {
+		return nil, status.Error(codes.InvalidArgument, "tripID is required")
+	}
+
+	payment, err := h.svc.GetPaymentByTrip(ctx, tripID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "no payment for trip %s", tripID)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get payment: %v", err)
+	}
+
+	return &pb.GetPaymentByTripResponse{
+		Payment: payment.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) ListPaymentsByRider(ctx context.Context, req *pb.ListPaymentsByRiderRequest) (*pb.ListPaymentsByRiderResponse, error) {
+	riderID := req.GetRiderID()
+	if riderID == "" {
+		return nil, status.Error(codes.InvalidArgument, "riderID is required")
+	}
+
+	var before time.Time
+	if req.GetBefore() > 0 {
+		before = time.UnixMilli(req.GetBefore())
+	}
+
+	payments, err := h.svc.ListPaymentsByRider(ctx, riderID, before, int(req.GetLimit()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list payments: %v", err)
+	}
+
+	res := &pb.ListPaymentsByRiderResponse{
+		Payments: make([]*pb.Payment, 0, len(payments)),
+	}
+	for _, payment := range payments {
+		res.Payments = append(res.Payments, payment.ToProto())
+	}
+	return res, nil
+}
+
+func (h *gRPCHandler) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.RefundPaymentResponse, error) {
+	paymentID := req.GetPaymentID()
+	if paymentID == "" {
+		return nil, status.Error(codes.InvalidArgument, "paymentID is required")
+	}
+
+	refund, payment, err := h.svc.RefundPayment(ctx, paymentID, req.GetAmount(), types.RefundReason(req.GetReason()), req.GetIdempotencyKey())
+	switch {
+	case errors.Is(err, service.ErrInvalidRefund):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		return nil, status.Errorf(codes.NotFound, "payment %s not found", paymentID)
+	case errors.Is(err, repo.ErrRefundExceedsPayment), errors.Is(err, service.ErrIdempotencyKeyReuse):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrRefundRejected):
+		return nil, status.Errorf(codes.Aborted, "refund rejected: %v", err)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to refund payment: %v", err)
+	}
+
+	// Refunds that complete later are published when their webhook arrives
+	if refund.Status == types.RefundStatusSucceeded {
+		if err := h.publisher.PublishRefund(ctx, refund, payment); err != nil {
+			log.Printf("Failed to publish refund %s of trip %s: %v", refund.ID, refund.TripID, err)
+		}
+	}
+
+	return &pb.RefundPaymentResponse{
+		Refund:  refund.ToProto(),
+		Payment: payment.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) GetDriverBalance(ctx context.Context, req *pb.GetDriverBalanceRequest) (*pb.GetDriverBalanceResponse, error) {
+	driverID := req.GetDriverID()
+	if driverID == "" {
+		return nil, status.Error(codes.InvalidArgument, "driverID is required")
+	}
+
+	balances, err := h.svc.GetDriverBalance(ctx, driverID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get driver balance: %v", err)
+	}
+
+	res := &pb.GetDriverBalanceResponse{
+		Balances: make([]*pb.DriverBalance, 0, len(balances)),
+	}
+	for _, balance := range balances {
+		// The driver's account is credited with what they earn
+		res.Balances = append(res.Balances, &pb.DriverBalance{
+			Currency: balance.Currency,
+			Earned:   balance.Credits,
+			Deducted: balance.Debits,
+			Payable:  -balance.Net(),
+		})
+	}
+	return res, nil
+}
+
+func (h *gRPCHandler) GetDriverEarnings(ctx context.Context, req *pb.GetDriverEarningsRequest) (*pb.GetDriverEarningsResponse, error) {
+	driverID := req.GetDriverID()
+	if driverID == "" {
+		return nil, status.Error(codes.InvalidArgument, "driverID is required")
+	}
+
+	var from, to time.Time
+	if req.GetFrom() > 0 {
+		from = time.UnixMilli(req.GetFrom())
+	}
+	if req.GetTo() > 0 {
+		to = time.UnixMilli(req.GetTo())
+	}
+
+	earnings, err := h.svc.GetDriverEarnings(ctx, driverID, ledger.Period(req.GetPeriod()), from, to)
+	if errors.Is(err, service.ErrInvalidEarningsQuery) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get driver earnings: %v", err)
+	}
+
+	res := &pb.GetDriverEarningsResponse{
+		Earnings: make([]*pb.DriverEarnings, 0, len(earnings)),
+	}
+	for _, e := range earnings {
+		res.Earnings = append(res.Earnings, &pb.DriverEarnings{
+			Key:      e.Key,
+			Start:    e.Start.UnixMilli(),
+			Currency: e.Currency,
+			Trips:    int32(e.Trips),
+			Gross:    e.Gross,
+			Refunded: e.Refunded,
+			Net:      e.Net(),
+		})
+	}
+	return res, nil
+}
+
+func (h *gRPCHandler) ListDriverPayouts(ctx context.Context, req *pb.ListDriverPayoutsRequest) (*pb.ListDriverPayoutsResponse, error) {
+	driverID := req.GetDriverID()
+	if driverID == "" {
+		return nil, status.Error(codes.InvalidArgument, "driverID is required")
+	}
+
+	var before time.Time
+	if req.GetBefore() > 0 {
+		before = time.UnixMilli(req.GetBefore())
+	}
+
+	payouts, err := h.svc.ListDriverPayouts(ctx, driverID, before, int(req.GetLimit()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list payouts: %v", err)
+	}
+
+	res := &pb.ListDriverPayoutsResponse{
+		Payouts: make([]*pb.Payout, 0, len(payouts)),
+	}
+	for _, payout := range payouts {
+		res.Payouts = append(res.Payouts, payout.ToProto())
+	}
+	return res, nil
+}
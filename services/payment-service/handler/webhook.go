@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/service"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+// maxWebhookBodyBytes caps the size of a webhook request, Stripe events are well below it
+const maxWebhookBodyBytes = 64 << 10
+
+// Publisher notifies other services about the outcome of a payment or a refund
+type Publisher interface {
+	PublishPaymentStatus(ctx context.Context, payment *types.Payment) error
+	PublishRefund(ctx context.Context, refund *types.Refund, payment *types.Payment) error
+}
+
+type WebhookHandler struct {
+	svc       repo.Service
+	publisher Publisher
+	secret    string
+}
+
+// NewWebhookHandler creates a handler for Stripe webhooks signed with the given endpoint secret
+func NewWebhookHandler(svc repo.Service, publisher Publisher, secret string) *WebhookHandler {
+	return &WebhookHandler{svc: svc, publisher: publisher, secret: secret}
+}
+
+// ServeHTTP verifies the signature of a Stripe event and applies it to the payment of its checkout session.
+// Stripe retries deliveries answered with a non-2xx status, so only failures worth retrying return one.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	// Only the session ID and status are read from the event, so events of other API versions are accepted
+	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), h.secret,
+		webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+	if err != nil {
+		log.Printf("Rejected webhook: %v", err)
+		http.Error(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	if event.Type == stripe.EventTypeRefundUpdated || event.Type == stripe.EventTypeRefundFailed {
+		h.handleRefundEvent(w, r, event)
+		return
+	}
+
+	var session stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := sessionOutcome(event.Type, &session)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	payment, err := h.svc.UpdatePaymentStatus(r.Context(), session.ID, status, time.Unix(event.Created, 0))
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		log.Printf("Ignoring %s for unknown session %s", event.Type, session.ID)
+		w.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, types.ErrInvalidTransition):
+		// A redelivered event is published again in case the first delivery failed to publish,
+		// any other event for a settled session is stale
+		payment, err = h.svc.GetPaymentBySessionID(r.Context(), session.ID)
+		if err != nil || payment.Status != status {
+			log.Printf("Ignoring %s for session %s: payment already settled", event.Type, session.ID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	case err != nil:
+		log.Printf("Failed to update payment for session %s: %v", session.ID, err)
+		http.Error(w, "failed to update payment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.publisher.PublishPaymentStatus(r.Context(), payment); err != nil {
+		log.Printf("Failed to publish %s payment for trip %s: %v", payment.Status, payment.TripID, err)
+		http.Error(w, "failed to publish payment status", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Payment for trip %s is %s", payment.TripID, payment.Status)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRefundEvent records the outcome of a refund Stripe completed after accepting it
+func (h *WebhookHandler) handleRefundEvent(w http.ResponseWriter, r *http.Request, event stripe.Event) {
+	var stripeRefund stripe.Refund
+	if err := json.Unmarshal(event.Data.Raw, &stripeRefund); err != nil {
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+
+	status := service.RefundStatusFromStripe(stripeRefund.Status)
+	if status == types.RefundStatusPending {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	refund, payment, err := h.svc.SettleRefund(r.Context(), stripeRefund.ID, status, time.Unix(event.Created, 0))
+	switch {
+	case errors.Is(err, repo.ErrRefundNotFound):
+		log.Printf("Ignoring %s for unknown refund %s", event.Type, stripeRefund.ID)
+		w.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, types.ErrInvalidTransition):
+		// A redelivered outcome is published again in case the first delivery failed to publish,
+		// any other event for a settled refund is stale
+		if refund == nil {
+			log.Printf("Ignoring %s for refund %s: refund already settled", event.Type, stripeRefund.ID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	case err != nil:
+		log.Printf("Failed to settle refund %s: %v", stripeRefund.ID, err)
+		http.Error(w, "failed to settle refund", http.StatusInternalServerError)
+		return
+	}
+
+	if refund.Status == types.RefundStatusSucceeded {
+		if err := h.publisher.PublishRefund(r.Context(), refund, payment); err != nil {
+			log.Printf("Failed to publish refund %s of trip %s: %v", refund.ID, refund.TripID, err)
+			http.Error(w, "failed to publish refund", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.Printf("Refund %s of trip %s %s", refund.ID, refund.TripID, refund.Status)
+	w.WriteHeader(http.StatusOK)
+}
+
+// sessionOutcome maps a checkout session event to the payment status it settles on.
+// A session completed with a delayed payment method stays pending until the async payment events arrive.
+func sessionOutcome(eventType stripe.EventType, session *stripe.CheckoutSession) (types.PaymentStatus, bool) {
+	switch eventType {
+	case stripe.EventTypeCheckoutSessionCompleted:
+		if session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid {
+			return types.PaymentStatusSuccess, true
+		}
+	case stripe.EventTypeCheckoutSessionAsyncPaymentSucceeded:
+		return types.PaymentStatusSuccess, true
+	case stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+		return types.PaymentStatusFailed, true
+	case stripe.EventTypeCheckoutSessionExpired:
+		return types.PaymentStatusCancelled, true
+	}
+	return "", false
+}
@@ -0,0 +1,487 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/handler"
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/service"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+// secret is the webhook endpoint secret the fixtures are signed with
+const secret = "whsec_fixture"
+
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// fixture returns the Stripe event stored in testdata/<name>.json
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	payload, err := fixtures.ReadFile("testdata/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+// sign returns the Stripe-Signature header for the payload signed with secret at the given time
+func sign(payload []byte, secret string, at time.Time) string {
+	return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    secret,
+		Timestamp: at,
+	}).Header
+}
+
+// fixtureProcessor is a PaymentProcessor that creates the checkout sessions the fixtures refer to,
+// named "cs_test_<tripID>"
+type fixtureProcessor struct{}
+
+func (fixtureProcessor) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string, idempotencyKey string) (string, error) {
+	return "cs_test_" + metadata["tripID"], nil
+}
+
+func (fixtureProcessor) Refund(ctx context.Context, sessionID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.ProviderRefund, error) {
+	return &types.ProviderRefund{ID: "re_test_" + idempotencyKey, Status: types.RefundStatusPending}, nil
+}
+
+func (fixtureProcessor) Provider() string {
+	return "fixture"
+}
+
+// publisher records the payments and refunds published by the handler, or fails while err is set
+type publisher struct {
+	mu        sync.Mutex
+	err       error
+	published []*types.Payment
+	refunds   []*types.Refund
+}
+
+func (p *publisher) PublishPaymentStatus(ctx context.Context, payment *types.Payment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, payment)
+	return nil
+}
+
+func (p *publisher) PublishRefund(ctx context.Context, refund *types.Refund, payment *types.Payment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.refunds = append(p.refunds, refund)
+	return nil
+}
+
+func (p *publisher) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// take returns and clears the recorded payments
+func (p *publisher) take() []*types.Payment {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	published := p.published
+	p.published = nil
+	return published
+}
+
+// takeRefunds returns and clears the recorded refunds
+func (p *publisher) takeRefunds() []*types.Refund {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	refunds := p.refunds
+	p.refunds = nil
+	return refunds
+}
+
+func newPaymentService(processor repo.PaymentProcessor) repo.Service {
+	ledger := ledger.NewLedger(ledger.NewInMemoStore(), ledger.DefaultConfig())
+	return service.NewPaymentService(processor, repo.NewInMemoRepository(), ledger, payout.NewInMemoStore(), time.UTC)
+}
+
+// harness is a webhook handler backed by an in-memory payment store
+type harness struct {
+	t         *testing.T
+	svc       repo.Service
+	publisher *publisher
+	handler   http.Handler
+}
+
+// newHarness creates a harness with a pending payment for each trip
+func newHarness(t *testing.T, tripIDs ...string) *harness {
+	t.Helper()
+	svc := newPaymentService(fixtureProcessor{})
+	for _, tripID := range tripIDs {
+		if _, err := svc.CreatePaymentSession(t.Context(), tripID, "rider", "driver", "sedan", types.PaymentPurposeRide, 25000, "inr"); err != nil {
+			t.Fatalf("create session for %s: %v", tripID, err)
+		}
+	}
+	p := &publisher{}
+	return &harness{t: t, svc: svc, publisher: p, handler: handler.NewWebhookHandler(svc, p, secret)}
+}
+
+// deliver sends the fixture to the handler and checks the response code
+// and the status of the payment published in response, if any
+func (h *harness) deliver(name, secret string, at time.Time, wantCode int, wantPublished types.PaymentStatus) {
+	h.t.Helper()
+	payload := fixture(h.t, name)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/stripe", bytes.NewReader(payload))
+	req.Header.Set("Stripe-Signature", sign(payload, secret, at))
+	rec := httptest.NewRecorder()
+	h.handler.ServeHTTP(rec, req)
+
+	if rec.Code != wantCode {
+		h.t.Fatalf("%s: got status code %d, want %d", name, rec.Code, wantCode)
+	}
+
+	published := h.publisher.take()
+	switch {
+	case wantPublished == "" && len(published) > 0:
+		h.t.Fatalf("%s: published %s payment, want nothing published", name, published[0].Status)
+	case wantPublished != "" && len(published) != 1:
+		h.t.Fatalf("%s: published %d payments, want 1", name, len(published))
+	case wantPublished != "" && published[0].Status != wantPublished:
+		h.t.Fatalf("%s: published %s payment, want %s", name, published[0].Status, wantPublished)
+	}
+}
+
+// payment returns the payment collected through the session
+func (h *harness) payment(sessionID string) *types.Payment {
+	h.t.Helper()
+	payment, err := h.svc.GetPaymentBySessionID(h.t.Context(), sessionID)
+	if err != nil {
+		h.t.Fatalf("get payment of %s: %v", sessionID, err)
+	}
+	return payment
+}
+
+// checkStatus checks the stored status of the payment collected through the session
+func (h *harness) checkStatus(sessionID string, want types.PaymentStatus) {
+	h.t.Helper()
+	if payment := h.payment(sessionID); payment.Status != want {
+		h.t.Fatalf("payment of %s is %s, want %s", sessionID, payment.Status, want)
+	}
+}
+
+// refundPaid settles the "paid" session and asks for a refund the processor completes later,
+// with the provider reference the refund fixtures carry
+func (h *harness) refundPaid() {
+	h.t.Helper()
+	h.deliver("session_completed_paid", secret, time.Now(), http.StatusOK, types.PaymentStatusSuccess)
+	payment := h.payment("cs_test_paid")
+	refund, _, err := h.svc.RefundPayment(h.t.Context(), payment.ID, 10000, types.RefundReasonFareDispute, "refund-1")
+	if err != nil {
+		h.t.Fatalf("RefundPayment: %v", err)
+	}
+	if refund.Status != types.RefundStatusPending || refund.ProviderReference != "re_test_refund-1" {
+		h.t.Fatalf("RefundPayment: got %s refund %q, want pending re_test_refund-1", refund.Status, refund.ProviderReference)
+	}
+}
+
+// TestWebhook drives the webhook handler with signed Stripe event fixtures and with the
+// webhooks of the fake payment processor, without a network or a Stripe account
+func TestWebhook(t *testing.T) {
+	t.Run("paid session", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		h.deliver("session_completed_paid", secret, time.Now(), http.StatusOK, types.PaymentStatusSuccess)
+		h.checkStatus("cs_test_paid", types.PaymentStatusSuccess)
+	})
+
+	t.Run("redelivered event", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		for range 2 {
+			// Every delivery is published, in case an earlier one failed to publish
+			h.deliver("session_completed_paid", secret, time.Now(), http.StatusOK, types.PaymentStatusSuccess)
+		}
+		h.checkStatus("cs_test_paid", types.PaymentStatusSuccess)
+	})
+
+	t.Run("failed publish", func(t *testing.T) {
+		// Stripe delivers an event answered with an error again, and the redelivery publishes it
+		h := newHarness(t, "paid")
+		h.publisher.setErr(errors.New("kafka unavailable"))
+		h.deliver("session_completed_paid", secret, time.Now(), http.StatusInternalServerError, "")
+		h.publisher.setErr(nil)
+		h.deliver("session_completed_paid", secret, time.Now(), http.StatusOK, types.PaymentStatusSuccess)
+
+		h.refundPaid()
+		h.publisher.setErr(errors.New("kafka unavailable"))
+		h.deliver("refund_succeeded", secret, time.Now(), http.StatusInternalServerError, "")
+		h.publisher.setErr(nil)
+		h.deliver("refund_succeeded", secret, time.Now(), http.StatusOK, "")
+		if refunds := h.publisher.takeRefunds(); len(refunds) != 1 {
+			t.Fatalf("published %d refunds, want 1", len(refunds))
+		}
+	})
+
+	t.Run("async payment", func(t *testing.T) {
+		h := newHarness(t, "async")
+		h.deliver("session_completed_unpaid", secret, time.Now(), http.StatusOK, "")
+		h.checkStatus("cs_test_async", types.PaymentStatusPending)
+		h.deliver("async_payment_failed", secret, time.Now(), http.StatusOK, types.PaymentStatusFailed)
+		h.checkStatus("cs_test_async", types.PaymentStatusFailed)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		h := newHarness(t, "expired")
+		h.deliver("session_expired", secret, time.Now(), http.StatusOK, types.PaymentStatusCancelled)
+		h.checkStatus("cs_test_expired", types.PaymentStatusCancelled)
+	})
+
+	t.Run("settled session", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		h.deliver("session_completed_paid", secret, time.Now(), http.StatusOK, types.PaymentStatusSuccess)
+		// A late expiry must not undo the payment
+		h.deliver("session_expired_after_payment", secret, time.Now(), http.StatusOK, "")
+		h.checkStatus("cs_test_paid", types.PaymentStatusSuccess)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		h := newHarness(t)
+		h.deliver("unknown_session", secret, time.Now(), http.StatusOK, "")
+	})
+
+	t.Run("refund succeeded", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		h.refundPaid()
+		for i := range 2 {
+			// Every delivery is published, in case an earlier one failed to publish
+			h.deliver("refund_succeeded", secret, time.Now(), http.StatusOK, "")
+			if refunds := h.publisher.takeRefunds(); len(refunds) != 1 {
+				t.Fatalf("delivery %d: published %d refunds, want 1", i+1, len(refunds))
+			}
+		}
+
+		payment := h.payment("cs_test_paid")
+		if payment.Status != types.PaymentStatusPartiallyRefunded || payment.RefundedAmount != 10000 || payment.Refundable() != 15000 {
+			t.Fatalf("payment is %s with %d refunded and %d refundable, want partially_refunded with 10000 and 15000",
+				payment.Status, payment.RefundedAmount, payment.Refundable())
+		}
+	})
+
+	t.Run("refund failed", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		h.refundPaid()
+		h.deliver("refund_failed", secret, time.Now(), http.StatusOK, "")
+		if refunds := h.publisher.takeRefunds(); len(refunds) > 0 {
+			t.Fatalf("published %d refunds, want none", len(refunds))
+		}
+
+		// The amount held for the failed refund can be refunded again
+		payment := h.payment("cs_test_paid")
+		if payment.Status != types.PaymentStatusSuccess || payment.Refundable() != payment.Amount {
+			t.Fatalf("payment is %s with %d refundable, want success with %d", payment.Status, payment.Refundable(), payment.Amount)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		h := newHarness(t, "paid")
+		h.deliver("session_completed_paid", "whsec_other", time.Now(), http.StatusBadRequest, "")
+		h.deliver("session_completed_paid", secret, time.Now().Add(-time.Hour), http.StatusBadRequest, "")
+
+		// A payload altered after signing
+		payload := fixture(t, "session_completed_paid")
+		tampered := strings.Replace(string(payload), `"amount_total": 25000`, `"amount_total": 1`, 1)
+		req := httptest.NewRequest(http.MethodPost, "/webhook/stripe", strings.NewReader(tampered))
+		req.Header.Set("Stripe-Signature", sign(payload, secret, time.Now()))
+		rec := httptest.NewRecorder()
+		h.handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("tampered payload: got status code %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+
+		if published := h.publisher.take(); len(published) > 0 {
+			t.Fatalf("published %d payments for rejected events", len(published))
+		}
+		h.checkStatus("cs_test_paid", types.PaymentStatusPending)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h := newHarness(t)
+		rec := httptest.NewRecorder()
+		h.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhook/stripe", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("got status code %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("fake processor", testFakeProcessor)
+}
+
+// fakeProcessor is the fake payment processor, which settles sessions through webhooks
+type fakeProcessor interface {
+	repo.PaymentProcessor
+	SetWebhookURL(url string)
+	SetOutcome(outcome types.FakeOutcome)
+}
+
+// fakeHarness is a payment service over the fake processor, whose webhooks are delivered to the
+// handler over a local listener
+type fakeHarness struct {
+	t         *testing.T
+	processor fakeProcessor
+	svc       repo.Service
+	publisher *publisher
+}
+
+func newFakeHarness(t *testing.T, outcome types.FakeOutcome) *fakeHarness {
+	processor := service.NewFakeProcessor(&types.FakeProcessorConfig{Outcome: outcome, WebhookSecret: secret})
+	svc := newPaymentService(processor)
+	p := &publisher{}
+
+	server := httptest.NewServer(handler.NewWebhookHandler(svc, p, secret))
+	t.Cleanup(server.Close)
+	processor.SetWebhookURL(server.URL + "/webhook/stripe")
+	return &fakeHarness{t: t, processor: processor, svc: svc, publisher: p}
+}
+
+func (h *fakeHarness) createSession() *types.PaymentIntent {
+	h.t.Helper()
+	intent, err := h.svc.CreatePaymentSession(h.t.Context(), "trip", "rider", "driver", "sedan", types.PaymentPurposeRide, 25000, "inr")
+	if err != nil {
+		h.t.Fatalf("CreatePaymentSession: %v", err)
+	}
+	return intent
+}
+
+// awaitPublished waits for the fake processor's webhooks to settle the session
+func (h *fakeHarness) awaitPublished(sessionID string) *types.Payment {
+	h.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if published := h.publisher.take(); len(published) > 0 {
+			if published[0].StripeSessionID != sessionID {
+				h.t.Fatalf("published payment of %s, want %s", published[0].StripeSessionID, sessionID)
+			}
+			return published[0]
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	h.t.Fatalf("no payment published for session %s", sessionID)
+	return nil
+}
+
+func testFakeProcessor(t *testing.T) {
+	outcomes := []struct {
+		outcome types.FakeOutcome
+		want    types.PaymentStatus
+	}{
+		{types.FakeOutcomeSucceed, types.PaymentStatusSuccess},
+		{types.FakeOutcomeFail, types.PaymentStatusFailed},
+		{types.FakeOutcomeExpire, types.PaymentStatusCancelled},
+	}
+	for _, o := range outcomes {
+		t.Run(string(o.outcome), func(t *testing.T) {
+			h := newFakeHarness(t, o.outcome)
+			intent := h.createSession()
+
+			if published := h.awaitPublished(intent.StripeSessionID); published.Status != o.want {
+				t.Fatalf("published %s payment, want %s", published.Status, o.want)
+			}
+			payment, err := h.svc.GetPaymentBySessionID(t.Context(), intent.StripeSessionID)
+			if err != nil {
+				t.Fatalf("GetPaymentBySessionID: %v", err)
+			}
+			if payment.Status != o.want {
+				t.Fatalf("payment is %s, want %s", payment.Status, o.want)
+			}
+		})
+	}
+
+	t.Run(string(types.FakeOutcomeDecline), func(t *testing.T) {
+		svc := newPaymentService(service.NewFakeProcessor(&types.FakeProcessorConfig{Outcome: types.FakeOutcomeDecline, WebhookSecret: secret}))
+		_, err := svc.CreatePaymentSession(t.Context(), "trip", "rider", "driver", "sedan", types.PaymentPurposeRide, 25000, "inr")
+		if !errors.Is(err, service.ErrPaymentDeclined) {
+			t.Fatalf("CreatePaymentSession: got %v, want ErrPaymentDeclined", err)
+		}
+	})
+
+	t.Run("refund", testFakeRefund)
+	t.Run("redelivery", testFakeRedelivery)
+}
+
+func testFakeRefund(t *testing.T) {
+	h := newFakeHarness(t, types.FakeOutcomeSucceed)
+	ctx := t.Context()
+	payment := h.awaitPublished(h.createSession().StripeSessionID)
+
+	partial, _, err := h.svc.RefundPayment(ctx, payment.ID, 10000, types.RefundReasonFareDispute, "partial")
+	if err != nil {
+		t.Fatalf("partial refund: %v", err)
+	}
+	if partial.Status != types.RefundStatusSucceeded {
+		t.Fatalf("partial refund is %s, want succeeded", partial.Status)
+	}
+	retried, _, err := h.svc.RefundPayment(ctx, payment.ID, 10000, types.RefundReasonFareDispute, "partial")
+	if err != nil || retried.ID != partial.ID {
+		t.Fatalf("retried partial refund: got %v, %v, want refund %s", retried, err, partial.ID)
+	}
+	if _, _, err := h.svc.RefundPayment(ctx, payment.ID, 5000, types.RefundReasonFareDispute, "partial"); !errors.Is(err, service.ErrIdempotencyKeyReuse) {
+		t.Fatalf("reused key: got %v, want ErrIdempotencyKeyReuse", err)
+	}
+
+	// An amount of 0 refunds the rest
+	rest, refunded, err := h.svc.RefundPayment(ctx, payment.ID, 0, types.RefundReasonServiceIssue, "rest")
+	if err != nil {
+		t.Fatalf("full refund: %v", err)
+	}
+	if rest.Amount != 15000 || refunded.Status != types.PaymentStatusRefunded || refunded.RefundedAmount != 25000 {
+		t.Fatalf("full refund of %d left the payment %s with %d refunded, want 15000 and refunded with 25000",
+			rest.Amount, refunded.Status, refunded.RefundedAmount)
+	}
+	if _, _, err := h.svc.RefundPayment(ctx, payment.ID, 1, types.RefundReasonServiceIssue, "more"); !errors.Is(err, repo.ErrRefundExceedsPayment) {
+		t.Fatalf("refund of a refunded payment: got %v, want ErrRefundExceedsPayment", err)
+	}
+
+	// The ledger reverses everything the driver earned from the payment
+	balances, err := h.svc.GetDriverBalance(ctx, "driver")
+	if err != nil {
+		t.Fatalf("GetDriverBalance: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Credits == 0 || balances[0].Net() != 0 {
+		t.Fatalf("GetDriverBalance: got %d balances, want one that earned and was fully reversed", len(balances))
+	}
+}
+
+// testFakeRedelivery requests a session for the same trip again, as a redelivered command does
+func testFakeRedelivery(t *testing.T) {
+	h := newFakeHarness(t, types.FakeOutcomeFail)
+
+	// A pending session is reused
+	failed := h.createSession()
+	if again := h.createSession(); again.StripeSessionID != failed.StripeSessionID {
+		t.Fatalf("CreatePaymentSession of a pending trip: got session %s, want %s", again.StripeSessionID, failed.StripeSessionID)
+	}
+	h.awaitPublished(failed.StripeSessionID)
+
+	// A failed session is replaced, and the paid replacement is reused
+	h.processor.SetOutcome(types.FakeOutcomeSucceed)
+	paid := h.createSession()
+	if paid.StripeSessionID == failed.StripeSessionID {
+		t.Fatalf("CreatePaymentSession after a failure: got the failed session %s again", failed.StripeSessionID)
+	}
+	h.awaitPublished(paid.StripeSessionID)
+	if again := h.createSession(); again.StripeSessionID != paid.StripeSessionID {
+		t.Fatalf("CreatePaymentSession of a paid trip: got session %s, want %s", again.StripeSessionID, paid.StripeSessionID)
+	}
+}
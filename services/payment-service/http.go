@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+type httpServer struct {
+	addr    string
+	webhook http.Handler
+}
+
+// NewhttpServer creates a new http server instance serving the payment processor's webhooks
+func NewhttpServer(addr string, webhook http.Handler) *httpServer {
+	return &httpServer{addr: addr, webhook: webhook}
+}
+
+// run starts the http server
+func (s *httpServer) run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/webhook/stripe", s.webhook)
+
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Start the server in a separate goroutine
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("http server running on %s", s.addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	// Wait for context cancellation or server error
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("http server error: %w", err)
+		}
+	}
+
+	// Graceful shutdown with a timeout
+	shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shCtx); err != nil {
+		return fmt.Errorf("http server shutdown error: %w", err)
+	}
+
+	log.Println("http server gracefully stopped")
+	return nil
+}
@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PackageCommission overrides the platform commission for a car package
+type PackageCommission struct {
+	Slug              string  `json:"slug" yaml:"slug"`
+	CommissionPercent float64 `json:"commissionPercent" yaml:"commissionPercent"`
+}
+
+// Config sets how each payment is split between the driver, the platform and taxes.
+// Fares include tax, and the commission is taken from the fare net of tax.
+type Config struct {
+	TaxPercent        float64              `json:"taxPercent" yaml:"taxPercent"`
+	CommissionPercent float64              `json:"commissionPercent" yaml:"commissionPercent"` // for packages without an override
+	Packages          []*PackageCommission `json:"packages" yaml:"packages"`
+}
+
+// DefaultConfig returns the built-in split used when no config file is provided
+func DefaultConfig() *Config {
+	return &Config{
+		TaxPercent:        5,
+		CommissionPercent: 20,
+		Packages: []*PackageCommission{
+			{Slug: "bike", CommissionPercent: 15},
+			{Slug: "auto", CommissionPercent: 15},
+			{Slug: "sedan", CommissionPercent: 20},
+			{Slug: "suv", CommissionPercent: 22},
+		},
+	}
+}
+
+// LoadConfig reads a ledger configuration from a YAML or JSON file, chosen by extension.
+// An empty path returns the default configuration.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger config: %w", err)
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported ledger config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ledger config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that every percentage is within range and each package is listed once
+func (c *Config) Validate() error {
+	if c.TaxPercent < 0 || c.TaxPercent >= 100 {
+		return fmt.Errorf("ledger config: tax percent %v is out of range", c.TaxPercent)
+	}
+	if c.CommissionPercent < 0 || c.CommissionPercent > 100 {
+		return fmt.Errorf("ledger config: commission percent %v is out of range", c.CommissionPercent)
+	}
+
+	seen := make(map[string]bool, len(c.Packages))
+	for _, p := range c.Packages {
+		if p.Slug == "" {
+			return fmt.Errorf("ledger config: package slug is required")
+		}
+		if seen[p.Slug] {
+			return fmt.Errorf("ledger config: duplicate package %q", p.Slug)
+		}
+		seen[p.Slug] = true
+
+		if p.CommissionPercent < 0 || p.CommissionPercent > 100 {
+			return fmt.Errorf("ledger config: package %q commission percent %v is out of range", p.Slug, p.CommissionPercent)
+		}
+	}
+	return nil
+}
+
+// Commission returns the commission percentage of the package, or the default for unknown packages
+func (c *Config) Commission(packageSlug string) float64 {
+	for _, p := range c.Packages {
+		if p.Slug == packageSlug {
+			return p.CommissionPercent
+		}
+	}
+	return c.CommissionPercent
+}
@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Period is how driver earnings are grouped
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+	PeriodTrip Period = "trip"
+)
+
+// IsValid reports whether p is a known period
+func (p Period) IsValid() bool {
+	return p == PeriodDay || p == PeriodWeek || p == PeriodTrip
+}
+
+// Earnings sums what a driver earned in a day, a week or a trip, in one currency
+type Earnings struct {
+	Key      string    `json:"key"`   // the date of the day, the date of the Monday starting the week, or the trip ID
+	Start    time.Time `json:"start"` // start of the day or week, or when the trip was first paid for
+	Currency string    `json:"currency"`
+	Trips    int       `json:"trips"`    // trips paid for, including cancellation fees
+	Gross    int64     `json:"gross"`    // the driver's share of payments
+	Refunded int64     `json:"refunded"` // shares reversed by refunds
+}
+
+// Net returns the earnings left after refunds
+func (e *Earnings) Net() int64 {
+	return e.Gross - e.Refunded
+}
+
+// DriverEarnings groups the driver's share of payments and refunds made in [from, to) by period,
+// oldest first. Days and weeks start at midnight in loc, and refunds count towards the period
+// they were made in. Payouts do not change earnings.
+func (l *Ledger) DriverEarnings(ctx context.Context, driverID string, period Period, from, to time.Time, loc *time.Location) ([]*Earnings, error) {
+	if !period.IsValid() {
+		return nil, fmt.Errorf("unknown earnings period %q", period)
+	}
+
+	account := DriverPayable(driverID)
+	transactions, err := l.store.ListByAccount(ctx, account, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		group    string
+		currency string
+	}
+	groups := make(map[key]*Earnings)
+	trips := make(map[key]map[string]bool)
+
+	for _, tx := range transactions {
+		if tx.Kind != KindRidePayment && tx.Kind != KindCancellationFee && tx.Kind != KindRefund {
+			continue
+		}
+
+		group, start := periodOf(period, tx, loc)
+		k := key{group, tx.Currency}
+		earnings, ok := groups[k]
+		if !ok {
+			earnings = &Earnings{Key: group, Start: start, Currency: tx.Currency}
+			groups[k] = earnings
+			trips[k] = make(map[string]bool)
+		}
+
+		// The driver's account is credited with earnings and debited with reversals
+		amount := tx.Amount(account)
+		if tx.Kind == KindRefund {
+			earnings.Refunded += amount
+			continue
+		}
+		earnings.Gross -= amount
+		if !trips[k][tx.TripID] {
+			trips[k][tx.TripID] = true
+			earnings.Trips++
+		}
+	}
+
+	summaries := make([]*Earnings, 0, len(groups))
+	for _, earnings := range groups {
+		summaries = append(summaries, earnings)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].Start.Equal(summaries[j].Start) {
+			return summaries[i].Start.Before(summaries[j].Start)
+		}
+		return summaries[i].Currency < summaries[j].Currency
+	})
+	return summaries, nil
+}
+
+// periodOf returns the key and start of the period the transaction falls in
+func periodOf(period Period, tx *Transaction, loc *time.Location) (string, time.Time) {
+	at := tx.CreatedAt.In(loc)
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+
+	switch period {
+	case PeriodWeek:
+		// Weeks start on Monday
+		monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return monday.Format(time.DateOnly), monday
+	case PeriodTrip:
+		return tx.TripID, tx.CreatedAt
+	default:
+		return day.Format(time.DateOnly), day
+	}
+}
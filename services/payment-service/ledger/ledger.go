@@ -0,0 +1,391 @@
+// Package ledger keeps a double-entry record of where the money of every payment goes.
+// Each transaction debits (positive amounts) and credits (negative amounts) accounts
+// so that its entries, and therefore the whole ledger, sum to zero.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+)
+
+var (
+	ErrUnbalanced          = errors.New("ledger entries do not sum to zero")
+	ErrTransactionExists   = errors.New("ledger transaction already recorded")
+	ErrTransactionNotFound = errors.New("ledger transaction not found")
+)
+
+// AccountType is the kind of a ledger account
+type AccountType string
+
+const (
+	AccountRiderReceivable AccountType = "rider_receivable" // charged to a rider
+	AccountDriverPayable   AccountType = "driver_payable"   // earned by a driver and not paid out yet
+	AccountPlatformRevenue AccountType = "platform_revenue" // commission kept by the platform
+	AccountTaxesPayable    AccountType = "taxes_payable"    // tax collected on fares
+	AccountRefunds         AccountType = "refunds"          // the platform's share of refunds
+	AccountPayouts         AccountType = "payouts"          // sent to drivers
+)
+
+// Account identifies a ledger account. Rider and driver accounts belong to a rider or driver,
+// platform accounts have no owner.
+type Account struct {
+	Type    AccountType `json:"type" bson:"type"`
+	OwnerID string      `json:"ownerID" bson:"ownerID"`
+}
+
+var (
+	PlatformRevenue = Account{Type: AccountPlatformRevenue}
+	TaxesPayable    = Account{Type: AccountTaxesPayable}
+	Refunds         = Account{Type: AccountRefunds}
+	Payouts         = Account{Type: AccountPayouts}
+)
+
+// RiderReceivable returns the account of the amounts charged to the rider
+func RiderReceivable(riderID string) Account {
+	return Account{Type: AccountRiderReceivable, OwnerID: riderID}
+}
+
+// DriverPayable returns the account of the amounts owed to the driver
+func DriverPayable(driverID string) Account {
+	return Account{Type: AccountDriverPayable, OwnerID: driverID}
+}
+
+func (a Account) String() string {
+	if a.OwnerID == "" {
+		return string(a.Type)
+	}
+	return string(a.Type) + ":" + a.OwnerID
+}
+
+// Entry debits (positive amount) or credits (negative amount) an account
+type Entry struct {
+	Account Account `json:"account" bson:"account"`
+	Amount  int64   `json:"amount" bson:"amount"` // in minor units (paise)
+}
+
+// TransactionKind is the event a ledger transaction records
+type TransactionKind string
+
+const (
+	KindRidePayment     TransactionKind = "ride_payment"
+	KindCancellationFee TransactionKind = "cancellation_fee"
+	KindRefund          TransactionKind = "refund"
+	KindPayout          TransactionKind = "payout"
+	KindPayoutReversal  TransactionKind = "payout_reversal"
+)
+
+// Transaction is a balanced set of entries recording one payment, refund or payout.
+// Its ID is derived from what it records, so each is recorded at most once.
+type Transaction struct {
+	ID        string          `json:"id" bson:"_id"`
+	Kind      TransactionKind `json:"kind" bson:"kind"`
+	PaymentID string          `json:"paymentID,omitempty" bson:"paymentID,omitempty"`
+	RefundID  string          `json:"refundID,omitempty" bson:"refundID,omitempty"`
+	PayoutID  string          `json:"payoutID,omitempty" bson:"payoutID,omitempty"`
+	TripID    string          `json:"tripID,omitempty" bson:"tripID,omitempty"`
+	Currency  string          `json:"currency" bson:"currency"`
+	Entries   []*Entry        `json:"entries" bson:"entries"`
+	CreatedAt time.Time       `json:"createdAt" bson:"createdAt"`
+}
+
+// Validate checks that the transaction moves money between at least two accounts and sums to zero
+func (t *Transaction) Validate() error {
+	if len(t.Entries) < 2 {
+		return fmt.Errorf("ledger transaction %s has %d entries, want at least 2", t.ID, len(t.Entries))
+	}
+	var sum int64
+	for _, entry := range t.Entries {
+		sum += entry.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: transaction %s sums to %d", ErrUnbalanced, t.ID, sum)
+	}
+	return nil
+}
+
+// Amount returns the sum of the transaction's entries on the account
+func (t *Transaction) Amount(account Account) int64 {
+	var sum int64
+	for _, entry := range t.Entries {
+		if entry.Account == account {
+			sum += entry.Amount
+		}
+	}
+	return sum
+}
+
+// account returns the first account of the given type the transaction has an entry on
+func (t *Transaction) account(accountType AccountType) (Account, bool) {
+	for _, entry := range t.Entries {
+		if entry.Account.Type == accountType {
+			return entry.Account, true
+		}
+	}
+	return Account{}, false
+}
+
+// Balance sums the entries of an account in one currency
+type Balance struct {
+	Account  Account `json:"account" bson:"account"`
+	Currency string  `json:"currency" bson:"currency"`
+	Debits   int64   `json:"debits" bson:"debits"`
+	Credits  int64   `json:"credits" bson:"credits"` // as a positive amount
+}
+
+// Net returns the debits less the credits, negative for accounts owed money such as a driver's
+func (b *Balance) Net() int64 {
+	return b.Debits - b.Credits
+}
+
+type Ledger struct {
+	store  Store
+	config *Config
+
+	// refunds of a payment are recorded one at a time, so each one's rounding accounts for the earlier ones
+	refundMu sync.Mutex
+}
+
+// NewLedger creates a ledger that writes to the store and splits payments with the config
+func NewLedger(store Store, config *Config) *Ledger {
+	return &Ledger{store: store, config: config}
+}
+
+// RecordPayment records how a collected payment is split between taxes, the platform and the driver.
+// Recording a payment again has no effect.
+func (l *Ledger) RecordPayment(ctx context.Context, payment *types.Payment) error {
+	tax, commission, driverShare := l.split(payment.Amount, payment.PackageSlug)
+	if payment.DriverID == "" {
+		// A fee charged before a driver was assigned is all the platform's
+		commission += driverShare
+		driverShare = 0
+	}
+
+	kind := KindRidePayment
+	if payment.Purpose == types.PaymentPurposeCancellationFee {
+		kind = KindCancellationFee
+	}
+
+	tx := &Transaction{
+		ID:        paymentTransactionID(payment.ID),
+		Kind:      kind,
+		PaymentID: payment.ID,
+		TripID:    payment.TripID,
+		Currency:  payment.Currency,
+		Entries: entries(
+			&Entry{Account: RiderReceivable(payment.RiderID), Amount: payment.Amount},
+			&Entry{Account: TaxesPayable, Amount: -tax},
+			&Entry{Account: PlatformRevenue, Amount: -commission},
+			&Entry{Account: DriverPayable(payment.DriverID), Amount: -driverShare},
+		),
+		CreatedAt: paidAt(payment),
+	}
+	return l.record(ctx, tx)
+}
+
+// RecordRefund records a refund that succeeded, reversing the driver's and the tax share of the
+// refunded amount and charging the rest to the platform. Recording a refund again has no effect.
+func (l *Ledger) RecordRefund(ctx context.Context, refund *types.Refund) error {
+	l.refundMu.Lock()
+	defer l.refundMu.Unlock()
+
+	id := refundTransactionID(refund.ID)
+	if _, err := l.store.Get(ctx, id); err == nil {
+		return nil
+	} else if !errors.Is(err, ErrTransactionNotFound) {
+		return err
+	}
+
+	charge, err := l.store.Get(ctx, paymentTransactionID(refund.PaymentID))
+	if err != nil {
+		return fmt.Errorf("failed to get the ledger transaction of payment %s: %w", refund.PaymentID, err)
+	}
+	transactions, err := l.store.ListByPayment(ctx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	rider, _ := charge.account(AccountRiderReceivable)
+	driver, hasDriver := charge.account(AccountDriverPayable)
+
+	// Reverse the shares in proportion to everything refunded so far,
+	// so refunds adding up to the whole payment reverse it exactly
+	var refundedBefore, driverBefore, taxBefore int64
+	for _, t := range transactions {
+		if t.Kind == KindRefund {
+			refundedBefore -= t.Amount(rider)
+			driverBefore += t.Amount(driver)
+			taxBefore += t.Amount(TaxesPayable)
+		}
+	}
+	charged := charge.Amount(rider)
+	refunded := refundedBefore + refund.Amount
+	if refunded > charged {
+		return fmt.Errorf("refunds of payment %s add up to %d, more than the %d charged", refund.PaymentID, refunded, charged)
+	}
+
+	taxReversal := proportion(-charge.Amount(TaxesPayable), refunded, charged) - taxBefore
+	var driverReversal int64
+	if hasDriver {
+		driverReversal = proportion(-charge.Amount(driver), refunded, charged) - driverBefore
+	}
+
+	tx := &Transaction{
+		ID:        id,
+		Kind:      KindRefund,
+		PaymentID: refund.PaymentID,
+		RefundID:  refund.ID,
+		TripID:    refund.TripID,
+		Currency:  refund.Currency,
+		Entries: entries(
+			&Entry{Account: rider, Amount: -refund.Amount},
+			&Entry{Account: TaxesPayable, Amount: taxReversal},
+			&Entry{Account: driver, Amount: driverReversal},
+			&Entry{Account: Refunds, Amount: refund.Amount - taxReversal - driverReversal},
+		),
+		CreatedAt: refund.UpdatedAt,
+	}
+	return l.record(ctx, tx)
+}
+
+// RecordPayout moves an amount paid out to the driver off their payable balance.
+// Recording a payout again has no effect.
+func (l *Ledger) RecordPayout(ctx context.Context, payoutID, driverID string, amount int64, currency string, at time.Time) error {
+	return l.record(ctx, &Transaction{
+		ID:       payoutTransactionID(payoutID),
+		Kind:     KindPayout,
+		PayoutID: payoutID,
+		Currency: currency,
+		Entries: []*Entry{
+			{Account: DriverPayable(driverID), Amount: amount},
+			{Account: Payouts, Amount: -amount},
+		},
+		CreatedAt: at,
+	})
+}
+
+// ReversePayout puts the amount of a payout that failed back on the driver's payable balance.
+// Reversing a payout again has no effect.
+func (l *Ledger) ReversePayout(ctx context.Context, payoutID, driverID string, amount int64, currency string, at time.Time) error {
+	return l.record(ctx, &Transaction{
+		ID:       payoutReversalTransactionID(payoutID),
+		Kind:     KindPayoutReversal,
+		PayoutID: payoutID,
+		Currency: currency,
+		Entries: []*Entry{
+			{Account: DriverPayable(driverID), Amount: -amount},
+			{Account: Payouts, Amount: amount},
+		},
+		CreatedAt: at,
+	})
+}
+
+// DriverBalance returns the driver's payable balance in each currency they earned in
+func (l *Ledger) DriverBalance(ctx context.Context, driverID string) ([]*Balance, error) {
+	return l.store.Balances(ctx, DriverPayable(driverID))
+}
+
+// PayableBalances returns the payable balance of every driver in each currency they earned in
+func (l *Ledger) PayableBalances(ctx context.Context) ([]*Balance, error) {
+	return l.store.BalancesByType(ctx, AccountDriverPayable)
+}
+
+// CheckInvariant verifies that all entries of the ledger sum to zero in every currency
+func (l *Ledger) CheckInvariant(ctx context.Context) error {
+	totals, err := l.store.Totals(ctx)
+	if err != nil {
+		return err
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%w: %s entries sum to %d", ErrUnbalanced, currency, total)
+		}
+	}
+	return nil
+}
+
+// Audit checks the invariant every interval until the context is done, logging violations
+func (l *Ledger) Audit(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.CheckInvariant(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Ledger audit failed: %v", err)
+			}
+		}
+	}
+}
+
+func (l *Ledger) record(ctx context.Context, tx *Transaction) error {
+	if err := tx.Validate(); err != nil {
+		return err
+	}
+	if err := l.store.Record(ctx, tx); err != nil && !errors.Is(err, ErrTransactionExists) {
+		return err
+	}
+	return nil
+}
+
+// split divides an amount including tax into the tax, the platform's commission and the driver's share
+func (l *Ledger) split(amount int64, packageSlug string) (tax, commission, driverShare int64) {
+	taxPercent := l.config.TaxPercent
+	tax = int64(math.Round(float64(amount) * taxPercent / (100 + taxPercent)))
+	commission = int64(math.Round(float64(amount-tax) * l.config.Commission(packageSlug) / 100))
+	return tax, commission, amount - tax - commission
+}
+
+// proportion returns share * part / whole, rounded to the nearest minor unit
+func proportion(share, part, whole int64) int64 {
+	if whole == 0 {
+		return 0
+	}
+	return int64(math.Round(float64(share) * float64(part) / float64(whole)))
+}
+
+// entries drops the entries of zero amount
+func entries(all ...*Entry) []*Entry {
+	kept := make([]*Entry, 0, len(all))
+	for _, entry := range all {
+		if entry.Amount != 0 {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// paidAt returns when the payment was collected
+func paidAt(payment *types.Payment) time.Time {
+	for _, change := range payment.StatusHistory {
+		if change.Status == types.PaymentStatusSuccess {
+			return change.ChangedAt
+		}
+	}
+	return payment.UpdatedAt
+}
+
+func paymentTransactionID(paymentID string) string {
+	return "payment:" + paymentID
+}
+
+func refundTransactionID(refundID string) string {
+	return "refund:" + refundID
+}
+
+func payoutTransactionID(payoutID string) string {
+	return "payout:" + payoutID
+}
+
+func payoutReversalTransactionID(payoutID string) string {
+	return "payout_reversal:" + payoutID
+}
@@ -0,0 +1,26 @@
+package ledger_test
+
+import (
+	"testing"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger/ledgertest"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestInMemoLedger(t *testing.T) {
+	if err := ledgertest.TestLedger(t.Context(), ledger.NewInMemoStore()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoLedger runs the suite against the MongoDB at MONGODB_URI
+func TestMongoLedger(t *testing.T) {
+	store, err := ledger.NewMongoStore(t.Context(), dbtest.Database(t, "ledgertest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledgertest.TestLedger(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,390 @@
+// Package ledgertest implements a contract suite for the ledger over any ledger.Store implementation.
+package ledgertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/google/uuid"
+)
+
+// TestLedger records payments and refunds through a ledger backed by the store and checks the
+// resulting entries and balances. It only writes records with fresh IDs, so it can run against
+// a shared database. It returns the first contract violation found, or nil.
+func TestLedger(ctx context.Context, store ledger.Store) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, ledger.Store) error
+	}{
+		{"duplicate transactions", testDuplicateTransactions},
+		{"unbalanced transactions", testUnbalancedTransactions},
+		{"payment split", testPaymentSplit},
+		{"cancellation fee without driver", testCancellationFeeWithoutDriver},
+		{"partial refunds", testPartialRefunds},
+		{"driver balance", testDriverBalance},
+		{"driver earnings", testDriverEarnings},
+		{"invariant", testInvariant},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, store); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// testConfig splits fares with round percentages, so the expected shares are easy to follow
+func testConfig() *ledger.Config {
+	return &ledger.Config{
+		TaxPercent:        5,
+		CommissionPercent: 20,
+		Packages:          []*ledger.PackageCommission{{Slug: "suv", CommissionPercent: 25}},
+	}
+}
+
+func testDuplicateTransactions(ctx context.Context, store ledger.Store) error {
+	tx := &ledger.Transaction{
+		ID:        "test:" + uuid.New().String(),
+		Kind:      ledger.KindRidePayment,
+		PaymentID: uuid.New().String(),
+		Currency:  "inr",
+		Entries: []*ledger.Entry{
+			{Account: ledger.RiderReceivable(uuid.New().String()), Amount: 100},
+			{Account: ledger.PlatformRevenue, Amount: -100},
+		},
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}
+	if err := store.Record(ctx, tx); err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	if err := store.Record(ctx, tx); !errors.Is(err, ledger.ErrTransactionExists) {
+		return fmt.Errorf("Record of a recorded transaction: got %v, want ErrTransactionExists", err)
+	}
+	if _, err := store.Get(ctx, "test:"+uuid.New().String()); !errors.Is(err, ledger.ErrTransactionNotFound) {
+		return fmt.Errorf("Get of an unknown transaction: got %v, want ErrTransactionNotFound", err)
+	}
+
+	got, err := store.Get(ctx, tx.ID)
+	if err != nil {
+		return fmt.Errorf("Get: %w", err)
+	}
+	if got.PaymentID != tx.PaymentID || len(got.Entries) != 2 || got.Entries[0].Account != tx.Entries[0].Account {
+		return fmt.Errorf("Get: got transaction of payment %s with %d entries, want %s with 2", got.PaymentID, len(got.Entries), tx.PaymentID)
+	}
+	return nil
+}
+
+func testUnbalancedTransactions(ctx context.Context, store ledger.Store) error {
+	tx := &ledger.Transaction{
+		ID: "test:" + uuid.New().String(),
+		Entries: []*ledger.Entry{
+			{Account: ledger.PlatformRevenue, Amount: 100},
+			{Account: ledger.TaxesPayable, Amount: -99},
+		},
+	}
+	if err := tx.Validate(); !errors.Is(err, ledger.ErrUnbalanced) {
+		return fmt.Errorf("Validate: got %v, want ErrUnbalanced", err)
+	}
+	return nil
+}
+
+func testPaymentSplit(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, testConfig())
+	payment := newPayment("suv", 21000)
+
+	// Recording twice, as a redelivered webhook does, writes the payment once
+	for i := 0; i < 2; i++ {
+		if err := l.RecordPayment(ctx, payment); err != nil {
+			return fmt.Errorf("RecordPayment %d: %w", i+1, err)
+		}
+	}
+
+	transactions, err := store.ListByPayment(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("ListByPayment: %w", err)
+	}
+	if len(transactions) != 1 {
+		return fmt.Errorf("ListByPayment: got %d transactions, want 1", len(transactions))
+	}
+
+	// 21000 includes 1000 of tax, the suv commission is 25% of the remaining 20000
+	tx := transactions[0]
+	want := map[ledger.Account]int64{
+		ledger.RiderReceivable(payment.RiderID): 21000,
+		ledger.TaxesPayable:                     -1000,
+		ledger.PlatformRevenue:                  -5000,
+		ledger.DriverPayable(payment.DriverID):  -15000,
+	}
+	for account, amount := range want {
+		if got := tx.Amount(account); got != amount {
+			return fmt.Errorf("entry on %s: got %d, want %d", account, got, amount)
+		}
+	}
+	if tx.Kind != ledger.KindRidePayment {
+		return fmt.Errorf("got a %s transaction, want %s", tx.Kind, ledger.KindRidePayment)
+	}
+	return tx.Validate()
+}
+
+func testCancellationFeeWithoutDriver(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, testConfig())
+	payment := newPayment("", 5250)
+	payment.Purpose = types.PaymentPurposeCancellationFee
+	payment.DriverID = ""
+
+	if err := l.RecordPayment(ctx, payment); err != nil {
+		return fmt.Errorf("RecordPayment: %w", err)
+	}
+	transactions, err := store.ListByPayment(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("ListByPayment: %w", err)
+	}
+	if len(transactions) != 1 || transactions[0].Kind != ledger.KindCancellationFee {
+		return fmt.Errorf("ListByPayment: got %d transactions, want 1 cancellation fee", len(transactions))
+	}
+
+	tx := transactions[0]
+	if got := tx.Amount(ledger.PlatformRevenue); got != -5000 {
+		return fmt.Errorf("entry on %s: got %d, want -5000", ledger.PlatformRevenue, got)
+	}
+	for _, entry := range tx.Entries {
+		if entry.Account.Type == ledger.AccountDriverPayable {
+			return fmt.Errorf("got an entry on %s for a fee without a driver", entry.Account)
+		}
+	}
+	return tx.Validate()
+}
+
+func testPartialRefunds(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, testConfig())
+	payment := newPayment("sedan", 10001)
+	if err := l.RecordPayment(ctx, payment); err != nil {
+		return fmt.Errorf("RecordPayment: %w", err)
+	}
+
+	// Three refunds with awkward amounts add up to the whole payment
+	for i, amount := range []int64{3333, 3333, 3335} {
+		refund := newRefund(payment, amount, time.Duration(i+1)*time.Minute)
+		for j := 0; j < 2; j++ {
+			if err := l.RecordRefund(ctx, refund); err != nil {
+				return fmt.Errorf("RecordRefund %d: %w", i+1, err)
+			}
+		}
+	}
+
+	transactions, err := store.ListByPayment(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("ListByPayment: %w", err)
+	}
+	if len(transactions) != 4 {
+		return fmt.Errorf("ListByPayment: got %d transactions, want the payment and 3 refunds", len(transactions))
+	}
+
+	// Refunding everything leaves nothing owed to the driver, the tax office or the rider
+	totals := make(map[ledger.Account]int64)
+	for _, tx := range transactions {
+		if err := tx.Validate(); err != nil {
+			return err
+		}
+		for _, entry := range tx.Entries {
+			totals[entry.Account] += entry.Amount
+		}
+	}
+	for _, account := range []ledger.Account{
+		ledger.RiderReceivable(payment.RiderID),
+		ledger.DriverPayable(payment.DriverID),
+		ledger.TaxesPayable,
+	} {
+		if totals[account] != 0 {
+			return fmt.Errorf("%s sums to %d after a full refund, want 0", account, totals[account])
+		}
+	}
+	if totals[ledger.PlatformRevenue] != -totals[ledger.Refunds] {
+		return fmt.Errorf("refunds of %d do not reverse the platform revenue of %d", totals[ledger.Refunds], -totals[ledger.PlatformRevenue])
+	}
+
+	// A refund beyond the payment is rejected
+	if err := l.RecordRefund(ctx, newRefund(payment, 1, time.Hour)); err == nil {
+		return fmt.Errorf("RecordRefund beyond the payment: got nil, want an error")
+	}
+	return nil
+}
+
+func testDriverBalance(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, testConfig())
+	driverID := uuid.New().String()
+
+	for _, amount := range []int64{10500, 21000} {
+		payment := newPayment("sedan", amount)
+		payment.DriverID = driverID
+		if err := l.RecordPayment(ctx, payment); err != nil {
+			return fmt.Errorf("RecordPayment: %w", err)
+		}
+		if amount == 21000 {
+			if err := l.RecordRefund(ctx, newRefund(payment, 10500, time.Minute)); err != nil {
+				return fmt.Errorf("RecordRefund: %w", err)
+			}
+		}
+	}
+
+	balances, err := l.DriverBalance(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("DriverBalance: %w", err)
+	}
+	if len(balances) != 1 {
+		return fmt.Errorf("DriverBalance: got %d currencies, want 1", len(balances))
+	}
+
+	// The driver earns 80% of the fares net of tax, 8000 and 16000, and loses half of the second
+	b := balances[0]
+	if b.Currency != "inr" || b.Credits != 24000 || b.Debits != 8000 || b.Net() != -16000 {
+		return fmt.Errorf("DriverBalance: got %d credits and %d debits in %s, want 24000 and 8000 in inr", b.Credits, b.Debits, b.Currency)
+	}
+
+	missing, err := l.DriverBalance(ctx, uuid.New().String())
+	if err != nil {
+		return fmt.Errorf("DriverBalance of unknown driver: %w", err)
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("DriverBalance of unknown driver: got %d balances, want 0", len(missing))
+	}
+	return nil
+}
+
+func testDriverEarnings(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, testConfig())
+	driverID := uuid.New().String()
+
+	// Monday evening and Tuesday in UTC, both on Tuesday in India
+	first := paidOn(newPayment("sedan", 10500), driverID, time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC))
+	second := paidOn(newPayment("sedan", 21000), driverID, time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC))
+	outside := paidOn(newPayment("sedan", 21000), driverID, time.Date(2026, 3, 12, 10, 0, 0, 0, time.UTC))
+	for _, payment := range []*types.Payment{first, second, outside} {
+		if err := l.RecordPayment(ctx, payment); err != nil {
+			return fmt.Errorf("RecordPayment: %w", err)
+		}
+	}
+	// Half of the second fare is refunded on Sunday, and a payout is made in between
+	if err := l.RecordRefund(ctx, newRefund(second, 10500, 5*24*time.Hour)); err != nil {
+		return fmt.Errorf("RecordRefund: %w", err)
+	}
+	if err := l.RecordPayout(ctx, uuid.New().String(), driverID, 5000, "inr", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)); err != nil {
+		return fmt.Errorf("RecordPayout: %w", err)
+	}
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	india := time.FixedZone("IST", 5*60*60+30*60)
+
+	tests := []struct {
+		period ledger.Period
+		loc    *time.Location
+		want   []ledger.Earnings
+	}{
+		{ledger.PeriodDay, time.UTC, []ledger.Earnings{
+			{Key: "2026-03-02", Trips: 1, Gross: 8000},
+			{Key: "2026-03-03", Trips: 1, Gross: 16000},
+			{Key: "2026-03-08", Refunded: 8000},
+		}},
+		{ledger.PeriodDay, india, []ledger.Earnings{
+			{Key: "2026-03-03", Trips: 2, Gross: 24000},
+			{Key: "2026-03-08", Refunded: 8000},
+		}},
+		{ledger.PeriodWeek, time.UTC, []ledger.Earnings{
+			{Key: "2026-03-02", Trips: 2, Gross: 24000, Refunded: 8000},
+		}},
+		{ledger.PeriodTrip, time.UTC, []ledger.Earnings{
+			{Key: first.TripID, Trips: 1, Gross: 8000},
+			{Key: second.TripID, Trips: 1, Gross: 16000, Refunded: 8000},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := l.DriverEarnings(ctx, driverID, tt.period, from, to, tt.loc)
+		if err != nil {
+			return fmt.Errorf("DriverEarnings by %s in %s: %w", tt.period, tt.loc, err)
+		}
+		if len(got) != len(tt.want) {
+			return fmt.Errorf("DriverEarnings by %s in %s: got %d summaries, want %d", tt.period, tt.loc, len(got), len(tt.want))
+		}
+		for i, want := range tt.want {
+			e := got[i]
+			if e.Key != want.Key || e.Currency != "inr" || e.Trips != want.Trips || e.Gross != want.Gross || e.Refunded != want.Refunded {
+				return fmt.Errorf("DriverEarnings by %s in %s: got %s with %d trips, %d gross and %d refunded in %s, want %s with %d, %d and %d in inr",
+					tt.period, tt.loc, e.Key, e.Trips, e.Gross, e.Refunded, e.Currency, want.Key, want.Trips, want.Gross, want.Refunded)
+			}
+		}
+	}
+
+	if _, err := l.DriverEarnings(ctx, driverID, "month", from, to, time.UTC); err == nil {
+		return fmt.Errorf("DriverEarnings by month: got nil, want an error")
+	}
+	return nil
+}
+
+func testInvariant(ctx context.Context, store ledger.Store) error {
+	l := ledger.NewLedger(store, ledger.DefaultConfig())
+	for _, slug := range []string{"bike", "auto", "sedan", "suv", "unknown"} {
+		payment := newPayment(slug, 12345)
+		if err := l.RecordPayment(ctx, payment); err != nil {
+			return fmt.Errorf("RecordPayment of %s: %w", slug, err)
+		}
+		if err := l.RecordRefund(ctx, newRefund(payment, 4321, time.Minute)); err != nil {
+			return fmt.Errorf("RecordRefund of %s: %w", slug, err)
+		}
+	}
+	return l.CheckInvariant(ctx)
+}
+
+// newPayment builds a ride payment of the package collected now
+func newPayment(packageSlug string, amount int64) *types.Payment {
+	paidAt := time.Now().Truncate(time.Millisecond)
+	return &types.Payment{
+		ID:          uuid.New().String(),
+		TripID:      uuid.New().String(),
+		RiderID:     uuid.New().String(),
+		DriverID:    uuid.New().String(),
+		PackageSlug: packageSlug,
+		Purpose:     types.PaymentPurposeRide,
+		Amount:      amount,
+		Currency:    "inr",
+		Status:      types.PaymentStatusSuccess,
+		StatusHistory: []*types.PaymentStatusChange{
+			{Status: types.PaymentStatusPending, ChangedAt: paidAt.Add(-time.Minute)},
+			{Status: types.PaymentStatusSuccess, ChangedAt: paidAt},
+		},
+		CreatedAt: paidAt.Add(-time.Minute),
+		UpdatedAt: paidAt,
+	}
+}
+
+// paidOn moves the payment to the driver and to the given time
+func paidOn(payment *types.Payment, driverID string, paidAt time.Time) *types.Payment {
+	payment.DriverID = driverID
+	payment.CreatedAt = paidAt.Add(-time.Minute)
+	payment.UpdatedAt = paidAt
+	payment.StatusHistory[0].ChangedAt = payment.CreatedAt
+	payment.StatusHistory[1].ChangedAt = paidAt
+	return payment
+}
+
+// newRefund builds a succeeded refund of the payment, settled after the given delay
+func newRefund(payment *types.Payment, amount int64, after time.Duration) *types.Refund {
+	return &types.Refund{
+		ID:             uuid.New().String(),
+		PaymentID:      payment.ID,
+		TripID:         payment.TripID,
+		RiderID:        payment.RiderID,
+		Amount:         amount,
+		Currency:       payment.Currency,
+		Reason:         types.RefundReasonFareDispute,
+		Status:         types.RefundStatusSucceeded,
+		IdempotencyKey: uuid.New().String(),
+		CreatedAt:      payment.UpdatedAt,
+		UpdatedAt:      payment.UpdatedAt.Add(after),
+	}
+}
@@ -0,0 +1,173 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const transactionsCollection = "ledger_transactions"
+
+type mongoStore struct {
+	transactions *mongo.Collection
+}
+
+// NewMongoStore creates a MongoDB backed Store and ensures its indexes exist
+func NewMongoStore(ctx context.Context, db *mongo.Database) (*mongoStore, error) {
+	s := &mongoStore{transactions: db.Collection(transactionsCollection)}
+
+	if _, err := s.transactions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "paymentID", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "entries.account.type", Value: 1}, {Key: "entries.account.ownerID", Value: 1}, {Key: "createdAt", Value: 1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create ledger indexes: %w", err)
+	}
+	return s, nil
+}
+
+// Record inserts the transaction, its ID being unique
+func (s *mongoStore) Record(ctx context.Context, tx *Transaction) error {
+	_, err := s.transactions.InsertOne(ctx, tx)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTransactionExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert ledger transaction: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a transaction by its ID
+func (s *mongoStore) Get(ctx context.Context, id string) (*Transaction, error) {
+	var tx Transaction
+	err := s.transactions.FindOne(ctx, bson.M{"_id": id}).Decode(&tx)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger transaction: %w", err)
+	}
+	return &tx, nil
+}
+
+// ListByPayment lists the transactions of a payment and its refunds, oldest first
+func (s *mongoStore) ListByPayment(ctx context.Context, paymentID string) ([]*Transaction, error) {
+	cursor, err := s.transactions.Find(ctx,
+		bson.M{"paymentID": paymentID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger transactions: %w", err)
+	}
+
+	transactions := []*Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// ListByAccount lists the transactions with entries on the account created in [from, to), oldest first
+func (s *mongoStore) ListByAccount(ctx context.Context, account Account, from, to time.Time) ([]*Transaction, error) {
+	cursor, err := s.transactions.Find(ctx,
+		bson.M{
+			"entries":   bson.M{"$elemMatch": bson.M{"account.type": account.Type, "account.ownerID": account.OwnerID}},
+			"createdAt": bson.M{"$gte": from, "$lt": to},
+		},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger transactions: %w", err)
+	}
+
+	transactions := []*Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// Balances sums the entries on the account in each currency, ordered by currency
+func (s *mongoStore) Balances(ctx context.Context, account Account) ([]*Balance, error) {
+	return s.balances(ctx, bson.M{"account.type": account.Type, "account.ownerID": account.OwnerID})
+}
+
+// BalancesByType sums the entries on every account of the type in each currency, ordered by owner and currency
+func (s *mongoStore) BalancesByType(ctx context.Context, accountType AccountType) ([]*Balance, error) {
+	return s.balances(ctx, bson.M{"account.type": accountType})
+}
+
+// balances sums the entries matching the filter per account and currency
+func (s *mongoStore) balances(ctx context.Context, entryFilter bson.M) ([]*Balance, error) {
+	unwound := bson.M{}
+	for field, value := range entryFilter {
+		unwound["entries."+field] = value
+	}
+
+	cursor, err := s.transactions.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"entries": bson.M{"$elemMatch": entryFilter}}}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: unwound}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"account": "$entries.account", "currency": "$currency"},
+			"debits": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$entries.amount", 0}}, "$entries.amount", 0,
+			}}},
+			"credits": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$lt": bson.A{"$entries.amount", 0}}, bson.M{"$multiply": bson.A{"$entries.amount", -1}}, 0,
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.account.ownerID", Value: 1}, {Key: "_id.currency", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ledger balances: %w", err)
+	}
+
+	var rows []struct {
+		ID struct {
+			Account  Account `bson:"account"`
+			Currency string  `bson:"currency"`
+		} `bson:"_id"`
+		Debits  int64 `bson:"debits"`
+		Credits int64 `bson:"credits"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger balances: %w", err)
+	}
+
+	balances := make([]*Balance, 0, len(rows))
+	for _, row := range rows {
+		balances = append(balances, &Balance{Account: row.ID.Account, Currency: row.ID.Currency, Debits: row.Debits, Credits: row.Credits})
+	}
+	return balances, nil
+}
+
+// Totals sums all entries in each currency
+func (s *mongoStore) Totals(ctx context.Context) (map[string]int64, error) {
+	cursor, err := s.transactions.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$group", Value: bson.M{"_id": "$currency", "total": bson.M{"$sum": "$entries.amount"}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ledger entries: %w", err)
+	}
+
+	var rows []struct {
+		Currency string `bson:"_id"`
+		Total    int64  `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger totals: %w", err)
+	}
+
+	totals := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		totals[row.Currency] = row.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,174 @@
+package ledger
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Store interface {
+	// Record saves a transaction, or returns ErrTransactionExists if one with its ID was saved before
+	Record(ctx context.Context, tx *Transaction) error
+	Get(ctx context.Context, id string) (*Transaction, error)
+	// ListByPayment lists the transactions of a payment and its refunds, oldest first
+	ListByPayment(ctx context.Context, paymentID string) ([]*Transaction, error)
+	// ListByAccount lists the transactions with entries on the account created in [from, to), oldest first
+	ListByAccount(ctx context.Context, account Account, from, to time.Time) ([]*Transaction, error)
+	// Balances sums the entries on the account in each currency
+	Balances(ctx context.Context, account Account) ([]*Balance, error)
+	// BalancesByType sums the entries on every account of the type in each currency
+	BalancesByType(ctx context.Context, accountType AccountType) ([]*Balance, error)
+	// Totals sums all entries in each currency
+	Totals(ctx context.Context) (map[string]int64, error)
+}
+
+type inMemoStore struct {
+	sync.RWMutex
+	transactions map[string]*Transaction
+}
+
+// NewInMemoStore creates a new instance of in-memory Store
+func NewInMemoStore() *inMemoStore {
+	return &inMemoStore{transactions: make(map[string]*Transaction)}
+}
+
+// Record saves a copy of the transaction
+func (s *inMemoStore) Record(ctx context.Context, tx *Transaction) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, exists := s.transactions[tx.ID]; exists {
+		return ErrTransactionExists
+	}
+	s.transactions[tx.ID] = cloneTransaction(tx)
+	return nil
+}
+
+// Get retrieves a transaction by its ID
+func (s *inMemoStore) Get(ctx context.Context, id string) (*Transaction, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	tx, exists := s.transactions[id]
+	if !exists {
+		return nil, ErrTransactionNotFound
+	}
+	return cloneTransaction(tx), nil
+}
+
+// ListByPayment lists the transactions of a payment and its refunds, oldest first
+func (s *inMemoStore) ListByPayment(ctx context.Context, paymentID string) ([]*Transaction, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	transactions := []*Transaction{}
+	for _, tx := range s.transactions {
+		if tx.PaymentID == paymentID {
+			transactions = append(transactions, cloneTransaction(tx))
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// ListByAccount lists the transactions with entries on the account created in [from, to), oldest first
+func (s *inMemoStore) ListByAccount(ctx context.Context, account Account, from, to time.Time) ([]*Transaction, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	transactions := []*Transaction{}
+	for _, tx := range s.transactions {
+		if tx.CreatedAt.Before(from) || !tx.CreatedAt.Before(to) {
+			continue
+		}
+		for _, entry := range tx.Entries {
+			if entry.Account == account {
+				transactions = append(transactions, cloneTransaction(tx))
+				break
+			}
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// Balances sums the entries on the account in each currency, ordered by currency
+func (s *inMemoStore) Balances(ctx context.Context, account Account) ([]*Balance, error) {
+	return s.balances(func(a Account) bool { return a == account })
+}
+
+// BalancesByType sums the entries on every account of the type in each currency, ordered by owner and currency
+func (s *inMemoStore) BalancesByType(ctx context.Context, accountType AccountType) ([]*Balance, error) {
+	return s.balances(func(a Account) bool { return a.Type == accountType })
+}
+
+func (s *inMemoStore) balances(match func(Account) bool) ([]*Balance, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	type key struct {
+		account  Account
+		currency string
+	}
+	byKey := make(map[key]*Balance)
+	for _, tx := range s.transactions {
+		for _, entry := range tx.Entries {
+			if !match(entry.Account) {
+				continue
+			}
+			k := key{entry.Account, tx.Currency}
+			balance, ok := byKey[k]
+			if !ok {
+				balance = &Balance{Account: entry.Account, Currency: tx.Currency}
+				byKey[k] = balance
+			}
+			if entry.Amount > 0 {
+				balance.Debits += entry.Amount
+			} else {
+				balance.Credits -= entry.Amount
+			}
+		}
+	}
+
+	balances := make([]*Balance, 0, len(byKey))
+	for _, balance := range byKey {
+		balances = append(balances, balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Account.OwnerID != balances[j].Account.OwnerID {
+			return balances[i].Account.OwnerID < balances[j].Account.OwnerID
+		}
+		return balances[i].Currency < balances[j].Currency
+	})
+	return balances, nil
+}
+
+// Totals sums all entries in each currency
+func (s *inMemoStore) Totals(ctx context.Context) (map[string]int64, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	totals := make(map[string]int64)
+	for _, tx := range s.transactions {
+		for _, entry := range tx.Entries {
+			totals[tx.Currency] += entry.Amount
+		}
+	}
+	return totals, nil
+}
+
+// cloneTransaction copies the transaction so callers cannot modify the stored one
+func cloneTransaction(tx *Transaction) *Transaction {
+	copied := *tx
+	copied.Entries = make([]*Entry, len(tx.Entries))
+	for i, entry := range tx.Entries {
+		e := *entry
+		copied.Entries[i] = &e
+	}
+	return &copied
+}
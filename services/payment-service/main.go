@@ -2,24 +2,45 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/cprakhar/uber-clone/services/payment-service/events"
+	"github.com/cprakhar/uber-clone/services/payment-service/handler"
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
 	"github.com/cprakhar/uber-clone/services/payment-service/service"
 	"github.com/cprakhar/uber-clone/services/payment-service/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/db"
 	"github.com/cprakhar/uber-clone/shared/env"
+	"github.com/cprakhar/uber-clone/shared/messaging"
 	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 )
 
 var (
-	brokers = []string{"kafka:9092"}
-	groupID = "payment-service-group"
-	appURL  = env.GetString("APP_URL", "http://localhost:3000")
-	topics  = []string{contracts.PaymentCmdCreateSession}
+	brokers     = []string{"kafka:9092"}
+	groupID     = "payment-service-group"
+	serviceName = "payment-service"
+	appURL      = env.GetString("APP_URL", "http://localhost:3000")
+	httpAddr    = env.GetString("HTTP_ADDR", ":9201")
+	provider    = env.GetString("PAYMENT_PROVIDER", "stripe")
+	backend     = env.GetString("PAYMENT_REPO", "memory")
+	topics      = []string{contracts.PaymentCmdCreateSession, contracts.PaymentCmdChargeCancellationFee}
+
+	ledgerConfigPath    = env.GetString("LEDGER_CONFIG", "")
+	ledgerAuditInterval = env.GetDuration("LEDGER_AUDIT_INTERVAL", 15*time.Minute)
+
+	payoutProvider  = env.GetString("PAYOUT_PROVIDER", "fake")
+	payoutThreshold = env.GetInt("PAYOUT_THRESHOLD", 50000)
+	payoutInterval  = env.GetDuration("PAYOUT_INTERVAL", time.Hour)
+	earningsTZ      = env.GetString("EARNINGS_TIMEZONE", "UTC")
 )
 
 func main() {
@@ -34,26 +55,176 @@ func main() {
 	log.Println("Kafka client connected")
 
 	stripeCfg := &types.PaymentConfig{
-		StripeSecretKey: env.GetString("STRIPE_SECRET_KEY", ""),
-		SuccessURL:      env.GetString("STRIPE_SUCCESS_URL", appURL+"?payment=success"),
-		CancelURL:       env.GetString("STRIPE_CANCEL_URL", appURL+"?payment=cancel"),
+		StripeSecretKey:     env.GetString("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: env.GetString("STRIPE_WEBHOOK_SECRET", ""),
+		SuccessURL:          env.GetString("STRIPE_SUCCESS_URL", appURL+"?payment=success"),
+		CancelURL:           env.GetString("STRIPE_CANCEL_URL", appURL+"?payment=cancel"),
+	}
+
+	paymentProcessor, err := newPaymentProcessor(stripeCfg)
+	if err != nil {
+		log.Fatalf("Failed to create payment processor: %v", err)
+	}
+
+	stores, err := newStores(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create payment repository: %v", err)
 	}
+	defer stores.close()
 
-	if stripeCfg.StripeSecretKey == "" {
-		log.Fatal("STRIPE_SECRET_KEY is not set")
+	ledgerCfg, err := ledger.LoadConfig(ledgerConfigPath)
+	if err != nil {
+		log.Fatalf("Failed to load ledger config: %v", err)
 	}
+	paymentLedger := ledger.NewLedger(stores.ledger, ledgerCfg)
+	go paymentLedger.Audit(ctx, ledgerAuditInterval)
 
-	paymentProcessor := service.NewStripeClient(stripeCfg)
-	paymentService := service.NewPaymentService(paymentProcessor)
+	payer, err := newPayoutProvider()
+	if err != nil {
+		log.Fatalf("Failed to create payout provider: %v", err)
+	}
+	// A zero interval turns payouts off, e.g. on all but one instance
+	if payoutInterval > 0 {
+		scheduler := payout.NewScheduler(paymentLedger, stores.payouts, payer, int64(payoutThreshold))
+		go scheduler.Run(ctx, payoutInterval)
+	}
 
-	tripConsumer := events.NewTripConsumer(kfClient, paymentService)
+	location, err := time.LoadLocation(earningsTZ)
+	if err != nil {
+		log.Fatalf("Failed to load EARNINGS_TIMEZONE: %v", err)
+	}
+
+	paymentService := service.NewPaymentService(paymentProcessor, stores.payments, paymentLedger, stores.payouts, location)
+
+	publisher := messaging.NewPublisher(kfClient.Producer, serviceName)
+	tripConsumer := events.NewTripConsumer(kfClient, publisher, paymentService)
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := tripConsumer.Consume(ctx, topics); err != nil && ctx.Err() == nil {
 			log.Printf("Error consuming payment topics: %v", err)
 		}
 		stop()
 	}()
 
+	// Start the webhook server
+	paymentProducer := events.NewPaymentEventProducer(publisher)
+	webhookHandler := handler.NewWebhookHandler(paymentService, paymentProducer, stripeCfg.StripeWebhookSecret)
+	httpServer := NewhttpServer(httpAddr, webhookHandler)
+	go func() {
+		if err := httpServer.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("http server error: %v", err)
+			stop()
+		}
+	}()
+
+	// Start the gRPC server
+	grpcServer := NewgRPCServer(":9200", paymentService, paymentProducer)
+	go func() {
+		if err := grpcServer.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("gRPC server error: %v", err)
+			stop()
+		}
+	}()
+
 	<-ctx.Done()
 	log.Println("Shutdown signal received, exiting...")
+	// Let the consumer finish the messages it is handling before the Kafka client closes
+	<-consumerDone
+}
+
+// newPaymentProcessor creates the processor selected by PAYMENT_PROVIDER.
+// The fake processor signs its webhooks with STRIPE_WEBHOOK_SECRET, so it defaults to a local secret.
+func newPaymentProcessor(cfg *types.PaymentConfig) (repo.PaymentProcessor, error) {
+	switch provider {
+	case "stripe":
+		if cfg.StripeSecretKey == "" {
+			return nil, fmt.Errorf("STRIPE_SECRET_KEY is not set")
+		}
+		if cfg.StripeWebhookSecret == "" {
+			return nil, fmt.Errorf("STRIPE_WEBHOOK_SECRET is not set")
+		}
+		log.Println("Using Stripe payment processor")
+		return service.NewStripeClient(cfg), nil
+	case "fake":
+		if cfg.StripeWebhookSecret == "" {
+			cfg.StripeWebhookSecret = "whsec_fake"
+		}
+		fakeCfg := &types.FakeProcessorConfig{
+			Outcome:       types.FakeOutcome(env.GetString("FAKE_PAYMENT_OUTCOME", string(types.FakeOutcomeSucceed))),
+			Delay:         env.GetDuration("FAKE_PAYMENT_DELAY", 2*time.Second),
+			WebhookURL:    env.GetString("FAKE_PAYMENT_WEBHOOK_URL", "http://localhost"+httpAddr+"/webhook/stripe"),
+			WebhookSecret: cfg.StripeWebhookSecret,
+		}
+		log.Printf("Using fake payment processor, sessions will %s", fakeCfg.Outcome)
+		return service.NewFakeProcessor(fakeCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", provider)
+	}
+}
+
+// newPayoutProvider creates the payout provider selected by PAYOUT_PROVIDER
+func newPayoutProvider() (payout.Provider, error) {
+	switch payoutProvider {
+	case "fake":
+		outcome := payout.FakeOutcome(env.GetString("FAKE_PAYOUT_OUTCOME", string(payout.FakeOutcomeSucceed)))
+		log.Printf("Using fake payout provider, payouts will %s", outcome)
+		return payout.NewFakeProvider(outcome), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYOUT_PROVIDER %q", payoutProvider)
+	}
+}
+
+// stores holds the repositories of the payment service
+type stores struct {
+	payments repo.PaymentRepo
+	ledger   ledger.Store
+	payouts  payout.Store
+	close    func()
+}
+
+// newStores creates the payment repository, ledger store and payout store selected by PAYMENT_REPO
+func newStores(ctx context.Context) (*stores, error) {
+	switch backend {
+	case "memory":
+		log.Println("Using in-memory payment repository")
+		return &stores{
+			payments: repo.NewInMemoRepository(),
+			ledger:   ledger.NewInMemoStore(),
+			payouts:  payout.NewInMemoStore(),
+			close:    func() {},
+		}, nil
+	case "mongo":
+		mongoCfg := db.NewMongoDefaultConfig()
+		client, err := db.NewMongoClient(ctx, mongoCfg)
+		if err != nil {
+			return nil, err
+		}
+		closeFn := func() {
+			if err := client.Disconnect(context.Background()); err != nil {
+				log.Printf("Failed to disconnect from MongoDB: %v", err)
+			}
+		}
+
+		database := db.GetDatabase(client, mongoCfg)
+		paymentRepo, err := repo.NewMongoRepository(ctx, database)
+		if err != nil {
+			closeFn()
+			return nil, err
+		}
+		ledgerStore, err := ledger.NewMongoStore(ctx, database)
+		if err != nil {
+			closeFn()
+			return nil, err
+		}
+		payoutStore, err := payout.NewMongoStore(ctx, database)
+		if err != nil {
+			closeFn()
+			return nil, err
+		}
+		log.Println("Using MongoDB payment repository")
+		return &stores{payments: paymentRepo, ledger: ledgerStore, payouts: payoutStore, close: closeFn}, nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_REPO %q", backend)
+	}
 }
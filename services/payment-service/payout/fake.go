@@ -0,0 +1,58 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FakeOutcome is how the fake provider settles payouts
+type FakeOutcome string
+
+const (
+	FakeOutcomeSucceed FakeOutcome = "succeed"
+	FakeOutcomeReject  FakeOutcome = "reject"
+)
+
+// fakeProvider pays out instantly without moving any money
+type fakeProvider struct {
+	mu         sync.Mutex
+	outcome    FakeOutcome
+	references map[string]string // payout ID -> reference
+}
+
+// NewFakeProvider creates a payout provider that settles every payout with the outcome
+func NewFakeProvider(outcome FakeOutcome) *fakeProvider {
+	return &fakeProvider{outcome: outcome, references: make(map[string]string)}
+}
+
+// SetOutcome changes how the payouts sent from now on are settled
+func (f *fakeProvider) SetOutcome(outcome FakeOutcome) {
+	f.mu.Lock()
+	f.outcome = outcome
+	f.mu.Unlock()
+}
+
+func (f *fakeProvider) Pay(ctx context.Context, payout *Payout) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if reference, ok := f.references[payout.ID]; ok {
+		return reference, nil
+	}
+	if f.outcome == FakeOutcomeReject {
+		return "", fmt.Errorf("failed to pay out on the fake provider: %w", ErrRejected)
+	}
+
+	reference := "po_fake_" + uuid.New().String()
+	f.references[payout.ID] = reference
+	log.Printf("Fake payout %s of %d %s to driver %s", reference, payout.Amount, payout.Currency, payout.DriverID)
+	return reference, nil
+}
+
+func (f *fakeProvider) Name() string {
+	return "fake"
+}
@@ -0,0 +1,95 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const payoutsCollection = "payouts"
+
+type mongoStore struct {
+	payouts *mongo.Collection
+}
+
+// NewMongoStore creates a MongoDB backed Store and ensures its indexes exist
+func NewMongoStore(ctx context.Context, db *mongo.Database) (*mongoStore, error) {
+	s := &mongoStore{payouts: db.Collection(payoutsCollection)}
+
+	if _, err := s.payouts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "driverID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create payout indexes: %w", err)
+	}
+	return s, nil
+}
+
+// Create inserts a new payout
+func (s *mongoStore) Create(ctx context.Context, payout *Payout) error {
+	if _, err := s.payouts.InsertOne(ctx, payout); err != nil {
+		return fmt.Errorf("failed to insert payout: %w", err)
+	}
+	return nil
+}
+
+// Settle moves a pending payout to paid or failed
+func (s *mongoStore) Settle(ctx context.Context, payoutID string, status Status, reference, failureReason string, at time.Time) (*Payout, error) {
+	var payout Payout
+	err := s.payouts.FindOneAndUpdate(ctx,
+		bson.M{"_id": payoutID, "status": StatusPending},
+		bson.M{"$set": bson.M{
+			"status":            status,
+			"providerReference": reference,
+			"failureReason":     failureReason,
+			"updatedAt":         at,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&payout)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Tell a missing payout from one that is already settled
+		count, countErr := s.payouts.CountDocuments(ctx, bson.M{"_id": payoutID})
+		if countErr != nil {
+			return nil, fmt.Errorf("failed to get payout: %w", countErr)
+		}
+		if count == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: payout %s", ErrSettled, payoutID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payout: %w", err)
+	}
+	return &payout, nil
+}
+
+// ListPending lists the payouts still waiting for the provider, oldest first
+func (s *mongoStore) ListPending(ctx context.Context) ([]*Payout, error) {
+	return s.find(ctx, bson.M{"status": StatusPending}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+}
+
+// ListByDriver lists up to limit payouts of the driver created before the given time, newest first
+func (s *mongoStore) ListByDriver(ctx context.Context, driverID string, before time.Time, limit int) ([]*Payout, error) {
+	return s.find(ctx,
+		bson.M{"driverID": driverID, "createdAt": bson.M{"$lt": before}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit)),
+	)
+}
+
+func (s *mongoStore) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*Payout, error) {
+	cursor, err := s.payouts.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payouts: %w", err)
+	}
+
+	payouts := []*Payout{}
+	if err := cursor.All(ctx, &payouts); err != nil {
+		return nil, fmt.Errorf("failed to decode payouts: %w", err)
+	}
+	return payouts, nil
+}
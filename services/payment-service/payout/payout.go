@@ -0,0 +1,63 @@
+// Package payout pays drivers what the ledger owes them, in batches, through a payout provider.
+package payout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/payment"
+)
+
+var (
+	ErrNotFound = errors.New("payout not found")
+	ErrRejected = errors.New("payout rejected by the provider")
+	ErrSettled  = errors.New("payout already settled")
+)
+
+// Status represents the current status of a payout
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusPaid    Status = "paid"
+	StatusFailed  Status = "failed"
+)
+
+// Payout sends a driver's payable balance in one currency to the driver
+type Payout struct {
+	ID                string    `json:"id" bson:"_id"`
+	DriverID          string    `json:"driverID" bson:"driverID"`
+	Amount            int64     `json:"amount" bson:"amount"` // in minor units (paise)
+	Currency          string    `json:"currency" bson:"currency"`
+	Status            Status    `json:"status" bson:"status"`
+	Provider          string    `json:"provider" bson:"provider"`
+	ProviderReference string    `json:"providerReference,omitempty" bson:"providerReference,omitempty"`
+	FailureReason     string    `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+	CreatedAt         time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ToProto converts the payout to its protobuf representation
+func (p *Payout) ToProto() *pb.Payout {
+	return &pb.Payout{
+		Id:                p.ID,
+		DriverID:          p.DriverID,
+		Amount:            p.Amount,
+		Currency:          p.Currency,
+		Status:            string(p.Status),
+		Provider:          p.Provider,
+		ProviderReference: p.ProviderReference,
+		FailureReason:     p.FailureReason,
+		CreatedAt:         p.CreatedAt.UnixMilli(),
+		UpdatedAt:         p.UpdatedAt.UnixMilli(),
+	}
+}
+
+// Provider sends money to drivers
+type Provider interface {
+	// Pay sends the payout to the driver and returns the provider's reference for it. A payout the
+	// provider will never accept returns ErrRejected. Calls for the same payout ID are carried out once.
+	Pay(ctx context.Context, payout *Payout) (string, error)
+	Name() string
+}
@@ -0,0 +1,33 @@
+package payout_test
+
+import (
+	"testing"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout/payouttest"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestInMemoPayouts(t *testing.T) {
+	if err := payouttest.TestPayouts(t.Context(), ledger.NewInMemoStore(), payout.NewInMemoStore()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoPayouts runs the suite against the MongoDB at MONGODB_URI, with the ledger and the
+// payouts in the same database
+func TestMongoPayouts(t *testing.T) {
+	database := dbtest.Database(t, "payouttest")
+	ledgerStore, err := ledger.NewMongoStore(t.Context(), database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := payout.NewMongoStore(t.Context(), database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := payouttest.TestPayouts(t.Context(), ledgerStore, store); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,313 @@
+// Package payouttest implements a contract suite for payout.Store implementations and the
+// payout scheduler running over them.
+package payouttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
+	"github.com/google/uuid"
+)
+
+// threshold is the smallest balance the scheduler pays out in the suite
+const threshold = 10000
+
+// TestPayouts checks the payout store, then runs the scheduler over it and a ledger backed by
+// ledgerStore. The scheduler pays out every balance in the ledger, so the stores should not be
+// shared with a running payment service. It returns the first contract violation found, or nil.
+func TestPayouts(ctx context.Context, ledgerStore ledger.Store, store payout.Store) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, ledger.Store, payout.Store) error
+	}{
+		{"settle", testSettle},
+		{"list by driver", testListByDriver},
+		{"threshold", testThreshold},
+		{"rejected payout", testRejectedPayout},
+		{"resumed payout", testResumedPayout},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, ledgerStore, store); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func testSettle(ctx context.Context, _ ledger.Store, store payout.Store) error {
+	p := newPayout(uuid.New().String(), time.Now())
+	if err := store.Create(ctx, p); err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+
+	at := p.CreatedAt.Add(time.Minute)
+	settled, err := store.Settle(ctx, p.ID, payout.StatusPaid, "po_test", "", at)
+	if err != nil {
+		return fmt.Errorf("Settle: %w", err)
+	}
+	if settled.Status != payout.StatusPaid || settled.ProviderReference != "po_test" || !settled.UpdatedAt.Equal(at) {
+		return fmt.Errorf("Settle: got %s payout with reference %q, want paid with po_test", settled.Status, settled.ProviderReference)
+	}
+
+	if _, err := store.Settle(ctx, p.ID, payout.StatusFailed, "", "late failure", at); !errors.Is(err, payout.ErrSettled) {
+		return fmt.Errorf("Settle of a paid payout: got %v, want ErrSettled", err)
+	}
+	if _, err := store.Settle(ctx, uuid.New().String(), payout.StatusPaid, "", "", at); !errors.Is(err, payout.ErrNotFound) {
+		return fmt.Errorf("Settle of an unknown payout: got %v, want ErrNotFound", err)
+	}
+
+	pending, err := store.ListPending(ctx)
+	if err != nil {
+		return fmt.Errorf("ListPending: %w", err)
+	}
+	for _, p := range pending {
+		if p.ID == settled.ID {
+			return fmt.Errorf("ListPending: got the paid payout %s", p.ID)
+		}
+	}
+	return nil
+}
+
+func testListByDriver(ctx context.Context, _ ledger.Store, store payout.Store) error {
+	driverID := uuid.New().String()
+	start := time.Now().Truncate(time.Millisecond)
+
+	// The payouts are created settled, so the scheduler checks later on do not resume them
+	ids := make([]string, 3)
+	for i := range ids {
+		p := newPayout(driverID, start.Add(time.Duration(i)*time.Minute))
+		p.Status = payout.StatusPaid
+		if err := store.Create(ctx, p); err != nil {
+			return fmt.Errorf("Create: %w", err)
+		}
+		ids[i] = p.ID
+	}
+	other := newPayout(uuid.New().String(), start)
+	other.Status = payout.StatusPaid
+	if err := store.Create(ctx, other); err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+
+	payouts, err := store.ListByDriver(ctx, driverID, start.Add(time.Hour), 2)
+	if err != nil {
+		return fmt.Errorf("ListByDriver: %w", err)
+	}
+	if len(payouts) != 2 || payouts[0].ID != ids[2] || payouts[1].ID != ids[1] {
+		return fmt.Errorf("ListByDriver: got %d payouts, want the newest 2 newest first", len(payouts))
+	}
+
+	payouts, err = store.ListByDriver(ctx, driverID, payouts[1].CreatedAt, 10)
+	if err != nil {
+		return fmt.Errorf("ListByDriver before a payout: %w", err)
+	}
+	if len(payouts) != 1 || payouts[0].ID != ids[0] {
+		return fmt.Errorf("ListByDriver before a payout: got %d payouts, want the oldest", len(payouts))
+	}
+	return nil
+}
+
+func testThreshold(ctx context.Context, ledgerStore ledger.Store, store payout.Store) error {
+	l := ledger.NewLedger(ledgerStore, ledger.DefaultConfig())
+	scheduler := payout.NewScheduler(l, store, payout.NewFakeProvider(payout.FakeOutcomeSucceed), threshold)
+
+	above, below := uuid.New().String(), uuid.New().String()
+	if err := earn(ctx, ledgerStore, above, threshold); err != nil {
+		return err
+	}
+	if err := earn(ctx, ledgerStore, below, threshold-1); err != nil {
+		return err
+	}
+
+	settled, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("RunOnce: %w", err)
+	}
+	if p := find(settled, above); p == nil || p.Status != payout.StatusPaid || p.Amount != threshold || p.ProviderReference == "" {
+		return fmt.Errorf("RunOnce: got payout %+v for a balance at the threshold, want a paid payout of %d", p, threshold)
+	}
+	if p := find(settled, below); p != nil {
+		return fmt.Errorf("RunOnce: got payout %s for a balance below the threshold", p.ID)
+	}
+
+	if err := payable(ctx, l, above, 0); err != nil {
+		return err
+	}
+	if err := payable(ctx, l, below, threshold-1); err != nil {
+		return err
+	}
+
+	// A second run has nothing left to pay the driver
+	settled, err = scheduler.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("second RunOnce: %w", err)
+	}
+	if p := find(settled, above); p != nil {
+		return fmt.Errorf("second RunOnce: got another payout %s for a paid out balance", p.ID)
+	}
+	return l.CheckInvariant(ctx)
+}
+
+func testRejectedPayout(ctx context.Context, ledgerStore ledger.Store, store payout.Store) error {
+	l := ledger.NewLedger(ledgerStore, ledger.DefaultConfig())
+	scheduler := payout.NewScheduler(l, store, payout.NewFakeProvider(payout.FakeOutcomeReject), threshold)
+
+	driverID := uuid.New().String()
+	if err := earn(ctx, ledgerStore, driverID, 2*threshold); err != nil {
+		return err
+	}
+
+	settled, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("RunOnce: %w", err)
+	}
+	p := find(settled, driverID)
+	if p == nil || p.Status != payout.StatusFailed || p.FailureReason == "" {
+		return fmt.Errorf("RunOnce: got payout %+v, want a failed payout with a reason", p)
+	}
+
+	// The rejected amount is owed to the driver again
+	if err := payable(ctx, l, driverID, 2*threshold); err != nil {
+		return err
+	}
+	return l.CheckInvariant(ctx)
+}
+
+func testResumedPayout(ctx context.Context, ledgerStore ledger.Store, store payout.Store) error {
+	l := ledger.NewLedger(ledgerStore, ledger.DefaultConfig())
+	provider := &flakyProvider{fake: payout.NewFakeProvider(payout.FakeOutcomeSucceed), down: true}
+	scheduler := payout.NewScheduler(l, store, provider, threshold)
+
+	driverID := uuid.New().String()
+	if err := earn(ctx, ledgerStore, driverID, threshold); err != nil {
+		return err
+	}
+
+	// The provider cannot be reached, so the payout stays pending with the amount set aside
+	settled, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("RunOnce: %w", err)
+	}
+	if p := find(settled, driverID); p != nil {
+		return fmt.Errorf("RunOnce: got %s payout while the provider is down, want it pending", p.Status)
+	}
+	if err := payable(ctx, l, driverID, 0); err != nil {
+		return err
+	}
+
+	provider.setDown(false)
+	settled, err = scheduler.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("RunOnce after the provider is back: %w", err)
+	}
+	p := find(settled, driverID)
+	if p == nil || p.Status != payout.StatusPaid || p.Amount != threshold {
+		return fmt.Errorf("RunOnce after the provider is back: got payout %+v, want a paid payout of %d", p, threshold)
+	}
+
+	payouts, err := store.ListByDriver(ctx, driverID, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		return fmt.Errorf("ListByDriver: %w", err)
+	}
+	if len(payouts) != 1 {
+		return fmt.Errorf("ListByDriver: got %d payouts, want the resumed one only", len(payouts))
+	}
+	if err := payable(ctx, l, driverID, 0); err != nil {
+		return err
+	}
+	return l.CheckInvariant(ctx)
+}
+
+// flakyProvider fails every payout with a transient error while it is down
+type flakyProvider struct {
+	mu   sync.Mutex
+	fake payout.Provider
+	down bool
+}
+
+func (f *flakyProvider) setDown(down bool) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *flakyProvider) Pay(ctx context.Context, p *payout.Payout) (string, error) {
+	f.mu.Lock()
+	down := f.down
+	f.mu.Unlock()
+
+	if down {
+		return "", errors.New("payout provider unavailable")
+	}
+	return f.fake.Pay(ctx, p)
+}
+
+func (f *flakyProvider) Name() string {
+	return "flaky"
+}
+
+// earn records a payment that leaves exactly share payable to the driver
+func earn(ctx context.Context, store ledger.Store, driverID string, share int64) error {
+	paidAt := time.Now().Truncate(time.Millisecond)
+	tx := &ledger.Transaction{
+		ID:        "test:" + uuid.New().String(),
+		Kind:      ledger.KindRidePayment,
+		PaymentID: uuid.New().String(),
+		TripID:    uuid.New().String(),
+		Currency:  "inr",
+		Entries: []*ledger.Entry{
+			{Account: ledger.RiderReceivable(uuid.New().String()), Amount: share},
+			{Account: ledger.DriverPayable(driverID), Amount: -share},
+		},
+		CreatedAt: paidAt,
+	}
+	if err := store.Record(ctx, tx); err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	return nil
+}
+
+// payable checks what the ledger still owes the driver
+func payable(ctx context.Context, l *ledger.Ledger, driverID string, want int64) error {
+	balances, err := l.DriverBalance(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("DriverBalance: %w", err)
+	}
+	var got int64
+	for _, b := range balances {
+		got -= b.Net()
+	}
+	if got != want {
+		return fmt.Errorf("DriverBalance: got %d payable, want %d", got, want)
+	}
+	return nil
+}
+
+// find returns the payout of the driver, or nil
+func find(payouts []*payout.Payout, driverID string) *payout.Payout {
+	for _, p := range payouts {
+		if p.DriverID == driverID {
+			return p
+		}
+	}
+	return nil
+}
+
+func newPayout(driverID string, createdAt time.Time) *payout.Payout {
+	createdAt = createdAt.Truncate(time.Millisecond)
+	return &payout.Payout{
+		ID:        uuid.New().String(),
+		DriverID:  driverID,
+		Amount:    threshold,
+		Currency:  "inr",
+		Status:    payout.StatusPending,
+		Provider:  "test",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
@@ -0,0 +1,136 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/google/uuid"
+)
+
+// Scheduler batches the payable balances of drivers into payouts. Only one instance of the
+// payment service should run it, since two schedulers would both pay out the same balances.
+type Scheduler struct {
+	ledger    *ledger.Ledger
+	store     Store
+	provider  Provider
+	threshold int64 // smallest payable balance paid out, in minor units
+
+	// runs never overlap, so a balance is never batched twice
+	mu sync.Mutex
+}
+
+// NewScheduler creates a scheduler that pays out balances of at least threshold through the provider
+func NewScheduler(ledger *ledger.Ledger, store Store, provider Provider, threshold int64) *Scheduler {
+	return &Scheduler{ledger: ledger, store: store, provider: provider, threshold: threshold}
+}
+
+// RunOnce sends the payouts left pending by earlier runs, then pays out every payable balance
+// at or above the threshold. Payouts the provider could not be reached for stay pending until
+// the next run. It returns the payouts it settled.
+func (s *Scheduler) RunOnce(ctx context.Context) ([]*Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, err := s.store.ListPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settled := []*Payout{}
+	for _, payout := range pending {
+		if p := s.send(ctx, payout); p != nil {
+			settled = append(settled, p)
+		}
+	}
+
+	// Pending payouts are already off the balances, so the balances only hold what is still owed
+	balances, err := s.ledger.PayableBalances(ctx)
+	if err != nil {
+		return settled, err
+	}
+	for _, balance := range balances {
+		amount := -balance.Net()
+		if amount <= 0 || amount < s.threshold {
+			continue
+		}
+
+		now := time.Now()
+		payout := &Payout{
+			ID:        uuid.New().String(),
+			DriverID:  balance.Account.OwnerID,
+			Amount:    amount,
+			Currency:  balance.Currency,
+			Status:    StatusPending,
+			Provider:  s.provider.Name(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.store.Create(ctx, payout); err != nil {
+			return settled, fmt.Errorf("failed to create payout for driver %s: %w", payout.DriverID, err)
+		}
+		if p := s.send(ctx, payout); p != nil {
+			settled = append(settled, p)
+		}
+	}
+	return settled, nil
+}
+
+// Run pays out balances every interval until the context is done
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settled, err := s.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Payout run failed: %v", err)
+			}
+			if len(settled) > 0 {
+				log.Printf("Payout run settled %d payouts", len(settled))
+			}
+		}
+	}
+}
+
+// send takes a pending payout off the driver's balance and pays it through the provider.
+// It returns the settled payout, or nil if the payout is still pending.
+func (s *Scheduler) send(ctx context.Context, payout *Payout) *Payout {
+	// Recording a payout again has no effect, so a payout resumed after a crash is taken off once
+	if err := s.ledger.RecordPayout(ctx, payout.ID, payout.DriverID, payout.Amount, payout.Currency, payout.CreatedAt); err != nil {
+		log.Printf("Failed to record payout %s in the ledger: %v", payout.ID, err)
+		return nil
+	}
+
+	reference, err := s.provider.Pay(ctx, payout)
+	if errors.Is(err, ErrRejected) {
+		now := time.Now()
+		if err := s.ledger.ReversePayout(ctx, payout.ID, payout.DriverID, payout.Amount, payout.Currency, now); err != nil {
+			log.Printf("Failed to reverse rejected payout %s in the ledger: %v", payout.ID, err)
+			return nil
+		}
+		return s.settle(ctx, payout, StatusFailed, "", err.Error(), now)
+	}
+	if err != nil {
+		log.Printf("Failed to send payout %s, retrying next run: %v", payout.ID, err)
+		return nil
+	}
+	return s.settle(ctx, payout, StatusPaid, reference, "", time.Now())
+}
+
+func (s *Scheduler) settle(ctx context.Context, payout *Payout, status Status, reference, failureReason string, at time.Time) *Payout {
+	settled, err := s.store.Settle(ctx, payout.ID, status, reference, failureReason, at)
+	if err != nil {
+		log.Printf("Failed to mark payout %s as %s: %v", payout.ID, status, err)
+		return nil
+	}
+	return settled
+}
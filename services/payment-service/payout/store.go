@@ -0,0 +1,93 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Store interface {
+	Create(ctx context.Context, payout *Payout) error
+	// Settle moves a pending payout to paid or failed, or returns ErrSettled if it is no longer pending
+	Settle(ctx context.Context, payoutID string, status Status, reference, failureReason string, at time.Time) (*Payout, error)
+	// ListPending lists the payouts still waiting for the provider, oldest first
+	ListPending(ctx context.Context) ([]*Payout, error)
+	// ListByDriver lists up to limit payouts of the driver created before the given time, newest first
+	ListByDriver(ctx context.Context, driverID string, before time.Time, limit int) ([]*Payout, error)
+}
+
+type inMemoStore struct {
+	sync.RWMutex
+	payouts map[string]*Payout
+}
+
+// NewInMemoStore creates a new instance of in-memory Store
+func NewInMemoStore() *inMemoStore {
+	return &inMemoStore{payouts: make(map[string]*Payout)}
+}
+
+// Create saves a copy of the payout
+func (s *inMemoStore) Create(ctx context.Context, payout *Payout) error {
+	s.Lock()
+	copied := *payout
+	s.payouts[payout.ID] = &copied
+	s.Unlock()
+	return nil
+}
+
+// Settle moves a pending payout to paid or failed
+func (s *inMemoStore) Settle(ctx context.Context, payoutID string, status Status, reference, failureReason string, at time.Time) (*Payout, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	payout, exists := s.payouts[payoutID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if payout.Status != StatusPending {
+		return nil, fmt.Errorf("%w: payout %s is %s", ErrSettled, payoutID, payout.Status)
+	}
+
+	payout.Status = status
+	payout.ProviderReference = reference
+	payout.FailureReason = failureReason
+	payout.UpdatedAt = at
+
+	copied := *payout
+	return &copied, nil
+}
+
+// ListPending lists the payouts still waiting for the provider, oldest first
+func (s *inMemoStore) ListPending(ctx context.Context) ([]*Payout, error) {
+	return s.list(func(p *Payout) bool { return p.Status == StatusPending }, false, 0)
+}
+
+// ListByDriver lists up to limit payouts of the driver created before the given time, newest first
+func (s *inMemoStore) ListByDriver(ctx context.Context, driverID string, before time.Time, limit int) ([]*Payout, error) {
+	return s.list(func(p *Payout) bool { return p.DriverID == driverID && p.CreatedAt.Before(before) }, true, limit)
+}
+
+func (s *inMemoStore) list(match func(*Payout) bool, newestFirst bool, limit int) ([]*Payout, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	payouts := []*Payout{}
+	for _, payout := range s.payouts {
+		if match(payout) {
+			copied := *payout
+			payouts = append(payouts, &copied)
+		}
+	}
+	sort.Slice(payouts, func(i, j int) bool {
+		if newestFirst {
+			return payouts[i].CreatedAt.After(payouts[j].CreatedAt)
+		}
+		return payouts[i].CreatedAt.Before(payouts[j].CreatedAt)
+	})
+	if limit > 0 && len(payouts) > limit {
+		payouts = payouts[:limit]
+	}
+	return payouts, nil
+}
@@ -0,0 +1,291 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	paymentsCollection = "payments"
+	refundsCollection  = "refunds"
+)
+
+type mongoRepo struct {
+	payments *mongo.Collection
+	refunds  *mongo.Collection
+}
+
+// NewMongoRepository creates a MongoDB backed PaymentRepo and ensures its indexes exist
+func NewMongoRepository(ctx context.Context, db *mongo.Database) (*mongoRepo, error) {
+	r := &mongoRepo{
+		payments: db.Collection(paymentsCollection),
+		refunds:  db.Collection(refundsCollection),
+	}
+
+	if _, err := r.payments.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "stripeSessionID", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tripID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "riderID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create payment indexes: %w", err)
+	}
+
+	if _, err := r.refunds.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "idempotencyKey", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "providerReference", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "paymentID", Value: 1}, {Key: "createdAt", Value: 1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create refund indexes: %w", err)
+	}
+
+	return r, nil
+}
+
+// Create inserts a new payment. The unique checkout session index turns a duplicate into ErrPaymentExists.
+func (r *mongoRepo) Create(ctx context.Context, payment *types.Payment) error {
+	if _, err := r.payments.InsertOne(ctx, payment); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrPaymentExists
+		}
+		return fmt.Errorf("failed to insert payment: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a payment by its ID
+func (r *mongoRepo) GetByID(ctx context.Context, paymentID string) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"_id": paymentID})
+}
+
+// GetBySessionID retrieves the payment collected through the given checkout session
+func (r *mongoRepo) GetBySessionID(ctx context.Context, sessionID string) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"stripeSessionID": sessionID})
+}
+
+// GetByTripID retrieves the latest payment collected for the trip
+func (r *mongoRepo) GetByTripID(ctx context.Context, tripID string) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"tripID": tripID}, options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+}
+
+// GetByTripAndPurpose retrieves the latest payment collected for the trip with the given purpose
+func (r *mongoRepo) GetByTripAndPurpose(ctx context.Context, tripID string, purpose types.PaymentPurpose) (*types.Payment, error) {
+	return r.findOne(ctx, bson.M{"tripID": tripID, "purpose": purpose}, options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+}
+
+// ListByRiderID lists up to limit payments of the rider created before the given time, newest first
+func (r *mongoRepo) ListByRiderID(ctx context.Context, riderID string, before time.Time, limit int) ([]*types.Payment, error) {
+	cursor, err := r.payments.Find(ctx,
+		bson.M{"riderID": riderID, "createdAt": bson.M{"$lt": before}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	payments := []*types.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, fmt.Errorf("failed to decode payments: %w", err)
+	}
+	return payments, nil
+}
+
+// UpdateStatus moves the payment to the given status if the transition is allowed.
+// The update only applies if the payment is still in the status it was read in.
+func (r *mongoRepo) UpdateStatus(ctx context.Context, sessionID string, status types.PaymentStatus, at time.Time) (*types.Payment, error) {
+	payment, err := r.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.transition(ctx, payment, status, at)
+}
+
+// transition moves the payment to the given status, provided it is still in the status it was read in
+func (r *mongoRepo) transition(ctx context.Context, payment *types.Payment, status types.PaymentStatus, at time.Time) (*types.Payment, error) {
+	from := payment.Status
+	if err := payment.Transition(status, at); err != nil {
+		return nil, err
+	}
+	change := payment.StatusHistory[len(payment.StatusHistory)-1]
+
+	res, err := r.payments.UpdateOne(ctx,
+		bson.M{"_id": payment.ID, "status": from},
+		bson.M{
+			"$set":  bson.M{"status": status, "updatedAt": at},
+			"$push": bson.M{"statusHistory": change},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, fmt.Errorf("%w: payment %s changed status concurrently", types.ErrInvalidTransition, payment.ID)
+	}
+	return payment, nil
+}
+
+// CreateRefund saves a pending refund and reserves its amount on the payment.
+// The unique idempotency key index turns a concurrent duplicate into ErrRefundExists.
+func (r *mongoRepo) CreateRefund(ctx context.Context, refund *types.Refund) (*types.Refund, error) {
+	if _, err := r.refunds.InsertOne(ctx, refund); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			existing, getErr := r.GetRefundByIdempotencyKey(ctx, refund.IdempotencyKey)
+			if getErr != nil {
+				return nil, getErr
+			}
+			return existing, ErrRefundExists
+		}
+		return nil, fmt.Errorf("failed to insert refund: %w", err)
+	}
+
+	// Reserve the amount only if it is still refundable
+	res, err := r.payments.UpdateOne(ctx,
+		bson.M{
+			"_id":    refund.PaymentID,
+			"status": bson.M{"$in": []types.PaymentStatus{types.PaymentStatusSuccess, types.PaymentStatusPartiallyRefunded}},
+			"$expr": bson.M{"$gte": bson.A{
+				bson.M{"$subtract": bson.A{"$amount", bson.M{"$add": bson.A{"$refundedAmount", "$pendingRefundAmount"}}}},
+				refund.Amount,
+			}},
+		},
+		bson.M{"$inc": bson.M{"pendingRefundAmount": refund.Amount}},
+	)
+	if err == nil && res.MatchedCount == 1 {
+		return refund, nil
+	}
+
+	if _, delErr := r.refunds.DeleteOne(ctx, bson.M{"_id": refund.ID}); delErr != nil {
+		return nil, fmt.Errorf("failed to remove unreserved refund %s: %w", refund.ID, delErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve refund: %w", err)
+	}
+	if _, err := r.GetByID(ctx, refund.PaymentID); err != nil {
+		return nil, err
+	}
+	return nil, ErrRefundExceedsPayment
+}
+
+// GetRefundByIdempotencyKey retrieves the refund created with the given idempotency key
+func (r *mongoRepo) GetRefundByIdempotencyKey(ctx context.Context, key string) (*types.Refund, error) {
+	return r.findRefund(ctx, bson.M{"idempotencyKey": key})
+}
+
+// GetRefundByProviderReference retrieves the refund known to the provider by the given reference
+func (r *mongoRepo) GetRefundByProviderReference(ctx context.Context, reference string) (*types.Refund, error) {
+	if reference == "" {
+		return nil, ErrRefundNotFound
+	}
+	return r.findRefund(ctx, bson.M{"providerReference": reference})
+}
+
+// ListRefundsByPayment lists the refunds of a payment, oldest first
+func (r *mongoRepo) ListRefundsByPayment(ctx context.Context, paymentID string) ([]*types.Refund, error) {
+	cursor, err := r.refunds.Find(ctx, bson.M{"paymentID": paymentID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	refunds := []*types.Refund{}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return nil, fmt.Errorf("failed to decode refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// SettleRefund records the provider's reference and the outcome of a pending refund
+func (r *mongoRepo) SettleRefund(ctx context.Context, refundID string, status types.RefundStatus, reference string, at time.Time) (*types.Refund, *types.Payment, error) {
+	set := bson.M{"status": status, "updatedAt": at}
+	if reference != "" {
+		set["providerReference"] = reference
+	}
+
+	var refund types.Refund
+	err := r.refunds.FindOneAndUpdate(ctx,
+		bson.M{"_id": refundID, "status": types.RefundStatusPending},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&refund)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, getErr := r.findRefund(ctx, bson.M{"_id": refundID}); getErr != nil {
+			return nil, nil, getErr
+		}
+		return nil, nil, fmt.Errorf("%w: refund %s is no longer pending", types.ErrInvalidTransition, refundID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	var inc bson.M
+	switch status {
+	case types.RefundStatusSucceeded:
+		inc = bson.M{"pendingRefundAmount": -refund.Amount, "refundedAmount": refund.Amount}
+	case types.RefundStatusFailed:
+		inc = bson.M{"pendingRefundAmount": -refund.Amount}
+	default:
+		payment, err := r.GetByID(ctx, refund.PaymentID)
+		return &refund, payment, err
+	}
+
+	var payment types.Payment
+	if err := r.payments.FindOneAndUpdate(ctx,
+		bson.M{"_id": refund.PaymentID},
+		bson.M{"$inc": inc},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&payment); err != nil {
+		return nil, nil, fmt.Errorf("failed to update refunded amount: %w", err)
+	}
+
+	if status == types.RefundStatusFailed {
+		return &refund, &payment, nil
+	}
+
+	// Another refund of the payment may settle at the same time, so the status is retried from a fresh read
+	for attempt := 0; ; attempt++ {
+		next := payment.RefundedStatus()
+		if next == payment.Status {
+			return &refund, &payment, nil
+		}
+		updated, err := r.transition(ctx, &payment, next, at)
+		if err == nil {
+			return &refund, updated, nil
+		}
+		if !errors.Is(err, types.ErrInvalidTransition) || attempt == 2 {
+			return nil, nil, err
+		}
+		fresh, err := r.GetByID(ctx, refund.PaymentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		payment = *fresh
+	}
+}
+
+func (r *mongoRepo) findRefund(ctx context.Context, filter bson.M) (*types.Refund, error) {
+	var refund types.Refund
+	if err := r.refunds.FindOne(ctx, filter).Decode(&refund); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+	return &refund, nil
+}
+
+func (r *mongoRepo) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*types.Payment, error) {
+	var payment types.Payment
+	if err := r.payments.FindOne(ctx, filter, opts...).Decode(&payment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return &payment, nil
+}
@@ -0,0 +1,277 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+)
+
+var (
+	ErrNotFound             = errors.New("payment not found")
+	ErrPaymentExists        = errors.New("a payment for this checkout session already exists")
+	ErrRefundNotFound       = errors.New("refund not found")
+	ErrRefundExists         = errors.New("a refund with this idempotency key already exists")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the refundable amount of the payment")
+)
+
+type PaymentRepo interface {
+	// Create saves a new payment, or returns ErrPaymentExists if its checkout session is already recorded
+	Create(ctx context.Context, payment *types.Payment) error
+	GetByID(ctx context.Context, paymentID string) (*types.Payment, error)
+	GetBySessionID(ctx context.Context, sessionID string) (*types.Payment, error)
+	GetByTripID(ctx context.Context, tripID string) (*types.Payment, error)
+	GetByTripAndPurpose(ctx context.Context, tripID string, purpose types.PaymentPurpose) (*types.Payment, error)
+	ListByRiderID(ctx context.Context, riderID string, before time.Time, limit int) ([]*types.Payment, error)
+	UpdateStatus(ctx context.Context, sessionID string, status types.PaymentStatus, at time.Time) (*types.Payment, error)
+
+	// CreateRefund saves a pending refund and reserves its amount on the payment. A refund with the same
+	// idempotency key is returned with ErrRefundExists instead.
+	CreateRefund(ctx context.Context, refund *types.Refund) (*types.Refund, error)
+	GetRefundByIdempotencyKey(ctx context.Context, key string) (*types.Refund, error)
+	GetRefundByProviderReference(ctx context.Context, reference string) (*types.Refund, error)
+	ListRefundsByPayment(ctx context.Context, paymentID string) ([]*types.Refund, error)
+	// SettleRefund records the provider's reference and, once known, the outcome of a pending refund.
+	// A succeeded refund is added to the payment's refunded amount, a failed one releases its reservation.
+	SettleRefund(ctx context.Context, refundID string, status types.RefundStatus, reference string, at time.Time) (*types.Refund, *types.Payment, error)
+}
+
+type inMemoRepo struct {
+	sync.RWMutex
+	payments   map[string]*types.Payment // checkout session ID -> payment
+	sessionIDs map[string]string         // payment ID -> checkout session ID
+	refunds    map[string]*types.Refund  // refund ID -> refund
+}
+
+// NewInMemoRepository creates a new instance of in-memory PaymentRepo
+func NewInMemoRepository() *inMemoRepo {
+	return &inMemoRepo{
+		payments:   make(map[string]*types.Payment),
+		sessionIDs: make(map[string]string),
+		refunds:    make(map[string]*types.Refund),
+	}
+}
+
+// Create adds a new payment to the in-memory store
+func (r *inMemoRepo) Create(ctx context.Context, payment *types.Payment) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, exists := r.payments[payment.StripeSessionID]; exists {
+		return ErrPaymentExists
+	}
+	r.payments[payment.StripeSessionID] = clonePayment(payment)
+	r.sessionIDs[payment.ID] = payment.StripeSessionID
+	return nil
+}
+
+// GetByID retrieves a payment by its ID
+func (r *inMemoRepo) GetByID(ctx context.Context, paymentID string) (*types.Payment, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	payment, exists := r.payments[r.sessionIDs[paymentID]]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return clonePayment(payment), nil
+}
+
+// GetBySessionID retrieves the payment collected through the given checkout session
+func (r *inMemoRepo) GetBySessionID(ctx context.Context, sessionID string) (*types.Payment, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	payment, exists := r.payments[sessionID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return clonePayment(payment), nil
+}
+
+// GetByTripID retrieves the latest payment collected for the trip
+func (r *inMemoRepo) GetByTripID(ctx context.Context, tripID string) (*types.Payment, error) {
+	return r.latest(func(p *types.Payment) bool { return p.TripID == tripID })
+}
+
+// GetByTripAndPurpose retrieves the latest payment collected for the trip with the given purpose
+func (r *inMemoRepo) GetByTripAndPurpose(ctx context.Context, tripID string, purpose types.PaymentPurpose) (*types.Payment, error) {
+	return r.latest(func(p *types.Payment) bool { return p.TripID == tripID && p.Purpose == purpose })
+}
+
+func (r *inMemoRepo) latest(match func(*types.Payment) bool) (*types.Payment, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	var latest *types.Payment
+	for _, payment := range r.payments {
+		if match(payment) && (latest == nil || payment.CreatedAt.After(latest.CreatedAt)) {
+			latest = payment
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return clonePayment(latest), nil
+}
+
+// ListByRiderID lists up to limit payments of the rider created before the given time, newest first
+func (r *inMemoRepo) ListByRiderID(ctx context.Context, riderID string, before time.Time, limit int) ([]*types.Payment, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	payments := []*types.Payment{}
+	for _, payment := range r.payments {
+		if payment.RiderID == riderID && payment.CreatedAt.Before(before) {
+			payments = append(payments, clonePayment(payment))
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.After(payments[j].CreatedAt)
+	})
+	if len(payments) > limit {
+		payments = payments[:limit]
+	}
+	return payments, nil
+}
+
+// UpdateStatus moves the payment to the given status if the transition is allowed
+func (r *inMemoRepo) UpdateStatus(ctx context.Context, sessionID string, status types.PaymentStatus, at time.Time) (*types.Payment, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	payment, exists := r.payments[sessionID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if err := payment.Transition(status, at); err != nil {
+		return nil, err
+	}
+	return clonePayment(payment), nil
+}
+
+// CreateRefund saves a pending refund and reserves its amount on the payment
+func (r *inMemoRepo) CreateRefund(ctx context.Context, refund *types.Refund) (*types.Refund, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, existing := range r.refunds {
+		if existing.IdempotencyKey == refund.IdempotencyKey {
+			copied := *existing
+			return &copied, ErrRefundExists
+		}
+	}
+
+	payment, exists := r.payments[r.sessionIDs[refund.PaymentID]]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if refund.Amount > payment.Refundable() {
+		return nil, ErrRefundExceedsPayment
+	}
+
+	payment.PendingRefunds += refund.Amount
+	copied := *refund
+	r.refunds[refund.ID] = &copied
+	return refund, nil
+}
+
+// GetRefundByIdempotencyKey retrieves the refund created with the given idempotency key
+func (r *inMemoRepo) GetRefundByIdempotencyKey(ctx context.Context, key string) (*types.Refund, error) {
+	return r.findRefund(func(refund *types.Refund) bool { return refund.IdempotencyKey == key })
+}
+
+// GetRefundByProviderReference retrieves the refund known to the provider by the given reference
+func (r *inMemoRepo) GetRefundByProviderReference(ctx context.Context, reference string) (*types.Refund, error) {
+	if reference == "" {
+		return nil, ErrRefundNotFound
+	}
+	return r.findRefund(func(refund *types.Refund) bool { return refund.ProviderReference == reference })
+}
+
+// ListRefundsByPayment lists the refunds of a payment, oldest first
+func (r *inMemoRepo) ListRefundsByPayment(ctx context.Context, paymentID string) ([]*types.Refund, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	refunds := []*types.Refund{}
+	for _, refund := range r.refunds {
+		if refund.PaymentID == paymentID {
+			copied := *refund
+			refunds = append(refunds, &copied)
+		}
+	}
+	sort.Slice(refunds, func(i, j int) bool {
+		return refunds[i].CreatedAt.Before(refunds[j].CreatedAt)
+	})
+	return refunds, nil
+}
+
+// SettleRefund records the provider's reference and the outcome of a pending refund
+func (r *inMemoRepo) SettleRefund(ctx context.Context, refundID string, status types.RefundStatus, reference string, at time.Time) (*types.Refund, *types.Payment, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	refund, exists := r.refunds[refundID]
+	if !exists {
+		return nil, nil, ErrRefundNotFound
+	}
+	if refund.Status != types.RefundStatusPending {
+		return nil, nil, fmt.Errorf("%w: refund %s is %s", types.ErrInvalidTransition, refundID, refund.Status)
+	}
+	payment, exists := r.payments[r.sessionIDs[refund.PaymentID]]
+	if !exists {
+		return nil, nil, ErrNotFound
+	}
+
+	if reference != "" {
+		refund.ProviderReference = reference
+	}
+	refund.Status = status
+	refund.UpdatedAt = at
+
+	switch status {
+	case types.RefundStatusSucceeded:
+		payment.PendingRefunds -= refund.Amount
+		payment.RefundedAmount += refund.Amount
+		if next := payment.RefundedStatus(); next != payment.Status {
+			if err := payment.Transition(next, at); err != nil {
+				return nil, nil, err
+			}
+		}
+	case types.RefundStatusFailed:
+		payment.PendingRefunds -= refund.Amount
+	}
+
+	copied := *refund
+	return &copied, clonePayment(payment), nil
+}
+
+func (r *inMemoRepo) findRefund(match func(*types.Refund) bool) (*types.Refund, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, refund := range r.refunds {
+		if match(refund) {
+			copied := *refund
+			return &copied, nil
+		}
+	}
+	return nil, ErrRefundNotFound
+}
+
+// clonePayment copies the payment so callers cannot modify the stored one
+func clonePayment(payment *types.Payment) *types.Payment {
+	copied := *payment
+	copied.StatusHistory = make([]*types.PaymentStatusChange, len(payment.StatusHistory))
+	for i, change := range payment.StatusHistory {
+		c := *change
+		copied.StatusHistory[i] = &c
+	}
+	return &copied
+}
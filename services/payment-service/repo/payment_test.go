@@ -0,0 +1,26 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/repo/repotest"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestInMemoRepo(t *testing.T) {
+	if err := repotest.TestPaymentRepo(t.Context(), repo.NewInMemoRepository()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoRepo runs the suite against the MongoDB at MONGODB_URI
+func TestMongoRepo(t *testing.T) {
+	r, err := repo.NewMongoRepository(t.Context(), dbtest.Database(t, "payment-repotest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repotest.TestPaymentRepo(t.Context(), r); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,14 +2,32 @@ package repo
 
 import (
 	"context"
+	"time"
 
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
 	"github.com/cprakhar/uber-clone/services/payment-service/types"
 )
 
 type Service interface {
-	CreatePaymentSession(ctx context.Context, tripID, riderID, driverID string, amount int64, currency string) (*types.PaymentIntent, error)
+	CreatePaymentSession(ctx context.Context, tripID, riderID, driverID, packageSlug string, purpose types.PaymentPurpose, amount int64, currency string) (*types.PaymentIntent, error)
+	GetPaymentBySessionID(ctx context.Context, sessionID string) (*types.Payment, error)
+	GetPaymentByTrip(ctx context.Context, tripID string) (*types.Payment, error)
+	ListPaymentsByRider(ctx context.Context, riderID string, before time.Time, limit int) ([]*types.Payment, error)
+	UpdatePaymentStatus(ctx context.Context, sessionID string, status types.PaymentStatus, at time.Time) (*types.Payment, error)
+	RefundPayment(ctx context.Context, paymentID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.Refund, *types.Payment, error)
+	SettleRefund(ctx context.Context, providerReference string, status types.RefundStatus, at time.Time) (*types.Refund, *types.Payment, error)
+	GetDriverBalance(ctx context.Context, driverID string) ([]*ledger.Balance, error)
+	GetDriverEarnings(ctx context.Context, driverID string, period ledger.Period, from, to time.Time) ([]*ledger.Earnings, error)
+	ListDriverPayouts(ctx context.Context, driverID string, before time.Time, limit int) ([]*payout.Payout, error)
 }
 
 type PaymentProcessor interface {
-	CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error)
+	// CreatePaymentSession opens a checkout session. Requests with the same idempotency key return
+	// the session created by the first one.
+	CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string, idempotencyKey string) (string, error)
+	// Refund gives back part of the payment collected through the session. Requests with the same
+	// idempotency key are only carried out once.
+	Refund(ctx context.Context, sessionID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.ProviderRefund, error)
+	Provider() string
 }
@@ -0,0 +1,381 @@
+// Package repotest implements a contract suite shared by every repo.PaymentRepo implementation.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/google/uuid"
+)
+
+// TestPaymentRepo exercises the behaviour every PaymentRepo must provide.
+// It only writes records with fresh IDs, so it can run against a shared database.
+// It returns the first contract violation found, or nil.
+func TestPaymentRepo(ctx context.Context, r repo.PaymentRepo) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, repo.PaymentRepo) error
+	}{
+		{"missing records", testMissingRecords},
+		{"payment round trip", testPaymentRoundTrip},
+		{"status transitions", testStatusTransitions},
+		{"latest payment by trip", testLatestPaymentByTrip},
+		{"duplicate session", testDuplicateSession},
+		{"payments by rider", testPaymentsByRider},
+		{"refund reservations", testRefundReservations},
+		{"refund settlement", testRefundSettlement},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, r); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func testMissingRecords(ctx context.Context, r repo.PaymentRepo) error {
+	missingID := uuid.New().String()
+
+	if _, err := r.GetBySessionID(ctx, missingID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetBySessionID of unknown session: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetByTripID(ctx, missingID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetByTripID of unknown trip: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.UpdateStatus(ctx, missingID, types.PaymentStatusSuccess, time.Now()); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("UpdateStatus of unknown session: got %v, want ErrNotFound", err)
+	}
+
+	payments, err := r.ListByRiderID(ctx, missingID, time.Now(), 10)
+	if err != nil {
+		return fmt.Errorf("ListByRiderID of unknown rider: %w", err)
+	}
+	if len(payments) != 0 {
+		return fmt.Errorf("ListByRiderID of unknown rider: got %d payments, want 0", len(payments))
+	}
+	return nil
+}
+
+func testPaymentRoundTrip(ctx context.Context, r repo.PaymentRepo) error {
+	payment, err := createPayment(ctx, r, uuid.New().String(), uuid.New().String(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	got, err := r.GetBySessionID(ctx, payment.StripeSessionID)
+	if err != nil {
+		return fmt.Errorf("GetBySessionID: %w", err)
+	}
+	if got.ID != payment.ID || got.TripID != payment.TripID || got.RiderID != payment.RiderID {
+		return fmt.Errorf("GetBySessionID: got payment %s of trip %s, want %s of trip %s", got.ID, got.TripID, payment.ID, payment.TripID)
+	}
+	if got.Amount != payment.Amount || got.Currency != payment.Currency || got.Purpose != payment.Purpose {
+		return fmt.Errorf("GetBySessionID: got %d %s for %s, want %d %s for %s",
+			got.Amount, got.Currency, got.Purpose, payment.Amount, payment.Currency, payment.Purpose)
+	}
+	if got.Provider != payment.Provider {
+		return fmt.Errorf("GetBySessionID: got provider %q, want %q", got.Provider, payment.Provider)
+	}
+	if got.Status != types.PaymentStatusPending || len(got.StatusHistory) != 1 {
+		return fmt.Errorf("GetBySessionID: got status %s with %d changes, want pending with 1", got.Status, len(got.StatusHistory))
+	}
+	return nil
+}
+
+func testStatusTransitions(ctx context.Context, r repo.PaymentRepo) error {
+	payment, err := createPayment(ctx, r, uuid.New().String(), uuid.New().String(), time.Now())
+	if err != nil {
+		return err
+	}
+	sessionID := payment.StripeSessionID
+
+	updated, err := r.UpdateStatus(ctx, sessionID, types.PaymentStatusSuccess, time.Now())
+	if err != nil {
+		return fmt.Errorf("pending -> success: %w", err)
+	}
+	if updated.Status != types.PaymentStatusSuccess {
+		return fmt.Errorf("pending -> success: got status %s", updated.Status)
+	}
+
+	if _, err := r.UpdateStatus(ctx, sessionID, types.PaymentStatusSuccess, time.Now()); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("success -> success: got %v, want ErrInvalidTransition", err)
+	}
+	if _, err := r.UpdateStatus(ctx, sessionID, types.PaymentStatusFailed, time.Now()); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("success -> failed: got %v, want ErrInvalidTransition", err)
+	}
+
+	got, err := r.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("GetBySessionID: %w", err)
+	}
+	if got.Status != types.PaymentStatusSuccess {
+		return fmt.Errorf("got status %s after rejected transitions, want success", got.Status)
+	}
+	if len(got.StatusHistory) != 2 {
+		return fmt.Errorf("got %d status changes, want 2", len(got.StatusHistory))
+	}
+	for i, change := range got.StatusHistory {
+		if change.ChangedAt.IsZero() {
+			return fmt.Errorf("status change %d (%s) has no timestamp", i, change.Status)
+		}
+	}
+	return nil
+}
+
+func testLatestPaymentByTrip(ctx context.Context, r repo.PaymentRepo) error {
+	tripID, riderID := uuid.New().String(), uuid.New().String()
+	now := time.Now()
+
+	if _, err := createPayment(ctx, r, tripID, riderID, now.Add(-time.Minute)); err != nil {
+		return err
+	}
+	latest, err := createPayment(ctx, r, tripID, riderID, now)
+	if err != nil {
+		return err
+	}
+
+	got, err := r.GetByTripID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("GetByTripID: %w", err)
+	}
+	if got.ID != latest.ID {
+		return fmt.Errorf("GetByTripID: got payment %s, want the latest %s", got.ID, latest.ID)
+	}
+
+	got, err = r.GetByTripAndPurpose(ctx, tripID, types.PaymentPurposeRide)
+	if err != nil {
+		return fmt.Errorf("GetByTripAndPurpose: %w", err)
+	}
+	if got.ID != latest.ID {
+		return fmt.Errorf("GetByTripAndPurpose: got payment %s, want the latest %s", got.ID, latest.ID)
+	}
+	if _, err := r.GetByTripAndPurpose(ctx, tripID, types.PaymentPurposeCancellationFee); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetByTripAndPurpose of another purpose: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func testDuplicateSession(ctx context.Context, r repo.PaymentRepo) error {
+	payment, err := createPayment(ctx, r, uuid.New().String(), uuid.New().String(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	duplicate := *payment
+	duplicate.ID = uuid.New().String()
+	if err := r.Create(ctx, &duplicate); !errors.Is(err, repo.ErrPaymentExists) {
+		return fmt.Errorf("Create with a recorded session: got %v, want ErrPaymentExists", err)
+	}
+
+	got, err := r.GetBySessionID(ctx, payment.StripeSessionID)
+	if err != nil {
+		return fmt.Errorf("GetBySessionID: %w", err)
+	}
+	if got.ID != payment.ID {
+		return fmt.Errorf("GetBySessionID: got payment %s, want the first %s", got.ID, payment.ID)
+	}
+	return nil
+}
+
+func testPaymentsByRider(ctx context.Context, r repo.PaymentRepo) error {
+	riderID := uuid.New().String()
+	now := time.Now()
+
+	var created []*types.Payment
+	for i := 0; i < 3; i++ {
+		payment, err := createPayment(ctx, r, uuid.New().String(), riderID, now.Add(time.Duration(i-3)*time.Minute))
+		if err != nil {
+			return err
+		}
+		created = append(created, payment)
+	}
+
+	payments, err := r.ListByRiderID(ctx, riderID, now, 2)
+	if err != nil {
+		return fmt.Errorf("ListByRiderID: %w", err)
+	}
+	if len(payments) != 2 {
+		return fmt.Errorf("ListByRiderID with limit 2: got %d payments", len(payments))
+	}
+	if payments[0].ID != created[2].ID || payments[1].ID != created[1].ID {
+		return fmt.Errorf("ListByRiderID: got payments %s, %s, want newest first", payments[0].ID, payments[1].ID)
+	}
+
+	older, err := r.ListByRiderID(ctx, riderID, payments[1].CreatedAt, 10)
+	if err != nil {
+		return fmt.Errorf("ListByRiderID before %v: %w", payments[1].CreatedAt, err)
+	}
+	if len(older) != 1 || older[0].ID != created[0].ID {
+		return fmt.Errorf("ListByRiderID of the next page: got %d payments, want only %s", len(older), created[0].ID)
+	}
+	return nil
+}
+
+func testRefundReservations(ctx context.Context, r repo.PaymentRepo) error {
+	payment, err := createPaidPayment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	key := uuid.New().String()
+	first, err := r.CreateRefund(ctx, newRefund(payment, key, 20000))
+	if err != nil {
+		return fmt.Errorf("CreateRefund: %w", err)
+	}
+
+	existing, err := r.CreateRefund(ctx, newRefund(payment, key, 1000))
+	if !errors.Is(err, repo.ErrRefundExists) {
+		return fmt.Errorf("CreateRefund with a used key: got %v, want ErrRefundExists", err)
+	}
+	if existing == nil || existing.ID != first.ID {
+		return fmt.Errorf("CreateRefund with a used key: got %v, want refund %s", existing, first.ID)
+	}
+
+	// 5000 of the 25000 is left while the first refund is pending
+	if _, err := r.CreateRefund(ctx, newRefund(payment, uuid.New().String(), 5001)); !errors.Is(err, repo.ErrRefundExceedsPayment) {
+		return fmt.Errorf("CreateRefund above the refundable amount: got %v, want ErrRefundExceedsPayment", err)
+	}
+	if _, err := r.CreateRefund(ctx, newRefund(payment, uuid.New().String(), 5000)); err != nil {
+		return fmt.Errorf("CreateRefund of the rest: %w", err)
+	}
+
+	got, err := r.GetByID(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if got.PendingRefunds != 25000 || got.Refundable() != 0 {
+		return fmt.Errorf("GetByID: got %d pending and %d refundable, want 25000 and 0", got.PendingRefunds, got.Refundable())
+	}
+
+	byKey, err := r.GetRefundByIdempotencyKey(ctx, key)
+	if err != nil || byKey.ID != first.ID {
+		return fmt.Errorf("GetRefundByIdempotencyKey: got %v, %v, want refund %s", byKey, err, first.ID)
+	}
+	refunds, err := r.ListRefundsByPayment(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("ListRefundsByPayment: %w", err)
+	}
+	if len(refunds) != 2 {
+		return fmt.Errorf("ListRefundsByPayment: got %d refunds, want 2", len(refunds))
+	}
+	return nil
+}
+
+func testRefundSettlement(ctx context.Context, r repo.PaymentRepo) error {
+	payment, err := createPaidPayment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	failed, err := r.CreateRefund(ctx, newRefund(payment, uuid.New().String(), 10000))
+	if err != nil {
+		return fmt.Errorf("CreateRefund: %w", err)
+	}
+	if _, got, err := r.SettleRefund(ctx, failed.ID, types.RefundStatusFailed, "", time.Now()); err != nil || got.Refundable() != 25000 {
+		return fmt.Errorf("SettleRefund failed: got %v, want the reservation released", err)
+	}
+
+	partial, err := r.CreateRefund(ctx, newRefund(payment, uuid.New().String(), 10000))
+	if err != nil {
+		return fmt.Errorf("CreateRefund: %w", err)
+	}
+	reference := "re_test_" + uuid.New().String()
+	if _, _, err := r.SettleRefund(ctx, partial.ID, types.RefundStatusPending, reference, time.Now()); err != nil {
+		return fmt.Errorf("SettleRefund with the provider reference: %w", err)
+	}
+	byReference, err := r.GetRefundByProviderReference(ctx, reference)
+	if err != nil || byReference.ID != partial.ID {
+		return fmt.Errorf("GetRefundByProviderReference: got %v, %v, want refund %s", byReference, err, partial.ID)
+	}
+
+	refund, got, err := r.SettleRefund(ctx, partial.ID, types.RefundStatusSucceeded, "", time.Now())
+	if err != nil {
+		return fmt.Errorf("SettleRefund succeeded: %w", err)
+	}
+	if refund.Status != types.RefundStatusSucceeded || refund.ProviderReference != reference {
+		return fmt.Errorf("SettleRefund succeeded: got %s refund %q", refund.Status, refund.ProviderReference)
+	}
+	if got.Status != types.PaymentStatusPartiallyRefunded || got.RefundedAmount != 10000 || got.PendingRefunds != 0 {
+		return fmt.Errorf("SettleRefund succeeded: payment is %s with %d refunded and %d pending, want partially_refunded with 10000 and 0",
+			got.Status, got.RefundedAmount, got.PendingRefunds)
+	}
+	if _, _, err := r.SettleRefund(ctx, partial.ID, types.RefundStatusFailed, "", time.Now()); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("SettleRefund of a settled refund: got %v, want ErrInvalidTransition", err)
+	}
+
+	rest, err := r.CreateRefund(ctx, newRefund(payment, uuid.New().String(), 15000))
+	if err != nil {
+		return fmt.Errorf("CreateRefund of the rest: %w", err)
+	}
+	if _, got, err = r.SettleRefund(ctx, rest.ID, types.RefundStatusSucceeded, "", time.Now()); err != nil {
+		return fmt.Errorf("SettleRefund of the rest: %w", err)
+	}
+	if got.Status != types.PaymentStatusRefunded || got.RefundedAmount != 25000 {
+		return fmt.Errorf("SettleRefund of the rest: payment is %s with %d refunded, want refunded with 25000", got.Status, got.RefundedAmount)
+	}
+	return nil
+}
+
+// createPaidPayment saves a ride payment that has been collected
+func createPaidPayment(ctx context.Context, r repo.PaymentRepo) (*types.Payment, error) {
+	payment, err := createPayment(ctx, r, uuid.New().String(), uuid.New().String(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	paid, err := r.UpdateStatus(ctx, payment.StripeSessionID, types.PaymentStatusSuccess, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("UpdateStatus: %w", err)
+	}
+	return paid, nil
+}
+
+// newRefund builds a pending refund of the payment
+func newRefund(payment *types.Payment, idempotencyKey string, amount int64) *types.Refund {
+	now := time.Now().Truncate(time.Millisecond)
+	return &types.Refund{
+		ID:             uuid.New().String(),
+		PaymentID:      payment.ID,
+		TripID:         payment.TripID,
+		RiderID:        payment.RiderID,
+		Amount:         amount,
+		Currency:       payment.Currency,
+		Reason:         types.RefundReasonRequestedByRider,
+		Status:         types.RefundStatusPending,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// createPayment saves a pending ride payment of the trip created at the given time
+func createPayment(ctx context.Context, r repo.PaymentRepo, tripID, riderID string, createdAt time.Time) (*types.Payment, error) {
+	// MongoDB stores times with millisecond precision
+	createdAt = createdAt.Truncate(time.Millisecond)
+
+	payment := &types.Payment{
+		ID:       uuid.New().String(),
+		TripID:   tripID,
+		RiderID:  riderID,
+		DriverID: uuid.New().String(),
+		Purpose:  types.PaymentPurposeRide,
+		Amount:   25000,
+		Currency: "inr",
+		Status:   types.PaymentStatusPending,
+		StatusHistory: []*types.PaymentStatusChange{
+			{Status: types.PaymentStatusPending, ChangedAt: createdAt},
+		},
+		Provider:        "repotest",
+		StripeSessionID: "cs_test_" + uuid.New().String(),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	if err := r.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	return payment, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
+)
+
+const (
+	defaultEarningsRange = 30 * 24 * time.Hour
+	maxEarningsRange     = 366 * 24 * time.Hour
+)
+
+var ErrInvalidEarningsQuery = errors.New("invalid earnings query")
+
+// GetDriverEarnings sums the driver's earnings in [from, to) by day, week or trip. The range
+// defaults to the 30 days before now.
+func (s *paymentService) GetDriverEarnings(ctx context.Context, driverID string, period ledger.Period, from, to time.Time) ([]*ledger.Earnings, error) {
+	if period == "" {
+		period = ledger.PeriodDay
+	}
+	if !period.IsValid() {
+		return nil, fmt.Errorf("%w: unknown period %q", ErrInvalidEarningsQuery, period)
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultEarningsRange)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidEarningsQuery)
+	}
+	if to.Sub(from) > maxEarningsRange {
+		return nil, fmt.Errorf("%w: range is longer than %d days", ErrInvalidEarningsQuery, int(maxEarningsRange.Hours()/24))
+	}
+
+	return s.ledger.DriverEarnings(ctx, driverID, period, from, to, s.location)
+}
+
+// ListDriverPayouts lists the driver's payouts created before the given time, newest first.
+// A zero before lists the latest payouts.
+func (s *paymentService) ListDriverPayouts(ctx context.Context, driverID string, before time.Time, limit int) ([]*payout.Payout, error) {
+	if before.IsZero() {
+		before = time.Now()
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	return s.payouts.ListByDriver(ctx, driverID, before, min(limit, maxListLimit))
+}
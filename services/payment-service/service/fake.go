@@ -0,0 +1,208 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+var ErrPaymentDeclined = errors.New("payment declined by the processor")
+
+const (
+	// minFakeWebhookDelay leaves time for a session to be saved before its events arrive
+	minFakeWebhookDelay = 500 * time.Millisecond
+
+	fakeWebhookAttempts   = 3
+	fakeWebhookRetryDelay = time.Second
+)
+
+// fakeProcessor is an in-process stand-in for Stripe. It settles every checkout session it creates
+// with the configured outcome by sending the signed webhook events Stripe would send.
+type fakeProcessor struct {
+	mu       sync.Mutex
+	config   *types.FakeProcessorConfig
+	client   *http.Client
+	refunds  map[string]*types.ProviderRefund // idempotency key -> refund
+	sessions map[string]string                // idempotency key -> checkout session ID
+}
+
+// NewFakeProcessor creates a payment processor that needs no Stripe account
+func NewFakeProcessor(config *types.FakeProcessorConfig) *fakeProcessor {
+	return &fakeProcessor{
+		config:   config,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		refunds:  make(map[string]*types.ProviderRefund),
+		sessions: make(map[string]string),
+	}
+}
+
+// SetWebhookURL changes where the webhook events are sent
+func (f *fakeProcessor) SetWebhookURL(url string) {
+	f.mu.Lock()
+	f.config.WebhookURL = url
+	f.mu.Unlock()
+}
+
+// SetOutcome changes how the sessions created from now on are settled
+func (f *fakeProcessor) SetOutcome(outcome types.FakeOutcome) {
+	f.mu.Lock()
+	f.config.Outcome = outcome
+	f.mu.Unlock()
+}
+
+func (f *fakeProcessor) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string, idempotencyKey string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Like Stripe, a repeated idempotency key returns the first session without settling it again
+	if sessionID, ok := f.sessions[idempotencyKey]; ok {
+		return sessionID, nil
+	}
+
+	outcome := f.config.Outcome
+	if outcome == types.FakeOutcomeDecline {
+		return "", fmt.Errorf("failed to create a payment session on the fake processor: %w", ErrPaymentDeclined)
+	}
+
+	session := &stripe.CheckoutSession{
+		ID:            "cs_fake_" + uuid.New().String(),
+		Object:        "checkout.session",
+		Mode:          stripe.CheckoutSessionModePayment,
+		Status:        stripe.CheckoutSessionStatusOpen,
+		PaymentStatus: stripe.CheckoutSessionPaymentStatusUnpaid,
+		AmountTotal:   amount,
+		Currency:      stripe.Currency(currency),
+		Metadata:      metadata,
+	}
+
+	time.AfterFunc(max(f.config.Delay, minFakeWebhookDelay), func() {
+		f.settle(session, outcome)
+	})
+
+	if idempotencyKey != "" {
+		f.sessions[idempotencyKey] = session.ID
+	}
+	log.Printf("Fake payment session %s created, it will %s", session.ID, outcome)
+	return session.ID, nil
+}
+
+// Refund succeeds immediately, unless the processor declines payments.
+// Like Stripe, a repeated idempotency key returns the first result.
+func (f *fakeProcessor) Refund(ctx context.Context, sessionID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.ProviderRefund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if refund, ok := f.refunds[idempotencyKey]; ok {
+		return refund, nil
+	}
+	if f.config.Outcome == types.FakeOutcomeDecline {
+		return nil, fmt.Errorf("failed to create a refund on the fake processor: %w", ErrRefundRejected)
+	}
+
+	refund := &types.ProviderRefund{ID: "re_fake_" + uuid.New().String(), Status: types.RefundStatusSucceeded}
+	f.refunds[idempotencyKey] = refund
+	log.Printf("Fake refund %s of %d for session %s (%s)", refund.ID, amount, sessionID, reason)
+	return refund, nil
+}
+
+func (f *fakeProcessor) Provider() string {
+	return "fake"
+}
+
+// settle sends the events Stripe sends when a checkout session ends with the outcome
+func (f *fakeProcessor) settle(session *stripe.CheckoutSession, outcome types.FakeOutcome) {
+	var events []stripe.EventType
+	switch outcome {
+	case types.FakeOutcomeSucceed:
+		session.Status = stripe.CheckoutSessionStatusComplete
+		session.PaymentStatus = stripe.CheckoutSessionPaymentStatusPaid
+		events = []stripe.EventType{stripe.EventTypeCheckoutSessionCompleted}
+	case types.FakeOutcomeFail:
+		// Checkout completes with a delayed payment method, which then fails
+		session.Status = stripe.CheckoutSessionStatusComplete
+		events = []stripe.EventType{stripe.EventTypeCheckoutSessionCompleted, stripe.EventTypeCheckoutSessionAsyncPaymentFailed}
+	case types.FakeOutcomeExpire:
+		session.Status = stripe.CheckoutSessionStatusExpired
+		events = []stripe.EventType{stripe.EventTypeCheckoutSessionExpired}
+	default:
+		log.Printf("Fake payment session %s left open: unknown outcome %q", session.ID, outcome)
+		return
+	}
+
+	for _, eventType := range events {
+		if err := f.sendEvent(eventType, session); err != nil {
+			log.Printf("Failed to send %s for fake payment session %s: %v", eventType, session.ID, err)
+			return
+		}
+	}
+}
+
+// sendEvent signs the event like Stripe does and posts it to the webhook, retrying failed deliveries
+func (f *fakeProcessor) sendEvent(eventType stripe.EventType, session *stripe.CheckoutSession) error {
+	object, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"id":          "evt_fake_" + uuid.New().String(),
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"created":     time.Now().Unix(),
+		"livemode":    false,
+		"type":        eventType,
+		"data":        map[string]json.RawMessage{"object": object},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = f.post(payload)
+		if err == nil || attempt == fakeWebhookAttempts {
+			return err
+		}
+		time.Sleep(fakeWebhookRetryDelay)
+	}
+}
+
+func (f *fakeProcessor) post(payload []byte) error {
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    f.config.WebhookSecret,
+		Timestamp: time.Now(),
+	})
+
+	f.mu.Lock()
+	url := f.config.WebhookURL
+	f.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signed.Header)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,48 +2,179 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/cprakhar/uber-clone/services/payment-service/ledger"
+	"github.com/cprakhar/uber-clone/services/payment-service/payout"
 	"github.com/cprakhar/uber-clone/services/payment-service/repo"
 	"github.com/cprakhar/uber-clone/services/payment-service/types"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
 type paymentService struct {
 	paymentProcessor repo.PaymentProcessor
+	payments         repo.PaymentRepo
+	ledger           *ledger.Ledger
+	payouts          payout.Store
+	location         *time.Location // days and weeks of driver earnings start at midnight here
 }
 
-func NewPaymentService(r repo.PaymentProcessor) repo.Service {
-	return &paymentService{paymentProcessor: r}
+func NewPaymentService(r repo.PaymentProcessor, payments repo.PaymentRepo, ledger *ledger.Ledger, payouts payout.Store, location *time.Location) repo.Service {
+	return &paymentService{paymentProcessor: r, payments: payments, ledger: ledger, payouts: payouts, location: location}
 }
 
+// CreatePaymentSession opens a checkout session for the trip. A redelivered request returns the
+// payment already pending or collected for the trip and purpose instead of opening another one.
 func (s *paymentService) CreatePaymentSession(
 	ctx context.Context,
-	tripID, riderID, driverID string,
+	tripID, riderID, driverID, packageSlug string,
+	purpose types.PaymentPurpose,
 	amount int64,
 	currency string) (*types.PaymentIntent, error) {
 
+	// Only a failed or cancelled session is followed by a new one. Its key depends on the payment it
+	// replaces, so a request retried after reaching the processor gets the same session back.
+	previous := "none"
+	existing, err := s.payments.GetByTripAndPurpose(ctx, tripID, purpose)
+	switch {
+	case err == nil && existing.Status != types.PaymentStatusFailed && existing.Status != types.PaymentStatusCancelled:
+		log.Printf("Reusing %s payment %s for trip %s", existing.Status, existing.ID, tripID)
+		return intentOf(existing), nil
+	case err == nil:
+		previous = existing.ID
+	case !errors.Is(err, repo.ErrNotFound):
+		return nil, fmt.Errorf("failed to get payment of trip: %w", err)
+	}
+	idempotencyKey := fmt.Sprintf("checkout:%s:%s:after:%s", tripID, purpose, previous)
+
 	metadata := map[string]string{
 		"tripID":   tripID,
 		"riderID":  riderID,
 		"driverID": driverID,
+		"purpose":  string(purpose),
 	}
 
-	sessionID, err := s.paymentProcessor.CreatePaymentSession(ctx, amount, currency, metadata)
+	sessionID, err := s.paymentProcessor.CreatePaymentSession(ctx, amount, currency, metadata, idempotencyKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment session: %w", err)
 	}
 
-	return &types.PaymentIntent{
+	intent := &types.PaymentIntent{
 		ID:              uuid.New().String(),
 		TripID:          tripID,
 		RiderID:         riderID,
 		DriverID:        driverID,
+		Purpose:         purpose,
 		Amount:          amount,
 		Currency:        currency,
 		StripeSessionID: sessionID,
 		CreatedAt:       time.Now(),
-	}, nil
+	}
+
+	// The payment stays pending until the processor reports the outcome of the session
+	payment := &types.Payment{
+		ID:          intent.ID,
+		TripID:      tripID,
+		RiderID:     riderID,
+		DriverID:    driverID,
+		PackageSlug: packageSlug,
+		Purpose:     purpose,
+		Amount:      amount,
+		Currency:    currency,
+		Status:      types.PaymentStatusPending,
+		StatusHistory: []*types.PaymentStatusChange{
+			{Status: types.PaymentStatusPending, ChangedAt: intent.CreatedAt},
+		},
+		Provider:        s.paymentProcessor.Provider(),
+		StripeSessionID: sessionID,
+		CreatedAt:       intent.CreatedAt,
+		UpdatedAt:       intent.CreatedAt,
+	}
+	if err := s.payments.Create(ctx, payment); errors.Is(err, repo.ErrPaymentExists) {
+		// A concurrent delivery of the same request saved the session first
+		existing, err := s.payments.GetBySessionID(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment of session: %w", err)
+		}
+		return intentOf(existing), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	return intent, nil
+}
+
+// intentOf describes the checkout session of a saved payment
+func intentOf(payment *types.Payment) *types.PaymentIntent {
+	return &types.PaymentIntent{
+		ID:              payment.ID,
+		TripID:          payment.TripID,
+		RiderID:         payment.RiderID,
+		DriverID:        payment.DriverID,
+		Purpose:         payment.Purpose,
+		Amount:          payment.Amount,
+		Currency:        payment.Currency,
+		StripeSessionID: payment.StripeSessionID,
+		CreatedAt:       payment.CreatedAt,
+	}
+}
+
+// GetPaymentBySessionID returns the payment collected through the given checkout session
+func (s *paymentService) GetPaymentBySessionID(ctx context.Context, sessionID string) (*types.Payment, error) {
+	return s.payments.GetBySessionID(ctx, sessionID)
+}
+
+// GetPaymentByTrip returns the latest payment collected for the trip
+func (s *paymentService) GetPaymentByTrip(ctx context.Context, tripID string) (*types.Payment, error) {
+	return s.payments.GetByTripID(ctx, tripID)
+}
+
+// ListPaymentsByRider lists the rider's payments created before the given time, newest first.
+// A zero time lists the latest payments, and the limit is capped at maxListLimit.
+func (s *paymentService) ListPaymentsByRider(ctx context.Context, riderID string, before time.Time, limit int) ([]*types.Payment, error) {
+	if before.IsZero() {
+		before = time.Now()
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	return s.payments.ListByRiderID(ctx, riderID, before, min(limit, maxListLimit))
+}
+
+// UpdatePaymentStatus records the outcome of a checkout session reported by the payment processor,
+// and writes a collected payment to the ledger. A repeated success is written to the ledger again
+// in case the first attempt failed, which the ledger ignores if it did not.
+func (s *paymentService) UpdatePaymentStatus(ctx context.Context, sessionID string, status types.PaymentStatus, at time.Time) (*types.Payment, error) {
+	payment, err := s.payments.UpdateStatus(ctx, sessionID, status, at)
+	if errors.Is(err, types.ErrInvalidTransition) && status == types.PaymentStatusSuccess {
+		if current, getErr := s.payments.GetBySessionID(ctx, sessionID); getErr == nil && current.Status == status {
+			if ledgerErr := s.ledger.RecordPayment(ctx, current); ledgerErr != nil {
+				return nil, fmt.Errorf("failed to record payment in the ledger: %w", ledgerErr)
+			}
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == types.PaymentStatusSuccess {
+		if err := s.ledger.RecordPayment(ctx, payment); err != nil {
+			return nil, fmt.Errorf("failed to record payment in the ledger: %w", err)
+		}
+	}
+	return payment, nil
+}
 
+// GetDriverBalance returns what the driver is owed in each currency they earned in
+func (s *paymentService) GetDriverBalance(ctx context.Context, driverID string) ([]*ledger.Balance, error) {
+	return s.ledger.DriverBalance(ctx, driverID)
 }
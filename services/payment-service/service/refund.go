@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/payment-service/repo"
+	"github.com/cprakhar/uber-clone/services/payment-service/types"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefund       = errors.New("invalid refund request")
+	ErrIdempotencyKeyReuse = errors.New("idempotency key was used for a different refund")
+	ErrRefundRejected      = errors.New("refund rejected by the processor")
+)
+
+// RefundPayment refunds the amount of a collected payment, or everything not refunded yet when amount is 0.
+// A retried request with the same idempotency key returns the original refund, and a refund whose
+// processor call did not complete is sent again under the same key.
+func (s *paymentService) RefundPayment(ctx context.Context, paymentID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.Refund, *types.Payment, error) {
+	if idempotencyKey == "" {
+		return nil, nil, fmt.Errorf("%w: idempotency key is required", ErrInvalidRefund)
+	}
+	if !reason.IsValid() {
+		return nil, nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidRefund, reason)
+	}
+	if amount < 0 {
+		return nil, nil, fmt.Errorf("%w: negative amount %d", ErrInvalidRefund, amount)
+	}
+
+	existing, err := s.payments.GetRefundByIdempotencyKey(ctx, idempotencyKey)
+	if err == nil {
+		return s.retryRefund(ctx, existing, paymentID, amount, reason)
+	}
+	if !errors.Is(err, repo.ErrRefundNotFound) {
+		return nil, nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	payment, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amount == 0 {
+		amount = payment.Refundable()
+	}
+	if amount == 0 || amount > payment.Refundable() {
+		return nil, nil, fmt.Errorf("%w: %d of %d %s is refundable", repo.ErrRefundExceedsPayment, payment.Refundable(), payment.Amount, payment.Currency)
+	}
+
+	now := time.Now()
+	refund, err := s.payments.CreateRefund(ctx, &types.Refund{
+		ID:             uuid.New().String(),
+		PaymentID:      payment.ID,
+		TripID:         payment.TripID,
+		RiderID:        payment.RiderID,
+		Amount:         amount,
+		Currency:       payment.Currency,
+		Reason:         reason,
+		Status:         types.RefundStatusPending,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if errors.Is(err, repo.ErrRefundExists) {
+		// A concurrent request with the same key got there first
+		return s.retryRefund(ctx, refund, paymentID, amount, reason)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.sendRefund(ctx, refund, payment.StripeSessionID)
+}
+
+// SettleRefund records the outcome of a refund the processor completed asynchronously. A repeated
+// outcome returns the refund and its payment with ErrInvalidTransition, so it can be published again.
+func (s *paymentService) SettleRefund(ctx context.Context, providerReference string, status types.RefundStatus, at time.Time) (*types.Refund, *types.Payment, error) {
+	refund, err := s.payments.GetRefundByProviderReference(ctx, providerReference)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settled, payment, err := s.payments.SettleRefund(ctx, refund.ID, status, providerReference, at)
+	if errors.Is(err, types.ErrInvalidTransition) && refund.Status == status {
+		// A repeated outcome is written to the ledger again in case the first attempt failed
+		if ledgerErr := s.recordRefund(ctx, refund); ledgerErr != nil {
+			return nil, nil, ledgerErr
+		}
+		payment, paymentErr := s.payments.GetByID(ctx, refund.PaymentID)
+		if paymentErr != nil {
+			return nil, nil, paymentErr
+		}
+		return refund, payment, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.recordRefund(ctx, settled); err != nil {
+		return nil, nil, err
+	}
+	return settled, payment, nil
+}
+
+// retryRefund returns the refund created earlier with the same idempotency key,
+// sending it to the processor again if the first attempt did not get a reply
+func (s *paymentService) retryRefund(ctx context.Context, refund *types.Refund, paymentID string, amount int64, reason types.RefundReason) (*types.Refund, *types.Payment, error) {
+	if refund.PaymentID != paymentID || refund.Reason != reason || (amount != 0 && refund.Amount != amount) {
+		return nil, nil, ErrIdempotencyKeyReuse
+	}
+
+	payment, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if refund.Status != types.RefundStatusPending || refund.ProviderReference != "" {
+		if err := s.recordRefund(ctx, refund); err != nil {
+			return nil, nil, err
+		}
+		return refund, payment, nil
+	}
+	return s.sendRefund(ctx, refund, payment.StripeSessionID)
+}
+
+// sendRefund asks the processor to carry out a pending refund and records its reply.
+// A refund the processor rejected releases its reserved amount.
+func (s *paymentService) sendRefund(ctx context.Context, refund *types.Refund, sessionID string) (*types.Refund, *types.Payment, error) {
+	result, err := s.paymentProcessor.Refund(ctx, sessionID, refund.Amount, refund.Reason, refund.IdempotencyKey)
+	if err != nil {
+		if errors.Is(err, ErrRefundRejected) {
+			if _, _, settleErr := s.payments.SettleRefund(ctx, refund.ID, types.RefundStatusFailed, "", time.Now()); settleErr != nil {
+				log.Printf("Failed to release declined refund %s: %v", refund.ID, settleErr)
+			}
+		}
+		// Other errors leave the refund pending, so a retry with the same key sends it again
+		return nil, nil, fmt.Errorf("failed to refund payment %s: %w", refund.PaymentID, err)
+	}
+
+	settled, payment, err := s.payments.SettleRefund(ctx, refund.ID, result.Status, result.ID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.recordRefund(ctx, settled); err != nil {
+		return nil, nil, err
+	}
+	return settled, payment, nil
+}
+
+// recordRefund writes a refund that succeeded to the ledger
+func (s *paymentService) recordRefund(ctx context.Context, refund *types.Refund) error {
+	if refund.Status != types.RefundStatusSucceeded {
+		return nil
+	}
+	if err := s.ledger.RecordRefund(ctx, refund); err != nil {
+		return fmt.Errorf("failed to record refund in the ledger: %w", err)
+	}
+	return nil
+}
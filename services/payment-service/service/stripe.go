@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/cprakhar/uber-clone/services/payment-service/repo"
 	"github.com/cprakhar/uber-clone/services/payment-service/types"
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/checkout/session"
+	"github.com/stripe/stripe-go/v81/refund"
 )
 
 type stripeClient struct {
@@ -22,7 +24,7 @@ func NewStripeClient(config *types.PaymentConfig) repo.PaymentProcessor {
 	}
 }
 
-func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error) {
+func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string, idempotencyKey string) (string, error) {
 	params := &stripe.CheckoutSessionParams{
 		SuccessURL: stripe.String(s.config.SuccessURL),
 		CancelURL:  stripe.String(s.config.CancelURL),
@@ -32,7 +34,7 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
 					Currency: stripe.String(currency),
 					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String("Ride Payment"),
+						Name: stripe.String(productName(metadata["purpose"])),
 					},
 					UnitAmount: stripe.Int64(amount),
 				},
@@ -41,6 +43,7 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 		},
 		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
 	}
+	params.SetIdempotencyKey(idempotencyKey)
 
 	result, err := session.New(params)
 	if err != nil {
@@ -49,3 +52,69 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 
 	return result.ID, nil
 }
+
+// Refund refunds part of the payment intent behind the checkout session
+func (s *stripeClient) Refund(ctx context.Context, sessionID string, amount int64, reason types.RefundReason, idempotencyKey string) (*types.ProviderRefund, error) {
+	sess, err := session.Get(sessionID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the payment session from stripe: %w", err)
+	}
+	if sess.PaymentIntent == nil {
+		return nil, fmt.Errorf("payment session %s has no payment to refund", sessionID)
+	}
+
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(sess.PaymentIntent.ID),
+		Amount:        stripe.Int64(amount),
+		Reason:        stripe.String(string(stripeRefundReason(reason))),
+		Metadata:      map[string]string{"reason": string(reason)},
+	}
+	params.SetIdempotencyKey(idempotencyKey)
+
+	result, err := refund.New(params)
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode >= 400 && stripeErr.HTTPStatusCode < 500 && stripeErr.HTTPStatusCode != 429 {
+		// Stripe will not accept the refund however often it is retried
+		return nil, fmt.Errorf("failed to create a refund on stripe: %w: %v", ErrRefundRejected, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a refund on stripe: %w", err)
+	}
+
+	return &types.ProviderRefund{ID: result.ID, Status: RefundStatusFromStripe(result.Status)}, nil
+}
+
+func (s *stripeClient) Provider() string {
+	return "stripe"
+}
+
+// stripeRefundReason maps a refund reason to the closest reason Stripe knows
+func stripeRefundReason(reason types.RefundReason) stripe.RefundReason {
+	switch reason {
+	case types.RefundReasonDuplicate:
+		return stripe.RefundReasonDuplicate
+	case types.RefundReasonFraudulent:
+		return stripe.RefundReasonFraudulent
+	}
+	return stripe.RefundReasonRequestedByCustomer
+}
+
+// RefundStatusFromStripe maps the status of a Stripe refund to the status of a refund.
+// Refunds that need action from the rider stay pending.
+func RefundStatusFromStripe(status stripe.RefundStatus) types.RefundStatus {
+	switch status {
+	case stripe.RefundStatusSucceeded:
+		return types.RefundStatusSucceeded
+	case stripe.RefundStatusFailed, stripe.RefundStatusCanceled:
+		return types.RefundStatusFailed
+	}
+	return types.RefundStatusPending
+}
+
+// productName returns the line item name shown to the rider on the checkout page
+func productName(purpose string) string {
+	if types.PaymentPurpose(purpose) == types.PaymentPurposeCancellationFee {
+		return "Cancellation Fee"
+	}
+	return "Ride Payment"
+}
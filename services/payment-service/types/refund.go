@@ -0,0 +1,76 @@
+package types
+
+import (
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/payment"
+)
+
+// RefundStatus represents the current status of a refund
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// RefundReason explains why money is given back to the rider
+type RefundReason string
+
+const (
+	RefundReasonRequestedByRider RefundReason = "requested_by_rider"
+	RefundReasonTripCancelled    RefundReason = "trip_cancelled"
+	RefundReasonFareDispute      RefundReason = "fare_dispute"
+	RefundReasonServiceIssue     RefundReason = "service_issue"
+	RefundReasonDuplicate        RefundReason = "duplicate"
+	RefundReasonFraudulent       RefundReason = "fraudulent"
+)
+
+// IsValid reports whether r is a known refund reason
+func (r RefundReason) IsValid() bool {
+	switch r {
+	case RefundReasonRequestedByRider, RefundReasonTripCancelled, RefundReasonFareDispute,
+		RefundReasonServiceIssue, RefundReasonDuplicate, RefundReasonFraudulent:
+		return true
+	}
+	return false
+}
+
+// Refund gives back part or all of a collected payment
+type Refund struct {
+	ID                string       `json:"id" bson:"_id"`
+	PaymentID         string       `json:"paymentID" bson:"paymentID"`
+	TripID            string       `json:"tripID" bson:"tripID"`
+	RiderID           string       `json:"riderID" bson:"riderID"`
+	Amount            int64        `json:"amount" bson:"amount"` // in minor units (paise)
+	Currency          string       `json:"currency" bson:"currency"`
+	Reason            RefundReason `json:"reason" bson:"reason"`
+	Status            RefundStatus `json:"status" bson:"status"`
+	IdempotencyKey    string       `json:"idempotencyKey" bson:"idempotencyKey"`
+	ProviderReference string       `json:"providerReference,omitempty" bson:"providerReference,omitempty"` // refund ID at the provider
+	CreatedAt         time.Time    `json:"createdAt" bson:"createdAt"`
+	UpdatedAt         time.Time    `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ProviderRefund is the result of a refund request at the payment processor
+type ProviderRefund struct {
+	ID     string
+	Status RefundStatus
+}
+
+// ToProto converts the refund to its protobuf representation
+func (r *Refund) ToProto() *pb.Refund {
+	return &pb.Refund{
+		Id:                r.ID,
+		PaymentID:         r.PaymentID,
+		TripID:            r.TripID,
+		Amount:            r.Amount,
+		Currency:          r.Currency,
+		Reason:            string(r.Reason),
+		Status:            string(r.Status),
+		ProviderReference: r.ProviderReference,
+		CreatedAt:         r.CreatedAt.UnixMilli(),
+		UpdatedAt:         r.UpdatedAt.UnixMilli(),
+	}
+}
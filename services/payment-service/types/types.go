@@ -1,6 +1,14 @@
 package types
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/payment"
+)
+
+var ErrInvalidTransition = errors.New("invalid payment status transition")
 
 // PaymentStatus represents the current status of a payment
 type PaymentStatus string
@@ -10,31 +18,130 @@ const (
 	PaymentStatusSuccess   PaymentStatus = "success"
 	PaymentStatusFailed    PaymentStatus = "failed"
 	PaymentStatusCancelled PaymentStatus = "cancelled"
+
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+// paymentTransitions lists the statuses a payment may move to from each status.
+// Success, failure and cancellation are final for a checkout session, only refunds follow a success.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending:           {PaymentStatusSuccess, PaymentStatusFailed, PaymentStatusCancelled},
+	PaymentStatusSuccess:           {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusRefunded},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentPurpose describes what the rider is paying for
+type PaymentPurpose string
+
+const (
+	PaymentPurposeRide            PaymentPurpose = "ride"
+	PaymentPurposeCancellationFee PaymentPurpose = "cancellation_fee"
 )
 
 // Payment represents a payment transaction
 type Payment struct {
-	ID              string        `json:"id"`
-	TripID          string        `json:"tripID"`
-	RiderID         string        `json:"riderID"`
-	Amount          int64         `json:"amount"`   // Amount in cents
-	Currency        string        `json:"currency"` // e.g., "usd"
-	Status          PaymentStatus `json:"status"`
-	StripeSessionID string        `json:"stripeSessionID"`
-	CreatedAt       time.Time     `json:"createdAt"`
-	UpdatedAt       time.Time     `json:"updatedAt"`
+	ID              string                 `json:"id" bson:"_id"`
+	TripID          string                 `json:"tripID" bson:"tripID"`
+	RiderID         string                 `json:"riderID" bson:"riderID"`
+	DriverID        string                 `json:"driverID" bson:"driverID"`
+	PackageSlug     string                 `json:"packageSlug,omitempty" bson:"packageSlug,omitempty"` // car package of the trip
+	Purpose         PaymentPurpose         `json:"purpose" bson:"purpose"`
+	Amount          int64                  `json:"amount" bson:"amount"`     // Amount in cents
+	Currency        string                 `json:"currency" bson:"currency"` // e.g., "usd"
+	Status          PaymentStatus          `json:"status" bson:"status"`
+	StatusHistory   []*PaymentStatusChange `json:"statusHistory" bson:"statusHistory"`
+	RefundedAmount  int64                  `json:"refundedAmount" bson:"refundedAmount"`           // refunds that succeeded
+	PendingRefunds  int64                  `json:"pendingRefundAmount" bson:"pendingRefundAmount"` // refunds still being processed
+	Provider        string                 `json:"provider" bson:"provider"`                       // processor that collects the payment, e.g. "stripe"
+	StripeSessionID string                 `json:"stripeSessionID" bson:"stripeSessionID"`
+	CreatedAt       time.Time              `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time              `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PaymentStatusChange records when a payment entered a status
+type PaymentStatusChange struct {
+	Status    PaymentStatus `json:"status" bson:"status"`
+	ChangedAt time.Time     `json:"changedAt" bson:"changedAt"`
+}
+
+// Transition moves the payment to the next status, recording the time of the change
+func (p *Payment) Transition(next PaymentStatus, at time.Time) error {
+	if !p.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, p.Status, next)
+	}
+	p.Status = next
+	p.UpdatedAt = at
+	p.StatusHistory = append(p.StatusHistory, &PaymentStatusChange{Status: next, ChangedAt: at})
+	return nil
+}
+
+// Refundable returns the amount that can still be refunded, or 0 if the payment was not collected
+func (p *Payment) Refundable() int64 {
+	if p.Status != PaymentStatusSuccess && p.Status != PaymentStatusPartiallyRefunded {
+		return 0
+	}
+	return p.Amount - p.RefundedAmount - p.PendingRefunds
+}
+
+// RefundedStatus returns the status of the payment once RefundedAmount has been refunded
+func (p *Payment) RefundedStatus() PaymentStatus {
+	if p.RefundedAmount >= p.Amount {
+		return PaymentStatusRefunded
+	}
+	return PaymentStatusPartiallyRefunded
+}
+
+// ToProto converts the payment to its protobuf representation
+func (p *Payment) ToProto() *pb.Payment {
+	history := make([]*pb.PaymentStatusChange, 0, len(p.StatusHistory))
+	for _, change := range p.StatusHistory {
+		history = append(history, &pb.PaymentStatusChange{
+			Status:    string(change.Status),
+			ChangedAt: change.ChangedAt.UnixMilli(),
+		})
+	}
+
+	return &pb.Payment{
+		Id:                p.ID,
+		TripID:            p.TripID,
+		RiderID:           p.RiderID,
+		DriverID:          p.DriverID,
+		PackageSlug:       p.PackageSlug,
+		Purpose:           string(p.Purpose),
+		Amount:            p.Amount,
+		Currency:          p.Currency,
+		Status:            string(p.Status),
+		Provider:          p.Provider,
+		ProviderReference: p.StripeSessionID,
+		CreatedAt:         p.CreatedAt.UnixMilli(),
+		UpdatedAt:         p.UpdatedAt.UnixMilli(),
+		StatusHistory:     history,
+		RefundedAmount:    p.RefundedAmount,
+	}
 }
 
 // PaymentIntent represents the intent to collect a payment
 type PaymentIntent struct {
-	ID              string    `json:"id"`
-	TripID          string    `json:"tripID"`
-	RiderID         string    `json:"riderID"`
-	DriverID        string    `json:"driverID"`
-	Amount          int64     `json:"amount"`
-	Currency        string    `json:"currency"`
-	StripeSessionID string    `json:"stripeSessionID"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ID              string         `json:"id"`
+	TripID          string         `json:"tripID"`
+	RiderID         string         `json:"riderID"`
+	DriverID        string         `json:"driverID"`
+	Purpose         PaymentPurpose `json:"purpose"`
+	Amount          int64          `json:"amount"`
+	Currency        string         `json:"currency"`
+	StripeSessionID string         `json:"stripeSessionID"`
+	CreatedAt       time.Time      `json:"createdAt"`
 }
 
 // PaymentConfig holds the configuration for the payment service
@@ -45,3 +152,21 @@ type PaymentConfig struct {
 	SuccessURL          string `json:"successURL"`
 	CancelURL           string `json:"cancelURL"`
 }
+
+// FakeOutcome is how the fake payment processor settles the checkout sessions it creates
+type FakeOutcome string
+
+const (
+	FakeOutcomeSucceed FakeOutcome = "succeed" // the rider pays
+	FakeOutcomeFail    FakeOutcome = "fail"    // the payment method fails after checkout
+	FakeOutcomeExpire  FakeOutcome = "expire"  // the rider abandons checkout and the session expires
+	FakeOutcomeDecline FakeOutcome = "decline" // the processor refuses to create the session
+)
+
+// FakeProcessorConfig holds the configuration for the fake payment processor
+type FakeProcessorConfig struct {
+	Outcome       FakeOutcome   `json:"outcome"`
+	Delay         time.Duration `json:"delay"`         // time between creating a session and settling it
+	WebhookURL    string        `json:"webhookURL"`    // where the webhook events are sent
+	WebhookSecret string        `json:"webhookSecret"` // secret the webhook events are signed with
+}
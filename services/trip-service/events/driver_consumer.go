@@ -2,11 +2,13 @@ package events
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
-	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
 	"github.com/cprakhar/uber-clone/services/trip-service/service"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
 	"github.com/cprakhar/uber-clone/shared/messaging"
 	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
@@ -14,127 +16,167 @@ import (
 	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
 )
 
+// paymentTripStatuses maps each payment outcome to the status of the paid trip
+var paymentTripStatuses = map[messaging.Event[messaging.PaymentStatusUpdateData]]types.TripStatus{
+	messaging.PaymentSucceeded: types.TripStatusPaid,
+	messaging.PaymentFailed:    types.TripStatusPaymentFailed,
+	messaging.PaymentCancelled: types.TripStatusPaymentFailed,
+}
+
+// cancellationFeePurpose marks payments of a cancellation fee, which leave the cancelled trip as it is
+const cancellationFeePurpose = "cancellation_fee"
+
 type DriverConsumer struct {
 	kfClient *kafka.KafkaClient
 	svc      service.TripService
+	producer *TripEventProducer
 }
 
 // NewDriverConsumer creates a new DriverConsumer with the given Kafka consumer.
-func NewDriverConsumer(kfClient *kafka.KafkaClient, svc service.TripService) *DriverConsumer {
-	return &DriverConsumer{kfClient: kfClient, svc: svc}
+func NewDriverConsumer(kfClient *kafka.KafkaClient, svc service.TripService, producer *TripEventProducer) *DriverConsumer {
+	return &DriverConsumer{kfClient: kfClient, svc: svc, producer: producer}
 }
 
 // Consume starts consuming messages from the specified topics and processes them.
 func (dc *DriverConsumer) Consume(ctx context.Context, topics []string) error {
-	return dc.kfClient.Consumer.SubscribeAndConsume(ctx, topics, func(ctx context.Context, msg *ckafka.Message) error {
-
-		var kafkaMsg contracts.KafkaMessage
-		if err := json.Unmarshal(msg.Value, &kafkaMsg); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
+	router := messaging.NewRouter()
+	messaging.Handle(router, messaging.DriverLocationUpdated, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.DriverLocationEventData) error {
+		dc.svc.RecordDriverSupply(payload.Driver, payload.Available)
+		return dc.handleDriverLocation(ctx, payload.Driver, payload.RecordedAt)
+	})
+	for event, status := range paymentTripStatuses {
+		messaging.Handle(router, event, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.PaymentStatusUpdateData) error {
+			return dc.handlePaymentStatus(ctx, payload, status)
+		})
+	}
+	messaging.Handle(router, messaging.TripNoDriversFound, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.TripEventData) error {
+		return dc.handleNoDriversFound(ctx, payload.Trip.GetId())
+	})
+	messaging.Handle(router, messaging.DriverTripAccept, func(ctx context.Context, _ *contracts.EventEnvelope, payload messaging.DriverTripResponseData) error {
+		if err := dc.handleTripAccept(ctx, payload.TripID, payload.Driver); err != nil {
+			log.Printf("Failed to handle trip accept: %v", err)
 			return err
 		}
-
-		var payload messaging.DriverTripResponseData
-		if kafkaMsg.Data != nil {
-			if err := json.Unmarshal(kafkaMsg.Data, &payload); err != nil {
-				log.Printf("Failed to unmarshal payload: %v", err)
-				return err
-			}
-		}
-
-		switch *msg.TopicPartition.Topic {
-		case contracts.DriverCmdTripAccept:
-			if err := dc.handleTripAccept(ctx, payload.TripID, payload.Driver); err != nil {
-				log.Printf("Failed to handle trip accept: %v", err)
-				return err
-			}
-		case contracts.DriverCmdTripDecline:
-			log.Printf("Driver declined trip %s", payload.TripID)
-			dc.handleTripDecline(ctx, payload.TripID)
+		return nil
+	})
+	messaging.Handle(router, messaging.DriverTripDecline, func(ctx context.Context, env *contracts.EventEnvelope, payload messaging.DriverTripResponseData) error {
+		driverID := env.EntityID
+		if payload.Driver != nil {
+			driverID = payload.Driver.Id
 		}
-
+		log.Printf("Driver %s declined trip %s", driverID, payload.TripID)
+		dc.handleTripDecline(ctx, payload.TripID, driverID)
 		return nil
 	})
+
+	return dc.kfClient.Consumer.SubscribeAndConsume(ctx, topics, router.HandleMessage)
 }
 
-func (dc *DriverConsumer) handleTripDecline(ctx context.Context, tripID string) error {
-	trip, err := dc.svc.GetTripByID(ctx, tripID)
+// handleDriverLocation forwards the location of a driver on a trip to the trip's rider
+func (dc *DriverConsumer) handleDriverLocation(ctx context.Context, driver *pbd.Driver, recordedAt time.Time) error {
+	if driver == nil {
+		return nil
+	}
+	if recordedAt.IsZero() {
+		recordedAt = time.Now()
+	}
+
+	tracking, err := dc.svc.TrackDriver(ctx, driver, recordedAt)
+	if errors.Is(err, repo.ErrNotFound) || errors.Is(err, service.ErrTrackingThrottled) {
+		// The driver is not on a trip, or the rider was updated moments ago
+		return nil
+	}
 	if err != nil {
-		log.Printf("Failed to get trip by ID: %v", err)
+		log.Printf("Failed to track driver %s: %v", driver.Id, err)
 		return err
 	}
 
-	tripEventData := &messaging.TripEventData{
-		Trip: trip.ToProto(),
+	if err := dc.producer.PublishDriverLocation(ctx, driver, tracking); err != nil {
+		log.Printf("Failed to send driver location for trip %s: %v", tracking.Trip.ID.Hex(), err)
+		return err
 	}
+	return nil
+}
 
-	data, err := json.Marshal(tripEventData)
+// handleTripDecline tells the driver service that the driver declined the offer,
+// so the trip is offered to the next candidate.
+func (dc *DriverConsumer) handleTripDecline(ctx context.Context, tripID, driverID string) error {
+	trip, err := dc.svc.GetTripByID(ctx, tripID)
 	if err != nil {
-		log.Printf("Failed to marshal trip event data: %v", err)
+		log.Printf("Failed to get trip by ID: %v", err)
+		return err
+	}
+
+	if trip.Status != types.TripStatusPending {
+		log.Printf("Ignoring decline for trip %s in status %s", tripID, trip.Status)
+		return nil
 	}
 
 	// Notify driver service to find another driver
-	if err := dc.kfClient.Producer.SendMessage(contracts.TripEventDriverNotInterested, &contracts.KafkaMessage{
-		EntityID: trip.RiderID,
-		Data:     data,
-	}); err != nil {
+	if err := dc.producer.PublishDriverNotInterested(ctx, trip, driverID); err != nil {
 		log.Printf("Failed to send driver not interested message: %v", err)
 	}
 
 	return nil
 }
 
-// handleTripAccept processes a trip acceptance from a driver.
+// handleTripAccept processes a trip acceptance from a driver. The rider is notified through the
+// "trip.event.driver_assigned" event saved with the assignment.
 func (dc *DriverConsumer) handleTripAccept(ctx context.Context, tripID string, driver *pbd.Driver) error {
-	updatedTrip, err := dc.svc.AcceptRide(ctx, tripID, &pb.TripDriver{
+	_, err := dc.svc.AcceptRide(ctx, tripID, &pb.TripDriver{
 		Id:         driver.Id,
 		Name:       driver.Name,
 		ProfilePic: driver.ProfilePic,
 		CarPlate:   driver.CarPlate,
 	})
+	if errors.Is(err, types.ErrInvalidTransition) {
+		// Another driver already accepted the trip, or it is no longer pending
+		log.Printf("Ignoring trip accept from driver %s for trip %s: %v", driver.Id, tripID, err)
+		return nil
+	}
 	if err != nil {
 		log.Printf("Failed to update trip with driver: %v", err)
 		return err
 	}
 
-	data, err := json.Marshal(updatedTrip)
+	log.Printf("Trip %s accepted by driver %s", tripID, driver.Id)
+	return nil
+}
+
+// handleNoDriversFound marks a pending trip as having no drivers available.
+func (dc *DriverConsumer) handleNoDriversFound(ctx context.Context, tripID string) error {
+	_, err := dc.svc.UpdateTripStatus(ctx, tripID, types.TripStatusNoDriversFound)
+	if errors.Is(err, types.ErrInvalidTransition) {
+		log.Printf("Ignoring no drivers found for trip %s: %v", tripID, err)
+		return nil
+	}
 	if err != nil {
-		log.Printf("Failed to marshal updated trip: %v", err)
+		log.Printf("Failed to update trip status: %v", err)
 		return err
 	}
 
-	// Notify rider about driver assignment
-	if err := dc.kfClient.Producer.SendMessage(contracts.TripEventDriverAssigned, &contracts.KafkaMessage{
-		EntityID: updatedTrip.RiderID,
-		Data:     data,
-	}); err != nil {
-		log.Printf("Failed to send driver assigned message: %v", err)
-		return err
-	}
+	log.Printf("No drivers found for trip %s", tripID)
+	return nil
+}
 
-	paymentTripResponseData := &messaging.PaymentTripResponseData{
-		TripID:   tripID,
-		RiderID:  updatedTrip.RiderID,
-		DriverID: driver.Id,
-		Amount:   updatedTrip.RideFare.TotalFareInPaise,
-		Currency: "INR",
+// handlePaymentStatus marks a completed trip as paid, or its payment as failed, and notifies the rider.
+func (dc *DriverConsumer) handlePaymentStatus(ctx context.Context, payment messaging.PaymentStatusUpdateData, status types.TripStatus) error {
+	if payment.Purpose == cancellationFeePurpose {
+		log.Printf("Cancellation fee payment for trip %s is %s", payment.TripID, payment.Status)
+		return nil
 	}
 
-	data, err = json.Marshal(paymentTripResponseData)
-	if err != nil {
-		log.Printf("Failed to marshal payment trip response data: %v", err)
-		return err
+	trip, err := dc.svc.UpdateTripStatus(ctx, payment.TripID, status)
+	if errors.Is(err, types.ErrInvalidTransition) || errors.Is(err, repo.ErrNotFound) {
+		// A redelivered payment event, or a failure reported after the trip was paid
+		log.Printf("Ignoring %s payment for trip %s: %v", payment.Status, payment.TripID, err)
+		return nil
 	}
-
-	// Notify payment service to create a payment session
-	if err := dc.kfClient.Producer.SendMessage(contracts.PaymentCmdCreateSession, &contracts.KafkaMessage{
-		EntityID: updatedTrip.RiderID,
-		Data:     data,
-	}); err != nil {
-		log.Printf("Failed to send payment command create session message: %v", err)
+	if err != nil {
+		log.Printf("Failed to update trip status: %v", err)
 		return err
 	}
 
-	log.Printf("Trip %s accepted by driver %s", tripID, driver.Id)
+	log.Printf("Trip %s is %s", payment.TripID, trip.Status)
 	return nil
 }
@@ -1,38 +1,51 @@
 package events
 
 import (
-	"encoding/json"
-	"fmt"
-	"time"
+	"context"
 
+	"github.com/cprakhar/uber-clone/services/trip-service/surge"
 	"github.com/cprakhar/uber-clone/services/trip-service/types"
-	"github.com/cprakhar/uber-clone/shared/contracts"
 	"github.com/cprakhar/uber-clone/shared/messaging"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
 )
 
 type TripEventProducer struct {
-	k *kafka.KafkaClient
+	publisher *messaging.Publisher
 }
 
-// NewTripEventProducer creates a new TripEventProducer with the given Kafka producer.
-func NewTripEventProducer(k *kafka.KafkaClient) *TripEventProducer {
-	return &TripEventProducer{k: k}
+// NewTripEventProducer creates a new TripEventProducer with the given publisher.
+func NewTripEventProducer(publisher *messaging.Publisher) *TripEventProducer {
+	return &TripEventProducer{publisher: publisher}
 }
 
-// PublishTripCreated publishes a "trip.event.created" event with the given payload and entity ID.
-func (tep *TripEventProducer) PublishTripCreated(trip *types.TripModel, timeout ...time.Duration) error {
-	msg := messaging.TripEventData{
-		Trip: trip.ToProto(),
-	}
+// PublishSurgeUpdated publishes a "pricing.event.surge_updated" event for the cell whose multiplier changed.
+func (tep *TripEventProducer) PublishSurgeUpdated(ctx context.Context, update surge.Update) error {
+	return messaging.Publish(ctx, tep.publisher, messaging.SurgeUpdated, update.Geohash, messaging.SurgeUpdatedData{
+		Geohash:    update.Geohash,
+		Multiplier: update.Multiplier,
+		Demand:     update.Demand,
+		Supply:     update.Supply,
+	})
+}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %v", err)
+// PublishDriverLocation sends the live location of the trip's driver to the rider as a "driver.cmd.location" command.
+func (tep *TripEventProducer) PublishDriverLocation(ctx context.Context, driver *pbd.Driver, tracking *types.DriverTracking) error {
+	msg := messaging.DriverTrackingList{
+		{
+			Driver:         driver,
+			TripID:         tracking.Trip.ID.Hex(),
+			DistanceMeters: tracking.DistanceMeters,
+			ETASeconds:     tracking.ETASeconds,
+		},
 	}
 
-	return tep.k.Producer.SendMessage(contracts.TripEventCreated, &contracts.KafkaMessage{
-		EntityID: trip.RiderID,
-		Data:     data,
+	return messaging.Publish(ctx, tep.publisher, messaging.DriverLocation, tracking.Trip.RiderID, msg)
+}
+
+// PublishDriverNotInterested publishes a "trip.event.driver_not_interested" event for a driver who declined the trip, keyed by the rider ID.
+func (tep *TripEventProducer) PublishDriverNotInterested(ctx context.Context, trip *types.TripModel, driverID string) error {
+	return messaging.Publish(ctx, tep.publisher, messaging.TripDriverNotInterested, trip.RiderID, messaging.DriverNotInterestedData{
+		Trip:     trip.ToProto(),
+		DriverID: driverID,
 	})
 }
@@ -6,22 +6,21 @@ import (
 	"log"
 	"net"
 
-	"github.com/cprakhar/uber-clone/services/trip-service/events"
 	"github.com/cprakhar/uber-clone/services/trip-service/handler"
+	"github.com/cprakhar/uber-clone/services/trip-service/idempotency"
 	"github.com/cprakhar/uber-clone/services/trip-service/service"
-	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 	"google.golang.org/grpc"
 )
 
 type gRPCServer struct {
 	addr        string
 	tripService service.TripService
-	kfClient    *kafka.KafkaClient
+	idempotency *idempotency.Cache
 }
 
 // NewgRPCServer creates a new gRPC server instance
-func NewgRPCServer(addr string, tripService service.TripService, kfc *kafka.KafkaClient) *gRPCServer {
-	return &gRPCServer{addr: addr, tripService: tripService, kfClient: kfc}
+func NewgRPCServer(addr string, tripService service.TripService, cache *idempotency.Cache) *gRPCServer {
+	return &gRPCServer{addr: addr, tripService: tripService, idempotency: cache}
 }
 
 // run starts the gRPC server and listens for incoming requests
@@ -33,8 +32,8 @@ func (s *gRPCServer) run(ctx context.Context) error {
 	}
 	
 	// gRPC server setup
-	srv := grpc.NewServer()
-	handler.NewgRPCHandler(srv, s.tripService, events.NewTripEventProducer(s.kfClient))
+	srv := grpc.NewServer(grpc.UnaryInterceptor(s.idempotency.UnaryServerInterceptor()))
+	handler.NewgRPCHandler(srv, s.tripService)
 
 	// Graceful shutdown on context cancellation
 	go func() {
@@ -2,9 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"log"
 
-	"github.com/cprakhar/uber-clone/services/trip-service/events"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
 	"github.com/cprakhar/uber-clone/services/trip-service/service"
 	"github.com/cprakhar/uber-clone/services/trip-service/types"
 	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
@@ -16,13 +17,12 @@ import (
 
 type gRPCHandler struct {
 	pb.UnimplementedTripServiceServer
-	svc      service.TripService
-	producer *events.TripEventProducer
+	svc service.TripService
 }
 
 // NewgRPCHandler registers the gRPC handler with the given gRPC server
-func NewgRPCHandler(srv *grpc.Server, svc service.TripService, producer *events.TripEventProducer) {
-	handler := &gRPCHandler{svc: svc, producer: producer}
+func NewgRPCHandler(srv *grpc.Server, svc service.TripService) {
+	handler := &gRPCHandler{svc: svc}
 	pb.RegisterTripServiceServer(srv, handler)
 }
 
@@ -45,7 +45,7 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 		return nil, status.Errorf(codes.Internal, "failed to get route: %v", err)
 	}
 
-	estimatedFares := h.svc.EstimatePackagesPriceWithRoute(route)
+	estimatedFares := h.svc.EstimatePackagesPriceWithRoute(pickupCoords, route)
 
 	fares, err := h.svc.GenerateTripFares(ctx, estimatedFares, req.GetRiderID(), route)
 	if err != nil {
@@ -64,21 +64,95 @@ func (h *gRPCHandler) CreateTrip(ctx context.Context, req *pb.CreateTripRequest)
 	riderID := req.GetRiderID()
 	fare, err := h.svc.GetAndValidateRideFare(ctx, fareID, riderID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid fare: %v", err)
+		return nil, fareError(err)
 	}
 
 	trip, err := h.svc.CreateTrip(ctx, fare)
 	if err != nil {
+		if errors.Is(err, repo.ErrFareExpired) || errors.Is(err, repo.ErrFareConsumed) {
+			return nil, fareError(err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to create trip: %v", err)
 	}
 
-	// Notify other services about the new trip
-	if err := h.producer.PublishTripCreated(trip); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to publish trip created event: %v", err)
-	}
-	log.Printf("Published trip created event for trip ID: %s", trip.ID.Hex())
+	log.Printf("Created trip %s for rider %s", trip.ID.Hex(), riderID)
 
 	return &pb.CreateTripResponse{
 		TripID: trip.ID.Hex(),
 	}, nil
 }
+
+// CancelTrip handles the CancelTrip gRPC request
+func (h *gRPCHandler) CancelTrip(ctx context.Context, req *pb.CancelTripRequest) (*pb.CancelTripResponse, error) {
+	by := types.CancelledBy(req.GetCancelledBy())
+	if by != types.CancelledByRider && by != types.CancelledByDriver {
+		return nil, status.Errorf(codes.InvalidArgument, "cancelledBy must be %q or %q", types.CancelledByRider, types.CancelledByDriver)
+	}
+
+	trip, err := h.svc.CancelTrip(ctx, req.GetTripID(), by, req.GetRequesterID(), req.GetReason())
+	if err != nil {
+		return nil, tripActionError(req.GetTripID(), err)
+	}
+
+	log.Printf("Trip %s cancelled by %s %s, fee %d", trip.ID.Hex(), by, req.GetRequesterID(), trip.Cancellation.FeeInPaise)
+
+	return &pb.CancelTripResponse{
+		Trip:                   trip.ToProto(),
+		CancellationFeeInPaise: trip.Cancellation.FeeInPaise,
+		Currency:               trip.Cancellation.Currency,
+	}, nil
+}
+
+// DriverArrived handles the DriverArrived gRPC request
+func (h *gRPCHandler) DriverArrived(ctx context.Context, req *pb.TripDriverActionRequest) (*pb.TripDriverActionResponse, error) {
+	trip, err := h.svc.MarkDriverArrived(ctx, req.GetTripID(), req.GetDriverID())
+	if err != nil {
+		return nil, tripActionError(req.GetTripID(), err)
+	}
+
+	return &pb.TripDriverActionResponse{Trip: trip.ToProto()}, nil
+}
+
+// StartTrip handles the StartTrip gRPC request
+func (h *gRPCHandler) StartTrip(ctx context.Context, req *pb.TripDriverActionRequest) (*pb.TripDriverActionResponse, error) {
+	trip, err := h.svc.StartTrip(ctx, req.GetTripID(), req.GetDriverID())
+	if err != nil {
+		return nil, tripActionError(req.GetTripID(), err)
+	}
+
+	return &pb.TripDriverActionResponse{Trip: trip.ToProto()}, nil
+}
+
+// CompleteTrip handles the CompleteTrip gRPC request
+func (h *gRPCHandler) CompleteTrip(ctx context.Context, req *pb.TripDriverActionRequest) (*pb.TripDriverActionResponse, error) {
+	trip, err := h.svc.CompleteTrip(ctx, req.GetTripID(), req.GetDriverID())
+	if err != nil {
+		return nil, tripActionError(req.GetTripID(), err)
+	}
+
+	log.Printf("Trip %s completed, %.0fm in %.0fs for %d", trip.ID.Hex(),
+		trip.FinalFare.DistanceMeters, trip.FinalFare.DurationSeconds, trip.FinalFare.TotalFareInPaise)
+
+	return &pb.TripDriverActionResponse{Trip: trip.ToProto()}, nil
+}
+
+// tripActionError maps an error from a driver's trip action to a gRPC status
+func tripActionError(tripID string, err error) error {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return status.Errorf(codes.NotFound, "trip %s not found", tripID)
+	case errors.Is(err, service.ErrNotTripParticipant):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, types.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to update trip %s: %v", tripID, err)
+}
+
+// fareError maps a ride fare validation error to a gRPC status
+func fareError(err error) error {
+	if errors.Is(err, repo.ErrFareExpired) || errors.Is(err, repo.ErrFareConsumed) {
+		return status.Errorf(codes.FailedPrecondition, "invalid fare: %v", err)
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid fare: %v", err)
+}
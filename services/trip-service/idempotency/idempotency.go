@@ -0,0 +1,187 @@
+// Package idempotency caches the results of gRPC requests sent with an idempotency key, so that a
+// retried request returns the first result instead of being carried out again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var ErrKeyExists = errors.New("idempotency key already claimed")
+
+// Record is the cached result of a request. It has no response while the request is in progress.
+type Record struct {
+	Key         string    `bson:"_id"` // the gRPC method and the idempotency key
+	RequestHash string    `bson:"requestHash"`
+	Response    []byte    `bson:"response,omitempty"` // an anypb.Any holding the response
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+type Store interface {
+	// Claim saves the record of a new request. If an unexpired record exists for the key it is
+	// returned with ErrKeyExists instead.
+	Claim(ctx context.Context, record *Record) (*Record, error)
+	// Complete saves the response of a claimed request and keeps it until expiresAt
+	Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error
+	// Release deletes the record of a request that failed, so it can be retried
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Config struct {
+	TTL         time.Duration    // how long results are replayed
+	LockTimeout time.Duration    // how long a request in progress holds its key
+	Now         func() time.Time // clock deciding when records expire, time.Now if nil
+}
+
+// Cache replays the results of requests sent with an idempotency key
+type Cache struct {
+	store  Store
+	config *Config
+}
+
+// NewCache creates a cache that keeps results in the store
+func NewCache(store Store, config *Config) *Cache {
+	return &Cache{store: store, config: config}
+}
+
+func (c *Cache) now() time.Time {
+	if c.config.Now != nil {
+		return c.config.Now()
+	}
+	return time.Now()
+}
+
+// UnaryServerInterceptor carries out the first request with each idempotency key and replays its
+// response to later ones. A key reused for a different request is rejected, and so is a retry
+// while the first request is still in progress. Failed requests are not cached.
+func (c *Cache) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := keyFromContext(ctx)
+		msg, ok := req.(proto.Message)
+		if key == "" || !ok {
+			return handler(ctx, req)
+		}
+		if len(key) > contracts.MaxIdempotencyKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency key is longer than %d characters", contracts.MaxIdempotencyKeyLength)
+		}
+
+		hash, err := hashRequest(msg)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash request: %v", err)
+		}
+
+		now := c.now()
+		record := &Record{
+			Key:         info.FullMethod + " " + key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(c.config.LockTimeout),
+		}
+		existing, err := c.store.Claim(ctx, record)
+		if errors.Is(err, ErrKeyExists) {
+			return replay(existing, hash)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to claim idempotency key: %v", err)
+		}
+
+		// The result is saved even if the caller gave up waiting for it
+		saveCtx := context.WithoutCancel(ctx)
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if releaseErr := c.store.Release(saveCtx, record.Key); releaseErr != nil {
+				log.Printf("Failed to release idempotency key %q: %v", record.Key, releaseErr)
+			}
+			return nil, err
+		}
+
+		if err := c.complete(saveCtx, record.Key, resp); err != nil {
+			log.Printf("Failed to cache response for idempotency key %q: %v", record.Key, err)
+		}
+		return resp, nil
+	}
+}
+
+// Sweep deletes expired records every interval until the context is done
+func (c *Cache) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := c.store.DeleteExpired(ctx, now); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to delete expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
+func (c *Cache) complete(ctx context.Context, key string, resp any) error {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return fmt.Errorf("response %T is not a protobuf message", resp)
+	}
+	packed, err := anypb.New(msg)
+	if err != nil {
+		return err
+	}
+	response, err := proto.Marshal(packed)
+	if err != nil {
+		return err
+	}
+	return c.store.Complete(ctx, key, response, c.now().Add(c.config.TTL))
+}
+
+// replay returns the cached response of the request that claimed the key
+func replay(record *Record, hash string) (any, error) {
+	if record.RequestHash != hash {
+		return nil, status.Error(codes.FailedPrecondition, "idempotency key was used for a different request")
+	}
+	if len(record.Response) == 0 {
+		return nil, status.Error(codes.Aborted, "a request with this idempotency key is in progress")
+	}
+
+	var packed anypb.Any
+	if err := proto.Unmarshal(record.Response, &packed); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode cached response: %v", err)
+	}
+	resp, err := packed.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode cached response: %v", err)
+	}
+	return resp, nil
+}
+
+func keyFromContext(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, contracts.IdempotencyKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func hashRequest(req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
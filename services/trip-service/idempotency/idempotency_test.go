@@ -0,0 +1,26 @@
+package idempotency_test
+
+import (
+	"testing"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/idempotency"
+	"github.com/cprakhar/uber-clone/services/trip-service/idempotency/idempotencytest"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestInMemoStore(t *testing.T) {
+	if err := idempotencytest.TestCache(t.Context(), idempotency.NewInMemoStore()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoStore runs the suite against the MongoDB at MONGODB_URI
+func TestMongoStore(t *testing.T) {
+	store, err := idempotency.NewMongoStore(t.Context(), dbtest.Database(t, "idempotencytest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idempotencytest.TestCache(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+}
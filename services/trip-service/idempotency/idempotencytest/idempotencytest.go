@@ -0,0 +1,214 @@
+// Package idempotencytest implements a contract suite for idempotency.Store implementations,
+// driven through the gRPC interceptor of the idempotency cache.
+package idempotencytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/idempotency"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var info = &grpc.UnaryServerInfo{FullMethod: pb.TripService_CreateTrip_FullMethodName}
+
+// TestCache sends requests through the interceptor of a cache backed by the store and checks which
+// of them reach the handler. It only uses fresh keys, so it can run against a shared database.
+// It returns the first contract violation found, or nil.
+func TestCache(ctx context.Context, store idempotency.Store) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, idempotency.Store) error
+	}{
+		{"replay", testReplay},
+		{"key reuse", testKeyReuse},
+		{"in progress", testInProgress},
+		{"failed request", testFailedRequest},
+		{"expiry", testExpiry},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, store); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func testReplay(ctx context.Context, store idempotency.Store) error {
+	intercept := newCache(store, time.Hour).UnaryServerInterceptor()
+	ctx = withKey(ctx, uuid.New().String())
+	handler := &countingHandler{}
+
+	first, err := intercept(ctx, newRequest("fare"), info, handler.handle)
+	if err != nil {
+		return fmt.Errorf("first request: %w", err)
+	}
+	second, err := intercept(ctx, newRequest("fare"), info, handler.handle)
+	if err != nil {
+		return fmt.Errorf("retried request: %w", err)
+	}
+
+	if handler.calls != 1 {
+		return fmt.Errorf("handler called %d times, want 1", handler.calls)
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		return fmt.Errorf("retried request got %v, want the first response %v", second, first)
+	}
+
+	// Requests without a key are always carried out
+	for i := 0; i < 2; i++ {
+		if _, err := intercept(context.Background(), newRequest("fare"), info, handler.handle); err != nil {
+			return fmt.Errorf("request without a key: %w", err)
+		}
+	}
+	if handler.calls != 3 {
+		return fmt.Errorf("handler called %d times with requests without a key, want 3", handler.calls)
+	}
+	return nil
+}
+
+func testKeyReuse(ctx context.Context, store idempotency.Store) error {
+	intercept := newCache(store, time.Hour).UnaryServerInterceptor()
+	ctx = withKey(ctx, uuid.New().String())
+	handler := &countingHandler{}
+
+	if _, err := intercept(ctx, newRequest("fare"), info, handler.handle); err != nil {
+		return fmt.Errorf("first request: %w", err)
+	}
+	if _, err := intercept(ctx, newRequest("another fare"), info, handler.handle); status.Code(err) != codes.FailedPrecondition {
+		return fmt.Errorf("different request with the key: got %v, want FailedPrecondition", err)
+	}
+
+	// The same key is independent across methods
+	other := &grpc.UnaryServerInfo{FullMethod: pb.TripService_PreviewTrip_FullMethodName}
+	if _, err := intercept(ctx, newRequest("another fare"), other, handler.handle); err != nil {
+		return fmt.Errorf("request to another method with the key: %w", err)
+	}
+	return nil
+}
+
+func testInProgress(ctx context.Context, store idempotency.Store) error {
+	intercept := newCache(store, time.Hour).UnaryServerInterceptor()
+	ctx = withKey(ctx, uuid.New().String())
+
+	// The retry arrives while the first request is still being handled
+	var retryErr error
+	_, err := intercept(ctx, newRequest("fare"), info, func(ctx context.Context, req any) (any, error) {
+		_, retryErr = intercept(ctx, req, info, (&countingHandler{}).handle)
+		return &pb.CreateTripResponse{TripID: "trip"}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("first request: %w", err)
+	}
+	if status.Code(retryErr) != codes.Aborted {
+		return fmt.Errorf("retry in progress: got %v, want Aborted", retryErr)
+	}
+	return nil
+}
+
+func testFailedRequest(ctx context.Context, store idempotency.Store) error {
+	intercept := newCache(store, time.Hour).UnaryServerInterceptor()
+	ctx = withKey(ctx, uuid.New().String())
+
+	failure := status.Error(codes.Unavailable, "try again")
+	if _, err := intercept(ctx, newRequest("fare"), info, func(context.Context, any) (any, error) {
+		return nil, failure
+	}); !errors.Is(err, failure) {
+		return fmt.Errorf("failing request: got %v, want the handler's error", err)
+	}
+
+	// A failed request is not cached, so the retry is carried out
+	handler := &countingHandler{}
+	if _, err := intercept(ctx, newRequest("fare"), info, handler.handle); err != nil {
+		return fmt.Errorf("retry of a failed request: %w", err)
+	}
+	if handler.calls != 1 {
+		return fmt.Errorf("retry of a failed request: handler called %d times, want 1", handler.calls)
+	}
+	return nil
+}
+
+func testExpiry(ctx context.Context, store idempotency.Store) error {
+	clock := &clock{now: time.Now()}
+	intercept := idempotency.NewCache(store, &idempotency.Config{
+		TTL:         time.Hour,
+		LockTimeout: time.Minute,
+		Now:         clock.Now,
+	}).UnaryServerInterceptor()
+	ctx = withKey(ctx, uuid.New().String())
+	handler := &countingHandler{}
+
+	if _, err := intercept(ctx, newRequest("fare"), info, handler.handle); err != nil {
+		return fmt.Errorf("first request: %w", err)
+	}
+	clock.advance(time.Hour - time.Second)
+	if _, err := intercept(ctx, newRequest("another fare"), info, handler.handle); status.Code(err) != codes.FailedPrecondition {
+		return fmt.Errorf("different request before expiry: got %v, want FailedPrecondition", err)
+	}
+	clock.advance(time.Second)
+
+	// Once its result expires, the key can be used again
+	if _, err := intercept(ctx, newRequest("another fare"), info, handler.handle); err != nil {
+		return fmt.Errorf("request after expiry: %w", err)
+	}
+	if handler.calls != 2 {
+		return fmt.Errorf("handler called %d times, want 2", handler.calls)
+	}
+
+	if _, err := store.DeleteExpired(ctx, clock.Now().Add(2*time.Hour)); err != nil {
+		return fmt.Errorf("DeleteExpired: %w", err)
+	}
+	return nil
+}
+
+// clock is the cache's clock, moved forward by the checks instead of waiting for records to expire
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// countingHandler creates a new trip on every call
+type countingHandler struct {
+	calls int
+}
+
+func (h *countingHandler) handle(ctx context.Context, req any) (any, error) {
+	h.calls++
+	return &pb.CreateTripResponse{TripID: uuid.New().String()}, nil
+}
+
+func newCache(store idempotency.Store, ttl time.Duration) *idempotency.Cache {
+	return idempotency.NewCache(store, &idempotency.Config{TTL: ttl, LockTimeout: time.Minute})
+}
+
+func newRequest(fareID string) *pb.CreateTripRequest {
+	return &pb.CreateTripRequest{RideFareID: fareID, RiderID: "rider"}
+}
+
+// withKey attaches the idempotency key the way the gRPC server receives it
+func withKey(ctx context.Context, key string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(contracts.IdempotencyKeyMetadata, key))
+}
@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyKeysCollection = "idempotency_keys"
+
+type mongoStore struct {
+	records *mongo.Collection
+}
+
+// NewMongoStore creates a MongoDB backed Store. MongoDB deletes expired records itself.
+func NewMongoStore(ctx context.Context, db *mongo.Database) (*mongoStore, error) {
+	s := &mongoStore{records: db.Collection(idempotencyKeysCollection)}
+
+	if _, err := s.records.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency key indexes: %w", err)
+	}
+	return s, nil
+}
+
+// Claim inserts the record unless an unexpired one exists for the key
+func (s *mongoStore) Claim(ctx context.Context, record *Record) (*Record, error) {
+	// MongoDB removes expired records about once a minute, so one may still be in the way
+	if _, err := s.records.DeleteOne(ctx, bson.M{"_id": record.Key, "expiresAt": bson.M{"$lte": record.CreatedAt}}); err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+	}
+
+	_, err := s.records.InsertOne(ctx, record)
+	if err == nil {
+		return record, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+
+	var existing Record
+	err = s.records.FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Released since the insert failed, the caller may retry
+		return &Record{Key: record.Key, RequestHash: record.RequestHash}, ErrKeyExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &existing, ErrKeyExists
+}
+
+// Complete saves the response of a claimed request
+func (s *mongoStore) Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error {
+	if _, err := s.records.UpdateByID(ctx, key, bson.M{"$set": bson.M{"response": response, "expiresAt": expiresAt}}); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the record of the key
+func (s *mongoStore) Release(ctx context.Context, key string) error {
+	if _, err := s.records.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes records that expired before the given time
+func (s *mongoStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.records.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lte": before}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type inMemoStore struct {
+	sync.Mutex
+	records map[string]*Record
+}
+
+// NewInMemoStore creates a new instance of in-memory Store
+func NewInMemoStore() *inMemoStore {
+	return &inMemoStore{records: make(map[string]*Record)}
+}
+
+// Claim saves the record unless an unexpired one exists for the key
+func (s *inMemoStore) Claim(ctx context.Context, record *Record) (*Record, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if existing, ok := s.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		copied := *existing
+		return &copied, ErrKeyExists
+	}
+	copied := *record
+	s.records[record.Key] = &copied
+	return record, nil
+}
+
+// Complete saves the response of a claimed request
+func (s *inMemoStore) Complete(ctx context.Context, key string, response []byte, expiresAt time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.Response = response
+		record.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// Release deletes the record of the key
+func (s *inMemoStore) Release(ctx context.Context, key string) error {
+	s.Lock()
+	delete(s.records, key)
+	s.Unlock()
+	return nil
+}
+
+// DeleteExpired removes records that expired before the given time
+func (s *inMemoStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var deleted int64
+	for key, record := range s.records {
+		if !record.ExpiresAt.After(before) {
+			delete(s.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -2,22 +2,86 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/cprakhar/uber-clone/services/trip-service/events"
+	"github.com/cprakhar/uber-clone/services/trip-service/idempotency"
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox"
+	"github.com/cprakhar/uber-clone/services/trip-service/pricing"
 	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/routing"
 	"github.com/cprakhar/uber-clone/services/trip-service/service"
+	"github.com/cprakhar/uber-clone/services/trip-service/surge"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
 	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/db"
+	"github.com/cprakhar/uber-clone/shared/env"
+	"github.com/cprakhar/uber-clone/shared/messaging"
 	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
 )
 
 var (
-	brokers = []string{"kafka:9092"}
-	groupID = "trip-service-group"
-	topics  = []string{contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline}
+	brokers       = []string{"kafka:9092"}
+	groupID       = "trip-service-group"
+	serviceName   = "trip-service"
+	repoBackend   = env.GetString("TRIP_REPO", "memory")
+	fareRetention = env.GetDuration("RIDE_FARE_RETENTION", 24*time.Hour)
+	routingCfg    = &routing.Config{
+		Provider:        env.GetString("ROUTE_PROVIDER", routing.ProviderOSRM),
+		OSRMBaseURL:     env.GetString("OSRM_API", "http://router.project-osrm.org"),
+		OSRMTimeout:     env.GetDuration("OSRM_TIMEOUT", 5*time.Second),
+		OSRMMaxRetries:  env.GetInt("OSRM_MAX_RETRIES", 2),
+		OSRMRetryDelay:  env.GetDuration("OSRM_RETRY_DELAY", 200*time.Millisecond),
+		OfflineMode:     env.GetString("OFFLINE_ROUTE_MODE", routing.OfflineModeStraight),
+		OfflineSpeedKmh: float64(env.GetInt("OFFLINE_ROUTE_SPEED_KMH", 30)),
+	}
+	pricingConfigPath     = env.GetString("PRICING_CONFIG", "")
+	pricingReloadInterval = env.GetDuration("PRICING_RELOAD_INTERVAL", 30*time.Second)
+	surgeCfg              = &surge.Config{
+		Window:           env.GetDuration("SURGE_WINDOW", 5*time.Minute),
+		GeohashPrecision: uint(env.GetInt("SURGE_GEOHASH_PRECISION", 5)),
+		MaxMultiplier:    env.GetFloat("SURGE_MAX_MULTIPLIER", 3.0),
+		Sensitivity:      env.GetFloat("SURGE_SENSITIVITY", 0.5),
+		Step:             env.GetFloat("SURGE_STEP", 0.1),
+	}
+	surgeEvalInterval = env.GetDuration("SURGE_EVAL_INTERVAL", 30*time.Second)
+	fareTTL           = env.GetDuration("RIDE_FARE_TTL", 5*time.Minute)
+	fareSweepInterval = env.GetDuration("RIDE_FARE_SWEEP_INTERVAL", time.Minute)
+	trackingCfg       = &types.TrackingConfig{
+		Interval:    env.GetDuration("DRIVER_TRACKING_INTERVAL", 2*time.Second),
+		AvgSpeedKmh: env.GetFloat("DRIVER_TRACKING_SPEED_KMH", 25),
+	}
+	cancellationPolicy = &types.CancellationPolicy{
+		GracePeriod: env.GetDuration("CANCELLATION_GRACE_PERIOD", 2*time.Minute),
+		FeeInPaise:  int64(env.GetInt("CANCELLATION_FEE", 5000)),
+	}
+	idempotencySweepInterval = env.GetDuration("IDEMPOTENCY_SWEEP_INTERVAL", time.Minute)
+	idempotencyCfg           = &idempotency.Config{
+		TTL:         env.GetDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		LockTimeout: env.GetDuration("IDEMPOTENCY_LOCK_TIMEOUT", 30*time.Second),
+	}
+	outboxCfg = &outbox.Config{
+		Interval:    env.GetDuration("OUTBOX_RELAY_INTERVAL", 500*time.Millisecond),
+		BatchSize:   env.GetInt("OUTBOX_BATCH_SIZE", 100),
+		SendTimeout: env.GetDuration("OUTBOX_SEND_TIMEOUT", 5*time.Second),
+		MinBackoff:  env.GetDuration("OUTBOX_MIN_BACKOFF", time.Second),
+		MaxBackoff:  env.GetDuration("OUTBOX_MAX_BACKOFF", time.Minute),
+		Retention:   env.GetDuration("OUTBOX_RETENTION", 24*time.Hour),
+	}
+	topics = []string{
+		contracts.DriverCmdTripAccept,
+		contracts.DriverCmdTripDecline,
+		contracts.TripEventNoDriversFound,
+		contracts.DriverEventLocationUpdated,
+		contracts.PaymentEventSuccess,
+		contracts.PaymentEventFailed,
+		contracts.PaymentEventCancelled,
+	}
 )
 
 func main() {
@@ -31,21 +95,62 @@ func main() {
 	}
 	defer kfClient.Close()
 	log.Println("Kafka client connected")
+	publisher := messaging.NewPublisher(kfClient.Producer, serviceName)
 
 	// Initialize repositories and services
-	tripRepo := repo.NewInMemoRepository()
-	tripService := service.NewService(tripRepo)
+	tripRepo, idempotencyStore, closeRepo, err := newTripRepo(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create trip repository: %v", err)
+	}
+	defer closeRepo()
+
+	// Retried requests with the same idempotency key get the first result
+	idempotencyCache := idempotency.NewCache(idempotencyStore, idempotencyCfg)
+	go idempotencyCache.Sweep(ctx, idempotencySweepInterval)
+
+	routeProvider, err := routing.NewRouteProvider(routingCfg)
+	if err != nil {
+		log.Fatalf("Failed to create route provider: %v", err)
+	}
+	log.Printf("Using %s route provider", routingCfg.Provider)
+
+	pricingEngine, err := pricing.NewEngineFromFile(pricingConfigPath)
+	if err != nil {
+		log.Fatalf("Failed to create pricing engine: %v", err)
+	}
+	if pricingConfigPath != "" {
+		go pricingEngine.Watch(ctx, pricingConfigPath, pricingReloadInterval)
+	}
+
+	// Publish surge multiplier changes as they happen
+	tripProducer := events.NewTripEventProducer(publisher)
+	surgeTracker := surge.NewTracker(surgeCfg, func(update surge.Update) {
+		if err := tripProducer.PublishSurgeUpdated(ctx, update); err != nil {
+			log.Printf("Failed to publish surge update for %s: %v", update.Geohash, err)
+		}
+	})
+	go surgeTracker.Run(ctx, surgeEvalInterval)
+
+	tripService := service.NewService(tripRepo, routeProvider, pricingEngine, surgeTracker, fareTTL, trackingCfg, cancellationPolicy)
+	// Used fares outlive the idempotency records of the requests that used them
+	go tripService.SweepExpiredFares(ctx, fareSweepInterval, idempotencyCfg.TTL)
+
+	// Publish the events saved with trip changes
+	outboxRelay := outbox.NewRelay(tripRepo, publisher, outboxCfg)
+	go outboxRelay.Run(ctx)
 
 	// Start consuming driver responses
-	driverConsumer := events.NewDriverConsumer(kfClient, tripService)
+	driverConsumer := events.NewDriverConsumer(kfClient, tripService, tripProducer)
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := driverConsumer.Consume(ctx, topics); err != nil {
 			log.Printf("Error consuming driver topics: %v", err)
 		}
 	}()
 
 	// Start gRPC server
-	gRPCServer := NewgRPCServer(":9000", tripService, kfClient)
+	gRPCServer := NewgRPCServer(":9000", tripService, idempotencyCache)
 	go func() {
 		if err := gRPCServer.run(ctx); err != nil && ctx.Err() == nil {
 			log.Printf("gRPC server error: %v", err)
@@ -55,4 +160,43 @@ func main() {
 
 	<-ctx.Done()
 	log.Println("Shutdown signal received, exiting...")
+	// Let the consumer finish the messages it is handling before the Kafka client closes
+	<-consumerDone
+}
+
+// newTripRepo creates the trip repository and idempotency key store selected by TRIP_REPO
+// ("memory" or "mongo") along with a function that releases their resources.
+func newTripRepo(ctx context.Context) (repo.TripRepo, idempotency.Store, func(), error) {
+	switch repoBackend {
+	case "memory":
+		log.Println("Using in-memory trip repository")
+		return repo.NewInMemoRepository(), idempotency.NewInMemoStore(), func() {}, nil
+	case "mongo":
+		mongoCfg := db.NewMongoDefaultConfig()
+		client, err := db.NewMongoClient(ctx, mongoCfg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		closeFn := func() {
+			if err := client.Disconnect(context.Background()); err != nil {
+				log.Printf("Failed to disconnect from MongoDB: %v", err)
+			}
+		}
+
+		database := db.GetDatabase(client, mongoCfg)
+		tripRepo, err := repo.NewMongoRepository(ctx, database, fareRetention)
+		if err != nil {
+			closeFn()
+			return nil, nil, nil, err
+		}
+		idempotencyStore, err := idempotency.NewMongoStore(ctx, database)
+		if err != nil {
+			closeFn()
+			return nil, nil, nil, err
+		}
+		log.Println("Using MongoDB trip repository")
+		return tripRepo, idempotencyStore, closeFn, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown TRIP_REPO %q", repoBackend)
+	}
 }
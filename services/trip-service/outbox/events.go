@@ -0,0 +1,102 @@
+// Package outbox builds the events announcing trip changes, which are saved in the trip repo's
+// outbox together with the changes, and relays them to Kafka.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	"github.com/cprakhar/uber-clone/shared/messaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// tripStatusEvents maps each trip status to the event published when a trip enters it
+var tripStatusEvents = map[types.TripStatus]messaging.Event[messaging.TripEventData]{
+	types.TripStatusPending:        messaging.TripCreated,
+	types.TripStatusDriverAssigned: messaging.TripDriverAssigned,
+	types.TripStatusDriverArrived:  messaging.TripDriverArrived,
+	types.TripStatusInProgress:     messaging.TripStarted,
+	types.TripStatusCompleted:      messaging.TripCompleted,
+	types.TripStatusCancelled:      messaging.TripCancelled,
+	types.TripStatusNoDriversFound: messaging.TripNoDriversFound,
+	types.TripStatusPaymentFailed:  messaging.TripPaymentFailed,
+	types.TripStatusPaid:           messaging.TripPaid,
+}
+
+// TripStatusChanged returns the "trip.event.*" event matching the trip's current status, keyed by the rider ID.
+// A cancelled trip is announced to its driver as well, if one was assigned.
+func TripStatusChanged(ctx context.Context, trip *types.TripModel) ([]*types.OutboxEvent, error) {
+	event, ok := tripStatusEvents[trip.Status]
+	if !ok {
+		return nil, fmt.Errorf("no event topic for trip status %q", trip.Status)
+	}
+
+	entityIDs := []string{trip.RiderID}
+	if driverID := trip.Driver.GetId(); trip.Status == types.TripStatusCancelled && driverID != "" {
+		entityIDs = append(entityIDs, driverID)
+	}
+
+	events := make([]*types.OutboxEvent, len(entityIDs))
+	for i, entityID := range entityIDs {
+		e, err := NewEvent(ctx, trip.ID.Hex(), event, entityID, messaging.TripEventData{Trip: trip.ToProto()})
+		if err != nil {
+			return nil, err
+		}
+		events[i] = e
+	}
+	return events, nil
+}
+
+// PaymentRequested asks the payment service to create a session for the final fare of a completed trip
+func PaymentRequested(ctx context.Context, trip *types.TripModel) (*types.OutboxEvent, error) {
+	return NewEvent(ctx, trip.ID.Hex(), messaging.PaymentCreateSession, trip.RiderID, messaging.PaymentTripResponseData{
+		TripID:      trip.ID.Hex(),
+		RiderID:     trip.RiderID,
+		DriverID:    trip.Driver.GetId(),
+		PackageSlug: trip.RideFare.PackageSlug,
+		Amount:      trip.FinalFare.TotalFareInPaise,
+		Currency:    trip.FinalFare.Currency,
+	})
+}
+
+// CancellationFee asks the payment service to charge the rider the trip's cancellation fee
+func CancellationFee(ctx context.Context, trip *types.TripModel) (*types.OutboxEvent, error) {
+	return NewEvent(ctx, trip.ID.Hex(), messaging.PaymentChargeCancellationFee, trip.RiderID, messaging.PaymentTripResponseData{
+		TripID:      trip.ID.Hex(),
+		RiderID:     trip.RiderID,
+		DriverID:    trip.Driver.GetId(),
+		PackageSlug: trip.RideFare.PackageSlug,
+		Amount:      trip.Cancellation.FeeInPaise,
+		Currency:    trip.Cancellation.Currency,
+	})
+}
+
+// NewEvent creates an unsent event of the trip publishing the payload, keyed by entityID.
+// It is correlated to the event handled in ctx, if any.
+func NewEvent[T any](ctx context.Context, tripID string, event messaging.Event[T], entityID string, payload T) (*types.OutboxEvent, error) {
+	env, err := messaging.NewEnvelope(ctx, event, entityID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	id := primitive.NewObjectID()
+	if env.CorrelationID == env.ID {
+		// The event starts a flow, and its envelope is published with the outbox ID
+		env.CorrelationID = id.Hex()
+	}
+	now := time.Now()
+	return &types.OutboxEvent{
+		ID:            id,
+		TripID:        tripID,
+		Topic:         env.Type,
+		SchemaVersion: env.SchemaVersion,
+		CorrelationID: env.CorrelationID,
+		CausationID:   env.CausationID,
+		EntityID:      entityID,
+		Data:          env.Payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
@@ -0,0 +1,265 @@
+// Package outboxtest implements a contract suite for the outbox relay running over a repo.Outbox
+// implementation.
+package outboxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const minBackoff = time.Second
+
+// TestRelay saves events in the outbox and relays them to a fake publisher. The relay publishes
+// every pending event in the store, so it should not be shared with a running trip service.
+// It returns the first contract violation found, or nil.
+func TestRelay(ctx context.Context, store repo.Outbox) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, repo.Outbox) error
+	}{
+		{"delivery", testDelivery},
+		{"failed event", testFailedEvent},
+		{"batch of waiting trips", testWaitingBatch},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, store); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func testDelivery(ctx context.Context, store repo.Outbox) error {
+	publisher := newFakePublisher()
+	clock := newClock()
+	relay := newRelay(store, publisher, clock, 1000)
+
+	tripID := primitive.NewObjectID().Hex()
+	events, err := addEvents(ctx, store, clock, tripID, 3)
+	if err != nil {
+		return err
+	}
+
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce: %w", err)
+	}
+	if got := publisher.sent(tripID); !slices.Equal(got, ids(events)) {
+		return fmt.Errorf("RelayOnce: published %v, want %v", got, ids(events))
+	}
+
+	// Sent events are not published again
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("second RelayOnce: %w", err)
+	}
+	if got := publisher.sent(tripID); len(got) != len(events) {
+		return fmt.Errorf("second RelayOnce: published %d events in total, want %d", len(got), len(events))
+	}
+	return nil
+}
+
+func testFailedEvent(ctx context.Context, store repo.Outbox) error {
+	publisher := newFakePublisher()
+	clock := newClock()
+	relay := newRelay(store, publisher, clock, 1000)
+
+	failingTrip, otherTrip := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+	failing, err := addEvents(ctx, store, clock, failingTrip, 2)
+	if err != nil {
+		return err
+	}
+	other, err := addEvents(ctx, store, clock, otherTrip, 1)
+	if err != nil {
+		return err
+	}
+
+	// The first event of the trip fails, so the second waits for it
+	publisher.fail(failing[0].ID.Hex(), true)
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce: %w", err)
+	}
+	if got := publisher.sent(failingTrip); len(got) != 0 {
+		return fmt.Errorf("RelayOnce: published %v of the trip with a failed event, want none", got)
+	}
+	if got := publisher.sent(otherTrip); !slices.Equal(got, ids(other)) {
+		return fmt.Errorf("RelayOnce: published %v of another trip, want %v", got, ids(other))
+	}
+
+	// Retries wait for the backoff
+	publisher.fail(failing[0].ID.Hex(), false)
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce during the backoff: %w", err)
+	}
+	if got := publisher.sent(failingTrip); len(got) != 0 {
+		return fmt.Errorf("RelayOnce during the backoff: published %v, want none", got)
+	}
+
+	clock.advance(minBackoff)
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce after the backoff: %w", err)
+	}
+	if got := publisher.sent(failingTrip); !slices.Equal(got, ids(failing)) {
+		return fmt.Errorf("RelayOnce after the backoff: published %v, want %v", got, ids(failing))
+	}
+	return nil
+}
+
+// testWaitingBatch fills a whole batch with events of trips waiting for a retry, which must not
+// hold back the event of a trip saved after them
+func testWaitingBatch(ctx context.Context, store repo.Outbox) error {
+	const batchSize = 3
+	publisher := newFakePublisher()
+	clock := newClock()
+	relay := newRelay(store, publisher, clock, batchSize)
+
+	var waiting []*types.OutboxEvent
+	for range batchSize {
+		events, err := addEvents(ctx, store, clock, primitive.NewObjectID().Hex(), 1)
+		if err != nil {
+			return err
+		}
+		publisher.fail(events[0].ID.Hex(), true)
+		waiting = append(waiting, events[0])
+	}
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce: %w", err)
+	}
+
+	healthyTrip := primitive.NewObjectID().Hex()
+	healthy, err := addEvents(ctx, store, clock, healthyTrip, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		return fmt.Errorf("RelayOnce during the backoff: %w", err)
+	}
+	if got := publisher.sent(healthyTrip); !slices.Equal(got, ids(healthy)) {
+		return fmt.Errorf("RelayOnce during the backoff: published %v of the healthy trip, want %v", got, ids(healthy))
+	}
+
+	// Leave nothing pending for the next checks
+	for _, e := range waiting {
+		publisher.fail(e.ID.Hex(), false)
+	}
+	clock.advance(minBackoff)
+	sent, err := relay.RelayOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("RelayOnce after the backoff: %w", err)
+	}
+	if sent != len(waiting) {
+		return fmt.Errorf("RelayOnce after the backoff: sent %d events, want %d", sent, len(waiting))
+	}
+	return nil
+}
+
+// clock is the relay's clock, moved forward by the checks instead of waiting for backoffs
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Now()}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// fakePublisher records the IDs of the events it publishes by trip
+type fakePublisher struct {
+	mu        sync.Mutex
+	published map[string][]string
+	failing   map[string]bool
+}
+
+func newFakePublisher() *fakePublisher {
+	return &fakePublisher{published: make(map[string][]string), failing: make(map[string]bool)}
+}
+
+func (p *fakePublisher) SendAndWait(ctx context.Context, env *contracts.EventEnvelope, timeout time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	eventID := env.ID
+	if p.failing[eventID] {
+		return errors.New("broker unavailable")
+	}
+	// The suite's events are keyed by their trip
+	p.published[env.EntityID] = append(p.published[env.EntityID], eventID)
+	return nil
+}
+
+func (p *fakePublisher) fail(eventID string, failing bool) {
+	p.mu.Lock()
+	p.failing[eventID] = failing
+	p.mu.Unlock()
+}
+
+func (p *fakePublisher) sent(tripID string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.published[tripID])
+}
+
+func newRelay(store repo.Outbox, publisher outbox.Publisher, clock *clock, batchSize int) *outbox.Relay {
+	return outbox.NewRelay(store, publisher, &outbox.Config{
+		Interval:    time.Second,
+		BatchSize:   batchSize,
+		SendTimeout: time.Second,
+		MinBackoff:  minBackoff,
+		MaxBackoff:  time.Minute,
+		Retention:   time.Hour,
+		Now:         clock.Now,
+	})
+}
+
+// addEvents saves count events of the trip a millisecond apart, from the clock's current time
+func addEvents(ctx context.Context, store repo.Outbox, clock *clock, tripID string, count int) ([]*types.OutboxEvent, error) {
+	start := clock.Now().Truncate(time.Millisecond)
+	events := make([]*types.OutboxEvent, count)
+	for i := range events {
+		id := primitive.NewObjectID()
+		createdAt := start.Add(time.Duration(i) * time.Millisecond)
+		events[i] = &types.OutboxEvent{
+			ID:            id,
+			TripID:        tripID,
+			Topic:         contracts.TripEventCreated,
+			EntityID:      tripID,
+			SchemaVersion: 1,
+			Data:          []byte(`{}`),
+			CreatedAt:     createdAt,
+			NextAttemptAt: start,
+		}
+	}
+	if err := store.AddOutboxEvents(ctx, events...); err != nil {
+		return nil, fmt.Errorf("AddOutboxEvents: %w", err)
+	}
+	return events, nil
+}
+
+func ids(events []*types.OutboxEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID.Hex()
+	}
+	return ids
+}
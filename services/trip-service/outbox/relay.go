@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+)
+
+// Store is the outbox the relay publishes from, implemented by the trip repo
+type Store interface {
+	ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*types.OutboxEvent, error)
+	MarkOutboxEventSent(ctx context.Context, eventID string, sentAt time.Time) error
+	MarkOutboxEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error
+	DeleteSentOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Publisher sends an envelope and waits for Kafka to acknowledge it
+type Publisher interface {
+	SendAndWait(ctx context.Context, env *contracts.EventEnvelope, timeout time.Duration) error
+}
+
+type Config struct {
+	Interval    time.Duration // how often the outbox is checked for pending events
+	BatchSize   int           // most events published per check
+	SendTimeout time.Duration // how long Kafka has to acknowledge an event
+	MinBackoff  time.Duration // delay before the first retry of an event, doubled after every failure
+	MaxBackoff  time.Duration
+	Retention   time.Duration    // how long sent events are kept
+	Now         func() time.Time // clock deciding when events are due, time.Now if nil
+}
+
+// Relay publishes the events saved in the outbox. Events are delivered at least once: one
+// published just before the relay stops may be published again.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	config    *Config
+}
+
+// NewRelay creates a relay publishing the store's pending events
+func NewRelay(store Store, publisher Publisher, config *Config) *Relay {
+	return &Relay{store: store, publisher: publisher, config: config}
+}
+
+func (r *Relay) now() time.Time {
+	if r.config.Now != nil {
+		return r.config.Now()
+	}
+	return time.Now()
+}
+
+// Run publishes pending events and deletes sent ones every interval until the context is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := r.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to relay outbox events: %v", err)
+			}
+			if _, err := r.store.DeleteSentOutboxEvents(ctx, now.Add(-r.config.Retention)); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to delete sent outbox events: %v", err)
+			}
+		}
+	}
+}
+
+// RelayOnce publishes a batch of pending events in the order they were saved and returns how many
+// were sent. An event that fails is retried with a growing backoff, and the later events of its
+// trip wait for it so that consumers see the trip's changes in order. Trips waiting for a retry
+// are left out of the batch, so they cannot hold back the events of other trips.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	now := r.now()
+	events, err := r.store.ListPendingOutboxEvents(ctx, now, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	blocked := make(map[string]bool)
+	sent := 0
+	for _, e := range events {
+		if blocked[e.TripID] {
+			continue
+		}
+		if e.NextAttemptAt.After(now) {
+			blocked[e.TripID] = true
+			continue
+		}
+
+		if err := r.publisher.SendAndWait(ctx, e.Envelope(), r.config.SendTimeout); err != nil {
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+			blocked[e.TripID] = true
+
+			retryAt := r.now().Add(r.backoff(e.Attempts + 1))
+			log.Printf("Failed to publish %s event %s of trip %s (attempt %d), retrying at %s: %v",
+				e.Topic, e.ID.Hex(), e.TripID, e.Attempts+1, retryAt.Format(time.RFC3339), err)
+			if err := r.store.MarkOutboxEventFailed(ctx, e.ID.Hex(), err.Error(), retryAt); err != nil {
+				return sent, fmt.Errorf("failed to record failed attempt of event %s: %w", e.ID.Hex(), err)
+			}
+			continue
+		}
+
+		if err := r.store.MarkOutboxEventSent(ctx, e.ID.Hex(), r.now()); err != nil {
+			return sent, fmt.Errorf("failed to mark event %s sent: %w", e.ID.Hex(), err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// backoff returns the delay before the given attempt of an event
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.config.MinBackoff
+	for i := 1; i < attempt && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.config.MaxBackoff)
+}
@@ -0,0 +1,27 @@
+package outbox_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox/outboxtest"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestRelay(t *testing.T) {
+	if err := outboxtest.TestRelay(t.Context(), repo.NewInMemoRepository()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoRelay runs the suite over the outbox of a trip repo on the MongoDB at MONGODB_URI
+func TestMongoRelay(t *testing.T) {
+	r, err := repo.NewMongoRepository(t.Context(), dbtest.Database(t, "outboxtest"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outboxtest.TestRelay(t.Context(), r); err != nil {
+		t.Fatal(err)
+	}
+}
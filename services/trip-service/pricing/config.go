@@ -0,0 +1,127 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PackagePricing holds the fare components for a car package.
+// All amounts are integer minor units of the configured currency (e.g. paise).
+type PackagePricing struct {
+	Slug        string `json:"slug" yaml:"slug"`
+	BaseFare    int64  `json:"baseFare" yaml:"baseFare"`
+	PerKm       int64  `json:"perKm" yaml:"perKm"`
+	PerMinute   int64  `json:"perMinute" yaml:"perMinute"`
+	MinimumFare int64  `json:"minimumFare" yaml:"minimumFare"`
+	BookingFee  int64  `json:"bookingFee" yaml:"bookingFee"`
+}
+
+// CityPricing overrides package pricing for pickups inside a city.
+// A pickup belongs to the city when its geohash starts with one of the prefixes.
+type CityPricing struct {
+	Name            string            `json:"name" yaml:"name"`
+	GeohashPrefixes []string          `json:"geohashPrefixes" yaml:"geohashPrefixes"`
+	Packages        []*PackagePricing `json:"packages" yaml:"packages"`
+}
+
+// Config is the complete pricing configuration
+type Config struct {
+	Currency string            `json:"currency" yaml:"currency"`
+	Packages []*PackagePricing `json:"packages" yaml:"packages"`
+	Cities   []*CityPricing    `json:"cities" yaml:"cities"`
+}
+
+// DefaultConfig returns the built-in pricing used when no config file is provided
+func DefaultConfig() *Config {
+	return &Config{
+		Currency: "INR",
+		Packages: []*PackagePricing{
+			{Slug: "bike", BaseFare: 5000, PerKm: 1000, PerMinute: 200, MinimumFare: 6000},
+			{Slug: "auto", BaseFare: 7000, PerKm: 1000, PerMinute: 200, MinimumFare: 8000},
+			{Slug: "sedan", BaseFare: 10000, PerKm: 1000, PerMinute: 200, MinimumFare: 12000},
+			{Slug: "suv", BaseFare: 15000, PerKm: 1000, PerMinute: 200, MinimumFare: 18000},
+		},
+	}
+}
+
+// LoadConfig reads a pricing configuration from a YAML or JSON file, chosen by extension
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing config: %w", err)
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported pricing config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pricing config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the configuration is complete and consistent
+func (c *Config) Validate() error {
+	if c.Currency == "" {
+		return fmt.Errorf("pricing config: currency is required")
+	}
+	if len(c.Packages) == 0 {
+		return fmt.Errorf("pricing config: at least one package is required")
+	}
+	if err := validatePackages(c.Packages); err != nil {
+		return fmt.Errorf("pricing config: %w", err)
+	}
+
+	defaults := make(map[string]bool, len(c.Packages))
+	for _, p := range c.Packages {
+		defaults[p.Slug] = true
+	}
+
+	for _, city := range c.Cities {
+		if city.Name == "" || len(city.GeohashPrefixes) == 0 {
+			return fmt.Errorf("pricing config: every city needs a name and at least one geohash prefix")
+		}
+		if err := validatePackages(city.Packages); err != nil {
+			return fmt.Errorf("pricing config: city %s: %w", city.Name, err)
+		}
+		for _, p := range city.Packages {
+			if !defaults[p.Slug] {
+				return fmt.Errorf("pricing config: city %s overrides unknown package %q", city.Name, p.Slug)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePackages(packages []*PackagePricing) error {
+	seen := make(map[string]bool, len(packages))
+	for _, p := range packages {
+		if p.Slug == "" {
+			return fmt.Errorf("package slug is required")
+		}
+		if seen[p.Slug] {
+			return fmt.Errorf("duplicate package %q", p.Slug)
+		}
+		seen[p.Slug] = true
+
+		if p.BaseFare < 0 || p.PerKm < 0 || p.PerMinute < 0 || p.MinimumFare < 0 || p.BookingFee < 0 {
+			return fmt.Errorf("package %q has a negative amount", p.Slug)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package pricing
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/mmcloughlin/geohash"
+)
+
+// Engine prices trips from a pricing configuration that can be swapped at runtime
+type Engine struct {
+	cfg atomic.Pointer[Config]
+}
+
+// NewEngine creates a pricing engine for the given configuration
+func NewEngine(cfg *Config) (*Engine, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Engine{}
+	e.cfg.Store(cfg)
+	return e, nil
+}
+
+// NewEngineFromFile creates a pricing engine from a config file,
+// falling back to DefaultConfig when path is empty
+func NewEngineFromFile(path string) (*Engine, error) {
+	if path == "" {
+		return NewEngine(DefaultConfig())
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(cfg)
+}
+
+// Currency returns the currency all amounts are expressed in
+func (e *Engine) Currency() string {
+	return e.cfg.Load().Currency
+}
+
+// ResolveCity returns the name of the city containing the coordinate, or "" when none matches
+func (e *Engine) ResolveCity(c *sharedtypes.Coordinate) string {
+	hash := geohash.Encode(c.Latitude, c.Longitude)
+	for _, city := range e.cfg.Load().Cities {
+		for _, prefix := range city.GeohashPrefixes {
+			if strings.HasPrefix(hash, prefix) {
+				return city.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Packages returns the pricing of every package in the city, applying its overrides to the defaults
+func (e *Engine) Packages(city string) []*PackagePricing {
+	cfg := e.cfg.Load()
+
+	overrides := map[string]*PackagePricing{}
+	for _, c := range cfg.Cities {
+		if c.Name == city {
+			for _, p := range c.Packages {
+				overrides[p.Slug] = p
+			}
+			break
+		}
+	}
+
+	packages := make([]*PackagePricing, len(cfg.Packages))
+	for i, p := range cfg.Packages {
+		if override, ok := overrides[p.Slug]; ok {
+			p = override
+		}
+		packages[i] = p
+	}
+	return packages
+}
+
+// Package returns the pricing of a single package in the city, or nil when the package is unknown
+func (e *Engine) Package(city, slug string) *PackagePricing {
+	for _, p := range e.Packages(city) {
+		if p.Slug == slug {
+			return p
+		}
+	}
+	return nil
+}
+
+// Quote computes the fare in minor units for travelling distanceMeters in durationSeconds
+func Quote(p *PackagePricing, distanceMeters, durationSeconds float64) int64 {
+	distanceFare := int64(math.Round(float64(p.PerKm) * distanceMeters / 1000))
+	durationFare := int64(math.Round(float64(p.PerMinute) * durationSeconds / 60))
+
+	fare := max(p.BaseFare+distanceFare+durationFare, p.MinimumFare)
+	return fare + p.BookingFee
+}
+
+// Reload replaces the configuration with the contents of the file.
+// The current configuration is kept if the file cannot be loaded.
+func (e *Engine) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	e.cfg.Store(cfg)
+	return nil
+}
+
+// Watch polls the config file every interval and reloads it whenever it changes, until ctx is done
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Printf("Failed to stat pricing config: %v", err)
+				continue
+			}
+			if !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			if err := e.Reload(path); err != nil {
+				log.Printf("Failed to reload pricing config, keeping previous: %v", err)
+				continue
+			}
+			log.Printf("Reloaded pricing config from %s", path)
+		}
+	}
+}
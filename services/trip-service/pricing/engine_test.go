@@ -0,0 +1,269 @@
+package pricing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/mmcloughlin/geohash"
+)
+
+var (
+	bangalore = &sharedtypes.Coordinate{Latitude: 12.9716, Longitude: 77.5946}
+	delhi     = &sharedtypes.Coordinate{Latitude: 28.6139, Longitude: 77.2090}
+)
+
+func TestQuote(t *testing.T) {
+	sedan := &PackagePricing{Slug: "sedan", BaseFare: 10000, PerKm: 1000, PerMinute: 200, MinimumFare: 12000}
+
+	tests := []struct {
+		name            string
+		pricing         *PackagePricing
+		distanceMeters  float64
+		durationSeconds float64
+		want            int64
+	}{
+		{name: "distance and duration", pricing: sedan, distanceMeters: 5000, durationSeconds: 600, want: 10000 + 5000 + 2000},
+		{name: "minimum fare", pricing: sedan, distanceMeters: 500, durationSeconds: 60, want: 12000},
+		{name: "zero trip", pricing: sedan, want: 12000},
+		{name: "rounded to the nearest minor unit", pricing: sedan, distanceMeters: 5555, durationSeconds: 601, want: 10000 + 5555 + 2003},
+		{
+			name:            "booking fee on top of the minimum",
+			pricing:         &PackagePricing{Slug: "bike", BaseFare: 5000, PerKm: 1000, MinimumFare: 6000, BookingFee: 500},
+			distanceMeters:  100,
+			durationSeconds: 30,
+			want:            6500,
+		},
+		{
+			name:           "booking fee on top of the metered fare",
+			pricing:        &PackagePricing{Slug: "bike", BaseFare: 5000, PerKm: 1000, MinimumFare: 6000, BookingFee: 500},
+			distanceMeters: 3000,
+			want:           8500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Quote(tt.pricing, tt.distanceMeters, tt.durationSeconds); got != tt.want {
+				t.Errorf("Quote() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "default config", modify: func(*Config) {}},
+		{name: "city override", modify: func(c *Config) {
+			c.Cities = []*CityPricing{{Name: "bangalore", GeohashPrefixes: []string{"tdr"}, Packages: []*PackagePricing{{Slug: "sedan", BaseFare: 1}}}}
+		}},
+		{name: "missing currency", modify: func(c *Config) { c.Currency = "" }, wantErr: "currency is required"},
+		{name: "no packages", modify: func(c *Config) { c.Packages = nil }, wantErr: "at least one package"},
+		{name: "missing slug", modify: func(c *Config) { c.Packages[0].Slug = "" }, wantErr: "slug is required"},
+		{name: "duplicate package", modify: func(c *Config) { c.Packages[1].Slug = c.Packages[0].Slug }, wantErr: "duplicate package"},
+		{name: "negative amount", modify: func(c *Config) { c.Packages[0].PerKm = -1 }, wantErr: "negative amount"},
+		{name: "city without prefixes", modify: func(c *Config) {
+			c.Cities = []*CityPricing{{Name: "bangalore"}}
+		}, wantErr: "geohash prefix"},
+		{name: "city overriding an unknown package", modify: func(c *Config) {
+			c.Cities = []*CityPricing{{Name: "bangalore", GeohashPrefixes: []string{"tdr"}, Packages: []*PackagePricing{{Slug: "rickshaw"}}}}
+		}, wantErr: "unknown package"},
+		{name: "city with a negative amount", modify: func(c *Config) {
+			c.Cities = []*CityPricing{{Name: "bangalore", GeohashPrefixes: []string{"tdr"}, Packages: []*PackagePricing{{Slug: "sedan", BookingFee: -1}}}}
+		}, wantErr: "city bangalore"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{name: "yaml", file: "pricing.yaml", content: "currency: INR\npackages:\n  - slug: sedan\n    baseFare: 100\n"},
+		{name: "yml", file: "pricing.yml", content: "currency: INR\npackages:\n  - slug: sedan\n    baseFare: 100\n"},
+		{name: "json", file: "pricing.json", content: `{"currency": "INR", "packages": [{"slug": "sedan", "baseFare": 100}]}`},
+		{name: "unsupported extension", file: "pricing.toml", content: `currency = "INR"`, wantErr: true},
+		{name: "malformed", file: "pricing.json", content: `{"currency": `, wantErr: true},
+		{name: "invalid", file: "pricing.yaml", content: "currency: INR\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadConfig() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() = %v", err)
+			}
+			if cfg.Currency != "INR" || len(cfg.Packages) != 1 || cfg.Packages[0].BaseFare != 100 {
+				t.Errorf("LoadConfig() = %+v, want INR with a sedan at base fare 100", cfg)
+			}
+		})
+	}
+}
+
+func TestCityPricing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cities = []*CityPricing{{
+		Name:            "bangalore",
+		GeohashPrefixes: []string{geohash.EncodeWithPrecision(bangalore.Latitude, bangalore.Longitude, 4)},
+		Packages:        []*PackagePricing{{Slug: "sedan", BaseFare: 20000, MinimumFare: 25000}},
+	}}
+	e, err := NewEngine(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pickup   *sharedtypes.Coordinate
+		city     string
+		slug     string
+		baseFare int64
+	}{
+		{name: "overridden package", pickup: bangalore, city: "bangalore", slug: "sedan", baseFare: 20000},
+		{name: "package without an override", pickup: bangalore, city: "bangalore", slug: "suv", baseFare: 15000},
+		{name: "outside every city", pickup: delhi, city: "", slug: "sedan", baseFare: 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			city := e.ResolveCity(tt.pickup)
+			if city != tt.city {
+				t.Fatalf("ResolveCity() = %q, want %q", city, tt.city)
+			}
+			p := e.Package(city, tt.slug)
+			if p == nil || p.BaseFare != tt.baseFare {
+				t.Errorf("Package(%q, %q) = %+v, want base fare %d", city, tt.slug, p, tt.baseFare)
+			}
+		})
+	}
+
+	if p := e.Package("bangalore", "rickshaw"); p != nil {
+		t.Errorf("Package() of an unknown package = %+v, want nil", p)
+	}
+	if got := len(e.Packages("bangalore")); got != len(cfg.Packages) {
+		t.Errorf("Packages() returned %d packages, want %d", got, len(cfg.Packages))
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	writeConfig(t, path, "currency: INR\npackages:\n  - slug: sedan\n    baseFare: 100\n", time.Now().Add(-time.Hour))
+
+	e, err := NewEngineFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig(t, path, "currency: INR\npackages:\n  - slug: sedan\n    baseFare: -1\n", time.Now())
+	if err := e.Reload(path); err == nil {
+		t.Fatal("Reload() of an invalid config succeeded, want an error")
+	}
+	if got := e.Package("", "sedan").BaseFare; got != 100 {
+		t.Errorf("base fare after a failed reload = %d, want the previous 100", got)
+	}
+
+	writeConfig(t, path, "currency: USD\npackages:\n  - slug: sedan\n    baseFare: 200\n", time.Now())
+	if err := e.Reload(path); err != nil {
+		t.Fatalf("Reload() = %v", err)
+	}
+	if got := e.Package("", "sedan").BaseFare; got != 200 || e.Currency() != "USD" {
+		t.Errorf("after reload: base fare %d in %s, want 200 in USD", got, e.Currency())
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	writeConfig(t, path, "currency: INR\npackages:\n  - slug: sedan\n    baseFare: 100\n", time.Now().Add(-time.Hour))
+
+	e, err := NewEngineFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		e.Watch(ctx, path, time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Watch takes the file's initial modification time in its own goroutine, so the changes are
+	// dated further into the future until one is picked up. The invalid change before each valid
+	// one is skipped.
+	modified := time.Now().Add(time.Hour)
+	deadline := time.Now().Add(time.Second)
+	for e.Package("", "sedan").BaseFare != 300 {
+		if time.Now().After(deadline) {
+			t.Fatal("config change not picked up after a second")
+		}
+		modified = modified.Add(time.Minute)
+		writeConfig(t, path, "currency: INR\n", modified)
+		modified = modified.Add(time.Minute)
+		writeConfig(t, path, "currency: INR\npackages:\n  - slug: sedan\n    baseFare: 300\n", modified)
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewEngineFromFileDefault(t *testing.T) {
+	e, err := NewEngineFromFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Currency() != DefaultConfig().Currency || e.Package("", "sedan") == nil {
+		t.Errorf("engine without a config file does not use DefaultConfig")
+	}
+}
+
+// writeConfig writes the config file with the given modification time, so Watch sees every change
+// regardless of the file system's timestamp resolution
+func writeConfig(t *testing.T, path, content string, modified time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,371 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tripsCollection     = "trips"
+	rideFaresCollection = "ride_fares"
+	outboxCollection    = "trip_outbox"
+)
+
+type mongoRepo struct {
+	client    *mongo.Client
+	trips     *mongo.Collection
+	rideFares *mongo.Collection
+	outbox    *mongo.Collection
+}
+
+// NewMongoRepository creates a MongoDB backed TripRepo and ensures its indexes exist.
+// Ride fares are removed by MongoDB once they are older than fareRetention.
+// Transactions need MongoDB to run as a replica set.
+func NewMongoRepository(ctx context.Context, db *mongo.Database, fareRetention time.Duration) (*mongoRepo, error) {
+	r := &mongoRepo{
+		client:    db.Client(),
+		trips:     db.Collection(tripsCollection),
+		rideFares: db.Collection(rideFaresCollection),
+		outbox:    db.Collection(outboxCollection),
+	}
+
+	if _, err := r.trips.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "riderID", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "driver.id", Value: 1}, {Key: "status", Value: 1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create trip indexes: %w", err)
+	}
+
+	if _, err := r.rideFares.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(fareRetention.Seconds())),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create ride fare indexes: %w", err)
+	}
+
+	// Also creates the collection, which MongoDB cannot do inside a transaction before 4.4
+	if _, err := r.outbox.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sentAt", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "sentAt", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create outbox indexes: %w", err)
+	}
+
+	return r, nil
+}
+
+// GetByID retrieves a trip by its ID
+func (r *mongoRepo) GetByID(ctx context.Context, tripID string) (*types.TripModel, error) {
+	id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var trip types.TripModel
+	if err := r.trips.FindOne(ctx, bson.M{"_id": id}).Decode(&trip); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+	return &trip, nil
+}
+
+// GetActiveTripByDriverID retrieves the trip the driver is currently assigned to
+func (r *mongoRepo) GetActiveTripByDriverID(ctx context.Context, driverID string) (*types.TripModel, error) {
+	filter := bson.M{
+		"driver.id": driverID,
+		"status":    bson.M{"$in": types.ActiveTripStatuses},
+	}
+
+	var trip types.TripModel
+	if err := r.trips.FindOne(ctx, filter).Decode(&trip); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get active trip: %w", err)
+	}
+	return &trip, nil
+}
+
+// Create inserts a new trip
+func (r *mongoRepo) Create(ctx context.Context, trip *types.TripModel) (*types.TripModel, error) {
+	if _, err := r.trips.InsertOne(ctx, trip); err != nil {
+		return nil, fmt.Errorf("failed to insert trip: %w", err)
+	}
+	return trip, nil
+}
+
+// SaveRideFare inserts a ride fare
+func (r *mongoRepo) SaveRideFare(ctx context.Context, fare *types.RideFareModel) error {
+	if _, err := r.rideFares.InsertOne(ctx, fare); err != nil {
+		return fmt.Errorf("failed to insert ride fare: %w", err)
+	}
+	return nil
+}
+
+// GetRideFareByID retrieves a ride fare by its ID
+func (r *mongoRepo) GetRideFareByID(ctx context.Context, fareID string) (*types.RideFareModel, error) {
+	id, err := primitive.ObjectIDFromHex(fareID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var fare types.RideFareModel
+	if err := r.rideFares.FindOne(ctx, bson.M{"_id": id}).Decode(&fare); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get ride fare: %w", err)
+	}
+	return &fare, nil
+}
+
+// ConsumeRideFare marks an unexpired, unused ride fare as used so it cannot create another trip
+func (r *mongoRepo) ConsumeRideFare(ctx context.Context, fareID string, now time.Time) (*types.RideFareModel, error) {
+	id, err := primitive.ObjectIDFromHex(fareID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var fare types.RideFareModel
+	err = r.rideFares.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":        id,
+			"consumedAt": bson.M{"$exists": false},
+			"expiresAt":  bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"consumedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&fare)
+	if err == nil {
+		return &fare, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to consume ride fare: %w", err)
+	}
+
+	// Nothing matched, so find out why
+	existing, err := r.GetRideFareByID(ctx, fareID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ConsumedAt != nil {
+		return nil, ErrFareConsumed
+	}
+	return nil, ErrFareExpired
+}
+
+// DeleteExpiredRideFares removes ride fares that expired before the given time, and were not used after consumedBefore
+func (r *mongoRepo) DeleteExpiredRideFares(ctx context.Context, before, consumedBefore time.Time) (int64, error) {
+	res, err := r.rideFares.DeleteMany(ctx, bson.M{
+		"expiresAt": bson.M{"$lte": before},
+		"$or": bson.A{
+			bson.M{"consumedAt": nil},
+			bson.M{"consumedAt": bson.M{"$lt": consumedBefore}},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired ride fares: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+// UpdateWithDriver assigns the driver to a pending trip and moves it to "driver_assigned".
+// A trip that already has a driver is left untouched and an ErrInvalidTransition is returned.
+func (r *mongoRepo) UpdateWithDriver(ctx context.Context, tripID string, driver *pbd.TripDriver) (*types.TripModel, error) {
+	return r.transition(ctx, tripID, "", types.TripStatusDriverAssigned, bson.M{"driver": driver})
+}
+
+// UpdateStatus moves a trip to the given status if the transition is allowed
+func (r *mongoRepo) UpdateStatus(ctx context.Context, tripID string, status types.TripStatus) (*types.TripModel, error) {
+	return r.transition(ctx, tripID, "", status, nil)
+}
+
+// Cancel moves the trip from the status from to "cancelled" if the transition is allowed and records the cancellation.
+// A trip that is no longer in from is left untouched and an ErrStatusChanged is returned.
+func (r *mongoRepo) Cancel(ctx context.Context, tripID string, from types.TripStatus, cancellation *types.TripCancellation) (*types.TripModel, error) {
+	return r.transition(ctx, tripID, from, types.TripStatusCancelled, bson.M{"cancellation": cancellation})
+}
+
+// Complete moves the trip to "completed" if the transition is allowed and records its final fare
+func (r *mongoRepo) Complete(ctx context.Context, tripID string, fare *types.TripFinalFare) (*types.TripModel, error) {
+	return r.transition(ctx, tripID, "", types.TripStatusCompleted, bson.M{"finalFare": fare})
+}
+
+// AppendLocation adds the location to the trip's trail while the trip is in progress
+func (r *mongoRepo) AppendLocation(ctx context.Context, tripID string, location *types.TripLocation) error {
+	id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	res, err := r.trips.UpdateOne(ctx,
+		bson.M{"_id": id, "status": types.TripStatusInProgress},
+		bson.M{"$push": bson.M{"locationTrail": location}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append trip location: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, tripID); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: trip %s is not in progress", types.ErrInvalidTransition, tripID)
+	}
+	return nil
+}
+
+// transition validates the status change against the stored trip and applies it together with
+// the extra fields in set. The update only matches while the trip is still in the status it was
+// read in, so a concurrent transition makes this one fail instead of overwriting it.
+// A non-empty expected status must also be the stored one.
+func (r *mongoRepo) transition(ctx context.Context, tripID string, expected, status types.TripStatus, set bson.M) (*types.TripModel, error) {
+	trip, err := r.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := trip.Status
+	if expected != "" && from != expected {
+		return nil, fmt.Errorf("%w: %w: trip %s is %s, not %s", types.ErrInvalidTransition, ErrStatusChanged, tripID, from, expected)
+	}
+	if err := trip.Transition(status, time.Now()); err != nil {
+		return nil, err
+	}
+	change := trip.StatusHistory[len(trip.StatusHistory)-1]
+
+	fields := bson.M{"status": status}
+	for k, v := range set {
+		fields[k] = v
+	}
+
+	res, err := r.trips.UpdateOne(ctx,
+		bson.M{"_id": trip.ID, "status": from},
+		bson.M{
+			"$set":  fields,
+			"$push": bson.M{"statusHistory": change},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update trip: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, fmt.Errorf("%w: %w: trip %s changed status concurrently", types.ErrInvalidTransition, ErrStatusChanged, tripID)
+	}
+
+	return r.GetByID(ctx, tripID)
+}
+
+// WithTransaction runs fn in a MongoDB transaction. fn may run more than once if the
+// transaction hits a transient error.
+func (r *mongoRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// AddOutboxEvents inserts the events
+func (r *mongoRepo) AddOutboxEvents(ctx context.Context, events ...*types.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	docs := make([]any, len(events))
+	for i, e := range events {
+		docs[i] = e
+	}
+	if _, err := r.outbox.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert outbox events: %w", err)
+	}
+	return nil
+}
+
+// ListPendingOutboxEvents returns up to limit unsent events, oldest first, leaving out the trips
+// whose oldest unsent event is waiting to be retried after now
+func (r *mongoRepo) ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*types.OutboxEvent, error) {
+	// The relay only attempts the oldest unsent event of a trip, so a trip waits for a retry
+	// exactly when one of its unsent events is due after now
+	waiting, err := r.outbox.Distinct(ctx, "tripID", bson.M{"sentAt": nil, "nextAttemptAt": bson.M{"$gt": now}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trips waiting for a retry: %w", err)
+	}
+
+	filter := bson.M{"sentAt": nil}
+	if len(waiting) > 0 {
+		filter["tripID"] = bson.M{"$nin": waiting}
+	}
+	cursor, err := r.outbox.Find(ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending outbox events: %w", err)
+	}
+
+	var events []*types.OutboxEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox events: %w", err)
+	}
+	return events, nil
+}
+
+// MarkOutboxEventSent records that the event was published
+func (r *mongoRepo) MarkOutboxEventSent(ctx context.Context, eventID string, sentAt time.Time) error {
+	return r.updateOutboxEvent(ctx, eventID, bson.M{
+		"$set": bson.M{"sentAt": sentAt},
+		"$inc": bson.M{"attempts": 1},
+	})
+}
+
+// MarkOutboxEventFailed records a failed publish attempt and when the event may be retried
+func (r *mongoRepo) MarkOutboxEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error {
+	return r.updateOutboxEvent(ctx, eventID, bson.M{
+		"$set": bson.M{"lastError": lastError, "nextAttemptAt": nextAttemptAt},
+		"$inc": bson.M{"attempts": 1},
+	})
+}
+
+// DeleteSentOutboxEvents removes events published before the given time
+func (r *mongoRepo) DeleteSentOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.outbox.DeleteMany(ctx, bson.M{"sentAt": bson.M{"$lte": before}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox events: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *mongoRepo) updateOutboxEvent(ctx context.Context, eventID string, update bson.M) error {
+	id, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	res, err := r.outbox.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox event: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
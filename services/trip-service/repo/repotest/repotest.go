@@ -0,0 +1,620 @@
+// Package repotest implements a contract suite shared by every repo.TripRepo implementation.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestTripRepo exercises the behaviour every TripRepo must provide.
+// It only writes records with fresh IDs, so it can run against a shared database.
+// It returns the first contract violation found, or nil.
+func TestTripRepo(ctx context.Context, r repo.TripRepo) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context, repo.TripRepo) error
+	}{
+		{"missing records", testMissingRecords},
+		{"ride fare round trip", testRideFareRoundTrip},
+		{"ride fare consumption", testRideFareConsumption},
+		{"expired ride fare purge", testExpiredRideFarePurge},
+		{"trip round trip", testTripRoundTrip},
+		{"driver assignment", testDriverAssignment},
+		{"active trip by driver", testActiveTripByDriver},
+		{"status transitions", testStatusTransitions},
+		{"cancellation", testCancellation},
+		{"completion", testCompletion},
+		{"outbox transaction", testOutboxTransaction},
+		{"outbox delivery", testOutboxDelivery},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(ctx, r); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func testMissingRecords(ctx context.Context, r repo.TripRepo) error {
+	missingID := primitive.NewObjectID().Hex()
+
+	if _, err := r.GetByID(ctx, missingID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetByID of unknown trip: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetRideFareByID(ctx, missingID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetRideFareByID of unknown fare: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.UpdateWithDriver(ctx, missingID, &pb.TripDriver{Id: "driver"}); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("UpdateWithDriver of unknown trip: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.UpdateStatus(ctx, missingID, types.TripStatusCancelled); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("UpdateStatus of unknown trip: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func testRideFareRoundTrip(ctx context.Context, r repo.TripRepo) error {
+	fare := newRideFare()
+	if err := r.SaveRideFare(ctx, fare); err != nil {
+		return fmt.Errorf("SaveRideFare: %w", err)
+	}
+
+	got, err := r.GetRideFareByID(ctx, fare.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("GetRideFareByID: %w", err)
+	}
+	if got.ID != fare.ID || got.RiderID != fare.RiderID || got.PackageSlug != fare.PackageSlug {
+		return fmt.Errorf("GetRideFareByID: got %+v, want %+v", got, fare)
+	}
+	if got.TotalFareInPaise != fare.TotalFareInPaise {
+		return fmt.Errorf("GetRideFareByID: got fare %v, want %v", got.TotalFareInPaise, fare.TotalFareInPaise)
+	}
+	if got.Route == nil || len(got.Route.Routes) != len(fare.Route.Routes) {
+		return fmt.Errorf("GetRideFareByID: route was not preserved")
+	}
+	return nil
+}
+
+func testRideFareConsumption(ctx context.Context, r repo.TripRepo) error {
+	fare := newRideFare()
+	if err := r.SaveRideFare(ctx, fare); err != nil {
+		return fmt.Errorf("SaveRideFare: %w", err)
+	}
+
+	now := time.Now()
+	consumed, err := r.ConsumeRideFare(ctx, fare.ID.Hex(), now)
+	if err != nil {
+		return fmt.Errorf("ConsumeRideFare: %w", err)
+	}
+	if consumed.ConsumedAt == nil {
+		return fmt.Errorf("ConsumeRideFare: fare was not marked consumed")
+	}
+
+	if _, err := r.ConsumeRideFare(ctx, fare.ID.Hex(), now); !errors.Is(err, repo.ErrFareConsumed) {
+		return fmt.Errorf("second ConsumeRideFare: got %v, want ErrFareConsumed", err)
+	}
+
+	got, err := r.GetRideFareByID(ctx, fare.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("GetRideFareByID: %w", err)
+	}
+	if got.ConsumedAt == nil {
+		return fmt.Errorf("GetRideFareByID: consumption was not persisted")
+	}
+
+	expired := newRideFare()
+	expired.ExpiresAt = now.Add(-time.Second)
+	if err := r.SaveRideFare(ctx, expired); err != nil {
+		return fmt.Errorf("SaveRideFare: %w", err)
+	}
+	if _, err := r.ConsumeRideFare(ctx, expired.ID.Hex(), now); !errors.Is(err, repo.ErrFareExpired) {
+		return fmt.Errorf("ConsumeRideFare of expired fare: got %v, want ErrFareExpired", err)
+	}
+
+	if _, err := r.ConsumeRideFare(ctx, primitive.NewObjectID().Hex(), now); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("ConsumeRideFare of unknown fare: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func testExpiredRideFarePurge(ctx context.Context, r repo.TripRepo) error {
+	now := time.Now()
+
+	expired := newRideFare()
+	expired.ExpiresAt = now.Add(-time.Minute)
+	fresh := newRideFare()
+	usedRecently := newRideFare()
+	usedRecently.ExpiresAt = now.Add(-time.Minute)
+	usedLongAgo := newRideFare()
+	usedLongAgo.ExpiresAt = now.Add(-2 * time.Hour)
+	for _, fare := range []*types.RideFareModel{expired, fresh, usedRecently, usedLongAgo} {
+		if err := r.SaveRideFare(ctx, fare); err != nil {
+			return fmt.Errorf("SaveRideFare: %w", err)
+		}
+	}
+	if _, err := r.ConsumeRideFare(ctx, usedRecently.ID.Hex(), now.Add(-2*time.Minute)); err != nil {
+		return fmt.Errorf("ConsumeRideFare: %w", err)
+	}
+	if _, err := r.ConsumeRideFare(ctx, usedLongAgo.ID.Hex(), now.Add(-3*time.Hour)); err != nil {
+		return fmt.Errorf("ConsumeRideFare: %w", err)
+	}
+
+	deleted, err := r.DeleteExpiredRideFares(ctx, now, now.Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("DeleteExpiredRideFares: %w", err)
+	}
+	if deleted < 2 {
+		return fmt.Errorf("DeleteExpiredRideFares: got %d deleted, want at least 2", deleted)
+	}
+
+	if _, err := r.GetRideFareByID(ctx, expired.ID.Hex()); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("expired fare still present: %v", err)
+	}
+	if _, err := r.GetRideFareByID(ctx, usedLongAgo.ID.Hex()); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("expired fare used before the retention still present: %v", err)
+	}
+	if _, err := r.GetRideFareByID(ctx, fresh.ID.Hex()); err != nil {
+		return fmt.Errorf("unexpired fare was purged: %v", err)
+	}
+	if _, err := r.GetRideFareByID(ctx, usedRecently.ID.Hex()); err != nil {
+		return fmt.Errorf("expired fare used within the retention was purged: %v", err)
+	}
+	return nil
+}
+
+func testTripRoundTrip(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	got, err := r.GetByID(ctx, trip.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if got.ID != trip.ID || got.RiderID != trip.RiderID || got.Status != types.TripStatusPending {
+		return fmt.Errorf("GetByID: got %+v, want %+v", got, trip)
+	}
+	if got.RideFare == nil || got.RideFare.ID != trip.RideFare.ID {
+		return fmt.Errorf("GetByID: ride fare was not preserved")
+	}
+	if len(got.StatusHistory) != 1 {
+		return fmt.Errorf("GetByID: got %d status changes, want 1", len(got.StatusHistory))
+	}
+	return nil
+}
+
+func testDriverAssignment(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	first := &pb.TripDriver{Id: "driver-1", Name: "First"}
+	updated, err := r.UpdateWithDriver(ctx, trip.ID.Hex(), first)
+	if err != nil {
+		return fmt.Errorf("UpdateWithDriver: %w", err)
+	}
+	if updated.Status != types.TripStatusDriverAssigned || updated.Driver.GetId() != first.Id {
+		return fmt.Errorf("UpdateWithDriver: got status %s driver %q", updated.Status, updated.Driver.GetId())
+	}
+
+	second := &pb.TripDriver{Id: "driver-2", Name: "Second"}
+	if _, err := r.UpdateWithDriver(ctx, trip.ID.Hex(), second); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("second UpdateWithDriver: got %v, want ErrInvalidTransition", err)
+	}
+
+	got, err := r.GetByID(ctx, trip.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if got.Driver.GetId() != first.Id {
+		return fmt.Errorf("second UpdateWithDriver overwrote driver: got %q, want %q", got.Driver.GetId(), first.Id)
+	}
+	return nil
+}
+
+func testActiveTripByDriver(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+	tripID := trip.ID.Hex()
+	driverID := "driver-" + tripID
+
+	if _, err := r.GetActiveTripByDriverID(ctx, driverID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetActiveTripByDriverID before assignment: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := r.UpdateWithDriver(ctx, tripID, &pb.TripDriver{Id: driverID}); err != nil {
+		return fmt.Errorf("UpdateWithDriver: %w", err)
+	}
+	got, err := r.GetActiveTripByDriverID(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("GetActiveTripByDriverID: %w", err)
+	}
+	if got.ID != trip.ID {
+		return fmt.Errorf("GetActiveTripByDriverID: got trip %s, want %s", got.ID.Hex(), tripID)
+	}
+
+	if _, err := r.UpdateStatus(ctx, tripID, types.TripStatusCancelled); err != nil {
+		return fmt.Errorf("UpdateStatus(cancelled): %w", err)
+	}
+	if _, err := r.GetActiveTripByDriverID(ctx, driverID); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("GetActiveTripByDriverID after cancellation: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func testStatusTransitions(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+	tripID := trip.ID.Hex()
+
+	if _, err := r.UpdateStatus(ctx, tripID, types.TripStatusCompleted); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("pending -> completed: got %v, want ErrInvalidTransition", err)
+	}
+
+	if _, err := r.UpdateWithDriver(ctx, tripID, &pb.TripDriver{Id: "driver"}); err != nil {
+		return fmt.Errorf("UpdateWithDriver: %w", err)
+	}
+
+	lifecycle := []types.TripStatus{
+		types.TripStatusDriverArrived,
+		types.TripStatusInProgress,
+		types.TripStatusCompleted,
+		types.TripStatusPaymentFailed,
+		types.TripStatusPaid,
+	}
+	for _, status := range lifecycle {
+		updated, err := r.UpdateStatus(ctx, tripID, status)
+		if err != nil {
+			return fmt.Errorf("UpdateStatus(%s): %w", status, err)
+		}
+		if updated.Status != status {
+			return fmt.Errorf("UpdateStatus(%s): got status %s", status, updated.Status)
+		}
+	}
+
+	if _, err := r.UpdateStatus(ctx, tripID, types.TripStatusCancelled); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("paid -> cancelled: got %v, want ErrInvalidTransition", err)
+	}
+
+	got, err := r.GetByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if len(got.StatusHistory) != len(lifecycle)+2 {
+		return fmt.Errorf("got %d status changes, want %d", len(got.StatusHistory), len(lifecycle)+2)
+	}
+	for i, change := range got.StatusHistory {
+		if change.ChangedAt.IsZero() {
+			return fmt.Errorf("status change %d (%s) has no timestamp", i, change.Status)
+		}
+	}
+	return nil
+}
+
+// createTrip saves a fare and a pending trip for it
+func testCancellation(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+	tripID := trip.ID.Hex()
+
+	cancellation := &types.TripCancellation{
+		By:          types.CancelledByRider,
+		RequesterID: trip.RiderID,
+		Reason:      "changed plans",
+		FeeInPaise:  5000,
+		Currency:    "inr",
+		CancelledAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if _, err := r.Cancel(ctx, tripID, types.TripStatusDriverArrived, cancellation); !errors.Is(err, repo.ErrStatusChanged) {
+		return fmt.Errorf("Cancel from a status the trip is not in: got %v, want ErrStatusChanged", err)
+	}
+	if got, err := r.GetByID(ctx, tripID); err != nil || got.Status != types.TripStatusPending {
+		return fmt.Errorf("Cancel from a status the trip is not in changed the trip: %v", err)
+	}
+
+	cancelled, err := r.Cancel(ctx, tripID, types.TripStatusPending, cancellation)
+	if err != nil {
+		return fmt.Errorf("Cancel: %w", err)
+	}
+	if cancelled.Status != types.TripStatusCancelled {
+		return fmt.Errorf("Cancel: got status %s", cancelled.Status)
+	}
+
+	got, err := r.GetByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if got.Cancellation == nil || got.Cancellation.FeeInPaise != cancellation.FeeInPaise || got.Cancellation.By != cancellation.By {
+		return fmt.Errorf("GetByID: cancellation was not preserved: %+v", got.Cancellation)
+	}
+
+	if _, err := r.Cancel(ctx, tripID, types.TripStatusCancelled, cancellation); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("second Cancel: got %v, want ErrInvalidTransition", err)
+	}
+	if _, err := r.Cancel(ctx, primitive.NewObjectID().Hex(), types.TripStatusPending, cancellation); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("Cancel of unknown trip: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func testCompletion(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+	tripID := trip.ID.Hex()
+
+	location := &types.TripLocation{Latitude: 12.97, Longitude: 77.59, RecordedAt: time.Now().UTC().Truncate(time.Millisecond)}
+	if err := r.AppendLocation(ctx, tripID, location); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("AppendLocation to pending trip: got %v, want ErrInvalidTransition", err)
+	}
+
+	if _, err := r.UpdateWithDriver(ctx, tripID, &pb.TripDriver{Id: "driver"}); err != nil {
+		return fmt.Errorf("UpdateWithDriver: %w", err)
+	}
+	for _, status := range []types.TripStatus{types.TripStatusDriverArrived, types.TripStatusInProgress} {
+		if _, err := r.UpdateStatus(ctx, tripID, status); err != nil {
+			return fmt.Errorf("UpdateStatus(%s): %w", status, err)
+		}
+	}
+
+	trail := []*types.TripLocation{
+		location,
+		{Latitude: 12.98, Longitude: 77.60, RecordedAt: location.RecordedAt.Add(time.Minute)},
+	}
+	if err := r.AppendLocation(ctx, tripID, trail[0]); err != nil {
+		return fmt.Errorf("AppendLocation: %w", err)
+	}
+	// Trips read from the repo are not changed by later updates, nor change the stored trip
+	read, err := r.GetByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	read.Status = types.TripStatusCancelled
+	read.LocationTrail[0].Latitude = 0
+	if err := r.AppendLocation(ctx, tripID, trail[1]); err != nil {
+		return fmt.Errorf("AppendLocation: %w", err)
+	}
+	if len(read.LocationTrail) != 1 {
+		return fmt.Errorf("AppendLocation: changed the trail of a trip read before, got %d locations", len(read.LocationTrail))
+	}
+
+	fare := &types.TripFinalFare{TotalFareInPaise: 12345, Currency: "inr", DistanceMeters: 1500, DurationSeconds: 60}
+	completed, err := r.Complete(ctx, tripID, fare)
+	if err != nil {
+		return fmt.Errorf("Complete: %w", err)
+	}
+	if completed.Status != types.TripStatusCompleted {
+		return fmt.Errorf("Complete: got status %s", completed.Status)
+	}
+
+	got, err := r.GetByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	if len(got.LocationTrail) != len(trail) {
+		return fmt.Errorf("GetByID: got %d trail locations, want %d", len(got.LocationTrail), len(trail))
+	}
+	if got.LocationTrail[0].Latitude != location.Latitude {
+		return fmt.Errorf("GetByID: got trail %+v, changed through a trip read before", got.LocationTrail[0])
+	}
+	if got.FinalFare == nil || *got.FinalFare != *fare {
+		return fmt.Errorf("GetByID: got final fare %+v, want %+v", got.FinalFare, fare)
+	}
+
+	if err := r.AppendLocation(ctx, tripID, location); !errors.Is(err, types.ErrInvalidTransition) {
+		return fmt.Errorf("AppendLocation to completed trip: got %v, want ErrInvalidTransition", err)
+	}
+	return nil
+}
+
+func testOutboxTransaction(ctx context.Context, r repo.TripRepo) error {
+	trip, err := createTrip(ctx, r)
+	if err != nil {
+		return err
+	}
+	tripID := trip.ID.Hex()
+
+	assigned := newOutboxEvent(tripID)
+	err = r.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := r.UpdateWithDriver(ctx, tripID, &pb.TripDriver{Id: "driver"}); err != nil {
+			return err
+		}
+		return r.AddOutboxEvents(ctx, assigned)
+	})
+	if err != nil {
+		return fmt.Errorf("WithTransaction: %w", err)
+	}
+	if got, err := r.GetByID(ctx, tripID); err != nil || got.Status != types.TripStatusDriverAssigned {
+		return fmt.Errorf("GetByID after the transaction: got %v, want the driver assigned", err)
+	}
+	if event, _, err := findPendingEvent(ctx, r, assigned.ID.Hex(), time.Now()); err != nil || event == nil {
+		return fmt.Errorf("ListPendingOutboxEvents: event of the committed transaction missing (%v)", err)
+	}
+
+	// Events added by a failed transaction are never published
+	errRollback := errors.New("rollback")
+	discarded := newOutboxEvent(tripID)
+	err = r.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.AddOutboxEvents(ctx, discarded); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		return fmt.Errorf("failed WithTransaction: got %v, want the error of the transaction", err)
+	}
+	if event, _, err := findPendingEvent(ctx, r, discarded.ID.Hex(), time.Now()); err != nil || event != nil {
+		return fmt.Errorf("ListPendingOutboxEvents: got the event of a failed transaction (%v)", err)
+	}
+	return r.MarkOutboxEventSent(ctx, assigned.ID.Hex(), time.Now())
+}
+
+func testOutboxDelivery(ctx context.Context, r repo.TripRepo) error {
+	tripID := primitive.NewObjectID().Hex()
+	first, second := newOutboxEvent(tripID), newOutboxEvent(tripID)
+	second.CreatedAt = first.CreatedAt.Add(time.Millisecond)
+	if err := r.AddOutboxEvents(ctx, first, second); err != nil {
+		return fmt.Errorf("AddOutboxEvents: %w", err)
+	}
+
+	_, firstAt, err := findPendingEvent(ctx, r, first.ID.Hex(), time.Now())
+	if err != nil {
+		return err
+	}
+	_, secondAt, err := findPendingEvent(ctx, r, second.ID.Hex(), time.Now())
+	if err != nil {
+		return err
+	}
+	if firstAt < 0 || secondAt < firstAt {
+		return fmt.Errorf("ListPendingOutboxEvents: got the events at %d and %d, want them oldest first", firstAt, secondAt)
+	}
+
+	retryAt := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	if err := r.MarkOutboxEventFailed(ctx, first.ID.Hex(), "broker down", retryAt); err != nil {
+		return fmt.Errorf("MarkOutboxEventFailed: %w", err)
+	}
+	// The trip waits for the retry, without holding back the events of other trips
+	other := newOutboxEvent(primitive.NewObjectID().Hex())
+	if err := r.AddOutboxEvents(ctx, other); err != nil {
+		return fmt.Errorf("AddOutboxEvents: %w", err)
+	}
+	for _, e := range []*types.OutboxEvent{first, second} {
+		if event, _, err := findPendingEvent(ctx, r, e.ID.Hex(), time.Now()); err != nil || event != nil {
+			return fmt.Errorf("ListPendingOutboxEvents before the retry: got an event of the waiting trip (%v)", err)
+		}
+	}
+	if event, _, err := findPendingEvent(ctx, r, other.ID.Hex(), time.Now()); err != nil || event == nil {
+		return fmt.Errorf("ListPendingOutboxEvents before the retry: event of another trip missing (%v)", err)
+	}
+	if err := r.MarkOutboxEventSent(ctx, other.ID.Hex(), time.Now()); err != nil {
+		return fmt.Errorf("MarkOutboxEventSent: %w", err)
+	}
+
+	failed, _, err := findPendingEvent(ctx, r, first.ID.Hex(), retryAt)
+	if err != nil {
+		return err
+	}
+	if failed == nil || failed.Attempts != 1 || failed.LastError != "broker down" || !failed.NextAttemptAt.Equal(retryAt) {
+		return fmt.Errorf("ListPendingOutboxEvents: got failed event %+v, want 1 attempt retried at %s", failed, retryAt)
+	}
+
+	sentAt := time.Now().Truncate(time.Millisecond)
+	if err := r.MarkOutboxEventSent(ctx, first.ID.Hex(), sentAt); err != nil {
+		return fmt.Errorf("MarkOutboxEventSent: %w", err)
+	}
+	if event, _, err := findPendingEvent(ctx, r, first.ID.Hex(), time.Now()); err != nil || event != nil {
+		return fmt.Errorf("ListPendingOutboxEvents: got the sent event (%v)", err)
+	}
+	if err := r.MarkOutboxEventSent(ctx, primitive.NewObjectID().Hex(), sentAt); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("MarkOutboxEventSent of unknown event: got %v, want ErrNotFound", err)
+	}
+
+	deleted, err := r.DeleteSentOutboxEvents(ctx, sentAt.Add(time.Millisecond))
+	if err != nil {
+		return fmt.Errorf("DeleteSentOutboxEvents: %w", err)
+	}
+	if deleted < 1 {
+		return fmt.Errorf("DeleteSentOutboxEvents: deleted %d events, want the sent one", deleted)
+	}
+	if err := r.MarkOutboxEventFailed(ctx, first.ID.Hex(), "", retryAt); !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("MarkOutboxEventFailed of a deleted event: got %v, want ErrNotFound", err)
+	}
+	if event, _, err := findPendingEvent(ctx, r, second.ID.Hex(), time.Now()); err != nil || event == nil {
+		return fmt.Errorf("DeleteSentOutboxEvents: deleted the pending event (%v)", err)
+	}
+
+	// Leave nothing pending for a relay sharing the database
+	return r.MarkOutboxEventSent(ctx, second.ID.Hex(), sentAt)
+}
+
+// findPendingEvent returns the pending event with the given ID and its position in the events
+// pending at now, or nil and -1 if it is not pending
+func findPendingEvent(ctx context.Context, r repo.TripRepo, eventID string, now time.Time) (*types.OutboxEvent, int, error) {
+	pending, err := r.ListPendingOutboxEvents(ctx, now, 1000)
+	if err != nil {
+		return nil, -1, fmt.Errorf("ListPendingOutboxEvents: %w", err)
+	}
+	for i, e := range pending {
+		if e.ID.Hex() == eventID {
+			return e, i, nil
+		}
+	}
+	return nil, -1, nil
+}
+
+func newOutboxEvent(tripID string) *types.OutboxEvent {
+	now := time.Now().Truncate(time.Millisecond)
+	return &types.OutboxEvent{
+		ID:            primitive.NewObjectID(),
+		TripID:        tripID,
+		Topic:         "trip.event.test",
+		EntityID:      "rider",
+		Data:          []byte(`{}`),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
+
+func createTrip(ctx context.Context, r repo.TripRepo) (*types.TripModel, error) {
+	fare := newRideFare()
+	if err := r.SaveRideFare(ctx, fare); err != nil {
+		return nil, fmt.Errorf("SaveRideFare: %w", err)
+	}
+
+	trip := &types.TripModel{
+		ID:      primitive.NewObjectID(),
+		RiderID: fare.RiderID,
+		Status:  types.TripStatusPending,
+		StatusHistory: []*types.TripStatusChange{
+			{Status: types.TripStatusPending, ChangedAt: time.Now()},
+		},
+		RideFare: fare,
+		Driver:   &pb.TripDriver{},
+	}
+	if _, err := r.Create(ctx, trip); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	return trip, nil
+}
+
+// newRideFare returns a ride fare with a fresh ID and a single-leg route
+func newRideFare() *types.RideFareModel {
+	route := &types.OSRMApiResponse{
+		Routes: []types.OSRMRoute{
+			{
+				Distance: 1200,
+				Duration: 300,
+				Geometry: types.OSRMGeometry{
+					Coordinates: [][]float64{{-122.41, 37.77}, {-122.42, 37.78}},
+				},
+			},
+		},
+	}
+
+	return &types.RideFareModel{
+		ID:               primitive.NewObjectID(),
+		RiderID:          "rider-" + primitive.NewObjectID().Hex(),
+		PackageSlug:      "sedan",
+		TotalFareInPaise: 25000,
+		Route:            route,
+		CreatedAt:        time.Now().Truncate(time.Millisecond),
+		ExpiresAt:        time.Now().Add(5 * time.Minute).Truncate(time.Millisecond),
+	}
+}
@@ -4,27 +4,60 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/cprakhar/uber-clone/services/trip-service/types"
 	pbd "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
-	ErrNotFound = fmt.Errorf("resource not found")
+	ErrNotFound     = fmt.Errorf("resource not found")
+	ErrFareExpired  = fmt.Errorf("ride fare has expired")
+	ErrFareConsumed = fmt.Errorf("ride fare has already been used")
+	// ErrStatusChanged is returned together with types.ErrInvalidTransition when a trip is no longer
+	// in the status a change was based on
+	ErrStatusChanged = fmt.Errorf("trip status changed")
 )
 
 type inMemoRepo struct {
 	sync.RWMutex
 	trips     map[string]*types.TripModel
 	rideFares map[string]*types.RideFareModel
+	outbox    []*types.OutboxEvent
 }
 
 type TripRepo interface {
+	Outbox
 	Create(ctx context.Context, trip *types.TripModel) (*types.TripModel, error)
 	SaveRideFare(ctx context.Context, fare *types.RideFareModel) error
 	GetRideFareByID(ctx context.Context, fareID string) (*types.RideFareModel, error)
+	ConsumeRideFare(ctx context.Context, fareID string, now time.Time) (*types.RideFareModel, error)
+	// DeleteExpiredRideFares removes ride fares that expired before the given time. Used fares are kept
+	// until consumedBefore, so a retried request with one is still told it was used rather than not found.
+	DeleteExpiredRideFares(ctx context.Context, before, consumedBefore time.Time) (int64, error)
 	UpdateWithDriver(ctx context.Context, tripID string, driver *pbd.TripDriver) (*types.TripModel, error)
+	UpdateStatus(ctx context.Context, tripID string, status types.TripStatus) (*types.TripModel, error)
+	Cancel(ctx context.Context, tripID string, from types.TripStatus, cancellation *types.TripCancellation) (*types.TripModel, error)
+	Complete(ctx context.Context, tripID string, fare *types.TripFinalFare) (*types.TripModel, error)
+	AppendLocation(ctx context.Context, tripID string, location *types.TripLocation) error
 	GetByID(ctx context.Context, tripID string) (*types.TripModel, error)
+	GetActiveTripByDriverID(ctx context.Context, driverID string) (*types.TripModel, error)
+	// WithTransaction runs fn so that the changes it makes through the repo with the given
+	// context are saved together or not at all
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Outbox keeps the events announcing trip changes until they are published
+type Outbox interface {
+	AddOutboxEvents(ctx context.Context, events ...*types.OutboxEvent) error
+	// ListPendingOutboxEvents returns up to limit unsent events, oldest first, leaving out the trips
+	// whose oldest unsent event is waiting to be retried after now
+	ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*types.OutboxEvent, error)
+	MarkOutboxEventSent(ctx context.Context, eventID string, sentAt time.Time) error
+	// MarkOutboxEventFailed records a failed publish attempt and when the event may be retried
+	MarkOutboxEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error
+	DeleteSentOutboxEvents(ctx context.Context, before time.Time) (int64, error)
 }
 
 // NewInMemoRepository creates a new instance of in-memory TripRepo
@@ -42,21 +75,33 @@ func (r *inMemoRepo) GetByID(ctx context.Context, tripID string) (*types.TripMod
 	if !exists {
 		return nil, ErrNotFound
 	}
-	return trip, nil
+	return cloneTrip(trip), nil
+}
+
+// GetActiveTripByDriverID retrieves the trip the driver is currently assigned to
+func (r *inMemoRepo) GetActiveTripByDriverID(ctx context.Context, driverID string) (*types.TripModel, error) {
+	r.RLock()
+	defer r.RUnlock()
+	for _, trip := range r.trips {
+		if trip.Status.IsActive() && trip.Driver.GetId() == driverID {
+			return cloneTrip(trip), nil
+		}
+	}
+	return nil, ErrNotFound
 }
 
 // Create adds a new trip to the in-memory store
 func (r *inMemoRepo) Create(ctx context.Context, trip *types.TripModel) (*types.TripModel, error) {
 	r.Lock()
-	r.trips[trip.ID.Hex()] = trip
+	r.trips[trip.ID.Hex()] = cloneTrip(trip)
 	r.Unlock()
-	return trip, nil
+	return cloneTrip(trip), nil
 }
 
 // SaveRideFare saves a ride fare to the in-memory store
 func (r *inMemoRepo) SaveRideFare(ctx context.Context, fare *types.RideFareModel) error {
 	r.Lock()
-	r.rideFares[fare.ID.Hex()] = fare
+	r.rideFares[fare.ID.Hex()] = cloneRideFare(fare)
 	r.Unlock()
 	return nil
 }
@@ -69,17 +114,296 @@ func (r *inMemoRepo) GetRideFareByID(ctx context.Context, fareID string) (*types
 	if !exists {
 		return nil, ErrNotFound
 	}
-	return fare, nil
+	return cloneRideFare(fare), nil
 }
 
-// UpdateWithDriver updates a trip with the given driver details and changes its status to "accepted"
+// ConsumeRideFare marks an unexpired, unused ride fare as used so it cannot create another trip
+func (r *inMemoRepo) ConsumeRideFare(ctx context.Context, fareID string, now time.Time) (*types.RideFareModel, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	fare, exists := r.rideFares[fareID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if fare.ConsumedAt != nil {
+		return nil, ErrFareConsumed
+	}
+	if fare.IsExpired(now) {
+		return nil, ErrFareExpired
+	}
+
+	fare.ConsumedAt = &now
+	return cloneRideFare(fare), nil
+}
+
+// DeleteExpiredRideFares removes ride fares that expired before the given time, and were not used after consumedBefore
+func (r *inMemoRepo) DeleteExpiredRideFares(ctx context.Context, before, consumedBefore time.Time) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	var deleted int64
+	for id, fare := range r.rideFares {
+		if fare.IsExpired(before) && (fare.ConsumedAt == nil || fare.ConsumedAt.Before(consumedBefore)) {
+			delete(r.rideFares, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// UpdateWithDriver assigns the driver to a pending trip and moves it to "driver_assigned".
+// A trip that already has a driver is left untouched and an ErrInvalidTransition is returned.
 func (r *inMemoRepo) UpdateWithDriver(ctx context.Context, tripID string, driver *pbd.TripDriver) (*types.TripModel, error) {
 	r.Lock()
-	r.trips[tripID].Driver = driver
-	r.trips[tripID].Status = "accepted"
+	defer r.Unlock()
+
+	trip, exists := r.trips[tripID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if err := trip.Transition(types.TripStatusDriverAssigned, time.Now()); err != nil {
+		return nil, err
+	}
+	trip.Driver = proto.Clone(driver).(*pbd.TripDriver)
+	return cloneTrip(trip), nil
+}
+
+// UpdateStatus moves a trip to the given status if the transition is allowed
+func (r *inMemoRepo) UpdateStatus(ctx context.Context, tripID string, status types.TripStatus) (*types.TripModel, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	trip, exists := r.trips[tripID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if err := trip.Transition(status, time.Now()); err != nil {
+		return nil, err
+	}
+	return cloneTrip(trip), nil
+}
+
+// Cancel moves the trip from the status from to "cancelled" if the transition is allowed and records the cancellation.
+// A trip that is no longer in from is left untouched and an ErrStatusChanged is returned.
+func (r *inMemoRepo) Cancel(ctx context.Context, tripID string, from types.TripStatus, cancellation *types.TripCancellation) (*types.TripModel, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	trip, exists := r.trips[tripID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if trip.Status != from {
+		return nil, fmt.Errorf("%w: %w: trip %s is %s, not %s", types.ErrInvalidTransition, ErrStatusChanged, tripID, trip.Status, from)
+	}
+
+	if err := trip.Transition(types.TripStatusCancelled, cancellation.CancelledAt); err != nil {
+		return nil, err
+	}
+	c := *cancellation
+	trip.Cancellation = &c
+	return cloneTrip(trip), nil
+}
+
+// Complete moves the trip to "completed" if the transition is allowed and records its final fare
+func (r *inMemoRepo) Complete(ctx context.Context, tripID string, fare *types.TripFinalFare) (*types.TripModel, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	trip, exists := r.trips[tripID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if err := trip.Transition(types.TripStatusCompleted, time.Now()); err != nil {
+		return nil, err
+	}
+	f := *fare
+	trip.FinalFare = &f
+	return cloneTrip(trip), nil
+}
+
+// AppendLocation adds the location to the trip's trail while the trip is in progress
+func (r *inMemoRepo) AppendLocation(ctx context.Context, tripID string, location *types.TripLocation) error {
+	r.Lock()
+	defer r.Unlock()
+
+	trip, exists := r.trips[tripID]
+	if !exists {
+		return ErrNotFound
+	}
+	if trip.Status != types.TripStatusInProgress {
+		return fmt.Errorf("%w: trip %s is %s", types.ErrInvalidTransition, tripID, trip.Status)
+	}
+	l := *location
+	trip.LocationTrail = append(trip.LocationTrail, &l)
+	return nil
+}
+
+// cloneTrip copies the trip so that callers neither see nor make changes to the stored one outside
+// the lock. The route is shared as it is never changed after the fare is saved.
+func cloneTrip(trip *types.TripModel) *types.TripModel {
+	c := *trip
+	c.StatusHistory = make([]*types.TripStatusChange, len(trip.StatusHistory))
+	for i, change := range trip.StatusHistory {
+		copied := *change
+		c.StatusHistory[i] = &copied
+	}
+	if trip.RideFare != nil {
+		c.RideFare = cloneRideFare(trip.RideFare)
+	}
+	if trip.Driver != nil {
+		c.Driver = proto.Clone(trip.Driver).(*pbd.TripDriver)
+	}
+	if trip.Cancellation != nil {
+		cancellation := *trip.Cancellation
+		c.Cancellation = &cancellation
+	}
+	if trip.LocationTrail != nil {
+		c.LocationTrail = make([]*types.TripLocation, len(trip.LocationTrail))
+		for i, location := range trip.LocationTrail {
+			copied := *location
+			c.LocationTrail[i] = &copied
+		}
+	}
+	if trip.FinalFare != nil {
+		fare := *trip.FinalFare
+		c.FinalFare = &fare
+	}
+	return &c
+}
+
+func cloneRideFare(fare *types.RideFareModel) *types.RideFareModel {
+	c := *fare
+	if fare.ConsumedAt != nil {
+		consumedAt := *fare.ConsumedAt
+		c.ConsumedAt = &consumedAt
+	}
+	return &c
+}
+
+// memoTxKey holds the events added in an in-memory transaction
+type memoTxKey struct{}
+
+type memoTx struct {
+	events []*types.OutboxEvent
+}
+
+// WithTransaction runs fn and adds the outbox events it added once it succeeds. Trip changes are
+// applied as fn makes them and are not undone if it fails afterwards.
+func (r *inMemoRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx := &memoTx{}
+	if err := fn(context.WithValue(ctx, memoTxKey{}, tx)); err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.outbox = append(r.outbox, tx.events...)
 	r.Unlock()
+	return nil
+}
+
+// AddOutboxEvents saves the events, or holds them until the transaction in ctx succeeds
+func (r *inMemoRepo) AddOutboxEvents(ctx context.Context, events ...*types.OutboxEvent) error {
+	copied := make([]*types.OutboxEvent, len(events))
+	for i, e := range events {
+		event := *e
+		copied[i] = &event
+	}
 
+	if tx, ok := ctx.Value(memoTxKey{}).(*memoTx); ok {
+		tx.events = append(tx.events, copied...)
+		return nil
+	}
+
+	r.Lock()
+	r.outbox = append(r.outbox, copied...)
+	r.Unlock()
+	return nil
+}
+
+// ListPendingOutboxEvents returns up to limit unsent events in the order they were added, leaving
+// out the trips whose oldest unsent event is waiting to be retried after now
+func (r *inMemoRepo) ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*types.OutboxEvent, error) {
 	r.RLock()
 	defer r.RUnlock()
-	return r.trips[tripID], nil
+
+	var pending []*types.OutboxEvent
+	waiting := make(map[string]bool) // by trip, decided by its oldest unsent event
+	for _, e := range r.outbox {
+		if len(pending) == limit {
+			break
+		}
+		if e.SentAt != nil {
+			continue
+		}
+		if _, seen := waiting[e.TripID]; !seen {
+			waiting[e.TripID] = e.NextAttemptAt.After(now)
+		}
+		if waiting[e.TripID] {
+			continue
+		}
+		event := *e
+		pending = append(pending, &event)
+	}
+	return pending, nil
+}
+
+// MarkOutboxEventSent records that the event was published
+func (r *inMemoRepo) MarkOutboxEventSent(ctx context.Context, eventID string, sentAt time.Time) error {
+	r.Lock()
+	defer r.Unlock()
+
+	event := r.findOutboxEvent(eventID)
+	if event == nil {
+		return ErrNotFound
+	}
+	event.Attempts++
+	event.SentAt = &sentAt
+	return nil
+}
+
+// MarkOutboxEventFailed records a failed publish attempt and when the event may be retried
+func (r *inMemoRepo) MarkOutboxEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error {
+	r.Lock()
+	defer r.Unlock()
+
+	event := r.findOutboxEvent(eventID)
+	if event == nil {
+		return ErrNotFound
+	}
+	event.Attempts++
+	event.LastError = lastError
+	event.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+// DeleteSentOutboxEvents removes events published before the given time
+func (r *inMemoRepo) DeleteSentOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	kept := r.outbox[:0]
+	for _, e := range r.outbox {
+		if e.SentAt == nil || e.SentAt.After(before) {
+			kept = append(kept, e)
+		}
+	}
+	deleted := int64(len(r.outbox) - len(kept))
+	clear(r.outbox[len(kept):])
+	r.outbox = kept
+	return deleted, nil
+}
+
+// findOutboxEvent returns the stored event with the given ID, or nil. The caller holds the lock.
+func (r *inMemoRepo) findOutboxEvent(eventID string) *types.OutboxEvent {
+	for _, e := range r.outbox {
+		if e.ID.Hex() == eventID {
+			return e
+		}
+	}
+	return nil
 }
@@ -0,0 +1,29 @@
+package repo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo/repotest"
+	"github.com/cprakhar/uber-clone/shared/db/dbtest"
+)
+
+func TestInMemoRepo(t *testing.T) {
+	if err := repotest.TestTripRepo(t.Context(), repo.NewInMemoRepository()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMongoRepo runs the suite against the MongoDB at MONGODB_URI, which must be a replica set
+// for the outbox transactions
+func TestMongoRepo(t *testing.T) {
+	database := dbtest.Database(t, "trip-repotest")
+	r, err := repo.NewMongoRepository(t.Context(), database, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repotest.TestTripRepo(t.Context(), r); err != nil {
+		t.Fatal(err)
+	}
+}
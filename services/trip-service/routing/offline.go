@@ -0,0 +1,76 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/cprakhar/uber-clone/shared/util"
+)
+
+const (
+	OfflineModeStraight = "straight"
+	OfflineModeGrid     = "grid"
+
+	// offlineSegmentPoints is the number of points generated along each straight segment
+	offlineSegmentPoints = 10
+)
+
+type offlineProvider struct {
+	mode     string
+	speedMps float64
+}
+
+// NewOfflineProvider creates a RouteProvider that computes routes locally without network access.
+// In "straight" mode the route is a direct line; in "grid" mode it travels along the
+// latitude first and then the longitude, like driving a city grid.
+// The same input always produces the same route.
+func NewOfflineProvider(mode string, speedKmh float64) (*offlineProvider, error) {
+	if mode != OfflineModeStraight && mode != OfflineModeGrid {
+		return nil, fmt.Errorf("unknown offline route mode %q", mode)
+	}
+	if speedKmh <= 0 {
+		return nil, fmt.Errorf("offline route speed must be positive, got %v", speedKmh)
+	}
+	return &offlineProvider{mode: mode, speedMps: speedKmh * 1000 / 3600}, nil
+}
+
+// GetRoute computes a route between pickup and destination
+func (p *offlineProvider) GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	waypoints := []*sharedtypes.Coordinate{pickup, destination}
+	if p.mode == OfflineModeGrid {
+		corner := &sharedtypes.Coordinate{Latitude: destination.Latitude, Longitude: pickup.Longitude}
+		waypoints = []*sharedtypes.Coordinate{pickup, corner, destination}
+	}
+
+	var distance float64
+	// Coordinates follow the OSRM GeoJSON order of [longitude, latitude]
+	coordinates := [][]float64{{pickup.Longitude, pickup.Latitude}}
+	for i := 1; i < len(waypoints); i++ {
+		from, to := waypoints[i-1], waypoints[i]
+		distance += util.HaversineDistance(from, to)
+
+		for step := 1; step <= offlineSegmentPoints; step++ {
+			f := float64(step) / offlineSegmentPoints
+			coordinates = append(coordinates, []float64{
+				from.Longitude + (to.Longitude-from.Longitude)*f,
+				from.Latitude + (to.Latitude-from.Latitude)*f,
+			})
+		}
+	}
+
+	return &types.OSRMApiResponse{
+		Routes: []types.OSRMRoute{
+			{
+				Distance: distance,
+				Duration: distance / p.speedMps,
+				Geometry: types.OSRMGeometry{Coordinates: coordinates},
+			},
+		},
+	}, nil
+}
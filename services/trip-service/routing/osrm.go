@@ -0,0 +1,100 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+)
+
+type osrmClient struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewOSRMClient creates a RouteProvider backed by an OSRM HTTP API.
+// Each attempt is bounded by timeout, and failed attempts are retried up to maxRetries times.
+func NewOSRMClient(baseURL string, timeout time.Duration, maxRetries int, retryDelay time.Duration) *osrmClient {
+	return &osrmClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+	}
+}
+
+// GetRoute fetches the route from the OSRM API between pickup and destination coordinates
+func (c *osrmClient) GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error) {
+	url := fmt.Sprintf("%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
+		c.baseURL,
+		pickup.Longitude, pickup.Latitude,
+		destination.Longitude, destination.Latitude,
+	)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay * time.Duration(attempt)):
+			}
+		}
+
+		route, retryable, err := c.fetchRoute(ctx, url)
+		if err == nil {
+			return route, nil
+		}
+		lastErr = err
+		if !retryable || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("failed to fetch route from OSRM api: %w", lastErr)
+}
+
+// fetchRoute performs a single request, reporting whether a failure is worth retrying
+func (c *osrmClient) fetchRoute(ctx context.Context, url string) (*types.OSRMApiResponse, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		return nil, true, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected status %d: %s", res.StatusCode, body)
+	}
+
+	var routeResponse types.OSRMApiResponse
+	if err := json.Unmarshal(body, &routeResponse); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(routeResponse.Routes) == 0 {
+		return nil, false, fmt.Errorf("no route found")
+	}
+
+	return &routeResponse, false, nil
+}
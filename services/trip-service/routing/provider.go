@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+)
+
+// RouteProvider computes a driving route between two coordinates
+type RouteProvider interface {
+	GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error)
+}
+
+const (
+	ProviderOSRM    = "osrm"
+	ProviderOffline = "offline"
+)
+
+// Config selects and configures a RouteProvider
+type Config struct {
+	Provider string
+
+	// OSRM settings
+	OSRMBaseURL    string
+	OSRMTimeout    time.Duration
+	OSRMMaxRetries int
+	OSRMRetryDelay time.Duration
+
+	// Offline settings
+	OfflineMode     string
+	OfflineSpeedKmh float64
+}
+
+// NewRouteProvider creates the RouteProvider selected by the config
+func NewRouteProvider(cfg *Config) (RouteProvider, error) {
+	switch cfg.Provider {
+	case ProviderOSRM:
+		return NewOSRMClient(cfg.OSRMBaseURL, cfg.OSRMTimeout, cfg.OSRMMaxRetries, cfg.OSRMRetryDelay), nil
+	case ProviderOffline:
+		return NewOfflineProvider(cfg.OfflineMode, cfg.OfflineSpeedKmh)
+	default:
+		return nil, fmt.Errorf("unknown route provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,183 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/cprakhar/uber-clone/shared/util"
+)
+
+var (
+	pickup      = &sharedtypes.Coordinate{Latitude: 12.9716, Longitude: 77.5946}
+	destination = &sharedtypes.Coordinate{Latitude: 12.9352, Longitude: 77.6245}
+)
+
+func TestNewRouteProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "osrm", cfg: Config{Provider: ProviderOSRM, OSRMBaseURL: "http://osrm"}},
+		{name: "offline straight", cfg: Config{Provider: ProviderOffline, OfflineMode: OfflineModeStraight, OfflineSpeedKmh: 30}},
+		{name: "offline grid", cfg: Config{Provider: ProviderOffline, OfflineMode: OfflineModeGrid, OfflineSpeedKmh: 30}},
+		{name: "unknown provider", cfg: Config{Provider: "google"}, wantErr: true},
+		{name: "unknown offline mode", cfg: Config{Provider: ProviderOffline, OfflineMode: "curvy", OfflineSpeedKmh: 30}, wantErr: true},
+		{name: "offline without a speed", cfg: Config{Provider: ProviderOffline, OfflineMode: OfflineModeStraight}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewRouteProvider(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRouteProvider() = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && provider == nil {
+				t.Error("NewRouteProvider() returned no provider")
+			}
+		})
+	}
+}
+
+func TestOfflineProvider(t *testing.T) {
+	corner := &sharedtypes.Coordinate{Latitude: destination.Latitude, Longitude: pickup.Longitude}
+
+	tests := []struct {
+		mode         string
+		wantDistance float64
+		wantPoints   int
+	}{
+		{mode: OfflineModeStraight, wantDistance: util.HaversineDistance(pickup, destination), wantPoints: 1 + offlineSegmentPoints},
+		{mode: OfflineModeGrid, wantDistance: util.HaversineDistance(pickup, corner) + util.HaversineDistance(corner, destination), wantPoints: 1 + 2*offlineSegmentPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p, err := NewOfflineProvider(tt.mode, 36)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := p.GetRoute(t.Context(), pickup, destination)
+			if err != nil {
+				t.Fatalf("GetRoute() = %v", err)
+			}
+			if len(res.Routes) != 1 {
+				t.Fatalf("GetRoute() returned %d routes, want 1", len(res.Routes))
+			}
+
+			route := res.Routes[0]
+			if math.Abs(route.Distance-tt.wantDistance) > 0.001 {
+				t.Errorf("distance = %f, want %f", route.Distance, tt.wantDistance)
+			}
+			if want := route.Distance / 10; math.Abs(route.Duration-want) > 0.001 {
+				t.Errorf("duration = %f at 36 km/h, want %f", route.Duration, want)
+			}
+
+			coordinates := route.Geometry.Coordinates
+			if len(coordinates) != tt.wantPoints {
+				t.Fatalf("route has %d points, want %d", len(coordinates), tt.wantPoints)
+			}
+			first, last := coordinates[0], coordinates[len(coordinates)-1]
+			if !closeTo(first, pickup) || !closeTo(last, destination) {
+				t.Errorf("route runs from %v to %v, want [lng, lat] of the pickup to the destination", first, last)
+			}
+
+			again, err := p.GetRoute(t.Context(), pickup, destination)
+			if err != nil || again.Routes[0].Distance != route.Distance || len(again.Routes[0].Geometry.Coordinates) != len(coordinates) {
+				t.Error("the same trip produced a different route")
+			}
+		})
+	}
+
+	p, err := NewOfflineProvider(OfflineModeStraight, 36)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := p.GetRoute(ctx, pickup, destination); err == nil {
+		t.Error("GetRoute() with a cancelled context succeeded, want an error")
+	}
+}
+
+func TestOSRMClient(t *testing.T) {
+	const route = `{"routes":[{"distance":4500.5,"duration":600,"geometry":{"coordinates":[[77.5946,12.9716],[77.6245,12.9352]]}}]}`
+
+	tests := []struct {
+		name         string
+		responses    []int // status of each attempt, the last one repeated
+		body         string
+		maxRetries   int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{name: "route", responses: []int{http.StatusOK}, body: route, maxRetries: 2, wantAttempts: 1},
+		{name: "server error retried", responses: []int{http.StatusBadGateway, http.StatusOK}, body: route, maxRetries: 2, wantAttempts: 2},
+		{name: "rate limit retried", responses: []int{http.StatusTooManyRequests, http.StatusOK}, body: route, maxRetries: 2, wantAttempts: 2},
+		{name: "retries used up", responses: []int{http.StatusServiceUnavailable}, body: route, maxRetries: 2, wantErr: true, wantAttempts: 3},
+		{name: "without retries", responses: []int{http.StatusServiceUnavailable}, body: route, wantErr: true, wantAttempts: 1},
+		{name: "bad request not retried", responses: []int{http.StatusBadRequest}, body: `{"code":"InvalidQuery"}`, maxRetries: 2, wantErr: true, wantAttempts: 1},
+		{name: "no route", responses: []int{http.StatusOK}, body: `{"routes":[]}`, maxRetries: 2, wantErr: true, wantAttempts: 1},
+		{name: "malformed response", responses: []int{http.StatusOK}, body: `{"routes":`, maxRetries: 2, wantErr: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			var path atomic.Value
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				path.Store(r.URL.String())
+				w.WriteHeader(tt.responses[min(n, len(tt.responses))-1])
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewOSRMClient(srv.URL+"/", time.Second, tt.maxRetries, time.Millisecond)
+			res, err := c.GetRoute(t.Context(), pickup, destination)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRoute() = %v, want error %v", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("made %d requests, want %d", got, tt.wantAttempts)
+			}
+			if want := "/route/v1/driving/77.594600,12.971600;77.624500,12.935200?overview=full&geometries=geojson"; path.Load() != want {
+				t.Errorf("requested %s, want %s", path.Load(), want)
+			}
+			if !tt.wantErr && (len(res.Routes) != 1 || res.Routes[0].Distance != 4500.5) {
+				t.Errorf("GetRoute() = %+v, want the route of the response", res)
+			}
+		})
+	}
+}
+
+func TestOSRMClientCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	c := NewOSRMClient(srv.URL, time.Second, 5, time.Hour)
+
+	start := time.Now()
+	_, err := c.GetRoute(ctx, pickup, destination)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetRoute() = %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetRoute() kept waiting %s for a retry after the context ended", elapsed)
+	}
+}
+
+func closeTo(point []float64, c *sharedtypes.Coordinate) bool {
+	return math.Abs(point[0]-c.Longitude) < 1e-9 && math.Abs(point[1]-c.Latitude) < 1e-9
+}
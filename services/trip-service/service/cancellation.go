@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox"
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+)
+
+// ErrNotTripParticipant is returned when someone who is not the trip's rider or driver tries to change it
+var ErrNotTripParticipant = errors.New("requester is not part of the trip")
+
+// CancelTrip cancels the trip on behalf of its rider or driver and records the fee the rider owes
+// under the cancellation policy. Both sides of the trip are notified and a fee is charged through
+// events saved with the cancellation.
+//
+// The fee depends on the trip's status, so the trip is only cancelled while it is still in the status
+// the fee was computed from. A trip that moved on in the meantime, e.g. because the driver arrived,
+// is read and priced again.
+func (s *tripService) CancelTrip(ctx context.Context, tripID string, by types.CancelledBy, requesterID, reason string) (*types.TripModel, error) {
+	for {
+		cancelled, err := s.cancelTrip(ctx, tripID, by, requesterID, reason)
+		// Trips only move forward through a few statuses, so this ends
+		if errors.Is(err, repo.ErrStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.tracking.forget(tripID)
+		s.surge.RemoveDemand(tripID, time.Now())
+		return cancelled, nil
+	}
+}
+
+// cancelTrip cancels the trip as it is now, returning an ErrStatusChanged if its status changed before the cancellation was saved
+func (s *tripService) cancelTrip(ctx context.Context, tripID string, by types.CancelledBy, requesterID, reason string) (*types.TripModel, error) {
+	trip, err := s.repo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch by {
+	case types.CancelledByRider:
+		if trip.RiderID != requesterID {
+			return nil, ErrNotTripParticipant
+		}
+	case types.CancelledByDriver:
+		if trip.Driver.GetId() == "" || trip.Driver.GetId() != requesterID {
+			return nil, ErrNotTripParticipant
+		}
+	default:
+		return nil, ErrNotTripParticipant
+	}
+
+	now := time.Now()
+	cancellation := &types.TripCancellation{
+		By:          by,
+		RequesterID: requesterID,
+		Reason:      reason,
+		FeeInPaise:  s.cancellation.Fee(trip, by, now),
+		Currency:    trip.RideFare.Currency,
+		CancelledAt: now,
+	}
+
+	return s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.Cancel(ctx, tripID, trip.Status, cancellation)
+	}, func(ctx context.Context, trip *types.TripModel) ([]*types.OutboxEvent, error) {
+		events, err := outbox.TripStatusChanged(ctx, trip)
+		if err != nil || trip.Cancellation.FeeInPaise <= 0 {
+			return events, err
+		}
+		fee, err := outbox.CancellationFee(ctx, trip)
+		if err != nil {
+			return nil, err
+		}
+		return append(events, fee), nil
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/surge"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCancelTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		trip        tripSetup
+		by          types.CancelledBy
+		requesterID string
+		wantErr     error
+		wantFee     int64
+	}{
+		{name: "rider cancels a pending trip", trip: tripSetup{status: types.TripStatusPending}, by: types.CancelledByRider, requesterID: "rider-1"},
+		{name: "rider cancels within the grace period", trip: tripSetup{status: types.TripStatusDriverAssigned}, by: types.CancelledByRider, requesterID: "rider-1"},
+		{name: "rider cancels past the grace period", trip: tripSetup{status: types.TripStatusDriverAssigned, assignedAgo: time.Hour}, by: types.CancelledByRider, requesterID: "rider-1", wantFee: 5000},
+		{name: "rider cancels once the driver arrived", trip: tripSetup{status: types.TripStatusDriverArrived}, by: types.CancelledByRider, requesterID: "rider-1", wantFee: 5000},
+		{name: "driver cancels once they arrived", trip: tripSetup{status: types.TripStatusDriverArrived}, by: types.CancelledByDriver, requesterID: "driver-1"},
+		{name: "another rider", trip: tripSetup{status: types.TripStatusDriverAssigned}, by: types.CancelledByRider, requesterID: "rider-2", wantErr: ErrNotTripParticipant},
+		{name: "another driver", trip: tripSetup{status: types.TripStatusDriverAssigned}, by: types.CancelledByDriver, requesterID: "driver-2", wantErr: ErrNotTripParticipant},
+		{name: "driver of a pending trip", trip: tripSetup{status: types.TripStatusPending}, by: types.CancelledByDriver, requesterID: "", wantErr: ErrNotTripParticipant},
+		{name: "trip in progress", trip: tripSetup{status: types.TripStatusInProgress}, by: types.CancelledByRider, requesterID: "rider-1", wantErr: types.ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := repo.NewInMemoRepository()
+			svc := newTestService(r, &types.TrackingConfig{Interval: time.Second, AvgSpeedKmh: 36})
+			tripID := createTestTrip(t, r, tt.trip)
+
+			cancelled, err := svc.CancelTrip(t.Context(), tripID, tt.by, tt.requesterID, "changed plans")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelTrip() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if trip, _ := r.GetByID(t.Context(), tripID); trip.Status != tt.trip.status {
+					t.Errorf("rejected cancellation moved the trip to %s", trip.Status)
+				}
+				return
+			}
+
+			if cancelled.Status != types.TripStatusCancelled || cancelled.Cancellation.FeeInPaise != tt.wantFee {
+				t.Errorf("CancelTrip() = %s with a fee of %d, want cancelled with a fee of %d", cancelled.Status, cancelled.Cancellation.FeeInPaise, tt.wantFee)
+			}
+			if charged := feeCharged(t, r); charged != (tt.wantFee > 0) {
+				t.Errorf("fee charged = %v, want %v", charged, tt.wantFee > 0)
+			}
+		})
+	}
+}
+
+func TestCancelTripDriverArrives(t *testing.T) {
+	inMemo := repo.NewInMemoRepository()
+	r := &arrivingRepo{TripRepo: inMemo}
+	svc := newTestService(r, &types.TrackingConfig{Interval: time.Second, AvgSpeedKmh: 36})
+	tripID := createTestTrip(t, inMemo, tripSetup{status: types.TripStatusDriverAssigned})
+
+	cancelled, err := svc.CancelTrip(t.Context(), tripID, types.CancelledByRider, "rider-1", "changed plans")
+	if err != nil {
+		t.Fatalf("CancelTrip() = %v", err)
+	}
+	if _, arrived := cancelled.StatusChangedAt(types.TripStatusDriverArrived); !arrived {
+		t.Fatal("the driver did not arrive before the cancellation")
+	}
+	if cancelled.Cancellation.FeeInPaise != 5000 || !feeCharged(t, inMemo) {
+		t.Errorf("fee = %d, want the fee for a driver who arrived", cancelled.Cancellation.FeeInPaise)
+	}
+}
+
+// arrivingRepo moves the trip to "driver_arrived" right before it is first cancelled,
+// as if the driver arrived while the rider was cancelling
+type arrivingRepo struct {
+	repo.TripRepo
+	arrived bool
+}
+
+func (r *arrivingRepo) Cancel(ctx context.Context, tripID string, from types.TripStatus, cancellation *types.TripCancellation) (*types.TripModel, error) {
+	if !r.arrived {
+		r.arrived = true
+		if _, err := r.UpdateStatus(ctx, tripID, types.TripStatusDriverArrived); err != nil {
+			return nil, err
+		}
+	}
+	return r.TripRepo.Cancel(ctx, tripID, from, cancellation)
+}
+
+// tripSetup describes a trip created for a test
+type tripSetup struct {
+	status      types.TripStatus
+	assignedAgo time.Duration // since the driver was assigned
+}
+
+func newTestService(r repo.TripRepo, tracking *types.TrackingConfig) *tripService {
+	return NewService(r, nil, nil, surge.NewTracker(&surge.Config{Window: time.Minute, GeohashPrecision: 5, MaxMultiplier: 2, Sensitivity: 0.1, Step: 0.1}, nil),
+		time.Minute, tracking, &types.CancellationPolicy{GracePeriod: 2 * time.Minute, FeeInPaise: 5000})
+}
+
+// createTestTrip saves a trip of rider-1 from Bangalore's MG Road to Koramangala, driven by driver-1 once assigned
+func createTestTrip(t *testing.T, r repo.TripRepo, setup tripSetup) string {
+	t.Helper()
+
+	trip := &types.TripModel{
+		ID:      primitive.NewObjectID(),
+		RiderID: "rider-1",
+		Status:  setup.status,
+		RideFare: &types.RideFareModel{
+			ID:          primitive.NewObjectID(),
+			RiderID:     "rider-1",
+			PackageSlug: "sedan",
+			Currency:    "inr",
+			Route: &types.OSRMApiResponse{Routes: []types.OSRMRoute{{
+				Distance: 5000,
+				Duration: 600,
+				Geometry: types.OSRMGeometry{Coordinates: [][]float64{{77.5946, 12.9716}, {77.6245, 12.9352}}},
+			}}},
+		},
+		StatusHistory: []*types.TripStatusChange{{Status: types.TripStatusPending, ChangedAt: time.Now().Add(-time.Hour - setup.assignedAgo)}},
+	}
+	if setup.status != types.TripStatusPending {
+		trip.Driver = &pb.TripDriver{Id: "driver-1", Name: "Asha"}
+		trip.StatusHistory = append(trip.StatusHistory, &types.TripStatusChange{Status: types.TripStatusDriverAssigned, ChangedAt: time.Now().Add(-setup.assignedAgo)})
+	}
+	if _, err := r.Create(t.Context(), trip); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return trip.ID.Hex()
+}
+
+// feeCharged reports whether a cancellation fee was requested from the payment service
+func feeCharged(t *testing.T, r repo.TripRepo) bool {
+	t.Helper()
+	events, err := r.ListPendingOutboxEvents(t.Context(), time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.Topic == contracts.PaymentCmdChargeCancellationFee {
+			return true
+		}
+	}
+	return false
+}
@@ -2,92 +2,127 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
+	"math"
+	"time"
 
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox"
+	"github.com/cprakhar/uber-clone/services/trip-service/pricing"
 	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/routing"
+	"github.com/cprakhar/uber-clone/services/trip-service/surge"
 	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
 	"github.com/cprakhar/uber-clone/shared/proto/trip"
 	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type tripService struct {
-	repo repo.TripRepo
+	repo         repo.TripRepo
+	routes       routing.RouteProvider
+	pricing      *pricing.Engine
+	surge        *surge.Tracker
+	fareTTL      time.Duration
+	tracking     *tripThrottle
+	cancellation *types.CancellationPolicy
 }
 
 type TripService interface {
 	CreateTrip(ctx context.Context, fare *types.RideFareModel) (*types.TripModel, error)
 	GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error)
-	EstimatePackagesPriceWithRoute(route *types.OSRMApiResponse) []*types.RideFareModel
+	EstimatePackagesPriceWithRoute(pickup *sharedtypes.Coordinate, route *types.OSRMApiResponse) []*types.RideFareModel
 	GenerateTripFares(ctx context.Context, fares []*types.RideFareModel, riderID string, route *types.OSRMApiResponse) ([]*types.RideFareModel, error)
+	RecordDriverSupply(driver *pbd.Driver, available bool)
 	GetAndValidateRideFare(ctx context.Context, fareID, riderID string) (*types.RideFareModel, error)
 	AcceptRide(ctx context.Context, tripID string, driver *trip.TripDriver) (*types.TripModel, error)
 	GetTripByID(ctx context.Context, tripID string) (*types.TripModel, error)
+	UpdateTripStatus(ctx context.Context, tripID string, status types.TripStatus) (*types.TripModel, error)
+	TrackDriver(ctx context.Context, driver *pbd.Driver, recordedAt time.Time) (*types.DriverTracking, error)
+	CancelTrip(ctx context.Context, tripID string, by types.CancelledBy, requesterID, reason string) (*types.TripModel, error)
+	MarkDriverArrived(ctx context.Context, tripID, driverID string) (*types.TripModel, error)
+	StartTrip(ctx context.Context, tripID, driverID string) (*types.TripModel, error)
+	CompleteTrip(ctx context.Context, tripID, driverID string) (*types.TripModel, error)
 }
 
 // NewService creates a new instance of GrpcTripService
-func NewService(repo repo.TripRepo) *tripService {
-	return &tripService{repo: repo}
+func NewService(repo repo.TripRepo, routes routing.RouteProvider, pricing *pricing.Engine, surge *surge.Tracker, fareTTL time.Duration, tracking *types.TrackingConfig, cancellation *types.CancellationPolicy) *tripService {
+	return &tripService{
+		repo:         repo,
+		routes:       routes,
+		pricing:      pricing,
+		surge:        surge,
+		fareTTL:      fareTTL,
+		tracking:     newTripThrottle(tracking),
+		cancellation: cancellation,
+	}
 }
 
 func (s *tripService) GetTripByID(ctx context.Context, tripID string) (*types.TripModel, error) {
 	return s.repo.GetByID(ctx, tripID)
 }
 
-// CreateTrip creates a new trip based on the provided fare
+// CreateTrip creates a new trip based on the provided fare, using up the fare, and saves the
+// "trip.event.created" event with it. The trip counts towards the surge at its pickup while it is pending.
 func (s *tripService) CreateTrip(ctx context.Context, fare *types.RideFareModel) (*types.TripModel, error) {
-	trip := &types.TripModel{
-		ID:       primitive.NewObjectID(),
-		RiderID:  fare.RiderID,
-		Status:   "pending",
-		RideFare: fare,
-		Driver:   &trip.TripDriver{},
-	}
-	return s.repo.Create(ctx, trip)
-}
-
-// GetRoute fetches the route from OSRM API between pickup and destination coordinates
-func (s *tripService) GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error) {
-	url := fmt.Sprintf("http://router.project-osrm.org/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
-		pickup.Longitude, pickup.Latitude,
-		destination.Longitude, destination.Latitude,
-	)
-
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch route from OSRM api: %v", err)
-	}
-	defer res.Body.Close()
+	created, err := s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		fare, err := s.repo.ConsumeRideFare(ctx, fare.ID.Hex(), time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to use ride fare: %w", err)
+		}
 
-	body, err := io.ReadAll(res.Body)
+		trip := &types.TripModel{
+			ID:      primitive.NewObjectID(),
+			RiderID: fare.RiderID,
+			Status:  types.TripStatusPending,
+			StatusHistory: []*types.TripStatusChange{
+				{Status: types.TripStatusPending, ChangedAt: time.Now()},
+			},
+			RideFare: fare,
+			Driver:   &trip.TripDriver{},
+		}
+		return s.repo.Create(ctx, trip)
+	}, outbox.TripStatusChanged)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return nil, err
 	}
 
-	var routeResponse types.OSRMApiResponse
-	if err := json.Unmarshal(body, &routeResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	if pickup := created.RideFare.Route.PickupProto(); pickup != nil {
+		s.surge.RecordDemand(created.ID.Hex(), &sharedtypes.Coordinate{
+			Latitude:  pickup.GetLatitude(),
+			Longitude: pickup.GetLongitude(),
+		}, time.Now())
 	}
+	return created, nil
+}
 
-	return &routeResponse, nil
+// GetRoute fetches the route between pickup and destination coordinates from the route provider
+func (s *tripService) GetRoute(ctx context.Context, pickup, destination *sharedtypes.Coordinate) (*types.OSRMApiResponse, error) {
+	return s.routes.GetRoute(ctx, pickup, destination)
 }
 
-// EstimatePackagesPriceWithRoute estimates prices for different car packages based on the provided route
-func (s *tripService) EstimatePackagesPriceWithRoute(route *types.OSRMApiResponse) []*types.RideFareModel {
-	baseFares := getBaseFares()
+// EstimatePackagesPriceWithRoute estimates prices for different car packages based on the provided route,
+// using the pricing of the city the pickup is in and the current surge multiplier at the pickup
+func (s *tripService) EstimatePackagesPriceWithRoute(pickup *sharedtypes.Coordinate, route *types.OSRMApiResponse) []*types.RideFareModel {
+	baseFares := s.getBaseFares(s.pricing.ResolveCity(pickup))
+	surgeMultiplier := s.surge.Multiplier(pickup, time.Now())
 	estimatedFares := make([]*types.RideFareModel, len(baseFares))
 
 	for i, fare := range baseFares {
-		estimatedFares[i] = estimateFareRoute(route, fare)
+		estimatedFares[i] = estimateFareRoute(route, fare, surgeMultiplier)
 	}
 	return estimatedFares
 }
 
+// RecordDriverSupply counts the driver towards the surge supply of the cell they are in
+func (s *tripService) RecordDriverSupply(driver *pbd.Driver, available bool) {
+	s.surge.RecordSupply(driver.GetId(), driver.GetGeohash(), available, time.Now())
+}
+
 // GenerateTripFares generates and saves ride fares for a rider based on the provided estimated fares and route
 func (s *tripService) GenerateTripFares(ctx context.Context, rideFares []*types.RideFareModel, riderID string, route *types.OSRMApiResponse) ([]*types.RideFareModel, error) {
+	now := time.Now()
 	fares := make([]*types.RideFareModel, len(rideFares))
 	for i, fare := range rideFares {
 		f := &types.RideFareModel{
@@ -95,7 +130,11 @@ func (s *tripService) GenerateTripFares(ctx context.Context, rideFares []*types.
 			ID:               primitive.NewObjectID(),
 			PackageSlug:      fare.PackageSlug,
 			TotalFareInPaise: fare.TotalFareInPaise,
+			Currency:         s.pricing.Currency(),
+			SurgeMultiplier:  fare.SurgeMultiplier,
 			Route:            route,
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(s.fareTTL),
 		}
 
 		if err := s.repo.SaveRideFare(ctx, f); err != nil {
@@ -108,10 +147,11 @@ func (s *tripService) GenerateTripFares(ctx context.Context, rideFares []*types.
 }
 
 // GetAndValidateRideFare retrieves a ride fare by ID and validates that it belongs to the specified rider
+// and can still be used
 func (s *tripService) GetAndValidateRideFare(ctx context.Context, fareID, riderID string) (*types.RideFareModel, error) {
 	fare, err := s.repo.GetRideFareByID(ctx, fareID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ride fare: %v", err)
+		return nil, fmt.Errorf("failed to get ride fare: %w", err)
 	}
 
 	if fare == nil {
@@ -122,51 +162,116 @@ func (s *tripService) GetAndValidateRideFare(ctx context.Context, fareID, riderI
 		return nil, fmt.Errorf("ride fare does not belong to the rider")
 	}
 
+	if fare.ConsumedAt != nil {
+		return nil, repo.ErrFareConsumed
+	}
+
+	if fare.IsExpired(time.Now()) {
+		return nil, repo.ErrFareExpired
+	}
+
 	return fare, nil
 }
 
+// SweepExpiredFares periodically purges expired ride fares until ctx is done. Used fares are kept for
+// consumedRetention after they were used, so retried requests to start a trip with one are told it was used.
+func (s *tripService) SweepExpiredFares(ctx context.Context, interval, consumedRetention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := s.repo.DeleteExpiredRideFares(ctx, now, now.Add(-consumedRetention))
+			if err != nil {
+				log.Printf("Failed to purge expired ride fares: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Purged %d expired ride fares", deleted)
+			}
+		}
+	}
+}
+
 // AcceptRide allows a driver to accept a trip, updating the trip with the driver's details
 func (s *tripService) AcceptRide(ctx context.Context, tripID string, driver *trip.TripDriver) (*types.TripModel, error) {
-	return s.repo.UpdateWithDriver(ctx, tripID, driver)
+	accepted, err := s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.UpdateWithDriver(ctx, tripID, driver)
+	}, outbox.TripStatusChanged)
+	if err != nil {
+		return nil, err
+	}
+	s.surge.RemoveDemand(tripID, time.Now())
+	return accepted, nil
+}
+
+// UpdateTripStatus moves a trip to the given status, rejecting transitions the lifecycle does not allow.
+// The "trip.event.no_drivers_found" event is emitted by the driver service, so it is not saved again here.
+func (s *tripService) UpdateTripStatus(ctx context.Context, tripID string, status types.TripStatus) (*types.TripModel, error) {
+	announce := outbox.TripStatusChanged
+	if status == types.TripStatusNoDriversFound {
+		announce = nil
+	}
+
+	trip, err := s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.UpdateStatus(ctx, tripID, status)
+	}, announce)
+	if err != nil {
+		return nil, err
+	}
+	// Only pending trips count as demand, and the trip has left that status
+	s.surge.RemoveDemand(tripID, time.Now())
+	if !status.IsActive() {
+		s.tracking.forget(tripID)
+	}
+	return trip, nil
 }
 
-// estimateFareRoute estimates the total fare for a given route and base fare
-func estimateFareRoute(route *types.OSRMApiResponse, fare *types.RideFareModel) *types.RideFareModel {
-	pricingCfg := types.DefaultPricingConfig()
-	carPackagePrice := fare.TotalFareInPaise
+// commit applies the trip change and saves the events announce returns for the changed trip
+// in the same transaction. A nil announce saves no events.
+func (s *tripService) commit(ctx context.Context, change func(ctx context.Context) (*types.TripModel, error), announce func(context.Context, *types.TripModel) ([]*types.OutboxEvent, error)) (*types.TripModel, error) {
+	var changed *types.TripModel
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		trip, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		changed = trip
+		if announce == nil {
+			return nil
+		}
 
-	distanceInKm := route.Routes[0].Distance
-	durationInMinutes := route.Routes[0].Duration
+		events, err := announce(ctx, trip)
+		if err != nil {
+			return fmt.Errorf("failed to build events of trip %s: %w", trip.ID.Hex(), err)
+		}
+		return s.repo.AddOutboxEvents(ctx, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
 
-	distanceFare := distanceInKm * pricingCfg.PricePerUnitDistance
-	durationFare := durationInMinutes * pricingCfg.PricePerMinute
+// estimateFareRoute estimates the total fare for a given route and package pricing, scaled by the surge multiplier.
+// OSRM reports the distance in meters and the duration in seconds.
+func estimateFareRoute(route *types.OSRMApiResponse, pkg *pricing.PackagePricing, surgeMultiplier float64) *types.RideFareModel {
+	distanceInMeters := route.Routes[0].Distance
+	durationInSeconds := route.Routes[0].Duration
 
-	totalFare := carPackagePrice + distanceFare + durationFare
+	fare := pricing.Quote(pkg, distanceInMeters, durationInSeconds)
 
 	return &types.RideFareModel{
-		PackageSlug:      fare.PackageSlug,
-		TotalFareInPaise: totalFare,
-	}
-}
-
-// getBaseFares returns a list of base fares for different car packages
-func getBaseFares() []*types.RideFareModel {
-	return []*types.RideFareModel{
-		{
-			PackageSlug:      "bike",
-			TotalFareInPaise: 50.0,
-		},
-		{
-			PackageSlug:      "auto",
-			TotalFareInPaise: 70.0,
-		},
-		{
-			PackageSlug:      "sedan",
-			TotalFareInPaise: 100.0,
-		},
-		{
-			PackageSlug:      "suv",
-			TotalFareInPaise: 150.0,
-		},
+		PackageSlug:      pkg.Slug,
+		TotalFareInPaise: int64(math.Round(float64(fare) * surgeMultiplier)),
+		SurgeMultiplier:  surgeMultiplier,
 	}
 }
+
+// getBaseFares returns the pricing for every car package in the given city
+func (s *tripService) getBaseFares(city string) []*pricing.PackagePricing {
+	return s.pricing.Packages(city)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/outbox"
+	"github.com/cprakhar/uber-clone/services/trip-service/pricing"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+)
+
+// MarkDriverArrived records that the trip's driver reached the pickup
+func (s *tripService) MarkDriverArrived(ctx context.Context, tripID, driverID string) (*types.TripModel, error) {
+	if _, err := s.getDriverTrip(ctx, tripID, driverID); err != nil {
+		return nil, err
+	}
+	return s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.UpdateStatus(ctx, tripID, types.TripStatusDriverArrived)
+	}, outbox.TripStatusChanged)
+}
+
+// StartTrip records that the rider was picked up. Driver locations are recorded on the trip's trail from now on.
+func (s *tripService) StartTrip(ctx context.Context, tripID, driverID string) (*types.TripModel, error) {
+	if _, err := s.getDriverTrip(ctx, tripID, driverID); err != nil {
+		return nil, err
+	}
+	return s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.UpdateStatus(ctx, tripID, types.TripStatusInProgress)
+	}, outbox.TripStatusChanged)
+}
+
+// CompleteTrip records the drop-off and prices the trip from the distance and time actually travelled.
+// The payment of the final fare is requested together with the "trip.event.completed" event.
+func (s *tripService) CompleteTrip(ctx context.Context, tripID, driverID string) (*types.TripModel, error) {
+	trip, err := s.getDriverTrip(ctx, tripID, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.commit(ctx, func(ctx context.Context) (*types.TripModel, error) {
+		return s.repo.Complete(ctx, tripID, s.finalFare(trip, time.Now()))
+	}, func(ctx context.Context, trip *types.TripModel) ([]*types.OutboxEvent, error) {
+		events, err := outbox.TripStatusChanged(ctx, trip)
+		if err != nil {
+			return nil, err
+		}
+		payment, err := outbox.PaymentRequested(ctx, trip)
+		if err != nil {
+			return nil, err
+		}
+		return append(events, payment), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.tracking.forget(tripID)
+	return completed, nil
+}
+
+// finalFare prices the trip with the package pricing of the pickup city and the surge multiplier locked in at booking.
+// The quoted fare is kept when too little of the trip was recorded to measure it.
+func (s *tripService) finalFare(trip *types.TripModel, completedAt time.Time) *types.TripFinalFare {
+	fare := trip.RideFare
+	final := &types.TripFinalFare{
+		TotalFareInPaise: fare.TotalFareInPaise,
+		Currency:         fare.Currency,
+		DistanceMeters:   types.TrailDistance(trip.LocationTrail),
+	}
+	if startedAt, ok := trip.StatusChangedAt(types.TripStatusInProgress); ok {
+		final.DurationSeconds = completedAt.Sub(startedAt).Seconds()
+	}
+
+	pickup := fare.Route.PickupProto()
+	if len(trip.LocationTrail) < 2 || pickup == nil {
+		log.Printf("Trip %s has %d recorded locations, charging the quoted fare", trip.ID.Hex(), len(trip.LocationTrail))
+		return final
+	}
+
+	city := s.pricing.ResolveCity(&sharedtypes.Coordinate{Latitude: pickup.Latitude, Longitude: pickup.Longitude})
+	pkg := s.pricing.Package(city, fare.PackageSlug)
+	if pkg == nil {
+		log.Printf("No pricing for package %s in city %q, charging the quoted fare of trip %s", fare.PackageSlug, city, trip.ID.Hex())
+		return final
+	}
+
+	surgeMultiplier := fare.SurgeMultiplier
+	if surgeMultiplier <= 0 {
+		surgeMultiplier = 1
+	}
+	quote := pricing.Quote(pkg, final.DistanceMeters, final.DurationSeconds)
+	final.TotalFareInPaise = int64(math.Round(float64(quote) * surgeMultiplier))
+	return final
+}
+
+// getDriverTrip returns the trip if the driver is assigned to it
+func (s *tripService) getDriverTrip(ctx context.Context, tripID, driverID string) (*types.TripModel, error) {
+	trip, err := s.repo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if driverID == "" || trip.Driver.GetId() != driverID {
+		return nil, ErrNotTripParticipant
+	}
+	return trip, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/cprakhar/uber-clone/shared/util"
+)
+
+// ErrTrackingThrottled is returned when a location update for the trip was sent too recently
+var ErrTrackingThrottled = errors.New("driver location update throttled")
+
+// TrackDriver locates the trip the driver is on and estimates their ETA to the pickup,
+// or to the destination once the trip has started, when the location is also added to the trip's trail.
+// It returns repo.ErrNotFound when the driver is not on a trip and ErrTrackingThrottled when the rider
+// was updated less than an interval ago.
+func (s *tripService) TrackDriver(ctx context.Context, driver *pbd.Driver, recordedAt time.Time) (*types.DriverTracking, error) {
+	trip, err := s.repo.GetActiveTripByDriverID(ctx, driver.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if trip.Status == types.TripStatusInProgress && driver.GetLocation() != nil {
+		location := &types.TripLocation{
+			Latitude:   driver.Location.Latitude,
+			Longitude:  driver.Location.Longitude,
+			RecordedAt: recordedAt,
+		}
+		if err := s.repo.AppendLocation(ctx, trip.ID.Hex(), location); err != nil {
+			return nil, fmt.Errorf("failed to record location of trip %s: %w", trip.ID.Hex(), err)
+		}
+	}
+
+	tripID := trip.ID.Hex()
+	if !s.tracking.allow(tripID, time.Now()) {
+		return nil, ErrTrackingThrottled
+	}
+
+	target := trip.RideFare.Route.PickupProto()
+	if trip.Status == types.TripStatusInProgress {
+		target = trip.RideFare.Route.DestinationProto()
+	}
+
+	tracking := &types.DriverTracking{Trip: trip}
+	if target != nil && driver.GetLocation() != nil {
+		tracking.DistanceMeters = util.HaversineDistance(
+			&sharedtypes.Coordinate{Latitude: driver.Location.Latitude, Longitude: driver.Location.Longitude},
+			&sharedtypes.Coordinate{Latitude: target.Latitude, Longitude: target.Longitude},
+		)
+		tracking.ETASeconds = tracking.DistanceMeters / (s.tracking.cfg.AvgSpeedKmh * 1000 / 3600)
+	}
+	return tracking, nil
+}
+
+// tripThrottle limits how often location updates are sent for each trip
+type tripThrottle struct {
+	mu   sync.Mutex
+	cfg  *types.TrackingConfig
+	last map[string]time.Time // trip ID -> time of the last update
+}
+
+func newTripThrottle(cfg *types.TrackingConfig) *tripThrottle {
+	return &tripThrottle{cfg: cfg, last: make(map[string]time.Time)}
+}
+
+// allow reports whether an update may be sent for the trip now, and records it if so
+func (t *tripThrottle) allow(tripID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.last[tripID]; ok && now.Sub(last) < t.cfg.Interval {
+		return false
+	}
+	t.last[tripID] = now
+	return true
+}
+
+// forget drops the throttle state of a trip that is no longer active
+func (t *tripThrottle) forget(tripID string) {
+	t.mu.Lock()
+	delete(t.last, tripID)
+	t.mu.Unlock()
+}
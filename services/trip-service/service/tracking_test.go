@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/services/trip-service/repo"
+	"github.com/cprakhar/uber-clone/services/trip-service/types"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/cprakhar/uber-clone/shared/util"
+)
+
+func TestTrackDriver(t *testing.T) {
+	// Ends of the route of createTestTrip
+	pickup := &sharedtypes.Coordinate{Latitude: 12.9716, Longitude: 77.5946}
+	destination := &sharedtypes.Coordinate{Latitude: 12.9352, Longitude: 77.6245}
+	driverAt := &pbd.Location{Latitude: 12.95, Longitude: 77.61}
+
+	tests := []struct {
+		name      string
+		status    types.TripStatus // of driver-1's trip, none if empty
+		driverID  string
+		location  *pbd.Location
+		wantErr   error
+		wantTo    *sharedtypes.Coordinate // where the distance is measured to, nil for no estimate
+		wantTrail int
+	}{
+		{name: "heading to the pickup", status: types.TripStatusDriverAssigned, driverID: "driver-1", location: driverAt, wantTo: pickup},
+		{name: "waiting at the pickup", status: types.TripStatusDriverArrived, driverID: "driver-1", location: driverAt, wantTo: pickup},
+		{name: "heading to the destination", status: types.TripStatusInProgress, driverID: "driver-1", location: driverAt, wantTo: destination, wantTrail: 1},
+		{name: "without a location", status: types.TripStatusInProgress, driverID: "driver-1"},
+		{name: "driver of another trip", status: types.TripStatusInProgress, driverID: "driver-2", location: driverAt, wantErr: repo.ErrNotFound},
+		{name: "trip completed", status: types.TripStatusCompleted, driverID: "driver-1", location: driverAt, wantErr: repo.ErrNotFound},
+		{name: "no trip", driverID: "driver-1", location: driverAt, wantErr: repo.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := repo.NewInMemoRepository()
+			svc := newTestService(r, &types.TrackingConfig{Interval: time.Second, AvgSpeedKmh: 36})
+			var tripID string
+			if tt.status != "" {
+				tripID = createTestTrip(t, r, tripSetup{status: tt.status})
+			}
+
+			recordedAt := time.Now().Add(-time.Second)
+			tracking, err := svc.TrackDriver(t.Context(), &pbd.Driver{Id: tt.driverID, Location: tt.location}, recordedAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TrackDriver() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if tracking.Trip.ID.Hex() != tripID {
+				t.Errorf("tracked trip %s, want %s", tracking.Trip.ID.Hex(), tripID)
+			}
+			var wantDistance float64
+			if tt.wantTo != nil {
+				wantDistance = util.HaversineDistance(&sharedtypes.Coordinate{Latitude: driverAt.Latitude, Longitude: driverAt.Longitude}, tt.wantTo)
+			}
+			if math.Abs(tracking.DistanceMeters-wantDistance) > 0.001 {
+				t.Errorf("distance = %f, want %f", tracking.DistanceMeters, wantDistance)
+			}
+			// 36 km/h is 10 m/s
+			if math.Abs(tracking.ETASeconds-wantDistance/10) > 0.001 {
+				t.Errorf("ETA = %fs, want %fs", tracking.ETASeconds, wantDistance/10)
+			}
+
+			trip, err := r.GetByID(t.Context(), tripID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trip.LocationTrail) != tt.wantTrail {
+				t.Fatalf("trail has %d points, want %d", len(trip.LocationTrail), tt.wantTrail)
+			}
+			if tt.wantTrail > 0 {
+				point := trip.LocationTrail[0]
+				if point.Latitude != driverAt.Latitude || point.Longitude != driverAt.Longitude || !point.RecordedAt.Equal(recordedAt) {
+					t.Errorf("trail point = %+v, want the driver's location recorded at %s", point, recordedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestTrackDriverThrottled(t *testing.T) {
+	r := repo.NewInMemoRepository()
+	svc := newTestService(r, &types.TrackingConfig{Interval: time.Hour, AvgSpeedKmh: 36})
+	tripID := createTestTrip(t, r, tripSetup{status: types.TripStatusInProgress})
+	driver := &pbd.Driver{Id: "driver-1", Location: &pbd.Location{Latitude: 12.95, Longitude: 77.61}}
+
+	if _, err := svc.TrackDriver(t.Context(), driver, time.Now()); err != nil {
+		t.Fatalf("first TrackDriver() = %v", err)
+	}
+	if _, err := svc.TrackDriver(t.Context(), driver, time.Now()); !errors.Is(err, ErrTrackingThrottled) {
+		t.Fatalf("second TrackDriver() = %v, want ErrTrackingThrottled", err)
+	}
+
+	// Throttled updates still extend the trail
+	trip, err := r.GetByID(t.Context(), tripID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trip.LocationTrail) != 2 {
+		t.Errorf("trail has %d points, want 2", len(trip.LocationTrail))
+	}
+}
+
+func TestTripThrottle(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		trip string
+		at   time.Duration // after start
+		want bool
+	}{
+		{name: "first update", trip: "trip-1", want: true},
+		{name: "within the interval", trip: "trip-1", at: 2 * time.Second},
+		{name: "another trip", trip: "trip-2", at: 2 * time.Second, want: true},
+		{name: "at the end of the interval", trip: "trip-1", at: 3 * time.Second, want: true},
+		{name: "within the interval after the last update", trip: "trip-1", at: 5999 * time.Millisecond},
+		{name: "after the interval", trip: "trip-1", at: 6 * time.Second, want: true},
+	}
+
+	throttle := newTripThrottle(&types.TrackingConfig{Interval: 3 * time.Second})
+	for _, tt := range tests {
+		if got := throttle.allow(tt.trip, start.Add(tt.at)); got != tt.want {
+			t.Errorf("%s: allow(%s, +%s) = %v, want %v", tt.name, tt.trip, tt.at, got, tt.want)
+		}
+	}
+
+	throttle.forget("trip-1")
+	if !throttle.allow("trip-1", start.Add(7*time.Second)) {
+		t.Error("allow() after forget = false, want true")
+	}
+}
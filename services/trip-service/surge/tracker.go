@@ -0,0 +1,233 @@
+package surge
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/mmcloughlin/geohash"
+)
+
+// Config controls how the surge multiplier is computed
+type Config struct {
+	Window           time.Duration // how long a supply signal, or a trip left pending, counts
+	GeohashPrecision uint          // size of the cells demand and supply are grouped by
+	MaxMultiplier    float64       // upper bound of the multiplier
+	Sensitivity      float64       // multiplier increase per unit of demand above supply
+	Step             float64       // multipliers are rounded down to a multiple of the step
+}
+
+// Update describes a change in the surge multiplier of a cell
+type Update struct {
+	Geohash    string
+	Multiplier float64
+	Demand     int
+	Supply     int
+}
+
+// Tracker counts pending trips and available drivers per geohash cell over a sliding window
+// and derives a surge multiplier for each cell
+type Tracker struct {
+	mu       sync.Mutex
+	cfg      *Config
+	demand   map[string]map[string]time.Time // cell -> trip ID -> requested at
+	supply   map[string]map[string]time.Time // cell -> driver ID -> last seen available
+	trips    map[string]string               // trip ID -> cell of the trip's pickup
+	drivers  map[string]string               // driver ID -> cell the driver was last seen in
+	current  map[string]float64              // cell -> last reported multiplier
+	onChange func(Update)
+}
+
+// NewTracker creates a surge tracker. onChange, if set, is called whenever a cell's multiplier changes.
+func NewTracker(cfg *Config, onChange func(Update)) *Tracker {
+	return &Tracker{
+		cfg:      cfg,
+		demand:   make(map[string]map[string]time.Time),
+		supply:   make(map[string]map[string]time.Time),
+		trips:    make(map[string]string),
+		drivers:  make(map[string]string),
+		current:  make(map[string]float64),
+		onChange: onChange,
+	}
+}
+
+// Cell returns the geohash cell containing the coordinate
+func (t *Tracker) Cell(c *sharedtypes.Coordinate) string {
+	return geohash.EncodeWithPrecision(c.Latitude, c.Longitude, t.cfg.GeohashPrecision)
+}
+
+// RecordDemand counts a trip waiting for a driver at the pickup coordinate, until RemoveDemand is
+// called for it or the window passes
+func (t *Tracker) RecordDemand(tripID string, pickup *sharedtypes.Coordinate, at time.Time) {
+	cell := t.Cell(pickup)
+
+	t.mu.Lock()
+	if t.demand[cell] == nil {
+		t.demand[cell] = make(map[string]time.Time)
+	}
+	t.demand[cell][tripID] = at
+	t.trips[tripID] = cell
+	update, changed := t.evaluate(cell, at)
+	t.mu.Unlock()
+
+	t.notify(update, changed)
+}
+
+// RemoveDemand stops counting the trip once it has a driver or will not get one. Unknown trips are ignored.
+func (t *Tracker) RemoveDemand(tripID string, at time.Time) {
+	t.mu.Lock()
+	cell, ok := t.trips[tripID]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.demand[cell], tripID)
+	delete(t.trips, tripID)
+	update, changed := t.evaluate(cell, at)
+	t.mu.Unlock()
+
+	t.notify(update, changed)
+}
+
+// RecordSupply registers a driver sighting. Unavailable drivers are removed from the supply immediately.
+func (t *Tracker) RecordSupply(driverID, driverGeohash string, available bool, at time.Time) {
+	if len(driverGeohash) < int(t.cfg.GeohashPrecision) {
+		return
+	}
+	cell := driverGeohash[:t.cfg.GeohashPrecision]
+
+	t.mu.Lock()
+	var updates []Update
+	if prev, ok := t.drivers[driverID]; ok && (prev != cell || !available) {
+		delete(t.supply[prev], driverID)
+		delete(t.drivers, driverID)
+		if update, changed := t.evaluate(prev, at); changed {
+			updates = append(updates, update)
+		}
+	}
+	if available {
+		if t.supply[cell] == nil {
+			t.supply[cell] = make(map[string]time.Time)
+		}
+		t.supply[cell][driverID] = at
+		t.drivers[driverID] = cell
+	}
+	if update, changed := t.evaluate(cell, at); changed {
+		updates = append(updates, update)
+	}
+	t.mu.Unlock()
+
+	for _, update := range updates {
+		t.notify(update, true)
+	}
+}
+
+// Multiplier returns the current surge multiplier for the pickup coordinate
+func (t *Tracker) Multiplier(pickup *sharedtypes.Coordinate, now time.Time) float64 {
+	cell := t.Cell(pickup)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	demand, supply := t.counts(cell, now)
+	return t.multiplier(demand, supply)
+}
+
+// Run periodically expires old signals and reports cells whose multiplier changed as a result, until ctx is done
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, update := range t.sweep(now) {
+				t.notify(update, true)
+			}
+		}
+	}
+}
+
+// sweep re-evaluates every known cell and drops cells without signals
+func (t *Tracker) sweep(now time.Time) []Update {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cells := make(map[string]struct{})
+	for cell := range t.demand {
+		cells[cell] = struct{}{}
+	}
+	for cell := range t.supply {
+		cells[cell] = struct{}{}
+	}
+
+	var updates []Update
+	for cell := range cells {
+		if update, changed := t.evaluate(cell, now); changed {
+			updates = append(updates, update)
+		}
+		if len(t.demand[cell]) == 0 && len(t.supply[cell]) == 0 {
+			delete(t.demand, cell)
+			delete(t.supply, cell)
+			delete(t.current, cell)
+		}
+	}
+	return updates
+}
+
+// evaluate recomputes the multiplier of a cell, reporting whether it differs from the last one.
+// Callers must hold t.mu.
+func (t *Tracker) evaluate(cell string, now time.Time) (Update, bool) {
+	demand, supply := t.counts(cell, now)
+	multiplier := t.multiplier(demand, supply)
+
+	prev, ok := t.current[cell]
+	if !ok {
+		prev = 1
+	}
+	t.current[cell] = multiplier
+
+	return Update{Geohash: cell, Multiplier: multiplier, Demand: demand, Supply: supply}, prev != multiplier
+}
+
+// counts prunes signals older than the window and returns the demand and supply of a cell.
+// Callers must hold t.mu.
+func (t *Tracker) counts(cell string, now time.Time) (int, int) {
+	cutoff := now.Add(-t.cfg.Window)
+	for tripID, at := range t.demand[cell] {
+		if at.Before(cutoff) {
+			delete(t.demand[cell], tripID)
+			delete(t.trips, tripID)
+		}
+	}
+	for driverID, at := range t.supply[cell] {
+		if at.Before(cutoff) {
+			delete(t.supply[cell], driverID)
+			delete(t.drivers, driverID)
+		}
+	}
+	return len(t.demand[cell]), len(t.supply[cell])
+}
+
+// multiplier maps demand and supply to a capped multiplier of at least 1
+func (t *Tracker) multiplier(demand, supply int) float64 {
+	if demand <= supply {
+		return 1
+	}
+
+	ratio := float64(demand) / float64(max(supply, 1))
+	multiplier := 1 + (ratio-1)*t.cfg.Sensitivity
+	if t.cfg.Step > 0 {
+		multiplier = 1 + math.Floor((multiplier-1)/t.cfg.Step+1e-9)*t.cfg.Step
+	}
+	return math.Round(min(multiplier, t.cfg.MaxMultiplier)*100) / 100
+}
+
+func (t *Tracker) notify(update Update, changed bool) {
+	if changed && t.onChange != nil {
+		t.onChange(update)
+	}
+}
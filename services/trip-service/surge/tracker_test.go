@@ -0,0 +1,97 @@
+package surge
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/mmcloughlin/geohash"
+)
+
+var pickup = &sharedtypes.Coordinate{Latitude: 12.9716, Longitude: 77.5946}
+
+func newTestTracker(onChange func(Update)) *Tracker {
+	return NewTracker(&Config{
+		Window:           5 * time.Minute,
+		GeohashPrecision: 5,
+		MaxMultiplier:    3,
+		Sensitivity:      0.5,
+		Step:             0.1,
+	}, onChange)
+}
+
+func TestMultiplier(t *testing.T) {
+	now := time.Date(2025, 1, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		demand    int           // pending trips at the pickup
+		demandAge time.Duration // how long before now the trips were created
+		removed   int           // trips that got a driver or were cancelled
+		supply    int           // available drivers at the pickup
+		supplyAge time.Duration // how long before now the drivers were last seen
+		want      float64
+	}{
+		{name: "no signals", want: 1},
+		{name: "demand met by supply", demand: 3, supply: 3, want: 1},
+		{name: "zero supply, single trip", demand: 1, want: 1},
+		{name: "zero supply", demand: 3, want: 2},
+		{name: "demand above supply", demand: 2, supply: 1, want: 1.5},
+		{name: "rounded down to the step", demand: 3, supply: 2, want: 1.2},
+		{name: "capped at max multiplier", demand: 20, supply: 1, want: 3},
+		{name: "capped with zero supply", demand: 20, want: 3},
+		{name: "trips leaving pending", demand: 3, removed: 2, want: 1},
+		{name: "demand inside the window", demand: 3, demandAge: 5 * time.Minute, want: 2},
+		{name: "demand outside the window", demand: 3, demandAge: 5*time.Minute + time.Second, want: 1},
+		{name: "supply outside the window", demand: 3, supply: 3, supplyAge: 6 * time.Minute, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTestTracker(nil)
+			driverGeohash := geohash.Encode(pickup.Latitude, pickup.Longitude)
+
+			for i := range tt.demand {
+				tracker.RecordDemand(fmt.Sprintf("trip-%d", i), pickup, now.Add(-tt.demandAge))
+			}
+			for i := range tt.removed {
+				tracker.RemoveDemand(fmt.Sprintf("trip-%d", i), now.Add(-tt.demandAge))
+			}
+			for i := range tt.supply {
+				tracker.RecordSupply(fmt.Sprintf("driver-%d", i), driverGeohash, true, now.Add(-tt.supplyAge))
+			}
+
+			if got := tracker.Multiplier(pickup, now); got != tt.want {
+				t.Errorf("Multiplier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 18, 0, 0, 0, time.UTC)
+	cell := geohash.EncodeWithPrecision(pickup.Latitude, pickup.Longitude, 5)
+
+	var updates []Update
+	tracker := newTestTracker(func(u Update) { updates = append(updates, u) })
+
+	for i := range 3 {
+		tracker.RecordDemand(fmt.Sprintf("trip-%d", i), pickup, now)
+	}
+	tracker.RemoveDemand("trip-0", now)
+	tracker.RemoveDemand("unknown", now)
+	tracker.RecordSupply("driver", geohash.Encode(pickup.Latitude, pickup.Longitude), true, now)
+	updates = append(updates, tracker.sweep(now.Add(6*time.Minute))...)
+
+	want := []Update{
+		{Geohash: cell, Multiplier: 1.5, Demand: 2}, // the first trip alone leaves the multiplier at 1
+		{Geohash: cell, Multiplier: 2, Demand: 3},
+		{Geohash: cell, Multiplier: 1.5, Demand: 2}, // the driver keeps the ratio at 2, so no update
+		{Geohash: cell, Multiplier: 1},              // every signal expired
+	}
+	if !slices.Equal(updates, want) {
+		t.Errorf("updates = %+v, want %+v", updates, want)
+	}
+}
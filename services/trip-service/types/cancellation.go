@@ -0,0 +1,58 @@
+package types
+
+import "time"
+
+// CancelledBy identifies which side of the trip cancelled it
+type CancelledBy string
+
+const (
+	CancelledByRider  CancelledBy = "rider"
+	CancelledByDriver CancelledBy = "driver"
+)
+
+// TripCancellation records who cancelled a trip and what it cost the rider
+type TripCancellation struct {
+	By          CancelledBy `bson:"by"`
+	RequesterID string      `bson:"requesterID"`
+	Reason      string      `bson:"reason,omitempty"`
+	FeeInPaise  int64       `bson:"feeInPaise"`
+	Currency    string      `bson:"currency"`
+	CancelledAt time.Time   `bson:"cancelledAt"`
+}
+
+// CancellationPolicy decides when a rider pays for cancelling a trip
+type CancellationPolicy struct {
+	GracePeriod time.Duration // time after a driver is assigned during which the rider may cancel for free
+	FeeInPaise  int64         // fee charged to the rider outside the grace period
+}
+
+// Fee returns the fee the rider is charged for cancelling the trip at the given time.
+// Riders cancel for free while no driver is assigned and within the grace period after assignment,
+// but pay once the driver has arrived at the pickup. Drivers cancelling never cost the rider anything.
+func (p *CancellationPolicy) Fee(trip *TripModel, by CancelledBy, now time.Time) int64 {
+	if by != CancelledByRider {
+		return 0
+	}
+
+	switch trip.Status {
+	case TripStatusDriverArrived:
+		return p.FeeInPaise
+	case TripStatusDriverAssigned:
+		assignedAt, ok := trip.StatusChangedAt(TripStatusDriverAssigned)
+		if ok && now.Sub(assignedAt) <= p.GracePeriod {
+			return 0
+		}
+		return p.FeeInPaise
+	}
+	return 0
+}
+
+// StatusChangedAt returns when the trip last entered the status
+func (t *TripModel) StatusChangedAt(status TripStatus) (time.Time, bool) {
+	for i := len(t.StatusHistory) - 1; i >= 0; i-- {
+		if t.StatusHistory[i].Status == status {
+			return t.StatusHistory[i].ChangedAt, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCancellationFee(t *testing.T) {
+	policy := &CancellationPolicy{GracePeriod: 2 * time.Minute, FeeInPaise: 5000}
+	assignedAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		status  TripStatus
+		history []TripStatus // entered in turn, one minute apart, until the driver was assigned
+		by      CancelledBy
+		after   time.Duration // since the driver was assigned
+		want    int64
+	}{
+		{name: "pending", status: TripStatusPending, by: CancelledByRider, after: time.Hour},
+		{name: "assigned within the grace period", status: TripStatusDriverAssigned, by: CancelledByRider, after: time.Minute},
+		{name: "assigned at the end of the grace period", status: TripStatusDriverAssigned, by: CancelledByRider, after: 2 * time.Minute},
+		{name: "assigned just past the grace period", status: TripStatusDriverAssigned, by: CancelledByRider, after: 2*time.Minute + time.Nanosecond, want: 5000},
+		{name: "assigned without a recorded assignment", status: TripStatusDriverAssigned, history: []TripStatus{TripStatusPending}, by: CancelledByRider, want: 5000},
+		{name: "driver arrived within the grace period", status: TripStatusDriverArrived, by: CancelledByRider, after: time.Minute, want: 5000},
+		{name: "driver cancels after arriving", status: TripStatusDriverArrived, by: CancelledByDriver, after: time.Hour},
+		{name: "driver cancels past the grace period", status: TripStatusDriverAssigned, by: CancelledByDriver, after: time.Hour},
+		{name: "not a participant", status: TripStatusDriverArrived, by: CancelledBy("support"), after: time.Hour},
+		{name: "in progress", status: TripStatusInProgress, by: CancelledByRider, after: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := tt.history
+			if history == nil {
+				history = []TripStatus{TripStatusPending, TripStatusDriverAssigned}
+			}
+			trip := &TripModel{Status: tt.status}
+			for i, status := range history {
+				changedAt := assignedAt.Add(time.Duration(i-len(history)+1) * time.Minute)
+				trip.StatusHistory = append(trip.StatusHistory, &TripStatusChange{Status: status, ChangedAt: changedAt})
+			}
+
+			if got := policy.Fee(trip, tt.by, assignedAt.Add(tt.after)); got != tt.want {
+				t.Errorf("Fee() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
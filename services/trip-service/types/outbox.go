@@ -0,0 +1,42 @@
+package types
+
+import (
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxEvent is a Kafka message waiting to be published. It is saved in the same transaction as
+// the trip change it announces, so the change is never saved without its event or the other way round.
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	TripID        string             `bson:"tripID"` // events of a trip are published in the order they were saved
+	Topic         string             `bson:"topic"`
+	SchemaVersion int                `bson:"schemaVersion"`
+	CorrelationID string             `bson:"correlationID,omitempty"`
+	CausationID   string             `bson:"causationID,omitempty"`
+	EntityID      string             `bson:"entityID"`
+	Data          []byte             `bson:"data"` // the JSON payload
+	Attempts      int                `bson:"attempts"`
+	LastError     string             `bson:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt"`
+	SentAt        *time.Time         `bson:"sentAt,omitempty"`
+}
+
+// Envelope returns the envelope to publish for the event. Its ID is the event's, so a consumer
+// sees the same ID when the relay publishes the event again.
+func (e *OutboxEvent) Envelope() *contracts.EventEnvelope {
+	return &contracts.EventEnvelope{
+		ID:            e.ID.Hex(),
+		Type:          e.Topic,
+		SchemaVersion: e.SchemaVersion,
+		OccurredAt:    e.CreatedAt.UTC(),
+		CorrelationID: e.CorrelationID,
+		CausationID:   e.CausationID,
+		EntityID:      e.EntityID,
+		Payload:       e.Data,
+		ContentType:   contracts.ContentTypeJSON,
+	}
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidTransition = errors.New("invalid trip status transition")
+
+// TripStatus represents a state in the trip lifecycle
+type TripStatus string
+
+const (
+	TripStatusPending        TripStatus = "pending"
+	TripStatusDriverAssigned TripStatus = "driver_assigned"
+	TripStatusDriverArrived  TripStatus = "driver_arrived"
+	TripStatusInProgress     TripStatus = "in_progress"
+	TripStatusCompleted      TripStatus = "completed"
+	TripStatusCancelled      TripStatus = "cancelled"
+	TripStatusNoDriversFound TripStatus = "no_drivers_found"
+	TripStatusPaymentFailed  TripStatus = "payment_failed"
+	TripStatusPaid           TripStatus = "paid"
+)
+
+// tripTransitions lists the statuses a trip may move to from each status
+var tripTransitions = map[TripStatus][]TripStatus{
+	TripStatusPending:        {TripStatusDriverAssigned, TripStatusCancelled, TripStatusNoDriversFound},
+	TripStatusDriverAssigned: {TripStatusDriverArrived, TripStatusCancelled},
+	TripStatusDriverArrived:  {TripStatusInProgress, TripStatusCancelled},
+	TripStatusInProgress:     {TripStatusCompleted},
+	TripStatusCompleted:      {TripStatusPaid, TripStatusPaymentFailed},
+	TripStatusPaymentFailed:  {TripStatusPaid},
+}
+
+// ActiveTripStatuses are the statuses in which a driver is bound to the trip
+var ActiveTripStatuses = []TripStatus{TripStatusDriverAssigned, TripStatusDriverArrived, TripStatusInProgress}
+
+// IsActive reports whether a driver is bound to a trip in status s
+func (s TripStatus) IsActive() bool {
+	for _, active := range ActiveTripStatuses {
+		if s == active {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a trip in status s may move to next
+func (s TripStatus) CanTransitionTo(next TripStatus) bool {
+	for _, allowed := range tripTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible from s
+func (s TripStatus) IsTerminal() bool {
+	return len(tripTransitions[s]) == 0
+}
+
+// TripStatusChange records when a trip entered a status
+type TripStatusChange struct {
+	Status    TripStatus `bson:"status"`
+	ChangedAt time.Time  `bson:"changedAt"`
+}
+
+// Transition moves the trip to the next status, recording the time of the change
+func (t *TripModel) Transition(next TripStatus, at time.Time) error {
+	if !t.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, t.Status, next)
+	}
+	t.Status = next
+	t.StatusHistory = append(t.StatusHistory, &TripStatusChange{Status: next, ChangedAt: at})
+	return nil
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var allTripStatuses = []TripStatus{
+	TripStatusPending,
+	TripStatusDriverAssigned,
+	TripStatusDriverArrived,
+	TripStatusInProgress,
+	TripStatusCompleted,
+	TripStatusCancelled,
+	TripStatusNoDriversFound,
+	TripStatusPaymentFailed,
+	TripStatusPaid,
+}
+
+func TestTransition(t *testing.T) {
+	// Every allowed transition, spelled out rather than read back from tripTransitions
+	allowed := map[TripStatus][]TripStatus{
+		TripStatusPending:        {TripStatusDriverAssigned, TripStatusCancelled, TripStatusNoDriversFound},
+		TripStatusDriverAssigned: {TripStatusDriverArrived, TripStatusCancelled},
+		TripStatusDriverArrived:  {TripStatusInProgress, TripStatusCancelled},
+		TripStatusInProgress:     {TripStatusCompleted},
+		TripStatusCompleted:      {TripStatusPaid, TripStatusPaymentFailed},
+		TripStatusPaymentFailed:  {TripStatusPaid},
+	}
+
+	type transition struct {
+		from, to TripStatus
+		ok       bool
+	}
+	var tests []transition
+	for _, from := range allTripStatuses {
+		for _, to := range allTripStatuses {
+			ok := false
+			for _, next := range allowed[from] {
+				ok = ok || next == to
+			}
+			tests = append(tests, transition{from, to, ok})
+		}
+	}
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			history := []*TripStatusChange{{Status: tt.from, ChangedAt: at.Add(-time.Minute)}}
+			trip := &TripModel{Status: tt.from, StatusHistory: history}
+
+			err := trip.Transition(tt.to, at)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Transition() = %v, want nil", err)
+				}
+				if trip.Status != tt.to {
+					t.Errorf("status = %s, want %s", trip.Status, tt.to)
+				}
+				if len(trip.StatusHistory) != 2 || *trip.StatusHistory[1] != (TripStatusChange{Status: tt.to, ChangedAt: at}) {
+					t.Errorf("history = %v, want the change to %s at %s appended", trip.StatusHistory, tt.to, at)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("Transition() = %v, want ErrInvalidTransition", err)
+			}
+			if trip.Status != tt.from || len(trip.StatusHistory) != 1 {
+				t.Errorf("rejected transition changed the trip to %s with %d history entries", trip.Status, len(trip.StatusHistory))
+			}
+		})
+	}
+}
+
+func TestTripStatus(t *testing.T) {
+	tests := []struct {
+		status   TripStatus
+		active   bool
+		terminal bool
+	}{
+		{TripStatusPending, false, false},
+		{TripStatusDriverAssigned, true, false},
+		{TripStatusDriverArrived, true, false},
+		{TripStatusInProgress, true, false},
+		{TripStatusCompleted, false, false},
+		{TripStatusCancelled, false, true},
+		{TripStatusNoDriversFound, false, true},
+		{TripStatusPaymentFailed, false, false},
+		{TripStatusPaid, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsActive(); got != tt.active {
+				t.Errorf("IsActive() = %v, want %v", got, tt.active)
+			}
+			if got := tt.status.IsTerminal(); got != tt.terminal {
+				t.Errorf("IsTerminal() = %v, want %v", got, tt.terminal)
+			}
+		})
+	}
+}
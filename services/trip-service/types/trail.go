@@ -0,0 +1,49 @@
+package types
+
+import (
+	"time"
+
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	sharedtypes "github.com/cprakhar/uber-clone/shared/types"
+	"github.com/cprakhar/uber-clone/shared/util"
+)
+
+// TripLocation is a driver position recorded while the trip is in progress
+type TripLocation struct {
+	Latitude   float64   `bson:"latitude"`
+	Longitude  float64   `bson:"longitude"`
+	RecordedAt time.Time `bson:"recordedAt"`
+}
+
+// TrailDistance returns the distance in meters along the recorded locations
+func TrailDistance(trail []*TripLocation) float64 {
+	var distance float64
+	for i := 1; i < len(trail); i++ {
+		distance += util.HaversineDistance(
+			&sharedtypes.Coordinate{Latitude: trail[i-1].Latitude, Longitude: trail[i-1].Longitude},
+			&sharedtypes.Coordinate{Latitude: trail[i].Latitude, Longitude: trail[i].Longitude},
+		)
+	}
+	return distance
+}
+
+// TripFinalFare is the fare charged for a completed trip, based on the distance and time actually travelled
+type TripFinalFare struct {
+	TotalFareInPaise int64   `bson:"totalFareInPaise"`
+	Currency         string  `bson:"currency"`
+	DistanceMeters   float64 `bson:"distanceMeters"`
+	DurationSeconds  float64 `bson:"durationSeconds"`
+}
+
+// ToProto converts TripFinalFare to its protobuf representation
+func (f *TripFinalFare) ToProto() *pb.FinalFare {
+	if f == nil {
+		return nil
+	}
+	return &pb.FinalFare{
+		TotalFareInPaise: f.TotalFareInPaise,
+		Currency:         f.Currency,
+		Distance:         f.DistanceMeters,
+		Duration:         f.DurationSeconds,
+	}
+}
@@ -1,16 +1,22 @@
 package types
 
 import (
+	"time"
+
 	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TripModel struct {
-	ID       primitive.ObjectID
-	RiderID  string
-	Status   string
-	RideFare *RideFareModel
-	Driver   *pb.TripDriver
+	ID            primitive.ObjectID  `bson:"_id"`
+	RiderID       string              `bson:"riderID"`
+	Status        TripStatus          `bson:"status"`
+	StatusHistory []*TripStatusChange `bson:"statusHistory"`
+	RideFare      *RideFareModel      `bson:"rideFare"`
+	Driver        *pb.TripDriver      `bson:"driver"`
+	Cancellation  *TripCancellation   `bson:"cancellation,omitempty"`
+	LocationTrail []*TripLocation     `bson:"locationTrail,omitempty"`
+	FinalFare     *TripFinalFare      `bson:"finalFare,omitempty"`
 }
 
 // ToProto converts TripModel to its protobuf representation
@@ -19,19 +25,31 @@ func (t *TripModel) ToProto() *pb.Trip {
 		Id:           t.ID.Hex(),
 		RiderID:      t.RiderID,
 		Route:        t.RideFare.Route.ToProto(),
-		Status:       t.Status,
+		Status:       string(t.Status),
 		SelectedFare: t.RideFare.ToProto(),
 		Driver:       t.Driver,
+		Pickup:       t.RideFare.Route.PickupProto(),
+		FinalFare:    t.FinalFare.ToProto(),
 	}
 
 }
 
 type RideFareModel struct {
-	ID               primitive.ObjectID
-	RiderID          string
-	PackageSlug      string
-	TotalFareInPaise float64
-	Route            *OSRMApiResponse
+	ID               primitive.ObjectID `bson:"_id"`
+	RiderID          string             `bson:"riderID"`
+	PackageSlug      string             `bson:"packageSlug"`
+	TotalFareInPaise int64              `bson:"totalFareInPaise"`
+	Currency         string             `bson:"currency"`
+	SurgeMultiplier  float64            `bson:"surgeMultiplier"`
+	Route            *OSRMApiResponse   `bson:"route"`
+	CreatedAt        time.Time          `bson:"createdAt"`
+	ExpiresAt        time.Time          `bson:"expiresAt"`
+	ConsumedAt       *time.Time         `bson:"consumedAt,omitempty"`
+}
+
+// IsExpired reports whether the fare can no longer be used at the given time
+func (r *RideFareModel) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
 }
 
 // ToProto converts RideFareModel to its protobuf representation
@@ -41,17 +59,23 @@ func (r *RideFareModel) ToProto() *pb.RideFare {
 		RiderID:          r.RiderID,
 		PackageSlug:      r.PackageSlug,
 		TotalFareInPaise: r.TotalFareInPaise,
+		SurgeMultiplier:  r.SurgeMultiplier,
+		ExpiresAt:        r.ExpiresAt.Format(time.RFC3339),
 	}
 }
 
 type OSRMApiResponse struct {
-	Routes []struct {
-		Distance float64 `json:"distance"`
-		Duration float64 `json:"duration"`
-		Geometry struct {
-			Coordinates [][]float64 `json:"coordinates"`
-		} `json:"geometry"`
-	} `json:"routes"`
+	Routes []OSRMRoute `json:"routes"`
+}
+
+type OSRMRoute struct {
+	Distance float64      `json:"distance"`
+	Duration float64      `json:"duration"`
+	Geometry OSRMGeometry `json:"geometry"`
+}
+
+type OSRMGeometry struct {
+	Coordinates [][]float64 `json:"coordinates"`
 }
 
 // ToProto converts OSRMApiResponse to its protobuf representation
@@ -82,6 +106,54 @@ func (o *OSRMApiResponse) ToProto() *pb.Route {
 	}
 }
 
+// PickupProto returns the start of the route as a protobuf coordinate, or nil for an empty route
+func (o *OSRMApiResponse) PickupProto() *pb.Coordinate {
+	if len(o.Routes) == 0 || len(o.Routes[0].Geometry.Coordinates) == 0 {
+		return nil
+	}
+
+	// GeoJSON coordinates are ordered [longitude, latitude]
+	start := o.Routes[0].Geometry.Coordinates[0]
+	if len(start) != 2 {
+		return nil
+	}
+	return &pb.Coordinate{
+		Latitude:  start[1],
+		Longitude: start[0],
+	}
+}
+
+// DestinationProto returns the end of the route as a protobuf coordinate, or nil for an empty route
+func (o *OSRMApiResponse) DestinationProto() *pb.Coordinate {
+	if len(o.Routes) == 0 || len(o.Routes[0].Geometry.Coordinates) == 0 {
+		return nil
+	}
+
+	// GeoJSON coordinates are ordered [longitude, latitude]
+	coordinates := o.Routes[0].Geometry.Coordinates
+	end := coordinates[len(coordinates)-1]
+	if len(end) != 2 {
+		return nil
+	}
+	return &pb.Coordinate{
+		Latitude:  end[1],
+		Longitude: end[0],
+	}
+}
+
+// TrackingConfig controls the driver location updates sent to riders during a trip
+type TrackingConfig struct {
+	Interval    time.Duration // minimum time between two updates sent for a trip
+	AvgSpeedKmh float64       // speed used to estimate the driver's ETA
+}
+
+// DriverTracking is the position of a trip's driver relative to where they are heading
+type DriverTracking struct {
+	Trip           *TripModel
+	DistanceMeters float64 // straight-line distance to the pickup, or to the destination once the trip started
+	ETASeconds     float64
+}
+
 // ToRideFaresProto converts a slice of RideFareModel to their protobuf representations
 func ToRideFaresProto(fares []*RideFareModel) []*pb.RideFare {
 	protoFares := make([]*pb.RideFare, len(fares))
@@ -90,16 +162,3 @@ func ToRideFaresProto(fares []*RideFareModel) []*pb.RideFare {
 	}
 	return protoFares
 }
-
-type PricingConfig struct {
-	PricePerUnitDistance float64
-	PricePerMinute       float64
-}
-
-// DefaultPricingConfig returns a default pricing configuration
-func DefaultPricingConfig() *PricingConfig {
-	return &PricingConfig{
-		PricePerUnitDistance: 10.0, // 10 Rs per km
-		PricePerMinute:       2.0,  // 2 Rs per minute
-	}
-}
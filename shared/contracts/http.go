@@ -9,3 +9,12 @@ type APIError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
+
+const (
+	// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyKeyMetadata carries the idempotency key of a request in gRPC metadata
+	IdempotencyKeyMetadata = "idempotency-key"
+	// MaxIdempotencyKeyLength is the longest idempotency key accepted
+	MaxIdempotencyKeyLength = 255
+)
@@ -1,32 +1,85 @@
 package contracts
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // KafkaMessage represents a message structure for Kafka communication.
+//
+// Deprecated: messages are published as an EventEnvelope. Consumers still read KafkaMessage while
+// producers are rolled out.
 type KafkaMessage struct {
 	EntityID string `json:"entityID"`
 	Data     []byte `json:"data"`
 }
 
+// EventEnvelope is the message published for every event and command
+type EventEnvelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`          // the event, which is also the topic it is published on
+	SchemaVersion int             `json:"schemaVersion"` // version of the payload's schema, 0 for a KafkaMessage
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Producer      string          `json:"producer"`              // service that published the event
+	CorrelationID string          `json:"correlationID"`         // shared by every event caused by the same first event
+	CausationID   string          `json:"causationID,omitempty"` // the event being handled when this one was published
+	EntityID      string          `json:"entityID"`              // the message key, e.g. the rider the event is for
+	Payload       json.RawMessage `json:"payload"`
+
+	// Data repeats the payload the way a KafkaMessage carries it, for consumers that do not read envelopes yet
+	Data []byte `json:"data,omitempty"`
+
+	// ContentType is how the payload is encoded, sent in the message's content-type header
+	ContentType string `json:"-"`
+}
+
+// Content types of Kafka messages, sent in their ContentTypeHeader
+const (
+	ContentTypeHeader   = "content-type"
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
 // Event and Command Types
 const (
 	// Trip events (trip.event.*)
 	TripEventCreated             = "trip.event.created"
 	TripEventDriverAssigned      = "trip.event.driver_assigned"
+	TripEventDriverArrived       = "trip.event.driver_arrived"
+	TripEventStarted             = "trip.event.started"
+	TripEventCompleted           = "trip.event.completed"
+	TripEventCancelled           = "trip.event.cancelled"
 	TripEventNoDriversFound      = "trip.event.no_drivers_found"
+	TripEventPaymentFailed       = "trip.event.payment_failed"
+	TripEventPaid                = "trip.event.paid"
 	TripEventDriverNotInterested = "trip.event.driver_not_interested"
 
 	// Driver commands (driver.cmd.*)
-	DriverCmdTripRequest = "driver.cmd.trip_request"
-	DriverCmdTripAccept  = "driver.cmd.trip_accept"
-	DriverCmdTripDecline = "driver.cmd.trip_decline"
-	DriverCmdLocation    = "driver.cmd.location"
-	DriverCmdRegister    = "driver.cmd.register"
+	DriverCmdTripRequest  = "driver.cmd.trip_request"
+	DriverCmdTripAccept   = "driver.cmd.trip_accept"
+	DriverCmdTripDecline  = "driver.cmd.trip_decline"
+	DriverCmdLocation     = "driver.cmd.location"
+	DriverCmdRegister     = "driver.cmd.register"
+	DriverCmdTripCancel   = "driver.cmd.trip_cancel"
+	DriverCmdTripArrived  = "driver.cmd.trip_arrived"
+	DriverCmdTripStart    = "driver.cmd.trip_start"
+	DriverCmdTripComplete = "driver.cmd.trip_complete"
+	DriverCmdAvailability = "driver.cmd.availability"
+
+	// Driver events (driver.event.*)
+	DriverEventLocationUpdated = "driver.event.location_updated"
+
+	// Pricing events (pricing.event.*)
+	PricingEventSurgeUpdated = "pricing.event.surge_updated"
 
 	// Payment events (payment.event.*)
 	PaymentEventSessionCreated = "payment.event.session_created"
 	PaymentEventSuccess        = "payment.event.success"
 	PaymentEventFailed         = "payment.event.failed"
 	PaymentEventCancelled      = "payment.event.cancelled"
+	PaymentEventRefunded       = "payment.event.refunded"
 
 	// Payment commands (payment.cmd.*)
-	PaymentCmdCreateSession = "payment.cmd.create_session"
+	PaymentCmdCreateSession         = "payment.cmd.create_session"
+	PaymentCmdChargeCancellationFee = "payment.cmd.charge_cancellation_fee"
 )
@@ -0,0 +1,35 @@
+// Package dbtest gives tests a database on the MongoDB at MONGODB_URI.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/db"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Database returns a new database named after prefix, dropped once the test ends. The test is
+// skipped if MONGODB_URI is not set.
+func Database(t testing.TB, prefix string) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("MONGODB_URI not set")
+	}
+
+	cfg := &db.MongoConfig{URI: uri, Database: fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())}
+	client, err := db.NewMongoClient(t.Context(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := db.GetDatabase(client, cfg)
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return database
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/env"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoConfig holds the configuration for connecting to MongoDB
+type MongoConfig struct {
+	URI      string
+	Database string
+}
+
+// NewMongoDefaultConfig returns a MongoConfig populated from the environment
+func NewMongoDefaultConfig() *MongoConfig {
+	return &MongoConfig{
+		URI:      env.GetString("MONGODB_URI", "mongodb://localhost:27017"),
+		Database: env.GetString("MONGODB_DATABASE", "uber-clone"),
+	}
+}
+
+// NewMongoClient connects to MongoDB and verifies the connection with a ping
+func NewMongoClient(ctx context.Context, cfg *MongoConfig) (*mongo.Client, error) {
+	if cfg.URI == "" {
+		return nil, fmt.Errorf("mongodb URI is required")
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
+	}
+
+	if err := client.Ping(connectCtx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping mongodb: %w", err)
+	}
+
+	return client, nil
+}
+
+// GetDatabase returns the configured database handle from the client
+func GetDatabase(client *mongo.Client, cfg *MongoConfig) *mongo.Database {
+	return client.Database(cfg.Database)
+}
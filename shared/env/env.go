@@ -57,3 +57,17 @@ func GetDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return durationVal
 }
+
+// GetFloat retrieves the value of the environment variable named by the key and converts it to a float64.
+// If the variable is empty, not present, or cannot be converted to a float64, it returns the specified default value.
+func GetFloat(key string, defaultValue float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+	floatVal, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return floatVal
+}
@@ -0,0 +1,112 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	"github.com/google/uuid"
+)
+
+// Event ties an event type to the type of its payload and the schema version producers publish.
+// A new version may only add fields, so consumers still decode versions newer than theirs.
+type Event[T any] struct {
+	Type    string
+	Version int
+}
+
+type envelopeKey struct{}
+
+// ContextWithEnvelope returns a context carrying the envelope being handled. Events published with
+// it are correlated to the envelope and caused by it.
+func ContextWithEnvelope(ctx context.Context, env *contracts.EventEnvelope) context.Context {
+	return context.WithValue(ctx, envelopeKey{}, env)
+}
+
+// EnvelopeFromContext returns the envelope being handled, if any
+func EnvelopeFromContext(ctx context.Context) (*contracts.EventEnvelope, bool) {
+	env, ok := ctx.Value(envelopeKey{}).(*contracts.EventEnvelope)
+	return env, ok
+}
+
+// NewEnvelope wraps the payload in an envelope of the event, keyed by entityID, with the payload
+// encoded as JSON. An event published while another is handled carries its correlation ID and
+// names it as its cause.
+func NewEnvelope[T any](ctx context.Context, event Event[T], entityID string, payload T) (*contracts.EventEnvelope, error) {
+	return newEnvelope(ctx, event, entityID, payload, kafka.JSONSerializer{})
+}
+
+func newEnvelope[T any](ctx context.Context, event Event[T], entityID string, payload T, s kafka.Serializer) (*contracts.EventEnvelope, error) {
+	data, err := s.MarshalPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", event.Type, err)
+	}
+
+	env := &contracts.EventEnvelope{
+		ID:            uuid.NewString(),
+		Type:          event.Type,
+		SchemaVersion: event.Version,
+		OccurredAt:    time.Now().UTC(),
+		EntityID:      entityID,
+		Payload:       data,
+		ContentType:   s.ContentType(),
+	}
+	env.CorrelationID = env.ID
+	if cause, ok := EnvelopeFromContext(ctx); ok {
+		if cause.CorrelationID != "" {
+			env.CorrelationID = cause.CorrelationID
+		}
+		env.CausationID = cause.ID
+	}
+	return env, nil
+}
+
+// DecodeEnvelope reads the envelope of a consumed message with the serializer of its content type.
+// A KafkaMessage published before envelopes is read as an envelope of schema version 0 with only
+// the entity ID and payload set.
+func DecodeEnvelope(msg *ckafka.Message) (*contracts.EventEnvelope, error) {
+	env, err := kafka.DecodeEnvelope(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	if env.Type == "" {
+		env.Type = *msg.TopicPartition.Topic
+	}
+	return env, nil
+}
+
+// PayloadJSON returns the envelope's payload as JSON, decoding a payload of another content type
+// with the payload type of its event. An empty protobuf payload is the zero payload, not a missing one.
+func PayloadJSON(env *contracts.EventEnvelope) (json.RawMessage, error) {
+	if env.ContentType == contracts.ContentTypeJSON {
+		return env.Payload, nil
+	}
+	return transcode(env, kafka.JSONSerializer{})
+}
+
+// transcode re-encodes the envelope's payload with the serializer
+func transcode(env *contracts.EventEnvelope, to kafka.Serializer) ([]byte, error) {
+	from, err := kafka.SerializerFor(env.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := payloads[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("no payload type for %s events to convert from %s to %s", env.Type, from.ContentType(), to.ContentType())
+	}
+
+	payload := p.new()
+	if err := from.UnmarshalPayload(env.Payload, payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s payload: %w", env.Type, err)
+	}
+	data, err := to.MarshalPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", env.Type, err)
+	}
+	return data, nil
+}
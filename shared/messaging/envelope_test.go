@@ -0,0 +1,183 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPublishHandle(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		legacyData  bool
+		wantData    bool // the envelope repeats its payload for KafkaMessage consumers
+	}{
+		{name: "json", contentType: contracts.ContentTypeJSON},
+		{name: "json with legacy data", contentType: contracts.ContentTypeJSON, legacyData: true, wantData: true},
+		{name: "protobuf", contentType: contracts.ContentTypeProtobuf},
+		{name: "protobuf with legacy data", contentType: contracts.ContentTypeProtobuf, legacyData: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := newTestBroker(t, TripCreated.Type)
+			t.Setenv("KAFKA_CONTENT_TYPE", tt.contentType)
+			t.Setenv("EVENT_LEGACY_DATA", strconv.FormatBool(tt.legacyData))
+			producer, err := kafka.NewProducer([]string{broker.BootstrapServers()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer producer.Close()
+			publisher := NewPublisher(producer, "trip-service")
+
+			cause := &contracts.EventEnvelope{ID: "cause-1", CorrelationID: "flow-1"}
+			trip := &pb.Trip{Id: "trip-1", RiderID: "rider-1", Status: "pending"}
+			ctx := ContextWithEnvelope(t.Context(), cause)
+			if err := PublishAndWait(ctx, publisher, TripCreated, trip.RiderID, TripEventData{Trip: trip}, 5*time.Second); err != nil {
+				t.Fatalf("PublishAndWait() = %v", err)
+			}
+
+			msg := readMessage(t, broker, TripCreated.Type)
+			if got := kafka.ContentType(msg); got != tt.contentType {
+				t.Errorf("content type = %s, want %s", got, tt.contentType)
+			}
+			if tt.contentType == contracts.ContentTypeJSON {
+				var legacy contracts.KafkaMessage
+				if err := json.Unmarshal(msg.Value, &legacy); err != nil {
+					t.Fatal(err)
+				}
+				if got := len(legacy.Data) > 0; got != tt.wantData {
+					t.Errorf("envelope has legacy data %v, want %v", got, tt.wantData)
+				}
+			}
+
+			var handled *contracts.EventEnvelope
+			var payload TripEventData
+			router := NewRouter()
+			Handle(router, TripCreated, func(ctx context.Context, env *contracts.EventEnvelope, p TripEventData) error {
+				if fromCtx, ok := EnvelopeFromContext(ctx); !ok || fromCtx != env {
+					t.Error("handler's context does not carry the envelope")
+				}
+				handled, payload = env, p
+				return nil
+			})
+			if err := router.HandleMessage(t.Context(), msg); err != nil {
+				t.Fatalf("HandleMessage() = %v", err)
+			}
+			if handled == nil {
+				t.Fatal("handler not called")
+			}
+
+			if !proto.Equal(payload.Trip, trip) {
+				t.Errorf("payload trip = %v, want %v", payload.Trip, trip)
+			}
+			want := contracts.EventEnvelope{
+				ID:            handled.ID,
+				Type:          TripCreated.Type,
+				SchemaVersion: TripCreated.Version,
+				OccurredAt:    handled.OccurredAt,
+				Producer:      "trip-service",
+				CorrelationID: cause.CorrelationID,
+				CausationID:   cause.ID,
+				EntityID:      trip.RiderID,
+				ContentType:   tt.contentType,
+			}
+			got := *handled
+			got.Payload = nil
+			if !envelopeEqual(got, want) {
+				t.Errorf("envelope = %+v, want %+v", got, want)
+			}
+			if handled.ID == "" || handled.ID == cause.ID || time.Since(handled.OccurredAt) > time.Minute {
+				t.Errorf("envelope has ID %q occurred at %s, want a new ID and the time it was published", handled.ID, handled.OccurredAt)
+			}
+		})
+	}
+}
+
+func TestNewEnvelopeCorrelation(t *testing.T) {
+	tests := []struct {
+		name            string
+		cause           *contracts.EventEnvelope
+		wantCorrelation string // "" for the envelope's own ID
+		wantCausation   string
+	}{
+		{name: "first event of a flow"},
+		{name: "caused by a handled event", cause: &contracts.EventEnvelope{ID: "cause-1", CorrelationID: "flow-1"}, wantCorrelation: "flow-1", wantCausation: "cause-1"},
+		{name: "caused by a legacy message", cause: &contracts.EventEnvelope{ID: "cause-1"}, wantCausation: "cause-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			if tt.cause != nil {
+				ctx = ContextWithEnvelope(ctx, tt.cause)
+			}
+			env, err := NewEnvelope(ctx, TripCreated, "rider-1", TripEventData{Trip: &pb.Trip{Id: "trip-1"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantCorrelation := tt.wantCorrelation
+			if wantCorrelation == "" {
+				wantCorrelation = env.ID
+			}
+			if env.CorrelationID != wantCorrelation || env.CausationID != tt.wantCausation {
+				t.Errorf("correlation %q, causation %q, want %q, %q", env.CorrelationID, env.CausationID, wantCorrelation, tt.wantCausation)
+			}
+		})
+	}
+}
+
+// newTestBroker starts an in-process Kafka cluster with a single partition for each topic
+func newTestBroker(t *testing.T, topics ...string) *ckafka.MockCluster {
+	t.Helper()
+	broker, err := ckafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(broker.Close)
+	for _, topic := range topics {
+		if err := broker.CreateTopic(topic, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return broker
+}
+
+// readMessage returns the first message of the topic
+func readMessage(t *testing.T, broker *ckafka.MockCluster, topic string) *ckafka.Message {
+	t.Helper()
+	consumer, err := ckafka.NewConsumer(&ckafka.ConfigMap{
+		"bootstrap.servers": broker.BootstrapServers(),
+		"group.id":          "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	if err := consumer.Assign([]ckafka.TopicPartition{{Topic: &topic, Partition: 0, Offset: ckafka.OffsetBeginning}}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := consumer.ReadMessage(5 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to read from %s: %v", topic, err)
+	}
+	return msg
+}
+
+func envelopeEqual(a, b contracts.EventEnvelope) bool {
+	return a.ID == b.ID && a.Type == b.Type && a.SchemaVersion == b.SchemaVersion &&
+		a.OccurredAt.Equal(b.OccurredAt) && a.Producer == b.Producer && a.CorrelationID == b.CorrelationID &&
+		a.CausationID == b.CausationID && a.EntityID == b.EntityID && string(a.Payload) == string(b.Payload) &&
+		string(a.Data) == string(b.Data) && a.ContentType == b.ContentType
+}
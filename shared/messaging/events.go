@@ -0,0 +1,82 @@
+package messaging
+
+import (
+	"sort"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Events published to Kafka with their payload types and current schema versions
+var (
+	TripCreated             = newEvent[TripEventData](contracts.TripEventCreated, 1)
+	TripDriverAssigned      = newEvent[TripEventData](contracts.TripEventDriverAssigned, 1)
+	TripDriverArrived       = newEvent[TripEventData](contracts.TripEventDriverArrived, 1)
+	TripStarted             = newEvent[TripEventData](contracts.TripEventStarted, 1)
+	TripCompleted           = newEvent[TripEventData](contracts.TripEventCompleted, 1)
+	TripCancelled           = newEvent[TripEventData](contracts.TripEventCancelled, 1)
+	TripNoDriversFound      = newEvent[TripEventData](contracts.TripEventNoDriversFound, 1)
+	TripPaymentFailed       = newEvent[TripEventData](contracts.TripEventPaymentFailed, 1)
+	TripPaid                = newEvent[TripEventData](contracts.TripEventPaid, 1)
+	TripDriverNotInterested = newEvent[DriverNotInterestedData](contracts.TripEventDriverNotInterested, 1)
+
+	DriverTripRequest = newEvent[TripEventData](contracts.DriverCmdTripRequest, 1)
+	DriverTripAccept  = newEvent[DriverTripResponseData](contracts.DriverCmdTripAccept, 1)
+	DriverTripDecline = newEvent[DriverTripResponseData](contracts.DriverCmdTripDecline, 1)
+	DriverLocation    = newEvent[DriverTrackingList](contracts.DriverCmdLocation, 1)
+
+	DriverLocationUpdated = newEvent[DriverLocationEventData](contracts.DriverEventLocationUpdated, 1)
+
+	SurgeUpdated = newEvent[SurgeUpdatedData](contracts.PricingEventSurgeUpdated, 1)
+
+	PaymentSessionCreated = newEvent[PaymentEventSessionCreatedData](contracts.PaymentEventSessionCreated, 1)
+	PaymentSucceeded      = newEvent[PaymentStatusUpdateData](contracts.PaymentEventSuccess, 1)
+	PaymentFailed         = newEvent[PaymentStatusUpdateData](contracts.PaymentEventFailed, 1)
+	PaymentCancelled      = newEvent[PaymentStatusUpdateData](contracts.PaymentEventCancelled, 1)
+	PaymentRefunded       = newEvent[PaymentRefundedData](contracts.PaymentEventRefunded, 1)
+
+	PaymentCreateSession         = newEvent[PaymentTripResponseData](contracts.PaymentCmdCreateSession, 1)
+	PaymentChargeCancellationFee = newEvent[PaymentTripResponseData](contracts.PaymentCmdChargeCancellationFee, 1)
+)
+
+// eventPayload is what the catalog knows of an event type's payload
+type eventPayload struct {
+	version int
+	new     func() kafka.ProtoPayload
+}
+
+// payloads holds the payload of every event in the catalog, by event type
+var payloads = map[string]eventPayload{}
+
+func newEvent[T any, PT interface {
+	*T
+	kafka.ProtoPayload
+}](eventType string, version int) Event[T] {
+	payloads[eventType] = eventPayload{
+		version: version,
+		new:     func() kafka.ProtoPayload { return PT(new(T)) },
+	}
+	return Event[T]{Type: eventType, Version: version}
+}
+
+// PayloadSchema is the protobuf message of an event's payload at the version producers publish
+type PayloadSchema struct {
+	Type    string
+	Version int
+	Message protoreflect.MessageDescriptor
+}
+
+// PayloadSchemas returns the payload schema of every event in the catalog, sorted by event type
+func PayloadSchemas() []PayloadSchema {
+	schemas := make([]PayloadSchema, 0, len(payloads))
+	for eventType, p := range payloads {
+		schemas = append(schemas, PayloadSchema{
+			Type:    eventType,
+			Version: p.version,
+			Message: p.new().ToProto().ProtoReflect().Descriptor(),
+		})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Type < schemas[j].Type })
+	return schemas
+}
@@ -2,25 +2,46 @@ package kafka
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
+// forwardTimeout is how long Kafka has to acknowledge a message sent to a retry or dead-letter topic
+const forwardTimeout = 10 * time.Second
+
 // Consumer wraps a Kafka consumer.
 type Consumer struct {
-	cr *kafka.Consumer // Kafka consumer instance
+	cr      *kafka.Consumer // Kafka consumer instance
+	groupID string
+	forward func(ctx context.Context, msg *kafka.Message) error // sends failed messages to retry and dead-letter topics
+	retry   *RetryPolicy
+	pool    *PoolConfig
+	paused  map[string]*pausedPartition // partitions waiting for a retry, keyed by topic and partition
+	workers *workerPool                 // set while messages are handled by a worker pool
+}
+
+// pausedPartition is a partition rewound to a message that is polled again once it is resumed
+type pausedPartition struct {
+	partition kafka.TopicPartition
+	until     time.Time
 }
 
 // NewConsumer creates a confluent consumer with safe defaults.
-func newConsumer(brokers []string, groupID string) (*Consumer, error) {
+func newConsumer(brokers []string, groupID string, producer *Producer, retry *RetryPolicy, pool *PoolConfig) (*Consumer, error) {
 	cfg := &kafka.ConfigMap{
 		"bootstrap.servers":  strings.Join(brokers, ","),
 		"group.id":           groupID,
 		"auto.offset.reset":  "earliest",
 		"enable.auto.commit": false,
 		"session.timeout.ms": 6000,
+		// Retry topics only exist once a message has failed
+		"allow.auto.create.topics": true,
 	}
 
 	cr, err := kafka.NewConsumer(cfg)
@@ -28,15 +49,44 @@ func newConsumer(brokers []string, groupID string) (*Consumer, error) {
 		return nil, err
 	}
 
-	return &Consumer{cr: cr}, nil
+	return &Consumer{
+		cr:      cr,
+		groupID: groupID,
+		forward: func(ctx context.Context, msg *kafka.Message) error {
+			return producer.produceAndWait(ctx, msg, forwardTimeout)
+		},
+		retry:  retry,
+		pool:   pool,
+		paused: make(map[string]*pausedPartition),
+	}, nil
 }
 
 // MessageHandler defines the function signature for processing Kafka messages.
 type MessageHandler func(context.Context, *kafka.Message) error
 
 // subscribeAndConsume subscribes to the given topic and processes messages using the provided handler.
+// A message the handler fails is sent to the group's retry topic and handled again after the retry
+// policy's backoff, with the topic it was first published on. Once its attempts are used up it goes
+// to the topic's dead-letter topic. A message is committed once it is handled, retried or dead-lettered.
+//
+// With more than one worker in the pool config, messages are handled concurrently, in order per key,
+// and an offset is committed only once every earlier message in its partition is done. On shutdown
+// the queued messages are drained before it returns.
 func (c *Consumer) SubscribeAndConsume(ctx context.Context, topics []string, handler MessageHandler) error {
-	if err := c.cr.SubscribeTopics(topics, nil); err != nil {
+	subscribed := slices.Clone(topics)
+	if c.retry.Attempts > 1 {
+		for _, topic := range topics {
+			subscribed = append(subscribed, RetryTopic(topic, c.groupID))
+		}
+	}
+	if c.pool.Workers > 1 {
+		c.workers = newWorkerPool(ctx, c, handler)
+		defer func() {
+			c.workers.drain()
+			c.workers = nil
+		}()
+	}
+	if err := c.cr.SubscribeTopics(subscribed, c.rebalanced); err != nil {
 		return err
 	}
 
@@ -45,22 +95,21 @@ func (c *Consumer) SubscribeAndConsume(ctx context.Context, topics []string, han
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
+			c.resumeDue(time.Now())
+			if c.workers != nil {
+				c.workers.commit()
+			}
+
 			e := c.cr.Poll(100)
 			if e == nil {
 				continue
 			}
 			switch ev := e.(type) {
 			case *kafka.Message:
-				if err := handler(ctx, ev); err != nil {
-					log.Printf("Error handling message: %v", err)
-					continue
-				}
-				if ev.Headers != nil {
-					log.Printf("Headers: %v\n", ev.Headers)
-				}
-				log.Printf("Message on %v: %s\n", ev.TopicPartition, string(ev.Value))
-				if _, err := c.cr.CommitMessage(ev); err != nil {
-					log.Printf("Failed to commit message: %v", err)
+				if c.workers != nil {
+					c.workers.dispatch(ctx, ev)
+				} else {
+					c.process(ctx, ev, handler)
 				}
 			case kafka.Error:
 				log.Printf("Kafka error: %v, code: %v\n", ev, ev.Code())
@@ -71,6 +120,149 @@ func (c *Consumer) SubscribeAndConsume(ctx context.Context, topics []string, han
 	}
 }
 
+// process handles the message on the polling goroutine and commits it once it is handled or passed
+// on. A retry that is not due yet pauses its partition instead.
+func (c *Consumer) process(ctx context.Context, msg *kafka.Message, handler MessageHandler) {
+	if notBefore, ok := c.notBefore(msg); ok {
+		c.pause(msg.TopicPartition, notBefore)
+		return
+	}
+
+	if err := c.handle(ctx, msg, handler); err != nil {
+		if ctx.Err() != nil {
+			// Shutting down, the message is polled again after a restart
+			return
+		}
+		log.Printf("Failed to pass on failed message from %v: %v", msg.TopicPartition, err)
+		c.pause(msg.TopicPartition, time.Now().Add(c.retry.Backoff))
+		return
+	}
+
+	if _, err := c.cr.CommitMessage(msg); err != nil {
+		log.Printf("Failed to commit message: %v", err)
+	}
+}
+
+// handle hands the message to the handler, with the topic it was first published on, and passes it
+// on to a retry or dead-letter topic if the handler fails. It returns an error if the message was
+// neither handled nor passed on, so it must not be committed.
+func (c *Consumer) handle(ctx context.Context, msg *kafka.Message, handler MessageHandler) error {
+	topic := *msg.TopicPartition.Topic
+	failures := 0
+	if c.isRetryTopic(topic) {
+		topic = header(msg, HeaderOriginalTopic)
+		failures = int(headerInt(msg, HeaderAttempts))
+	}
+
+	handled := *msg
+	handled.TopicPartition.Topic = &topic
+	if err := handler(ctx, &handled); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Error handling message: %v", err)
+		return c.fail(ctx, msg, topic, failures+1, err)
+	}
+
+	if msg.Headers != nil {
+		log.Printf("Headers: %v\n", msg.Headers)
+	}
+	log.Printf("Message on %v: %s\n", msg.TopicPartition, string(msg.Value))
+	return nil
+}
+
+// notBefore returns when a retried message is due, if that is still to come
+func (c *Consumer) notBefore(msg *kafka.Message) (time.Time, bool) {
+	if !c.isRetryTopic(*msg.TopicPartition.Topic) {
+		return time.Time{}, false
+	}
+	notBefore := time.UnixMilli(headerInt(msg, HeaderNotBefore))
+	return notBefore, time.Now().Before(notBefore)
+}
+
+// fail sends a message the handler failed to the group's retry topic, or to the dead-letter topic
+// once it failed as often as the retry policy allows
+func (c *Consumer) fail(ctx context.Context, msg *kafka.Message, topic string, failures int, handlerErr error) error {
+	now := time.Now()
+	headers := map[string]string{
+		HeaderOriginalTopic:     topic,
+		HeaderOriginalPartition: strconv.Itoa(int(msg.TopicPartition.Partition)),
+		HeaderOriginalOffset:    msg.TopicPartition.Offset.String(),
+		HeaderConsumerGroup:     c.groupID,
+		HeaderAttempts:          strconv.Itoa(failures),
+		HeaderError:             handlerErr.Error(),
+		HeaderFailedAt:          now.UTC().Format(time.RFC3339),
+	}
+	if c.isRetryTopic(*msg.TopicPartition.Topic) {
+		// Keep the position the message had on the topic it was first published on
+		headers[HeaderOriginalPartition] = header(msg, HeaderOriginalPartition)
+		headers[HeaderOriginalOffset] = header(msg, HeaderOriginalOffset)
+	}
+
+	target := DeadLetterTopic(topic)
+	if failures < c.retry.Attempts {
+		target = RetryTopic(topic, c.groupID)
+		retryAt := now.Add(c.retry.delay(failures))
+		headers[HeaderNotBefore] = strconv.FormatInt(retryAt.UnixMilli(), 10)
+		log.Printf("Retrying message from %s at %s (attempt %d of %d)", topic, retryAt.Format(time.RFC3339), failures+1, c.retry.Attempts)
+	} else {
+		log.Printf("Sending message from %s to %s after %d failed attempts", topic, target, failures)
+	}
+
+	if err := c.forward(ctx, failedCopy(msg, target, headers)); err != nil {
+		return fmt.Errorf("failed to send message to %s: %w", target, err)
+	}
+	return nil
+}
+
+// pause stops fetching the partition until the given time and rewinds it to the partition's offset,
+// so the message there is polled again once the partition is resumed
+func (c *Consumer) pause(partition kafka.TopicPartition, until time.Time) {
+	if err := c.cr.Pause([]kafka.TopicPartition{partition}); err != nil {
+		log.Printf("Failed to pause %v: %v", partition, err)
+	}
+	if _, err := c.cr.SeekPartitions([]kafka.TopicPartition{partition}); err != nil {
+		log.Printf("Failed to rewind %v: %v", partition, err)
+	}
+	c.paused[partitionKey(partition)] = &pausedPartition{partition: partition, until: until}
+}
+
+// resumeDue resumes the paused partitions whose wait is over
+func (c *Consumer) resumeDue(now time.Time) {
+	for key, p := range c.paused {
+		if now.Before(p.until) {
+			continue
+		}
+		if err := c.cr.Resume([]kafka.TopicPartition{p.partition}); err != nil {
+			log.Printf("Failed to resume %v: %v", p.partition, err)
+		}
+		delete(c.paused, key)
+	}
+}
+
+// rebalanced forgets paused partitions that were revoked. Whoever is assigned them next starts
+// from their committed offset, which is before the message waiting for a retry. With a worker pool
+// it first waits for the revoked partitions' messages to be handled and commits them.
+func (c *Consumer) rebalanced(_ *kafka.Consumer, ev kafka.Event) error {
+	if revoked, ok := ev.(kafka.RevokedPartitions); ok {
+		if c.workers != nil {
+			c.workers.revoke(revoked.Partitions)
+		}
+		for _, p := range revoked.Partitions {
+			delete(c.paused, partitionKey(p))
+		}
+	}
+	return nil
+}
+
+func (c *Consumer) isRetryTopic(topic string) bool {
+	return strings.HasSuffix(topic, retryTopicSuffix+"."+c.groupID)
+}
+
+func partitionKey(p kafka.TopicPartition) string {
+	return fmt.Sprintf("%s/%d", *p.Topic, p.Partition)
+}
+
 // Close shuts down the consumer.
 func (c *Consumer) Close() {
 	if c.cr != nil {
@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// DeadLetter is a message a consumer group gave up on, read from a dead-letter topic
+type DeadLetter struct {
+	Partition         int32 // position in the dead-letter topic
+	Offset            int64
+	Topic             string // the topic the message was first published on
+	OriginalPartition string
+	OriginalOffset    string
+	ConsumerGroup     string
+	Attempts          int
+	Error             string
+	FailedAt          time.Time
+	Key               []byte
+	Value             []byte
+	ContentType       string
+
+	message *kafka.Message
+}
+
+func newDeadLetter(msg *kafka.Message) *DeadLetter {
+	failedAt, _ := time.Parse(time.RFC3339, header(msg, HeaderFailedAt))
+	return &DeadLetter{
+		Partition:         msg.TopicPartition.Partition,
+		Offset:            int64(msg.TopicPartition.Offset),
+		Topic:             header(msg, HeaderOriginalTopic),
+		OriginalPartition: header(msg, HeaderOriginalPartition),
+		OriginalOffset:    header(msg, HeaderOriginalOffset),
+		ConsumerGroup:     header(msg, HeaderConsumerGroup),
+		Attempts:          int(headerInt(msg, HeaderAttempts)),
+		Error:             header(msg, HeaderError),
+		FailedAt:          failedAt,
+		Key:               msg.Key,
+		Value:             msg.Value,
+		ContentType:       ContentType(msg),
+		message:           msg,
+	}
+}
+
+// ReadDeadLetters reads every message in the dead-letter topic of the topic, oldest first per partition.
+// It commits no offsets, so the messages stay in the topic.
+func ReadDeadLetters(ctx context.Context, brokers []string, topic string) ([]*DeadLetter, error) {
+	dlq := DeadLetterTopic(topic)
+	cr, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  strings.Join(brokers, ","),
+		"group.id":           "dlq-reader",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+
+	md, err := cr.GetMetadata(&dlq, false, 10000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata of %s: %w", dlq, err)
+	}
+	tm, ok := md.Topics[dlq]
+	if !ok || tm.Error.Code() == kafka.ErrUnknownTopicOrPart {
+		return nil, nil
+	}
+	if tm.Error.Code() != kafka.ErrNoError {
+		return nil, fmt.Errorf("failed to get metadata of %s: %w", dlq, tm.Error)
+	}
+
+	// Read each partition up to its current end
+	end := make(map[int32]int64)
+	var partitions []kafka.TopicPartition
+	for _, p := range tm.Partitions {
+		low, high, err := cr.QueryWatermarkOffsets(dlq, p.ID, 10000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get offsets of %s [%d]: %w", dlq, p.ID, err)
+		}
+		if high > low {
+			end[p.ID] = high
+			partitions = append(partitions, kafka.TopicPartition{Topic: &dlq, Partition: p.ID, Offset: kafka.Offset(low)})
+		}
+	}
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+	if err := cr.Assign(partitions); err != nil {
+		return nil, fmt.Errorf("failed to assign %s: %w", dlq, err)
+	}
+
+	var letters []*DeadLetter
+	for len(end) > 0 {
+		if err := ctx.Err(); err != nil {
+			return letters, err
+		}
+
+		switch ev := cr.Poll(500).(type) {
+		case *kafka.Message:
+			letters = append(letters, newDeadLetter(ev))
+			if int64(ev.TopicPartition.Offset) >= end[ev.TopicPartition.Partition]-1 {
+				delete(end, ev.TopicPartition.Partition)
+			}
+		case kafka.Error:
+			if ev.IsFatal() {
+				return letters, ev
+			}
+		}
+	}
+	return letters, nil
+}
+
+// Replay publishes the dead letter again on the topic it was first published on, without its
+// retry headers, and waits for Kafka to acknowledge it
+func (p *Producer) Replay(ctx context.Context, letter *DeadLetter, timeout time.Duration) error {
+	if letter.Topic == "" {
+		return errors.New("dead letter has no original topic")
+	}
+
+	msg := failedCopy(letter.message, letter.Topic, map[string]string{
+		HeaderReplayedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	return p.produceAndWait(ctx, msg, timeout)
+}
@@ -7,13 +7,15 @@ type KafkaClient struct {
 }
 
 // NewKafkaClient creates a new KafkaClient with the given brokers and group ID.
+// Failed messages are retried under the retry policy configured in the environment, and messages
+// are handled by the worker pool configured there.
 func NewKafkaClient(brokers []string, groupID string) (*KafkaClient, error) {
-	p, err := newProducer(brokers)
+	p, err := NewProducer(brokers)
 	if err != nil {
 		return nil, err
 	}
 
-	c, err := newConsumer(brokers, groupID)
+	c, err := newConsumer(brokers, groupID, p, NewDefaultRetryPolicy(), NewDefaultPoolConfig())
 	if err != nil {
 		p.Close()
 		return nil, err
@@ -0,0 +1,354 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/env"
+)
+
+// PoolConfig decides how many messages a consumer handles at once
+type PoolConfig struct {
+	Workers      int           // goroutines handling messages, 1 handles every message in turn on the polling goroutine
+	QueueSize    int           // messages waiting for each worker before polling blocks
+	DrainTimeout time.Duration // time queued and running messages have to finish on shutdown or rebalance
+}
+
+// NewDefaultPoolConfig returns a PoolConfig populated from the environment
+func NewDefaultPoolConfig() *PoolConfig {
+	return &PoolConfig{
+		Workers:      env.GetInt("KAFKA_CONSUMER_WORKERS", 1),
+		QueueSize:    env.GetInt("KAFKA_CONSUMER_QUEUE_SIZE", 16),
+		DrainTimeout: env.GetDuration("KAFKA_CONSUMER_DRAIN_TIMEOUT", 10*time.Second),
+	}
+}
+
+// workerPool handles messages on a fixed set of workers. Messages with the same key always go to
+// the same worker, so they are handled in the order they were polled while other keys run in parallel.
+type workerPool struct {
+	c       *Consumer
+	handler MessageHandler
+	queues  []chan *kafka.Message
+	offsets *offsetTracker
+	wg      sync.WaitGroup
+	commits func([]kafka.TopicPartition) ([]kafka.TopicPartition, error) // commits offsets on the consumer
+
+	ctx    context.Context // handlers' context, cancelled once a drain times out
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	delayed  map[*kafka.Message]*time.Timer // messages waiting to be queued again
+	requeues sync.WaitGroup                 // delayed messages being queued again
+	stopping chan struct{}                  // closed once the pool drains, so delayed messages are not queued again
+	stopped  bool
+}
+
+// newWorkerPool starts the workers. Handlers keep running after ctx is cancelled until the pool is
+// drained, so they can finish the messages they were given.
+func newWorkerPool(ctx context.Context, c *Consumer, handler MessageHandler) *workerPool {
+	poolCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	p := &workerPool{
+		c:        c,
+		handler:  handler,
+		queues:   make([]chan *kafka.Message, c.pool.Workers),
+		offsets:  newOffsetTracker(),
+		commits:  c.cr.CommitOffsets,
+		ctx:      poolCtx,
+		cancel:   cancel,
+		delayed:  make(map[*kafka.Message]*time.Timer),
+		stopping: make(chan struct{}),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan *kafka.Message, c.pool.QueueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+// dispatch queues the message on the worker for its key. It blocks while that worker's queue is
+// full, unless ctx is cancelled, which leaves the message uncommitted.
+func (p *workerPool) dispatch(ctx context.Context, msg *kafka.Message) {
+	// Started before it is queued, so a worker cannot finish it first
+	p.offsets.start(msg.TopicPartition)
+	select {
+	case p.queue(msg) <- msg:
+	case <-ctx.Done():
+		p.offsets.abandon(msg.TopicPartition)
+	}
+}
+
+// queue returns the queue of the worker for the message's key
+func (p *workerPool) queue(msg *kafka.Message) chan<- *kafka.Message {
+	key := msg.Key
+	if len(key) == 0 {
+		// Unkeyed messages keep the order of their partition
+		key = []byte(partitionKey(msg.TopicPartition))
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return p.queues[h.Sum32()%uint32(len(p.queues))]
+}
+
+// work handles the messages of the queue. A retry that is not due yet, or a message that could not
+// be passed on to a retry topic, is queued again later instead of holding up the worker's other
+// keys, so later messages of its key may be handled first, as they are once it is retried.
+func (p *workerPool) work(queue <-chan *kafka.Message) {
+	defer p.wg.Done()
+	for msg := range queue {
+		if p.ctx.Err() != nil {
+			// The drain timed out, the message is polled again after a restart
+			continue
+		}
+		if notBefore, ok := p.c.notBefore(msg); ok {
+			p.delay(msg, time.Until(notBefore))
+			continue
+		}
+
+		err := p.c.handle(p.ctx, msg, p.handler)
+		switch {
+		case err == nil:
+			p.offsets.finish(msg.TopicPartition)
+		case p.ctx.Err() != nil:
+			// The drain timed out, the message is polled again after a restart
+		default:
+			log.Printf("Failed to pass on failed message from %v: %v", msg.TopicPartition, err)
+			p.delay(msg, p.c.retry.Backoff)
+		}
+	}
+}
+
+// delay queues the message on its worker again after d. Once the pool drains, or the message's
+// partition is revoked, it is left uncommitted and polled again by whoever consumes the partition.
+func (p *workerPool) delay(msg *kafka.Message, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		p.offsets.abandon(msg.TopicPartition)
+		return
+	}
+
+	p.delayed[msg] = time.AfterFunc(d, func() {
+		p.mu.Lock()
+		if _, ok := p.delayed[msg]; !ok {
+			p.mu.Unlock()
+			return
+		}
+		delete(p.delayed, msg)
+		p.requeues.Add(1)
+		p.mu.Unlock()
+		defer p.requeues.Done()
+
+		select {
+		case p.queue(msg) <- msg:
+		case <-p.stopping:
+			p.offsets.abandon(msg.TopicPartition)
+		}
+	})
+}
+
+// undelay stops the delayed messages that match and leaves them uncommitted
+func (p *workerPool) undelay(match func(*kafka.Message) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for msg, timer := range p.delayed {
+		if match(msg) {
+			timer.Stop()
+			delete(p.delayed, msg)
+			p.offsets.abandon(msg.TopicPartition)
+		}
+	}
+}
+
+// commit commits the offsets of every partition up to its oldest message still being handled
+func (p *workerPool) commit() {
+	partitions := p.offsets.committable()
+	if len(partitions) == 0 {
+		return
+	}
+	if _, err := p.commits(partitions); err != nil {
+		log.Printf("Failed to commit offsets: %v", err)
+		return
+	}
+	p.offsets.committed(partitions)
+}
+
+// revoke waits for the messages of the revoked partitions to be handled, commits them and forgets
+// the partitions, so whoever is assigned them next starts after the handled messages
+func (p *workerPool) revoke(partitions []kafka.TopicPartition) {
+	revoked := make(map[string]bool, len(partitions))
+	for _, tp := range partitions {
+		revoked[partitionKey(tp)] = true
+	}
+	p.undelay(func(msg *kafka.Message) bool { return revoked[partitionKey(msg.TopicPartition)] })
+
+	if !p.offsets.wait(partitions, p.c.pool.DrainTimeout) {
+		log.Printf("Messages of revoked partitions still running after %s", p.c.pool.DrainTimeout)
+	}
+	p.commit()
+	p.offsets.forget(partitions)
+}
+
+// drain stops taking messages and waits for the queued ones to be handled. Delayed messages and,
+// once the drain timeout passes, the remaining ones are left uncommitted, and the handlers' context
+// is cancelled.
+func (p *workerPool) drain() {
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.undelay(func(*kafka.Message) bool { return true })
+	close(p.stopping)
+	p.requeues.Wait()
+
+	for _, q := range p.queues {
+		close(q)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(p.c.pool.DrainTimeout):
+		log.Printf("Consumer drain timed out after %s, cancelling handlers", p.c.pool.DrainTimeout)
+		p.cancel()
+		<-done
+	}
+	p.cancel()
+	p.commit()
+}
+
+// offsetTracker follows the messages being handled in each partition, so an offset is committed
+// only once every earlier message in its partition is handled
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[string]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	partition kafka.TopicPartition
+	running   []kafka.Offset // offsets being handled, oldest first
+	finished  map[kafka.Offset]bool
+	abandoned map[kafka.Offset]bool // running offsets that will not be handled, so never committed past
+	next      kafka.Offset          // offset to commit, after the last message handled in order
+	committed kafka.Offset
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[string]*partitionOffsets)}
+}
+
+func (t *offsetTracker) start(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey(tp)
+	po, ok := t.partitions[key]
+	if !ok {
+		po = &partitionOffsets{
+			partition: kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition},
+			finished:  make(map[kafka.Offset]bool),
+			abandoned: make(map[kafka.Offset]bool),
+			next:      kafka.OffsetInvalid,
+			committed: kafka.OffsetInvalid,
+		}
+		t.partitions[key] = po
+	}
+	po.running = append(po.running, tp.Offset)
+}
+
+func (t *offsetTracker) finish(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	po, ok := t.partitions[partitionKey(tp)]
+	if !ok {
+		// Revoked while it was handled
+		return
+	}
+	po.finished[tp.Offset] = true
+	for len(po.running) > 0 && po.finished[po.running[0]] {
+		delete(po.finished, po.running[0])
+		po.next = po.running[0] + 1
+		po.running = po.running[1:]
+	}
+}
+
+// abandon records that the message will not be handled before its partition is forgotten. Its
+// offset is not committed, nor any later one, but it no longer keeps the partition from being idle.
+func (t *offsetTracker) abandon(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if po, ok := t.partitions[partitionKey(tp)]; ok {
+		po.abandoned[tp.Offset] = true
+	}
+}
+
+// committable returns the partitions whose offset to commit moved since their last commit
+func (t *offsetTracker) committable() []kafka.TopicPartition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var partitions []kafka.TopicPartition
+	for _, po := range t.partitions {
+		if po.next != kafka.OffsetInvalid && po.next != po.committed {
+			tp := po.partition
+			tp.Offset = po.next
+			partitions = append(partitions, tp)
+		}
+	}
+	return partitions
+}
+
+func (t *offsetTracker) committed(partitions []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range partitions {
+		if po, ok := t.partitions[partitionKey(tp)]; ok {
+			po.committed = tp.Offset
+		}
+	}
+}
+
+// wait blocks until no message of the partitions is being handled, other than abandoned ones, or
+// the timeout passes.
+// It returns false on a timeout.
+func (t *offsetTracker) wait(partitions []kafka.TopicPartition, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !t.idle(partitions) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func (t *offsetTracker) idle(partitions []kafka.TopicPartition) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range partitions {
+		if po, ok := t.partitions[partitionKey(tp)]; ok && len(po.running) > len(po.finished)+len(po.abandoned) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *offsetTracker) forget(partitions []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range partitions {
+		delete(t.partitions, partitionKey(tp))
+	}
+}
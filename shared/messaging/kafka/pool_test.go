@@ -0,0 +1,292 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const testTopic = "trips"
+
+func TestOffsetTracker(t *testing.T) {
+	tests := []struct {
+		name      string
+		finished  []kafka.Offset // of the started offsets 10, 11 and 12
+		abandoned []kafka.Offset
+		want      kafka.Offset // offset to commit, OffsetInvalid if none
+		idle      bool
+	}{
+		{name: "none finished", want: kafka.OffsetInvalid},
+		{name: "in order", finished: []kafka.Offset{10, 11, 12}, want: 13, idle: true},
+		{name: "out of order", finished: []kafka.Offset{12, 11, 10}, want: 13, idle: true},
+		{name: "waiting for the oldest", finished: []kafka.Offset{12, 11}, want: kafka.OffsetInvalid},
+		{name: "gap", finished: []kafka.Offset{10, 12}, want: 11},
+		{name: "abandoned oldest", finished: []kafka.Offset{11, 12}, abandoned: []kafka.Offset{10}, want: kafka.OffsetInvalid, idle: true},
+		{name: "abandoned in the middle", finished: []kafka.Offset{10, 12}, abandoned: []kafka.Offset{11}, want: 11, idle: true},
+		{name: "abandoned with one running", finished: []kafka.Offset{10}, abandoned: []kafka.Offset{11}, want: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+			for offset := range kafka.Offset(3) {
+				tracker.start(partitionAt(0, 10+offset))
+			}
+			for _, offset := range tt.abandoned {
+				tracker.abandon(partitionAt(0, offset))
+			}
+			for _, offset := range tt.finished {
+				tracker.finish(partitionAt(0, offset))
+			}
+
+			if got := committedOffset(tracker.committable()); got != tt.want {
+				t.Errorf("committable() = %v, want %v", got, tt.want)
+			}
+			if got := tracker.idle([]kafka.TopicPartition{partitionAt(0, 0)}); got != tt.idle {
+				t.Errorf("idle() = %v, want %v", got, tt.idle)
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerCommitted(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.start(partitionAt(0, 1))
+	tracker.start(partitionAt(0, 2))
+	tracker.finish(partitionAt(0, 1))
+
+	partitions := tracker.committable()
+	tracker.committed(partitions)
+	if got := tracker.committable(); len(got) != 0 {
+		t.Fatalf("committable() after committing = %v, want none", got)
+	}
+
+	tracker.finish(partitionAt(0, 2))
+	if got := committedOffset(tracker.committable()); got != 3 {
+		t.Errorf("committable() = %v, want 3", got)
+	}
+}
+
+func TestOffsetTrackerRevoke(t *testing.T) {
+	tracker := newOffsetTracker()
+	revoked := []kafka.TopicPartition{partitionAt(0, 0)}
+	tracker.start(partitionAt(0, 1))
+	tracker.start(partitionAt(1, 1))
+
+	if tracker.wait(revoked, 20*time.Millisecond) {
+		t.Fatal("wait() = true with a message running, want false")
+	}
+
+	go tracker.finish(partitionAt(0, 1))
+	if !tracker.wait(revoked, time.Second) {
+		t.Fatal("wait() = false once the message finished, want true")
+	}
+
+	tracker.forget(revoked)
+	// A message of the revoked partition finishing late is ignored
+	tracker.finish(partitionAt(0, 2))
+	if got := tracker.committable(); len(got) != 0 {
+		t.Errorf("committable() = %v, want none", got)
+	}
+	if tracker.idle([]kafka.TopicPartition{partitionAt(1, 0)}) {
+		t.Error("idle() = true for a partition that was not revoked, want false")
+	}
+}
+
+func TestWorkerPoolDrain(t *testing.T) {
+	var mu sync.Mutex
+	handled := make(map[string][]kafka.Offset) // by key
+	p, commits := newTestPool(t, 4, 16, func(ctx context.Context, msg *kafka.Message) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled[string(msg.Key)] = append(handled[string(msg.Key)], msg.TopicPartition.Offset)
+		mu.Unlock()
+		return nil
+	})
+
+	for offset := range kafka.Offset(20) {
+		for partition := range int32(2) {
+			msg := message(testTopic, partition, offset)
+			msg.Key = fmt.Appendf(nil, "%d-%d", partition, offset%3)
+			p.dispatch(t.Context(), msg)
+		}
+	}
+	p.drain()
+
+	for key, offsets := range handled {
+		if !slices.IsSorted(offsets) {
+			t.Errorf("messages of key %s handled in order %v", key, offsets)
+		}
+	}
+	for partition := range int32(2) {
+		if got := commits.offset(testTopic, partition); got != 20 {
+			t.Errorf("committed offset of partition %d = %v, want 20", partition, got)
+		}
+	}
+}
+
+func TestWorkerPoolDelayedRetry(t *testing.T) {
+	handled := make(chan *kafka.Message, 2)
+	p, commits := newTestPool(t, 1, 16, func(ctx context.Context, msg *kafka.Message) error {
+		handled <- msg
+		return nil
+	})
+	defer p.drain()
+
+	notBefore := time.Now().Add(200 * time.Millisecond).Truncate(time.Millisecond) // as in the header
+	retry := retryMessage(0, notBefore)
+	p.dispatch(t.Context(), retry)
+	p.dispatch(t.Context(), message(testTopic, 0, 0))
+
+	// The retry waits without holding up the next message on the worker
+	first := <-handled
+	if first.TopicPartition.Offset != 0 || time.Now().After(notBefore) {
+		t.Fatalf("handled %v first at %s, want the message after the retry before %s", first.TopicPartition, time.Now(), notBefore)
+	}
+	<-handled
+	if time.Now().Before(notBefore) {
+		t.Errorf("handled the retry before %s", notBefore)
+	}
+
+	p.commit()
+	if got := commits.offset(*retry.TopicPartition.Topic, 0); got != 1 {
+		t.Errorf("committed offset of the retry topic = %v, want 1", got)
+	}
+}
+
+func TestWorkerPoolRevokeDelayed(t *testing.T) {
+	p, commits := newTestPool(t, 1, 16, func(ctx context.Context, msg *kafka.Message) error {
+		t.Errorf("handled %v, want it left for the partition's next consumer", msg.TopicPartition)
+		return nil
+	})
+	defer p.drain()
+
+	retry := retryMessage(0, time.Now().Add(time.Hour))
+	p.dispatch(t.Context(), retry)
+	waitFor(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.delayed) == 1
+	})
+
+	start := time.Now()
+	p.revoke([]kafka.TopicPartition{retry.TopicPartition})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("revoke waited %s for a delayed message", elapsed)
+	}
+	if got := commits.offset(*retry.TopicPartition.Topic, 0); got != kafka.OffsetInvalid {
+		t.Errorf("committed offset %v of the revoked retry, want none", got)
+	}
+}
+
+func TestWorkerPoolDispatchCancelled(t *testing.T) {
+	release := make(chan struct{})
+	p, commits := newTestPool(t, 1, 0, func(ctx context.Context, msg *kafka.Message) error {
+		<-release
+		return nil
+	})
+	defer p.drain()
+
+	// The worker is busy with the first message, so the second cannot be queued
+	p.dispatch(t.Context(), message(testTopic, 0, 0))
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	p.dispatch(ctx, message(testTopic, 0, 1))
+	close(release)
+
+	start := time.Now()
+	p.revoke([]kafka.TopicPartition{partitionAt(0, 0)})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("revoke waited %s for a message that was never queued", elapsed)
+	}
+	if got := commits.offset(testTopic, 0); got != 1 {
+		t.Errorf("committed offset = %v, want 1", got)
+	}
+}
+
+// commitLog records the offsets a pool commits
+type commitLog struct {
+	mu      sync.Mutex
+	offsets map[string]kafka.Offset
+}
+
+func (l *commitLog) commit(partitions []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, tp := range partitions {
+		l.offsets[partitionKey(tp)] = tp.Offset
+	}
+	return partitions, nil
+}
+
+func (l *commitLog) offset(topic string, partition int32) kafka.Offset {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	offset, ok := l.offsets[partitionKey(kafka.TopicPartition{Topic: &topic, Partition: partition})]
+	if !ok {
+		return kafka.OffsetInvalid
+	}
+	return offset
+}
+
+// newTestPool starts a pool whose commits are recorded instead of sent to a broker
+func newTestPool(t *testing.T, workers, queueSize int, handler MessageHandler) (*workerPool, *commitLog) {
+	t.Helper()
+	c := &Consumer{
+		groupID: "group",
+		retry:   &RetryPolicy{Attempts: 3, Backoff: 50 * time.Millisecond, MaxBackoff: time.Second},
+		pool:    &PoolConfig{Workers: workers, QueueSize: queueSize, DrainTimeout: 5 * time.Second},
+	}
+
+	commits := &commitLog{offsets: make(map[string]kafka.Offset)}
+	p := newWorkerPool(t.Context(), c, handler)
+	p.commits = commits.commit
+	return p, commits
+}
+
+func partitionAt(partition int32, offset kafka.Offset) kafka.TopicPartition {
+	topic := testTopic
+	return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+}
+
+func message(topic string, partition int32, offset kafka.Offset) *kafka.Message {
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset},
+		Value:          []byte(`{}`),
+	}
+}
+
+// retryMessage returns a message on the group's retry topic of testTopic, due at notBefore
+func retryMessage(offset kafka.Offset, notBefore time.Time) *kafka.Message {
+	msg := message(RetryTopic(testTopic, "group"), 0, offset)
+	msg.Headers = []kafka.Header{
+		{Key: HeaderOriginalTopic, Value: []byte(testTopic)},
+		{Key: HeaderAttempts, Value: []byte("1")},
+		{Key: HeaderNotBefore, Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+	}
+	return msg
+}
+
+func committedOffset(partitions []kafka.TopicPartition) kafka.Offset {
+	if len(partitions) == 0 {
+		return kafka.OffsetInvalid
+	}
+	return partitions[0].Offset
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met after a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,7 +2,6 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -10,14 +9,17 @@ import (
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/env"
 )
 
 type Producer struct {
-	pr *kafka.Producer
+	pr         *kafka.Producer
+	serializer Serializer
 }
 
-// NewProducer creates a confluent producer with safe defaults.
-func newProducer(brokers []string) (*Producer, error) {
+// NewProducer creates a confluent producer with safe defaults. It encodes envelopes with the
+// serializer of KAFKA_CONTENT_TYPE, JSON by default.
+func NewProducer(brokers []string) (*Producer, error) {
 	cfg := &kafka.ConfigMap{
 		"bootstrap.servers":                     strings.Join(brokers, ","),
 		"security.protocol":                     "PLAINTEXT",
@@ -30,6 +32,11 @@ func newProducer(brokers []string) (*Producer, error) {
 		"compression.type":                      "zstd",
 	}
 
+	serializer, err := SerializerFor(env.GetString("KAFKA_CONTENT_TYPE", contracts.ContentTypeJSON))
+	if err != nil {
+		return nil, err
+	}
+
 	pr, err := kafka.NewProducer(cfg)
 	if err != nil {
 		return nil, err
@@ -57,38 +64,58 @@ func newProducer(brokers []string) (*Producer, error) {
 		}
 	}()
 
-	return &Producer{pr: pr}, nil
+	return &Producer{pr: pr, serializer: serializer}, nil
 }
 
-func (p *Producer) SendMessage(topic string, message *contracts.KafkaMessage) error {
-	data, err := json.Marshal(message)
+// Serializer returns the serializer the producer encodes envelopes with. The payloads of the
+// envelopes it sends must already be encoded with it.
+func (p *Producer) Serializer() Serializer {
+	return p.serializer
+}
+
+// Send produces the envelope on the topic of its type, keyed by its entity ID, without waiting
+// for Kafka to acknowledge it
+func (p *Producer) Send(env *contracts.EventEnvelope) error {
+	msg, err := p.newMessage(env)
 	if err != nil {
-		return fmt.Errorf("failed to marshal the message: %w", err)
+		return err
 	}
+	return p.pr.Produce(msg, nil)
+}
 
-	msg := &kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Key:            []byte(message.EntityID),
-		Value:          data,
+// SendAndWait produces the envelope like Send and waits for Kafka to acknowledge it
+func (p *Producer) SendAndWait(ctx context.Context, env *contracts.EventEnvelope, timeout time.Duration) error {
+	msg, err := p.newMessage(env)
+	if err != nil {
+		return err
 	}
-
-	return p.pr.Produce(msg, nil)
+	return p.produceAndWait(ctx, msg, timeout)
 }
 
-func (p *Producer) SendMessageAndWait(ctx context.Context, topic string, message *contracts.KafkaMessage, timeout time.Duration) error {
-	deliveryChan := make(chan kafka.Event)
-	defer close(deliveryChan)
+// newMessage encodes the envelope, naming its content type in the message's header
+func (p *Producer) newMessage(env *contracts.EventEnvelope) (*kafka.Message, error) {
+	if env.ContentType != p.serializer.ContentType() {
+		return nil, fmt.Errorf("%s envelope has a %s payload, expected %s", env.Type, env.ContentType, p.serializer.ContentType())
+	}
 
-	data, err := json.Marshal(message)
+	value, err := p.serializer.MarshalEnvelope(env)
 	if err != nil {
-		return fmt.Errorf("failed to marshal the message: %w", err)
+		return nil, fmt.Errorf("failed to marshal %s envelope: %w", env.Type, err)
 	}
 
-	msg := &kafka.Message{
+	topic := env.Type
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Key:            []byte(message.EntityID),
-		Value:          data,
-	}
+		Key:            []byte(env.EntityID),
+		Value:          value,
+		Headers:        []kafka.Header{{Key: contracts.ContentTypeHeader, Value: []byte(env.ContentType)}},
+	}, nil
+}
+
+// produceAndWait produces the message as it is and waits for Kafka to acknowledge it
+func (p *Producer) produceAndWait(ctx context.Context, msg *kafka.Message, timeout time.Duration) error {
+	// Buffered, so a report arriving after the wait gave up does not block the producer
+	deliveryChan := make(chan kafka.Event, 1)
 
 	if err := p.pr.Produce(msg, deliveryChan); err != nil {
 		return fmt.Errorf("failed to produce message: %w", err)
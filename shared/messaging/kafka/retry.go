@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/env"
+)
+
+// Headers added to messages sent to retry and dead-letter topics
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderConsumerGroup     = "x-consumer-group"
+	HeaderAttempts          = "x-attempts" // how many times the handler failed the message
+	HeaderError             = "x-error"    // the last handler error
+	HeaderFailedAt          = "x-failed-at"
+	HeaderNotBefore         = "x-not-before" // Unix milliseconds before which a retry is not handled
+	HeaderReplayedAt        = "x-replayed-at"
+)
+
+const (
+	retryTopicSuffix      = ".retry"
+	deadLetterTopicSuffix = ".dlq"
+)
+
+// RetryPolicy decides how often a consumer retries a message its handler fails
+type RetryPolicy struct {
+	Attempts   int           // handler attempts before a message goes to its dead-letter topic, 1 disables retries
+	Backoff    time.Duration // delay before the first retry, doubled for every later one
+	MaxBackoff time.Duration
+}
+
+// NewDefaultRetryPolicy returns a RetryPolicy populated from the environment
+func NewDefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		Attempts:   env.GetInt("KAFKA_RETRY_ATTEMPTS", 4),
+		Backoff:    env.GetDuration("KAFKA_RETRY_BACKOFF", time.Second),
+		MaxBackoff: env.GetDuration("KAFKA_RETRY_MAX_BACKOFF", time.Minute),
+	}
+}
+
+// delay returns how long to wait before retrying a message that failed the given number of times
+func (p *RetryPolicy) delay(failures int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < failures && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// RetryTopic returns the topic the consumer group retries failed messages of the topic from.
+// Every group has its own, so a retry is not handled again by groups that succeeded.
+func RetryTopic(topic, groupID string) string {
+	return topic + retryTopicSuffix + "." + groupID
+}
+
+// DeadLetterTopic returns the topic messages of the topic go to once their retries are used up
+func DeadLetterTopic(topic string) string {
+	return topic + deadLetterTopicSuffix
+}
+
+// failedCopy returns a copy of the failed message for the given topic, with the retry headers
+// replaced by the ones given
+func failedCopy(msg *kafka.Message, topic string, headers map[string]string) *kafka.Message {
+	copied := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+	}
+	for _, h := range msg.Headers {
+		if !isRetryHeader(h.Key) {
+			copied.Headers = append(copied.Headers, h)
+		}
+	}
+	for _, key := range retryHeaders {
+		if value, ok := headers[key]; ok {
+			copied.Headers = append(copied.Headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+	}
+	return copied
+}
+
+// retryHeaders are the headers of retried and dead-lettered messages, in the order they are added
+var retryHeaders = []string{
+	HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset, HeaderConsumerGroup,
+	HeaderAttempts, HeaderError, HeaderFailedAt, HeaderNotBefore, HeaderReplayedAt,
+}
+
+func isRetryHeader(key string) bool {
+	return slices.Contains(retryHeaders, key)
+}
+
+// header returns the value of the message header, or "" if it has none
+func header(msg *kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// headerInt returns the integer value of the message header, or 0
+func headerInt(msg *kafka.Message, key string) int64 {
+	n, _ := strconv.ParseInt(header(msg, key), 10, 64)
+	return n
+}
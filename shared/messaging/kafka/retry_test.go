@@ -0,0 +1,277 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		failures int
+		want     time.Duration
+	}{
+		{name: "first retry", policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, failures: 1, want: time.Second},
+		{name: "doubled", policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, failures: 2, want: 2 * time.Second},
+		{name: "doubled twice", policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, failures: 3, want: 4 * time.Second},
+		{name: "capped", policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, failures: 5, want: 10 * time.Second},
+		{name: "capped after many failures", policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, failures: 100, want: 10 * time.Second},
+		{name: "backoff above the cap", policy: RetryPolicy{Backoff: time.Minute, MaxBackoff: 10 * time.Second}, failures: 1, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTopics(t *testing.T) {
+	c := &Consumer{groupID: "payments"}
+
+	if got := RetryTopic(testTopic, "payments"); got != "trips.retry.payments" {
+		t.Errorf("RetryTopic() = %q, want trips.retry.payments", got)
+	}
+	if got := DeadLetterTopic(testTopic); got != "trips.dlq" {
+		t.Errorf("DeadLetterTopic() = %q, want trips.dlq", got)
+	}
+
+	tests := []struct {
+		topic string
+		retry bool
+	}{
+		{topic: testTopic, retry: false},
+		{topic: RetryTopic(testTopic, "payments"), retry: true},
+		{topic: RetryTopic(testTopic, "drivers"), retry: false},
+		{topic: DeadLetterTopic(testTopic), retry: false},
+	}
+	for _, tt := range tests {
+		if got := c.isRetryTopic(tt.topic); got != tt.retry {
+			t.Errorf("isRetryTopic(%q) = %v, want %v", tt.topic, got, tt.retry)
+		}
+	}
+}
+
+func TestFailureRouting(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	retryTopic := RetryTopic(testTopic, "group")
+
+	tests := []struct {
+		name       string
+		msg        *kafka.Message
+		handlerErr error
+		forwardErr error
+		cancelled  bool
+
+		wantErr     bool
+		wantTopic   string // of the forwarded message, "" if none is forwarded
+		wantHeaders map[string]string
+		wantDelay   time.Duration // until the forwarded retry is due
+	}{
+		{
+			name: "handled",
+			msg:  message(testTopic, 2, 7),
+		},
+		{
+			name:       "first failure goes to the group's retry topic",
+			msg:        message(testTopic, 2, 7),
+			handlerErr: errHandler,
+			wantTopic:  retryTopic,
+			wantHeaders: map[string]string{
+				HeaderOriginalTopic: testTopic, HeaderOriginalPartition: "2", HeaderOriginalOffset: "7",
+				HeaderConsumerGroup: "group", HeaderAttempts: "1", HeaderError: errHandler.Error(),
+			},
+			wantDelay: time.Second,
+		},
+		{
+			name:       "failed retry goes back to the retry topic with a longer delay",
+			msg:        failedMessage(retryTopic, 1),
+			handlerErr: errHandler,
+			wantTopic:  retryTopic,
+			wantHeaders: map[string]string{
+				HeaderOriginalTopic: testTopic, HeaderOriginalPartition: "2", HeaderOriginalOffset: "7",
+				HeaderAttempts: "2",
+			},
+			wantDelay: 2 * time.Second,
+		},
+		{
+			name:       "last failure goes to the dead-letter topic",
+			msg:        failedMessage(retryTopic, 2),
+			handlerErr: errHandler,
+			wantTopic:  DeadLetterTopic(testTopic),
+			wantHeaders: map[string]string{
+				HeaderOriginalTopic: testTopic, HeaderOriginalPartition: "2", HeaderOriginalOffset: "7",
+				HeaderConsumerGroup: "group", HeaderAttempts: "3", HeaderError: errHandler.Error(),
+			},
+		},
+		{
+			name:       "failure the producer does not acknowledge",
+			msg:        message(testTopic, 2, 7),
+			handlerErr: errHandler,
+			forwardErr: errors.New("broker down"),
+			wantErr:    true,
+			wantTopic:  retryTopic,
+			wantDelay:  time.Second,
+		},
+		{
+			name:       "failure while shutting down is not passed on",
+			msg:        message(testTopic, 2, 7),
+			handlerErr: errHandler,
+			cancelled:  true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded := &forwardLog{err: tt.forwardErr}
+			c := &Consumer{
+				groupID: "group",
+				forward: forwarded.forward,
+				retry:   &RetryPolicy{Attempts: 3, Backoff: time.Second, MaxBackoff: time.Minute},
+			}
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			var handledTopic string
+			start := time.Now().Truncate(time.Millisecond)
+			err := c.handle(ctx, tt.msg, func(ctx context.Context, msg *kafka.Message) error {
+				handledTopic = *msg.TopicPartition.Topic
+				return tt.handlerErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handle() = %v, want error %v", err, tt.wantErr)
+			}
+			if handledTopic != testTopic {
+				t.Errorf("handler got the message on %q, want the original topic %q", handledTopic, testTopic)
+			}
+
+			if tt.wantTopic == "" {
+				if len(forwarded.messages) != 0 {
+					t.Fatalf("forwarded %d messages, want none", len(forwarded.messages))
+				}
+				return
+			}
+			if len(forwarded.messages) != 1 {
+				t.Fatalf("forwarded %d messages, want 1", len(forwarded.messages))
+			}
+			msg := forwarded.messages[0]
+			if got := *msg.TopicPartition.Topic; got != tt.wantTopic {
+				t.Errorf("forwarded to %q, want %q", got, tt.wantTopic)
+			}
+			if string(msg.Key) != string(tt.msg.Key) || string(msg.Value) != string(tt.msg.Value) {
+				t.Errorf("forwarded message %q: %s, want the failed %q: %s", msg.Key, msg.Value, tt.msg.Key, tt.msg.Value)
+			}
+			for key, want := range tt.wantHeaders {
+				if got := header(msg, key); got != want {
+					t.Errorf("header %s = %q, want %q", key, got, want)
+				}
+			}
+
+			notBefore := header(msg, HeaderNotBefore)
+			if tt.wantDelay == 0 {
+				if notBefore != "" {
+					t.Errorf("dead letter has a %s header", HeaderNotBefore)
+				}
+				return
+			}
+			due := time.UnixMilli(headerInt(msg, HeaderNotBefore))
+			if due.Before(start.Add(tt.wantDelay)) || due.After(time.Now().Add(tt.wantDelay)) {
+				t.Errorf("retry due at %s, want %s after the failure at %s", due, tt.wantDelay, start)
+			}
+		})
+	}
+}
+
+func TestFailedCopy(t *testing.T) {
+	msg := failedMessage(RetryTopic(testTopic, "group"), 2)
+	msg.Headers = append(msg.Headers, kafka.Header{Key: contracts.ContentTypeHeader, Value: []byte(contracts.ContentTypeProtobuf)})
+
+	copied := failedCopy(msg, testTopic, map[string]string{HeaderReplayedAt: "2025-01-01T00:00:00Z"})
+
+	if got := *copied.TopicPartition.Topic; got != testTopic || copied.TopicPartition.Partition != kafka.PartitionAny {
+		t.Errorf("copy goes to %v, want any partition of %s", copied.TopicPartition, testTopic)
+	}
+	var keys []string
+	for _, h := range copied.Headers {
+		keys = append(keys, h.Key)
+	}
+	if want := []string{contracts.ContentTypeHeader, HeaderReplayedAt}; !slices.Equal(keys, want) {
+		t.Errorf("copy has headers %v, want %v", keys, want)
+	}
+	if len(msg.Headers) != 7 {
+		t.Errorf("copying changed the failed message's headers to %v", msg.Headers)
+	}
+}
+
+func TestNewDeadLetter(t *testing.T) {
+	msg := failedMessage(DeadLetterTopic(testTopic), 3)
+	msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderFailedAt, Value: []byte("2025-01-01T10:00:00Z")})
+
+	letter := newDeadLetter(msg)
+	want := DeadLetter{
+		Partition:         0,
+		Offset:            40,
+		Topic:             testTopic,
+		OriginalPartition: "2",
+		OriginalOffset:    "7",
+		ConsumerGroup:     "group",
+		Attempts:          3,
+		Error:             "handler failed",
+		FailedAt:          time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		ContentType:       contracts.ContentTypeJSON, // without a content type header
+	}
+	got := *letter
+	if string(got.Key) != "trip-1" || string(got.Value) != "{}" || got.message != msg {
+		t.Errorf("newDeadLetter() kept key %q and value %s, want the dead-lettered message's", got.Key, got.Value)
+	}
+	got.Key, got.Value, got.message = nil, nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newDeadLetter() = %+v, want %+v", got, want)
+	}
+}
+
+// forwardLog records the messages a consumer passes on to retry and dead-letter topics
+type forwardLog struct {
+	mu       sync.Mutex
+	messages []*kafka.Message
+	err      error
+}
+
+func (l *forwardLog) forward(ctx context.Context, msg *kafka.Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+	return l.err
+}
+
+// failedMessage returns the message at offset 7 of partition 2 of testTopic after it failed the
+// given number of times, as read from a retry or dead-letter topic at offset 40
+func failedMessage(topic string, failures int) *kafka.Message {
+	msg := message(topic, 0, 40)
+	msg.Key = []byte("trip-1")
+	msg.Headers = []kafka.Header{
+		{Key: HeaderOriginalTopic, Value: []byte(testTopic)},
+		{Key: HeaderOriginalPartition, Value: []byte("2")},
+		{Key: HeaderOriginalOffset, Value: []byte("7")},
+		{Key: HeaderConsumerGroup, Value: []byte("group")},
+		{Key: HeaderAttempts, Value: []byte(strconv.Itoa(failures))},
+		{Key: HeaderError, Value: []byte("handler failed")},
+	}
+	return msg
+}
@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	pbe "github.com/cprakhar/uber-clone/shared/proto/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serializer encodes envelopes and their payloads for a content type
+type Serializer interface {
+	ContentType() string
+	MarshalEnvelope(env *contracts.EventEnvelope) ([]byte, error)
+	UnmarshalEnvelope(data []byte) (*contracts.EventEnvelope, error)
+	MarshalPayload(v any) ([]byte, error)
+	UnmarshalPayload(data []byte, v any) error
+}
+
+// ProtoMarshaler is a payload with a protobuf message form, so the protobuf serializer can send it
+type ProtoMarshaler interface {
+	// ToProto returns the payload's message. Called on the zero payload it returns an empty message
+	// of the payload's message type.
+	ToProto() proto.Message
+}
+
+// ProtoPayload is a payload the protobuf serializer can also read, usually a pointer to a ProtoMarshaler
+type ProtoPayload interface {
+	ProtoMarshaler
+	// FromProto sets the payload from a message of the type ToProto returns
+	FromProto(m proto.Message) error
+}
+
+var serializers = map[string]Serializer{
+	contracts.ContentTypeJSON:     JSONSerializer{},
+	contracts.ContentTypeProtobuf: ProtobufSerializer{},
+}
+
+// SerializerFor returns the serializer of the content type
+func SerializerFor(contentType string) (Serializer, error) {
+	s, ok := serializers[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	return s, nil
+}
+
+// ContentType returns the content type in the message's header. Messages without one were
+// published before the header was added, as JSON.
+func ContentType(msg *kafka.Message) string {
+	if ct := header(msg, contracts.ContentTypeHeader); ct != "" {
+		return ct
+	}
+	return contracts.ContentTypeJSON
+}
+
+// DecodeEnvelope reads the envelope of a consumed message with the serializer of its content type
+func DecodeEnvelope(msg *kafka.Message) (*contracts.EventEnvelope, error) {
+	s, err := SerializerFor(ContentType(msg))
+	if err != nil {
+		return nil, err
+	}
+	return s.UnmarshalEnvelope(msg.Value)
+}
+
+// JSONSerializer encodes envelopes and payloads as JSON
+type JSONSerializer struct{}
+
+func (JSONSerializer) ContentType() string {
+	return contracts.ContentTypeJSON
+}
+
+func (JSONSerializer) MarshalEnvelope(env *contracts.EventEnvelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+// UnmarshalEnvelope reads a KafkaMessage published before envelopes as an envelope of schema
+// version 0 with only the entity ID and payload set
+func (JSONSerializer) UnmarshalEnvelope(data []byte) (*contracts.EventEnvelope, error) {
+	var env contracts.EventEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.SchemaVersion == 0 && env.Payload == nil {
+		env.Payload = env.Data
+	}
+	env.Data = nil
+	env.ContentType = contracts.ContentTypeJSON
+	return &env, nil
+}
+
+func (JSONSerializer) MarshalPayload(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONSerializer) UnmarshalPayload(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtobufSerializer encodes envelopes as events.EventEnvelope messages and payloads as their
+// protobuf messages. Payloads must be proto.Message or ProtoMarshaler values,
+// and are read into proto.Message or ProtoPayload values.
+type ProtobufSerializer struct{}
+
+func (ProtobufSerializer) ContentType() string {
+	return contracts.ContentTypeProtobuf
+}
+
+func (ProtobufSerializer) MarshalEnvelope(env *contracts.EventEnvelope) ([]byte, error) {
+	return proto.Marshal(&pbe.EventEnvelope{
+		Id:            env.ID,
+		Type:          env.Type,
+		SchemaVersion: int32(env.SchemaVersion),
+		OccurredAt:    timestamppb.New(env.OccurredAt),
+		Producer:      env.Producer,
+		CorrelationID: env.CorrelationID,
+		CausationID:   env.CausationID,
+		EntityID:      env.EntityID,
+		Payload:       env.Payload,
+	})
+}
+
+func (ProtobufSerializer) UnmarshalEnvelope(data []byte) (*contracts.EventEnvelope, error) {
+	var m pbe.EventEnvelope
+	if err := proto.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &contracts.EventEnvelope{
+		ID:            m.Id,
+		Type:          m.Type,
+		SchemaVersion: int(m.SchemaVersion),
+		OccurredAt:    m.OccurredAt.AsTime(),
+		Producer:      m.Producer,
+		CorrelationID: m.CorrelationID,
+		CausationID:   m.CausationID,
+		EntityID:      m.EntityID,
+		Payload:       m.Payload,
+		ContentType:   contracts.ContentTypeProtobuf,
+	}, nil
+}
+
+func (ProtobufSerializer) MarshalPayload(v any) ([]byte, error) {
+	switch p := v.(type) {
+	case proto.Message:
+		return proto.Marshal(p)
+	case ProtoMarshaler:
+		return proto.Marshal(p.ToProto())
+	}
+	return nil, fmt.Errorf("%T has no protobuf form", v)
+}
+
+func (ProtobufSerializer) UnmarshalPayload(data []byte, v any) error {
+	switch p := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, p)
+	case ProtoPayload:
+		m := p.ToProto().ProtoReflect().Type().New().Interface()
+		if err := proto.Unmarshal(data, m); err != nil {
+			return err
+		}
+		return p.FromProto(m)
+	}
+	return fmt.Errorf("%T has no protobuf form", v)
+}
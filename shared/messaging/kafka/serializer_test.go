@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	pbe "github.com/cprakhar/uber-clone/shared/proto/events"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	trip := &pbe.TripEvent{Trip: &pb.Trip{Id: "trip-1", RiderID: "rider-1"}}
+
+	tests := []struct {
+		serializer Serializer
+		payload    any
+	}{
+		{serializer: JSONSerializer{}, payload: map[string]string{"tripID": "trip-1"}},
+		{serializer: ProtobufSerializer{}, payload: trip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serializer.ContentType(), func(t *testing.T) {
+			payload, err := tt.serializer.MarshalPayload(tt.payload)
+			if err != nil {
+				t.Fatalf("MarshalPayload() = %v", err)
+			}
+			want := &contracts.EventEnvelope{
+				ID:            "event-1",
+				Type:          contracts.TripEventCreated,
+				SchemaVersion: 1,
+				OccurredAt:    time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+				Producer:      "trip-service",
+				CorrelationID: "flow-1",
+				CausationID:   "cause-1",
+				EntityID:      "rider-1",
+				Payload:       payload,
+				ContentType:   tt.serializer.ContentType(),
+			}
+
+			data, err := tt.serializer.MarshalEnvelope(want)
+			if err != nil {
+				t.Fatalf("MarshalEnvelope() = %v", err)
+			}
+			got, err := tt.serializer.UnmarshalEnvelope(data)
+			if err != nil {
+				t.Fatalf("UnmarshalEnvelope() = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("UnmarshalEnvelope() = %+v, want %+v", got, want)
+			}
+
+			// The message's header picks the serializer that reads the envelope back
+			msg := &kafka.Message{
+				Value:   data,
+				Headers: []kafka.Header{{Key: contracts.ContentTypeHeader, Value: []byte(tt.serializer.ContentType())}},
+			}
+			decoded, err := DecodeEnvelope(msg)
+			if err != nil || decoded.ID != want.ID || decoded.ContentType != want.ContentType {
+				t.Errorf("DecodeEnvelope() = %+v, %v, want the envelope", decoded, err)
+			}
+		})
+	}
+}
+
+func TestProtobufPayload(t *testing.T) {
+	trip := &pbe.TripEvent{Trip: &pb.Trip{Id: "trip-1", RiderID: "rider-1"}}
+	s := ProtobufSerializer{}
+
+	tests := []struct {
+		name    string
+		payload any
+		into    func() any
+		wantErr bool
+	}{
+		{name: "message", payload: trip, into: func() any { return &pbe.TripEvent{} }},
+		{name: "payload with a message form", payload: testPayload{TripID: "trip-1"}, into: func() any { return &testPayload{} }},
+		{name: "payload without a message form", payload: map[string]string{"tripID": "trip-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.MarshalPayload(tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("MarshalPayload() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MarshalPayload() = %v", err)
+			}
+
+			got := tt.into()
+			if err := s.UnmarshalPayload(data, got); err != nil {
+				t.Fatalf("UnmarshalPayload() = %v", err)
+			}
+			switch want := tt.payload.(type) {
+			case proto.Message:
+				if !proto.Equal(got.(proto.Message), want) {
+					t.Errorf("UnmarshalPayload() = %v, want %v", got, want)
+				}
+			default:
+				if !reflect.DeepEqual(reflect.ValueOf(got).Elem().Interface(), want) {
+					t.Errorf("UnmarshalPayload() = %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+
+	if err := s.UnmarshalPayload([]byte{0xff}, &pbe.TripEvent{}); err == nil {
+		t.Error("UnmarshalPayload() of a malformed payload succeeded, want an error")
+	}
+	var plain map[string]string
+	if err := s.UnmarshalPayload(nil, &plain); err == nil {
+		t.Error("UnmarshalPayload() into a value without a message form succeeded, want an error")
+	}
+}
+
+func TestLegacyEnvelope(t *testing.T) {
+	payload := `{"tripID":"trip-1"}`
+	legacy, err := json.Marshal(contracts.KafkaMessage{EntityID: "rider-1", Data: []byte(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		wantVersion int
+		wantPayload string
+	}{
+		{name: "KafkaMessage", value: string(legacy), wantVersion: 0, wantPayload: payload},
+		{name: "envelope with legacy data", value: `{"schemaVersion":1,"entityID":"rider-1","payload":` + payload + `,"data":"e30="}`, wantVersion: 1, wantPayload: payload},
+		{name: "envelope without a payload", value: `{"schemaVersion":1,"entityID":"rider-1","data":"e30="}`, wantVersion: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := DecodeEnvelope(&kafka.Message{Value: []byte(tt.value)})
+			if err != nil {
+				t.Fatalf("DecodeEnvelope() = %v", err)
+			}
+			if env.SchemaVersion != tt.wantVersion || env.EntityID != "rider-1" || string(env.Payload) != tt.wantPayload {
+				t.Errorf("DecodeEnvelope() = v%d for %q with payload %s, want v%d for rider-1 with %s",
+					env.SchemaVersion, env.EntityID, env.Payload, tt.wantVersion, tt.wantPayload)
+			}
+			if env.Data != nil || env.ContentType != contracts.ContentTypeJSON {
+				t.Errorf("DecodeEnvelope() kept data %q with content type %s", env.Data, env.ContentType)
+			}
+		})
+	}
+}
+
+func TestSerializerFor(t *testing.T) {
+	for _, contentType := range []string{contracts.ContentTypeJSON, contracts.ContentTypeProtobuf} {
+		s, err := SerializerFor(contentType)
+		if err != nil || s.ContentType() != contentType {
+			t.Errorf("SerializerFor(%q) = %v, %v", contentType, s, err)
+		}
+	}
+	if _, err := SerializerFor("application/xml"); err == nil {
+		t.Error("SerializerFor() of an unsupported content type succeeded, want an error")
+	}
+	if _, err := DecodeEnvelope(&kafka.Message{Headers: []kafka.Header{{Key: contracts.ContentTypeHeader, Value: []byte("application/xml")}}}); err == nil {
+		t.Error("DecodeEnvelope() of an unsupported content type succeeded, want an error")
+	}
+}
+
+// testPayload is a payload sent as a pbe.PaymentSessionCreated message
+type testPayload struct {
+	TripID string
+}
+
+func (p testPayload) ToProto() proto.Message {
+	return &pbe.PaymentSessionCreated{TripID: p.TripID}
+}
+
+func (p *testPayload) FromProto(m proto.Message) error {
+	*p = testPayload{TripID: m.(*pbe.PaymentSessionCreated).TripID}
+	return nil
+}
@@ -0,0 +1,261 @@
+package messaging
+
+import (
+	"fmt"
+	"time"
+
+	pbe "github.com/cprakhar/uber-clone/shared/proto/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The protobuf forms of the payloads, sent when producers use the protobuf content type.
+// Each payload's message is declared in proto/events.proto.
+
+func (d TripEventData) ToProto() proto.Message {
+	return &pbe.TripEvent{Trip: d.Trip}
+}
+
+func (d *TripEventData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.TripEvent](m)
+	if err != nil {
+		return err
+	}
+	*d = TripEventData{Trip: msg.Trip}
+	return nil
+}
+
+func (d DriverNotInterestedData) ToProto() proto.Message {
+	return &pbe.DriverNotInterested{Trip: d.Trip, DriverID: d.DriverID}
+}
+
+func (d *DriverNotInterestedData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.DriverNotInterested](m)
+	if err != nil {
+		return err
+	}
+	*d = DriverNotInterestedData{Trip: msg.Trip, DriverID: msg.DriverID}
+	return nil
+}
+
+func (d DriverTripResponseData) ToProto() proto.Message {
+	return &pbe.DriverTripResponse{Driver: d.Driver, RiderID: d.RiderID, TripID: d.TripID}
+}
+
+func (d *DriverTripResponseData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.DriverTripResponse](m)
+	if err != nil {
+		return err
+	}
+	*d = DriverTripResponseData{Driver: msg.Driver, RiderID: msg.RiderID, TripID: msg.TripID}
+	return nil
+}
+
+func (d DriverLocationEventData) ToProto() proto.Message {
+	return &pbe.DriverLocationUpdated{Driver: d.Driver, Available: d.Available, RecordedAt: toTimestamp(d.RecordedAt)}
+}
+
+func (d *DriverLocationEventData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.DriverLocationUpdated](m)
+	if err != nil {
+		return err
+	}
+	*d = DriverLocationEventData{Driver: msg.Driver, Available: msg.Available, RecordedAt: fromTimestamp(msg.RecordedAt)}
+	return nil
+}
+
+func (l DriverTrackingList) ToProto() proto.Message {
+	msg := &pbe.DriverLocation{Drivers: make([]*pbe.DriverTracking, 0, len(l))}
+	for _, d := range l {
+		msg.Drivers = append(msg.Drivers, &pbe.DriverTracking{
+			Driver:         d.Driver,
+			TripID:         d.TripID,
+			DistanceMeters: d.DistanceMeters,
+			EtaSeconds:     d.ETASeconds,
+		})
+	}
+	return msg
+}
+
+func (l *DriverTrackingList) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.DriverLocation](m)
+	if err != nil {
+		return err
+	}
+	list := make(DriverTrackingList, 0, len(msg.Drivers))
+	for _, d := range msg.Drivers {
+		list = append(list, DriverTrackingData{
+			Driver:         d.Driver,
+			TripID:         d.TripID,
+			DistanceMeters: d.DistanceMeters,
+			ETASeconds:     d.EtaSeconds,
+		})
+	}
+	*l = list
+	return nil
+}
+
+func (d SurgeUpdatedData) ToProto() proto.Message {
+	return &pbe.SurgeUpdated{
+		Geohash:    d.Geohash,
+		Multiplier: d.Multiplier,
+		Demand:     int32(d.Demand),
+		Supply:     int32(d.Supply),
+	}
+}
+
+func (d *SurgeUpdatedData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.SurgeUpdated](m)
+	if err != nil {
+		return err
+	}
+	*d = SurgeUpdatedData{
+		Geohash:    msg.Geohash,
+		Multiplier: msg.Multiplier,
+		Demand:     int(msg.Demand),
+		Supply:     int(msg.Supply),
+	}
+	return nil
+}
+
+func (d PaymentEventSessionCreatedData) ToProto() proto.Message {
+	return &pbe.PaymentSessionCreated{
+		TripID:    d.TripID,
+		SessionID: d.SessionID,
+		Amount:    d.Amount,
+		Currency:  d.Currency,
+		Purpose:   d.Purpose,
+	}
+}
+
+func (d *PaymentEventSessionCreatedData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.PaymentSessionCreated](m)
+	if err != nil {
+		return err
+	}
+	*d = PaymentEventSessionCreatedData{
+		TripID:    msg.TripID,
+		SessionID: msg.SessionID,
+		Amount:    msg.Amount,
+		Currency:  msg.Currency,
+		Purpose:   msg.Purpose,
+	}
+	return nil
+}
+
+func (d PaymentTripResponseData) ToProto() proto.Message {
+	return &pbe.PaymentTripRequest{
+		TripID:      d.TripID,
+		RiderID:     d.RiderID,
+		DriverID:    d.DriverID,
+		PackageSlug: d.PackageSlug,
+		Amount:      d.Amount,
+		Currency:    d.Currency,
+	}
+}
+
+func (d *PaymentTripResponseData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.PaymentTripRequest](m)
+	if err != nil {
+		return err
+	}
+	*d = PaymentTripResponseData{
+		TripID:      msg.TripID,
+		RiderID:     msg.RiderID,
+		DriverID:    msg.DriverID,
+		PackageSlug: msg.PackageSlug,
+		Amount:      msg.Amount,
+		Currency:    msg.Currency,
+	}
+	return nil
+}
+
+func (d PaymentStatusUpdateData) ToProto() proto.Message {
+	return &pbe.PaymentStatusUpdate{
+		TripID:    d.TripID,
+		RiderID:   d.RiderID,
+		DriverID:  d.DriverID,
+		SessionID: d.SessionID,
+		Purpose:   d.Purpose,
+		Status:    d.Status,
+		Amount:    d.Amount,
+		Currency:  d.Currency,
+	}
+}
+
+func (d *PaymentStatusUpdateData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.PaymentStatusUpdate](m)
+	if err != nil {
+		return err
+	}
+	*d = PaymentStatusUpdateData{
+		TripID:    msg.TripID,
+		RiderID:   msg.RiderID,
+		DriverID:  msg.DriverID,
+		SessionID: msg.SessionID,
+		Purpose:   msg.Purpose,
+		Status:    msg.Status,
+		Amount:    msg.Amount,
+		Currency:  msg.Currency,
+	}
+	return nil
+}
+
+func (d PaymentRefundedData) ToProto() proto.Message {
+	return &pbe.PaymentRefunded{
+		TripID:         d.TripID,
+		RiderID:        d.RiderID,
+		DriverID:       d.DriverID,
+		PaymentID:      d.PaymentID,
+		RefundID:       d.RefundID,
+		Purpose:        d.Purpose,
+		Reason:         d.Reason,
+		Amount:         d.Amount,
+		RefundedAmount: d.RefundedAmount,
+		Currency:       d.Currency,
+		PaymentStatus:  d.PaymentStatus,
+	}
+}
+
+func (d *PaymentRefundedData) FromProto(m proto.Message) error {
+	msg, err := protoAs[*pbe.PaymentRefunded](m)
+	if err != nil {
+		return err
+	}
+	*d = PaymentRefundedData{
+		TripID:         msg.TripID,
+		RiderID:        msg.RiderID,
+		DriverID:       msg.DriverID,
+		PaymentID:      msg.PaymentID,
+		RefundID:       msg.RefundID,
+		Purpose:        msg.Purpose,
+		Reason:         msg.Reason,
+		Amount:         msg.Amount,
+		RefundedAmount: msg.RefundedAmount,
+		Currency:       msg.Currency,
+		PaymentStatus:  msg.PaymentStatus,
+	}
+	return nil
+}
+
+func protoAs[M proto.Message](m proto.Message) (M, error) {
+	msg, ok := m.(M)
+	if !ok {
+		return msg, fmt.Errorf("unexpected payload message %s", m.ProtoReflect().Descriptor().FullName())
+	}
+	return msg, nil
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,112 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
+	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
+)
+
+func TestPayloadProtoRoundTrip(t *testing.T) {
+	trip := &pb.Trip{
+		Id:      "trip-1",
+		RiderID: "rider-1",
+		Status:  "driver_assigned",
+		Driver:  &pb.TripDriver{Id: "driver-1", Name: "Asha", CarPlate: "KA01AB1234"},
+	}
+	driver := &pbd.Driver{
+		Id:          "driver-1",
+		Name:        "Asha",
+		PackageSlug: "sedan",
+		Geohash:     "tdr1y",
+		Location:    &pbd.Location{Latitude: 12.97, Longitude: 77.59},
+	}
+
+	tests := []struct {
+		event   string
+		payload any
+	}{
+		{event: contracts.TripEventCreated, payload: TripEventData{Trip: trip}},
+		{event: contracts.TripEventDriverNotInterested, payload: DriverNotInterestedData{Trip: trip, DriverID: "driver-1"}},
+		{event: contracts.DriverCmdTripAccept, payload: DriverTripResponseData{Driver: driver, RiderID: "rider-1", TripID: "trip-1"}},
+		{event: contracts.DriverCmdLocation, payload: DriverTrackingList{
+			{Driver: driver, TripID: "trip-1", DistanceMeters: 1250.5, ETASeconds: 180},
+			{Driver: &pbd.Driver{Id: "driver-2"}},
+		}},
+		{event: contracts.DriverEventLocationUpdated, payload: DriverLocationEventData{
+			Driver:     driver,
+			Available:  true,
+			RecordedAt: time.Date(2025, 1, 1, 10, 0, 0, 500_000_000, time.UTC),
+		}},
+		{event: contracts.PricingEventSurgeUpdated, payload: SurgeUpdatedData{Geohash: "tdr1y", Multiplier: 1.4, Demand: 12, Supply: 5}},
+		{event: contracts.PaymentEventSessionCreated, payload: PaymentEventSessionCreatedData{
+			TripID: "trip-1", SessionID: "cs_1", Amount: 250.5, Currency: "inr", Purpose: "cancellation_fee",
+		}},
+		{event: contracts.PaymentCmdCreateSession, payload: PaymentTripResponseData{
+			TripID: "trip-1", RiderID: "rider-1", DriverID: "driver-1", PackageSlug: "sedan", Amount: 25050, Currency: "INR",
+		}},
+		{event: contracts.PaymentEventSuccess, payload: PaymentStatusUpdateData{
+			TripID: "trip-1", RiderID: "rider-1", DriverID: "driver-1", SessionID: "cs_1", Status: "paid", Amount: 25050, Currency: "INR",
+		}},
+		{event: contracts.PaymentEventRefunded, payload: PaymentRefundedData{
+			TripID: "trip-1", RiderID: "rider-1", DriverID: "driver-1", PaymentID: "pay-1", RefundID: "re-1",
+			Reason: "driver no-show", Amount: 5000, RefundedAmount: 7000, Currency: "INR", PaymentStatus: "partially_refunded",
+		}},
+		{event: contracts.DriverCmdTripRequest, payload: TripEventData{}},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[fmt.Sprintf("%T", tt.payload)] = true
+
+		t.Run(fmt.Sprintf("%s %T", tt.event, tt.payload), func(t *testing.T) {
+			original, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := &contracts.EventEnvelope{Type: tt.event, Payload: original, ContentType: contracts.ContentTypeJSON}
+
+			// JSON to protobuf, the way a protobuf publisher relays an outbox payload
+			data, err := transcode(env, kafka.ProtobufSerializer{})
+			if err != nil {
+				t.Fatalf("JSON to protobuf: %v", err)
+			}
+
+			// and back, the way the gateway forwards a protobuf payload to WebSocket clients
+			converted, err := PayloadJSON(&contracts.EventEnvelope{Type: tt.event, Payload: data, ContentType: contracts.ContentTypeProtobuf})
+			if err != nil {
+				t.Fatalf("protobuf to JSON: %v", err)
+			}
+
+			var want, got any
+			if err := json.Unmarshal(original, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(converted, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip through protobuf changed the payload\n got: %s\nwant: %s", converted, original)
+			}
+		})
+	}
+
+	for eventType, p := range payloads {
+		if name := fmt.Sprintf("%T", reflect.ValueOf(p.new()).Elem().Interface()); !covered[name] {
+			t.Errorf("no round trip for the %s payload of %s", name, eventType)
+		}
+	}
+}
+
+func TestTranscodeUnknownEvent(t *testing.T) {
+	env := &contracts.EventEnvelope{Type: contracts.DriverCmdRegister, Payload: []byte(`{}`), ContentType: contracts.ContentTypeJSON}
+	if _, err := transcode(env, kafka.ProtobufSerializer{}); err == nil {
+		t.Error("transcode() of an event without a payload type succeeded, want an error")
+	}
+}
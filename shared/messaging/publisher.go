@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/env"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+)
+
+// Publisher sends envelopes to Kafka on behalf of a service
+type Publisher struct {
+	producer   *kafka.Producer
+	service    string
+	legacyData bool // repeat the payload in the envelope's data field
+}
+
+// NewPublisher creates a Publisher stamping envelopes with the service's name. Unless
+// EVENT_LEGACY_DATA is false, JSON envelopes repeat their payload for consumers still reading a
+// KafkaMessage during a rolling deploy. The default turns false in the next release.
+func NewPublisher(producer *kafka.Producer, service string) *Publisher {
+	return &Publisher{
+		producer:   producer,
+		service:    service,
+		legacyData: env.GetBool("EVENT_LEGACY_DATA", true),
+	}
+}
+
+// Publish sends the payload to the event's topic in a new envelope, keyed by entityID
+func Publish[T any](ctx context.Context, p *Publisher, event Event[T], entityID string, payload T) error {
+	env, err := newEnvelope(ctx, event, entityID, payload, p.producer.Serializer())
+	if err != nil {
+		return err
+	}
+	return p.Send(env)
+}
+
+// PublishAndWait sends the payload like Publish and waits for Kafka to acknowledge it
+func PublishAndWait[T any](ctx context.Context, p *Publisher, event Event[T], entityID string, payload T, timeout time.Duration) error {
+	env, err := newEnvelope(ctx, event, entityID, payload, p.producer.Serializer())
+	if err != nil {
+		return err
+	}
+	return p.SendAndWait(ctx, env, timeout)
+}
+
+// Send publishes the envelope on the topic of its type, keyed by its entity ID. A payload encoded
+// for another content type than the producer's is converted first.
+func (p *Publisher) Send(env *contracts.EventEnvelope) error {
+	sent, err := p.prepare(env)
+	if err != nil {
+		return err
+	}
+	return p.producer.Send(sent)
+}
+
+// SendAndWait publishes the envelope like Send and waits for Kafka to acknowledge it
+func (p *Publisher) SendAndWait(ctx context.Context, env *contracts.EventEnvelope, timeout time.Duration) error {
+	sent, err := p.prepare(env)
+	if err != nil {
+		return err
+	}
+	return p.producer.SendAndWait(ctx, sent, timeout)
+}
+
+func (p *Publisher) prepare(env *contracts.EventEnvelope) (*contracts.EventEnvelope, error) {
+	sent := *env
+	if sent.Producer == "" {
+		sent.Producer = p.service
+	}
+
+	s := p.producer.Serializer()
+	if sent.ContentType != s.ContentType() {
+		payload, err := transcode(&sent, s)
+		if err != nil {
+			return nil, err
+		}
+		sent.Payload = payload
+		sent.ContentType = s.ContentType()
+	}
+
+	// Only a JSON envelope reads like a KafkaMessage
+	if p.legacyData && sent.ContentType == contracts.ContentTypeJSON {
+		sent.Data = sent.Payload
+	}
+	return &sent, nil
+}
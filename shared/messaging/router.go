@@ -0,0 +1,56 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+	"github.com/cprakhar/uber-clone/shared/messaging/kafka"
+)
+
+// Router hands each consumed message to the handler of its topic
+type Router struct {
+	handlers map[string]func(context.Context, *contracts.EventEnvelope) error
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]func(context.Context, *contracts.EventEnvelope) error)}
+}
+
+// Handle registers fn for the messages of the event, with their payload decoded to T.
+// The context fn gets carries the envelope, so the events fn publishes are caused by it.
+func Handle[T any](r *Router, event Event[T], fn func(ctx context.Context, env *contracts.EventEnvelope, payload T) error) {
+	r.handlers[event.Type] = func(ctx context.Context, env *contracts.EventEnvelope) error {
+		var payload T
+		if len(env.Payload) > 0 {
+			s, err := kafka.SerializerFor(env.ContentType)
+			if err != nil {
+				return err
+			}
+			if err := s.UnmarshalPayload(env.Payload, &payload); err != nil {
+				return fmt.Errorf("failed to unmarshal %s payload: %w", event.Type, err)
+			}
+		}
+		if env.SchemaVersion > event.Version {
+			log.Printf("Handling %s event %s of schema version %d as version %d", event.Type, env.ID, env.SchemaVersion, event.Version)
+		}
+		return fn(ctx, env, payload)
+	}
+}
+
+// HandleMessage is the MessageHandler of the router. Messages of topics without a handler are skipped.
+func (r *Router) HandleMessage(ctx context.Context, msg *ckafka.Message) error {
+	env, err := DecodeEnvelope(msg)
+	if err != nil {
+		return err
+	}
+
+	handler, ok := r.handlers[*msg.TopicPartition.Topic]
+	if !ok {
+		log.Printf("No handler for %s event %s", *msg.TopicPartition.Topic, env.ID)
+		return nil
+	}
+	return handler(ContextWithEnvelope(ctx, env), env)
+}
@@ -0,0 +1,136 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/cprakhar/uber-clone/shared/contracts"
+)
+
+func TestHandleMessage(t *testing.T) {
+	payload := `{"trip":{"id":"trip-1","riderID":"rider-1"}}`
+
+	tests := []struct {
+		name        string
+		topic       string
+		value       string
+		contentType string // of the message header, none if ""
+
+		wantErr     bool
+		wantHandled bool
+		wantVersion int
+		wantEntity  string
+	}{
+		{
+			name:        "envelope",
+			value:       `{"id":"event-1","type":"trip.event.created","schemaVersion":1,"entityID":"rider-1","payload":` + payload + `}`,
+			contentType: contracts.ContentTypeJSON,
+			wantHandled: true,
+			wantVersion: 1,
+			wantEntity:  "rider-1",
+		},
+		{
+			name:        "envelope without a content type header",
+			value:       `{"id":"event-1","type":"trip.event.created","schemaVersion":1,"entityID":"rider-1","payload":` + payload + `}`,
+			wantHandled: true,
+			wantVersion: 1,
+			wantEntity:  "rider-1",
+		},
+		{
+			name:        "envelope with legacy data",
+			value:       `{"id":"event-1","type":"trip.event.created","schemaVersion":1,"entityID":"rider-1","payload":` + payload + `,"data":"bm90IHRoZSBwYXlsb2Fk"}`,
+			wantHandled: true,
+			wantVersion: 1,
+			wantEntity:  "rider-1",
+		},
+		{
+			name:        "legacy KafkaMessage",
+			value:       legacyMessage(t, "rider-1", payload),
+			wantHandled: true,
+			wantVersion: 0,
+			wantEntity:  "rider-1",
+		},
+		{
+			name:        "newer schema version with an unknown field",
+			value:       `{"id":"event-1","type":"trip.event.created","schemaVersion":2,"entityID":"rider-1","payload":{"trip":{"id":"trip-1","riderID":"rider-1"},"tip":500}}`,
+			wantHandled: true,
+			wantVersion: 2,
+			wantEntity:  "rider-1",
+		},
+		{
+			name:    "malformed",
+			value:   `{"id":`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed payload",
+			value:   `{"id":"event-1","type":"trip.event.created","schemaVersion":1,"payload":{"trip":"trip-1"}}`,
+			wantErr: true,
+		},
+		{
+			name:        "unknown content type",
+			value:       `{}`,
+			contentType: "application/xml",
+			wantErr:     true,
+		},
+		{
+			name:  "topic without a handler",
+			topic: contracts.TripEventCancelled,
+			value: `{"id":"event-1","type":"trip.event.cancelled","schemaVersion":1,"payload":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *contracts.EventEnvelope
+			var got TripEventData
+			router := NewRouter()
+			Handle(router, TripCreated, func(ctx context.Context, env *contracts.EventEnvelope, payload TripEventData) error {
+				handled, got = env, payload
+				return nil
+			})
+
+			topic := tt.topic
+			if topic == "" {
+				topic = TripCreated.Type
+			}
+			msg := &ckafka.Message{TopicPartition: ckafka.TopicPartition{Topic: &topic}, Value: []byte(tt.value)}
+			if tt.contentType != "" {
+				msg.Headers = []ckafka.Header{{Key: contracts.ContentTypeHeader, Value: []byte(tt.contentType)}}
+			}
+
+			err := router.HandleMessage(t.Context(), msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HandleMessage() = %v, want error %v", err, tt.wantErr)
+			}
+			if (handled != nil) != tt.wantHandled {
+				t.Fatalf("handler called %v, want %v", handled != nil, tt.wantHandled)
+			}
+			if !tt.wantHandled {
+				return
+			}
+
+			if handled.Type != TripCreated.Type || handled.SchemaVersion != tt.wantVersion || handled.EntityID != tt.wantEntity {
+				t.Errorf("envelope %s v%d for %q, want %s v%d for %q", handled.Type, handled.SchemaVersion, handled.EntityID, TripCreated.Type, tt.wantVersion, tt.wantEntity)
+			}
+			if handled.Data != nil {
+				t.Errorf("envelope kept legacy data %q", handled.Data)
+			}
+			if got.Trip.GetId() != "trip-1" || got.Trip.GetRiderID() != "rider-1" {
+				t.Errorf("payload trip = %v, want trip-1 of rider-1", got.Trip)
+			}
+		})
+	}
+}
+
+// legacyMessage returns a contracts.KafkaMessage as published before envelopes
+func legacyMessage(t *testing.T, entityID, payload string) string {
+	t.Helper()
+	data, err := json.Marshal(contracts.KafkaMessage{EntityID: entityID, Data: []byte(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotFound      = errors.New("schema not found")
+	ErrNotRegistered = errors.New("schema version not registered")
+	ErrChanged       = errors.New("schema changed without a version bump")
+	ErrStaleVersion  = errors.New("schema version older than the latest registered")
+)
+
+// Registry keeps every version of every event schema as a JSON file, dir/<subject>/v<version>.json,
+// committed with the code so a change to a payload is reviewed with its schema
+type Registry struct {
+	dir string
+}
+
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// Versions returns the registered versions of the subject in ascending order
+func (r *Registry) Versions(subject string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(r.dir, subject))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "v"), ".json"))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// Get returns the registered schema of the subject at the version
+func (r *Registry) Get(subject string, version int) (*Schema, error) {
+	data, err := os.ReadFile(r.path(subject, version))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s v%d", ErrNotFound, subject, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", r.path(subject, version), err)
+	}
+	return &s, nil
+}
+
+// Check compares the schema with the registry. It returns nil when the schema is registered as is,
+// ErrNotRegistered when it is a new version that can be registered, and otherwise ErrChanged,
+// ErrStaleVersion or an *IncompatibleError against the latest registered version.
+func (r *Registry) Check(s *Schema) error {
+	versions, err := r.Versions(s.Subject)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		if v != s.Version {
+			continue
+		}
+		registered, err := r.Get(s.Subject, v)
+		if err != nil {
+			return err
+		}
+		same, err := equal(registered, s)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("%w: %s v%d differs from %s", ErrChanged, s.Subject, s.Version, r.path(s.Subject, v))
+		}
+		return nil
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("%w: %s v%d", ErrNotRegistered, s.Subject, s.Version)
+	}
+	latest := versions[len(versions)-1]
+	if s.Version < latest {
+		return fmt.Errorf("%w: %s v%d, latest is v%d", ErrStaleVersion, s.Subject, s.Version, latest)
+	}
+	prev, err := r.Get(s.Subject, latest)
+	if err != nil {
+		return err
+	}
+	if err := CheckCompatible(prev, s); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s v%d", ErrNotRegistered, s.Subject, s.Version)
+}
+
+// Register saves a new version of the schema once Check allows it. It reports whether the
+// schema was written, which it is not when already registered.
+func (r *Registry) Register(s *Schema) (bool, error) {
+	err := r.Check(s)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrNotRegistered) {
+		return false, err
+	}
+
+	data, err := encode(s)
+	if err != nil {
+		return false, err
+	}
+	path := r.path(s.Subject, s.Version)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Registry) path(subject string, version int) string {
+	return filepath.Join(r.dir, subject, fmt.Sprintf("v%d.json", version))
+}
+
+func encode(s *Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func equal(a, b *Schema) (bool, error) {
+	ea, err := encode(a)
+	if err != nil {
+		return false, err
+	}
+	eb, err := encode(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ea, eb), nil
+}
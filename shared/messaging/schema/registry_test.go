@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+	v1 := paymentSchema(1)
+
+	if err := r.Check(v1); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("Check() of an unregistered subject = %v, want ErrNotRegistered", err)
+	}
+	if written, err := r.Register(v1); err != nil || !written {
+		t.Fatalf("Register() = %v, %v, want the schema written", written, err)
+	}
+	if written, err := r.Register(v1); err != nil || written {
+		t.Fatalf("Register() of a registered schema = %v, %v, want nothing written", written, err)
+	}
+
+	got, err := r.Get(v1.Subject, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Check(got); err != nil {
+		t.Errorf("Check() of the schema read back = %v, want nil", err)
+	}
+	if _, err := r.Get(v1.Subject, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a missing version = %v, want ErrNotFound", err)
+	}
+
+	tests := []struct {
+		name    string
+		version int
+		change  func(s *Schema)
+		wantErr error // nil if the version can be registered
+	}{
+		{name: "compatible new version", version: 2, change: func(s *Schema) {
+			payment(s).Fields = append(payment(s).Fields, &Field{Number: 4, Name: "tip", Kind: "int64"})
+		}},
+		{name: "changed without a version bump", version: 1, change: func(s *Schema) {
+			payment(s).Fields = append(payment(s).Fields, &Field{Number: 4, Name: "tip", Kind: "int64"})
+		}, wantErr: ErrChanged},
+		{name: "incompatible new version", version: 2, change: func(s *Schema) {
+			payment(s).Fields = payment(s).Fields[:2]
+		}, wantErr: &IncompatibleError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := paymentSchema(tt.version)
+			tt.change(s)
+
+			err := r.Check(s)
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if !errors.Is(err, ErrNotRegistered) {
+					t.Errorf("Check() = %v, want ErrNotRegistered", err)
+				}
+			case *IncompatibleError:
+				if !errors.As(err, &want) {
+					t.Errorf("Check() = %v, want an *IncompatibleError", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("Check() = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+
+	v3 := paymentSchema(3)
+	payment(v3).Fields = append(payment(v3).Fields, &Field{Number: 4, Name: "tip", Kind: "int64"})
+	if written, err := r.Register(v3); err != nil || !written {
+		t.Fatalf("Register() of a compatible version = %v, %v, want the schema written", written, err)
+	}
+	if err := r.Check(paymentSchema(2)); !errors.Is(err, ErrStaleVersion) {
+		t.Errorf("Check() of a version below the latest = %v, want ErrStaleVersion", err)
+	}
+	if versions, err := r.Versions(v1.Subject); err != nil || !slices.Equal(versions, []int{1, 3}) {
+		t.Errorf("Versions() = %v, %v, want [1 3]", versions, err)
+	}
+	if versions, err := r.Versions("unknown"); err != nil || len(versions) != 0 {
+		t.Errorf("Versions() of an unknown subject = %v, %v, want none", versions, err)
+	}
+}
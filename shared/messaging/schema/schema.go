@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Schema is the shape of an event's protobuf payload at a version, as the registry stores it
+type Schema struct {
+	Subject  string     `json:"subject"` // the event type
+	Version  int        `json:"version"`
+	Message  string     `json:"message"`  // full name of the payload message
+	Messages []*Message `json:"messages"` // the payload message and every message it uses, by name
+}
+
+type Message struct {
+	Name          string   `json:"name"`
+	Fields        []*Field `json:"fields"` // by number
+	Reserved      []Range  `json:"reserved,omitempty"`
+	ReservedNames []string `json:"reservedNames,omitempty"`
+}
+
+type Field struct {
+	Number   int32  `json:"number"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Repeated bool   `json:"repeated,omitempty"`
+	TypeName string `json:"typeName,omitempty"` // full name of a message or enum field's type
+}
+
+// Range is a range of reserved field numbers, both ends included
+type Range struct {
+	From int32 `json:"from"`
+	To   int32 `json:"to"`
+}
+
+// FromDescriptor returns the schema of the subject's payload message md at the version
+func FromDescriptor(subject string, version int, md protoreflect.MessageDescriptor) *Schema {
+	s := &Schema{Subject: subject, Version: version, Message: string(md.FullName())}
+
+	seen := map[protoreflect.FullName]bool{md.FullName(): true}
+	queue := []protoreflect.MessageDescriptor{md}
+	for len(queue) > 0 {
+		md, queue = queue[0], queue[1:]
+		m := &Message{Name: string(md.FullName())}
+
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			f := &Field{
+				Number:   int32(fd.Number()),
+				Name:     string(fd.Name()),
+				Kind:     fd.Kind().String(),
+				Repeated: fd.Cardinality() == protoreflect.Repeated,
+			}
+			switch {
+			case fd.Message() != nil:
+				f.TypeName = string(fd.Message().FullName())
+				if !seen[fd.Message().FullName()] {
+					seen[fd.Message().FullName()] = true
+					queue = append(queue, fd.Message())
+				}
+			case fd.Enum() != nil:
+				f.TypeName = string(fd.Enum().FullName())
+			}
+			m.Fields = append(m.Fields, f)
+		}
+		sort.Slice(m.Fields, func(i, j int) bool { return m.Fields[i].Number < m.Fields[j].Number })
+
+		ranges := md.ReservedRanges()
+		for i := 0; i < ranges.Len(); i++ {
+			r := ranges.Get(i)
+			m.Reserved = append(m.Reserved, Range{From: int32(r[0]), To: int32(r[1]) - 1})
+		}
+		names := md.ReservedNames()
+		for i := 0; i < names.Len(); i++ {
+			m.ReservedNames = append(m.ReservedNames, string(names.Get(i)))
+		}
+		sort.Strings(m.ReservedNames)
+
+		s.Messages = append(s.Messages, m)
+	}
+
+	sort.Slice(s.Messages, func(i, j int) bool { return s.Messages[i].Name < s.Messages[j].Name })
+	return s
+}
+
+// IncompatibleError lists why consumers of the previous schema cannot read payloads of the next one
+type IncompatibleError struct {
+	Subject  string
+	Version  int
+	Problems []string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("%s v%d is not backward compatible: %s", e.Subject, e.Version, strings.Join(e.Problems, "; "))
+}
+
+// CheckCompatible returns an *IncompatibleError when next is not backward compatible with prev.
+// Fields may be added, and removed when their number is reserved. A field may not change its
+// number, name, kind, cardinality or type, as protobuf consumers match fields by number and JSON
+// consumers by name. Enum values are not compared.
+func CheckCompatible(prev, next *Schema) error {
+	var problems []string
+	if prev.Message != next.Message {
+		problems = append(problems, fmt.Sprintf("payload message changed from %s to %s", prev.Message, next.Message))
+	}
+
+	for _, pm := range prev.Messages {
+		nm := next.message(pm.Name)
+		if nm == nil {
+			// No longer used, and whatever stopped using it is reported
+			continue
+		}
+		for _, pf := range pm.Fields {
+			nf := nm.field(pf.Number)
+			if nf == nil {
+				if !nm.reserved(pf.Number) {
+					problems = append(problems, fmt.Sprintf("%s.%s (%d) removed without reserving its number", pm.Name, pf.Name, pf.Number))
+				}
+				continue
+			}
+			if nf.Name != pf.Name {
+				problems = append(problems, fmt.Sprintf("%s field %d renamed from %s to %s", pm.Name, pf.Number, pf.Name, nf.Name))
+			}
+			if nf.Kind != pf.Kind || nf.TypeName != pf.TypeName {
+				problems = append(problems, fmt.Sprintf("%s.%s changed type from %s to %s", pm.Name, pf.Name, pf.typeString(), nf.typeString()))
+			}
+			if nf.Repeated != pf.Repeated {
+				problems = append(problems, fmt.Sprintf("%s.%s changed repeated from %t to %t", pm.Name, pf.Name, pf.Repeated, nf.Repeated))
+			}
+		}
+		for _, nf := range nm.Fields {
+			if pm.field(nf.Number) == nil && pm.reserved(nf.Number) {
+				problems = append(problems, fmt.Sprintf("%s.%s reuses reserved number %d", pm.Name, nf.Name, nf.Number))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &IncompatibleError{Subject: next.Subject, Version: next.Version, Problems: problems}
+	}
+	return nil
+}
+
+func (s *Schema) message(name string) *Message {
+	for _, m := range s.Messages {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func (m *Message) field(number int32) *Field {
+	for _, f := range m.Fields {
+		if f.Number == number {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *Message) reserved(number int32) bool {
+	for _, r := range m.Reserved {
+		if number >= r.From && number <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Field) typeString() string {
+	if f.TypeName != "" {
+		return f.TypeName
+	}
+	return f.Kind
+}
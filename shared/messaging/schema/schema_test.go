@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	pbe "github.com/cprakhar/uber-clone/shared/proto/events"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     func(s *Schema) // changes the previous version, if set
+		change   func(s *Schema) // turns the previous version into the next
+		problems []string        // expected in the error, none if compatible
+	}{
+		{name: "unchanged", change: func(*Schema) {}},
+		{name: "field added", change: func(s *Schema) {
+			payment(s).Fields = append(payment(s).Fields, &Field{Number: 4, Name: "tip", Kind: "int64"})
+		}},
+		{name: "field removed with its number reserved", change: func(s *Schema) {
+			payment(s).Fields = payment(s).Fields[:2]
+			payment(s).Reserved = []Range{{From: 3, To: 3}}
+		}},
+		{name: "message no longer used", change: func(s *Schema) {
+			s.Messages = s.Messages[1:]
+			payment(s).Fields = payment(s).Fields[:2]
+			payment(s).Reserved = []Range{{From: 3, To: 5}}
+		}},
+		{name: "field removed", change: func(s *Schema) {
+			payment(s).Fields = payment(s).Fields[:2]
+		}, problems: []string{"test.Payment.money (3) removed without reserving its number"}},
+		{name: "field renamed", change: func(s *Schema) {
+			payment(s).Fields[1].Name = "total"
+		}, problems: []string{"test.Payment field 2 renamed from amount to total"}},
+		{name: "kind changed", change: func(s *Schema) {
+			payment(s).Fields[1].Kind = "double"
+		}, problems: []string{"test.Payment.amount changed type from int64 to double"}},
+		{name: "message type changed", change: func(s *Schema) {
+			payment(s).Fields[2].TypeName = "test.Amount"
+		}, problems: []string{"test.Payment.money changed type from test.Money to test.Amount"}},
+		{name: "made repeated", change: func(s *Schema) {
+			payment(s).Fields[0].Repeated = true
+		}, problems: []string{"test.Payment.tripID changed repeated from false to true"}},
+		{name: "nested message changed", change: func(s *Schema) {
+			s.Messages[0].Fields[0].Kind = "int32"
+		}, problems: []string{"test.Money.units changed type from int64 to int32"}},
+		{name: "reserved number reused", prev: func(s *Schema) {
+			payment(s).Reserved = []Range{{From: 8, To: 9}}
+		}, change: func(s *Schema) {
+			payment(s).Fields = append(payment(s).Fields, &Field{Number: 9, Name: "tip", Kind: "int64"})
+		}, problems: []string{"test.Payment.tip reuses reserved number 9"}},
+		{name: "payload message replaced", change: func(s *Schema) {
+			s.Message = "test.Money"
+		}, problems: []string{"payload message changed from test.Payment to test.Money"}},
+		{name: "several problems", change: func(s *Schema) {
+			payment(s).Fields[0].Name = "trip"
+			payment(s).Fields[1].Kind = "string"
+		}, problems: []string{"renamed from tripID to trip", "amount changed type from int64 to string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, next := paymentSchema(1), paymentSchema(2)
+			if tt.prev != nil {
+				tt.prev(prev)
+				tt.prev(next)
+			}
+			tt.change(next)
+
+			err := CheckCompatible(prev, next)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("CheckCompatible() = %v, want nil", err)
+				}
+				return
+			}
+
+			var incompatible *IncompatibleError
+			if !errors.As(err, &incompatible) {
+				t.Fatalf("CheckCompatible() = %v, want an *IncompatibleError", err)
+			}
+			if incompatible.Subject != "payment.event.success" || incompatible.Version != 2 {
+				t.Errorf("error is for %s v%d, want payment.event.success v2", incompatible.Subject, incompatible.Version)
+			}
+			if len(incompatible.Problems) != len(tt.problems) {
+				t.Errorf("problems = %q, want %d", incompatible.Problems, len(tt.problems))
+			}
+			for _, want := range tt.problems {
+				if !slices.ContainsFunc(incompatible.Problems, func(p string) bool { return strings.Contains(p, want) }) {
+					t.Errorf("problems = %q, want one containing %q", incompatible.Problems, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFromDescriptor(t *testing.T) {
+	s := FromDescriptor("trip.event.created", 3, (&pbe.TripEvent{}).ProtoReflect().Descriptor())
+
+	if s.Subject != "trip.event.created" || s.Version != 3 || s.Message != "events.TripEvent" {
+		t.Fatalf("FromDescriptor() = %s v%d of %s, want trip.event.created v3 of events.TripEvent", s.Subject, s.Version, s.Message)
+	}
+
+	var names []string
+	for _, m := range s.Messages {
+		names = append(names, m.Name)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("messages %v are not sorted by name", names)
+	}
+	for _, want := range []string{"events.TripEvent", "trip.Trip", "trip.TripDriver", "trip.Route"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("messages %v do not include %s", names, want)
+		}
+	}
+
+	event := s.message("events.TripEvent")
+	if len(event.Fields) != 1 || *event.Fields[0] != (Field{Number: 1, Name: "trip", Kind: "message", TypeName: "trip.Trip"}) {
+		t.Errorf("events.TripEvent fields = %+v, want the trip message", event.Fields)
+	}
+	for _, m := range s.Messages {
+		if !slices.IsSortedFunc(m.Fields, func(a, b *Field) int { return int(a.Number - b.Number) }) {
+			t.Errorf("fields of %s are not sorted by number", m.Name)
+		}
+	}
+
+	// A schema is compatible with itself
+	if err := CheckCompatible(s, s); err != nil {
+		t.Errorf("CheckCompatible() of the same schema = %v", err)
+	}
+}
+
+// paymentSchema returns the schema of a test.Payment payload using a test.Money message
+func paymentSchema(version int) *Schema {
+	return &Schema{
+		Subject: "payment.event.success",
+		Version: version,
+		Message: "test.Payment",
+		Messages: []*Message{
+			{Name: "test.Money", Fields: []*Field{
+				{Number: 1, Name: "units", Kind: "int64"},
+				{Number: 2, Name: "currency", Kind: "string"},
+			}},
+			{Name: "test.Payment", Fields: []*Field{
+				{Number: 1, Name: "tripID", Kind: "string"},
+				{Number: 2, Name: "amount", Kind: "int64"},
+				{Number: 3, Name: "money", Kind: "message", TypeName: "test.Money"},
+			}},
+		},
+	}
+}
+
+func payment(s *Schema) *Message {
+	return s.message("test.Payment")
+}
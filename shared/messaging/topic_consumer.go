@@ -2,7 +2,7 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
 
 	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -27,20 +27,22 @@ func NewTopicConsumer(kf *kafka.KafkaClient, connMgr *ConnectionManager, topics
 func (tc *TopicConsumer) Consume(ctx context.Context) error {
 	return tc.kf.Consumer.SubscribeAndConsume(ctx, tc.topics,
 		func(ctx context.Context, msg *ckafka.Message) error {
-			var kfMsg contracts.KafkaMessage
-			if err := json.Unmarshal(msg.Value, &kfMsg); err != nil {
-				log.Printf("Failed to unmarshal message: %v", err)
+			env, err := DecodeEnvelope(msg)
+			if err != nil {
+				log.Printf("Failed to decode message: %v", err)
 				return err
 			}
+			entityID := env.EntityID
 
-			entityID := kfMsg.EntityID
-
+			// Browsers read JSON whatever the content type of the message
 			var payload any
-			if kfMsg.Data != nil {
-				if err := json.Unmarshal(kfMsg.Data, &payload); err != nil {
-					log.Printf("Failed to unmarshal payload: %v", err)
-					return err
-				}
+			data, err := PayloadJSON(env)
+			if err != nil {
+				log.Printf("Failed to convert %s payload to JSON: %v", env.Type, err)
+				return err
+			}
+			if len(data) > 0 {
+				payload = data
 			}
 
 			clientMsg := contracts.WSMessage{
@@ -48,7 +50,13 @@ func (tc *TopicConsumer) Consume(ctx context.Context) error {
 				Data: payload,
 			}
 
-			return tc.connMgr.SendMessage(entityID, clientMsg)
+			err = tc.connMgr.SendMessage(entityID, clientMsg)
+			if errors.Is(err, ErrConnectionNotFound) {
+				// The client is not connected to this gateway, which is not worth a retry
+				log.Printf("No connection for %s, dropping %s message", entityID, clientMsg.Type)
+				return nil
+			}
+			return err
 		},
 	)
 }
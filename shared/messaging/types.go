@@ -1,6 +1,8 @@
 package messaging
 
 import (
+	"time"
+
 	pbd "github.com/cprakhar/uber-clone/shared/proto/driver"
 	pb "github.com/cprakhar/uber-clone/shared/proto/trip"
 )
@@ -9,29 +11,80 @@ type TripEventData struct {
 	Trip *pb.Trip `json:"trip"`
 }
 
+// DriverNotInterestedData is published when a driver declines a trip offer
+type DriverNotInterestedData struct {
+	Trip     *pb.Trip `json:"trip"`
+	DriverID string   `json:"driverID"`
+}
+
 type DriverTripResponseData struct {
 	Driver  *pbd.Driver `json:"driver"`
 	RiderID string      `json:"riderID"`
 	TripID  string      `json:"tripID"`
 }
 
+type DriverLocationEventData struct {
+	Driver     *pbd.Driver `json:"driver"`
+	Available  bool        `json:"available"`
+	RecordedAt time.Time   `json:"recordedAt"`
+}
+
+// DriverTrackingData is sent to the rider with the live location of their driver
+type DriverTrackingData struct {
+	*pbd.Driver
+	TripID         string  `json:"tripID"`
+	DistanceMeters float64 `json:"distanceMeters"`
+	ETASeconds     float64 `json:"etaSeconds"`
+}
+
+// DriverTrackingList is the list of drivers the rider's map renders
+type DriverTrackingList []DriverTrackingData
+
+type SurgeUpdatedData struct {
+	Geohash    string  `json:"geohash"`
+	Multiplier float64 `json:"multiplier"`
+	Demand     int     `json:"demand"`
+	Supply     int     `json:"supply"`
+}
+
 type PaymentEventSessionCreatedData struct {
 	TripID    string  `json:"tripID"`
 	SessionID string  `json:"sessionID"`
 	Amount    float64 `json:"amount"`
 	Currency  string  `json:"currency"`
+	Purpose   string  `json:"purpose,omitempty"`
 }
 
 type PaymentTripResponseData struct {
-	TripID   string  `json:"tripID"`
-	RiderID  string  `json:"riderID"`
-	DriverID string  `json:"driverID"`
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	TripID      string `json:"tripID"`
+	RiderID     string `json:"riderID"`
+	DriverID    string `json:"driverID"`
+	PackageSlug string `json:"packageSlug,omitempty"`
+	Amount      int64  `json:"amount"` // Amount in minor units (paise)
+	Currency    string `json:"currency"`
 }
 
 type PaymentStatusUpdateData struct {
-	TripID   string `json:"tripID"`
-	RiderID  string `json:"riderID"`
-	DriverID string `json:"driverID"`
+	TripID    string `json:"tripID"`
+	RiderID   string `json:"riderID"`
+	DriverID  string `json:"driverID"`
+	SessionID string `json:"sessionID,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Amount    int64  `json:"amount,omitempty"` // Amount in minor units (paise)
+	Currency  string `json:"currency,omitempty"`
+}
+
+type PaymentRefundedData struct {
+	TripID         string `json:"tripID"`
+	RiderID        string `json:"riderID"`
+	DriverID       string `json:"driverID"`
+	PaymentID      string `json:"paymentID"`
+	RefundID       string `json:"refundID"`
+	Purpose        string `json:"purpose,omitempty"`
+	Reason         string `json:"reason"`
+	Amount         int64  `json:"amount"`         // Refunded amount in minor units (paise)
+	RefundedAmount int64  `json:"refundedAmount"` // Total refunded from the payment so far
+	Currency       string `json:"currency"`
+	PaymentStatus  string `json:"paymentStatus"`
 }
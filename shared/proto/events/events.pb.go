@@ -0,0 +1,1082 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v3.21.12
+// source: events.proto
+
+package events
+
+import (
+	driver "github.com/cprakhar/uber-clone/shared/proto/driver"
+	trip "github.com/cprakhar/uber-clone/shared/proto/trip"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventEnvelope is the message published for every event with the protobuf content type.
+// Its payload is the event's message below, encoded with protobuf.
+type EventEnvelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	SchemaVersion int32                  `protobuf:"varint,3,opt,name=schemaVersion,proto3" json:"schemaVersion,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=occurredAt,proto3" json:"occurredAt,omitempty"`
+	Producer      string                 `protobuf:"bytes,5,opt,name=producer,proto3" json:"producer,omitempty"`
+	CorrelationID string                 `protobuf:"bytes,6,opt,name=correlationID,proto3" json:"correlationID,omitempty"`
+	CausationID   string                 `protobuf:"bytes,7,opt,name=causationID,proto3" json:"causationID,omitempty"`
+	EntityID      string                 `protobuf:"bytes,8,opt,name=entityID,proto3" json:"entityID,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,9,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventEnvelope) Reset() {
+	*x = EventEnvelope{}
+	mi := &file_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventEnvelope) ProtoMessage() {}
+
+func (x *EventEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventEnvelope.ProtoReflect.Descriptor instead.
+func (*EventEnvelope) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventEnvelope) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *EventEnvelope) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *EventEnvelope) GetProducer() string {
+	if x != nil {
+		return x.Producer
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetCorrelationID() string {
+	if x != nil {
+		return x.CorrelationID
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetCausationID() string {
+	if x != nil {
+		return x.CausationID
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetEntityID() string {
+	if x != nil {
+		return x.EntityID
+	}
+	return ""
+}
+
+func (x *EventEnvelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// TripEvent is the payload of the trip.event.* events and the driver.cmd.trip_request command
+type TripEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *trip.Trip             `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripEvent) Reset() {
+	*x = TripEvent{}
+	mi := &file_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripEvent) ProtoMessage() {}
+
+func (x *TripEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripEvent.ProtoReflect.Descriptor instead.
+func (*TripEvent) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TripEvent) GetTrip() *trip.Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+// trip.event.driver_not_interested
+type DriverNotInterested struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *trip.Trip             `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	DriverID      string                 `protobuf:"bytes,2,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DriverNotInterested) Reset() {
+	*x = DriverNotInterested{}
+	mi := &file_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverNotInterested) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverNotInterested) ProtoMessage() {}
+
+func (x *DriverNotInterested) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverNotInterested.ProtoReflect.Descriptor instead.
+func (*DriverNotInterested) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DriverNotInterested) GetTrip() *trip.Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+func (x *DriverNotInterested) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+// driver.cmd.trip_accept and driver.cmd.trip_decline
+type DriverTripResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Driver        *driver.Driver         `protobuf:"bytes,1,opt,name=driver,proto3" json:"driver,omitempty"`
+	RiderID       string                 `protobuf:"bytes,2,opt,name=riderID,proto3" json:"riderID,omitempty"`
+	TripID        string                 `protobuf:"bytes,3,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DriverTripResponse) Reset() {
+	*x = DriverTripResponse{}
+	mi := &file_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverTripResponse) ProtoMessage() {}
+
+func (x *DriverTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverTripResponse.ProtoReflect.Descriptor instead.
+func (*DriverTripResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DriverTripResponse) GetDriver() *driver.Driver {
+	if x != nil {
+		return x.Driver
+	}
+	return nil
+}
+
+func (x *DriverTripResponse) GetRiderID() string {
+	if x != nil {
+		return x.RiderID
+	}
+	return ""
+}
+
+func (x *DriverTripResponse) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+// driver.event.location_updated
+type DriverLocationUpdated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Driver        *driver.Driver         `protobuf:"bytes,1,opt,name=driver,proto3" json:"driver,omitempty"`
+	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	RecordedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=recordedAt,proto3" json:"recordedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DriverLocationUpdated) Reset() {
+	*x = DriverLocationUpdated{}
+	mi := &file_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverLocationUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverLocationUpdated) ProtoMessage() {}
+
+func (x *DriverLocationUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverLocationUpdated.ProtoReflect.Descriptor instead.
+func (*DriverLocationUpdated) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DriverLocationUpdated) GetDriver() *driver.Driver {
+	if x != nil {
+		return x.Driver
+	}
+	return nil
+}
+
+func (x *DriverLocationUpdated) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *DriverLocationUpdated) GetRecordedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return nil
+}
+
+// driver.cmd.location
+type DriverLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Drivers       []*DriverTracking      `protobuf:"bytes,1,rep,name=drivers,proto3" json:"drivers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DriverLocation) Reset() {
+	*x = DriverLocation{}
+	mi := &file_events_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverLocation) ProtoMessage() {}
+
+func (x *DriverLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverLocation.ProtoReflect.Descriptor instead.
+func (*DriverLocation) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DriverLocation) GetDrivers() []*DriverTracking {
+	if x != nil {
+		return x.Drivers
+	}
+	return nil
+}
+
+type DriverTracking struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Driver         *driver.Driver         `protobuf:"bytes,1,opt,name=driver,proto3" json:"driver,omitempty"`
+	TripID         string                 `protobuf:"bytes,2,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	DistanceMeters float64                `protobuf:"fixed64,3,opt,name=distanceMeters,proto3" json:"distanceMeters,omitempty"`
+	EtaSeconds     float64                `protobuf:"fixed64,4,opt,name=etaSeconds,proto3" json:"etaSeconds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DriverTracking) Reset() {
+	*x = DriverTracking{}
+	mi := &file_events_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverTracking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverTracking) ProtoMessage() {}
+
+func (x *DriverTracking) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverTracking.ProtoReflect.Descriptor instead.
+func (*DriverTracking) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DriverTracking) GetDriver() *driver.Driver {
+	if x != nil {
+		return x.Driver
+	}
+	return nil
+}
+
+func (x *DriverTracking) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *DriverTracking) GetDistanceMeters() float64 {
+	if x != nil {
+		return x.DistanceMeters
+	}
+	return 0
+}
+
+func (x *DriverTracking) GetEtaSeconds() float64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+// pricing.event.surge_updated
+type SurgeUpdated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Geohash       string                 `protobuf:"bytes,1,opt,name=geohash,proto3" json:"geohash,omitempty"`
+	Multiplier    float64                `protobuf:"fixed64,2,opt,name=multiplier,proto3" json:"multiplier,omitempty"`
+	Demand        int32                  `protobuf:"varint,3,opt,name=demand,proto3" json:"demand,omitempty"`
+	Supply        int32                  `protobuf:"varint,4,opt,name=supply,proto3" json:"supply,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SurgeUpdated) Reset() {
+	*x = SurgeUpdated{}
+	mi := &file_events_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SurgeUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SurgeUpdated) ProtoMessage() {}
+
+func (x *SurgeUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SurgeUpdated.ProtoReflect.Descriptor instead.
+func (*SurgeUpdated) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SurgeUpdated) GetGeohash() string {
+	if x != nil {
+		return x.Geohash
+	}
+	return ""
+}
+
+func (x *SurgeUpdated) GetMultiplier() float64 {
+	if x != nil {
+		return x.Multiplier
+	}
+	return 0
+}
+
+func (x *SurgeUpdated) GetDemand() int32 {
+	if x != nil {
+		return x.Demand
+	}
+	return 0
+}
+
+func (x *SurgeUpdated) GetSupply() int32 {
+	if x != nil {
+		return x.Supply
+	}
+	return 0
+}
+
+// payment.event.session_created
+type PaymentSessionCreated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	SessionID     string                 `protobuf:"bytes,2,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency      string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Purpose       string                 `protobuf:"bytes,5,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentSessionCreated) Reset() {
+	*x = PaymentSessionCreated{}
+	mi := &file_events_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentSessionCreated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentSessionCreated) ProtoMessage() {}
+
+func (x *PaymentSessionCreated) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentSessionCreated.ProtoReflect.Descriptor instead.
+func (*PaymentSessionCreated) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PaymentSessionCreated) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *PaymentSessionCreated) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+func (x *PaymentSessionCreated) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *PaymentSessionCreated) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PaymentSessionCreated) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+// payment.cmd.create_session and payment.cmd.charge_cancellation_fee
+type PaymentTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	RiderID       string                 `protobuf:"bytes,2,opt,name=riderID,proto3" json:"riderID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,3,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	PackageSlug   string                 `protobuf:"bytes,4,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
+	Amount        int64                  `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"` // minor units (paise)
+	Currency      string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentTripRequest) Reset() {
+	*x = PaymentTripRequest{}
+	mi := &file_events_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentTripRequest) ProtoMessage() {}
+
+func (x *PaymentTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentTripRequest.ProtoReflect.Descriptor instead.
+func (*PaymentTripRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PaymentTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *PaymentTripRequest) GetRiderID() string {
+	if x != nil {
+		return x.RiderID
+	}
+	return ""
+}
+
+func (x *PaymentTripRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *PaymentTripRequest) GetPackageSlug() string {
+	if x != nil {
+		return x.PackageSlug
+	}
+	return ""
+}
+
+func (x *PaymentTripRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *PaymentTripRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// payment.event.success, payment.event.failed and payment.event.cancelled
+type PaymentStatusUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	RiderID       string                 `protobuf:"bytes,2,opt,name=riderID,proto3" json:"riderID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,3,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	SessionID     string                 `protobuf:"bytes,4,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	Purpose       string                 `protobuf:"bytes,5,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Amount        int64                  `protobuf:"varint,7,opt,name=amount,proto3" json:"amount,omitempty"` // minor units (paise)
+	Currency      string                 `protobuf:"bytes,8,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentStatusUpdate) Reset() {
+	*x = PaymentStatusUpdate{}
+	mi := &file_events_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentStatusUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentStatusUpdate) ProtoMessage() {}
+
+func (x *PaymentStatusUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentStatusUpdate.ProtoReflect.Descriptor instead.
+func (*PaymentStatusUpdate) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PaymentStatusUpdate) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetRiderID() string {
+	if x != nil {
+		return x.RiderID
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PaymentStatusUpdate) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *PaymentStatusUpdate) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// payment.event.refunded
+type PaymentRefunded struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TripID         string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	RiderID        string                 `protobuf:"bytes,2,opt,name=riderID,proto3" json:"riderID,omitempty"`
+	DriverID       string                 `protobuf:"bytes,3,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	PaymentID      string                 `protobuf:"bytes,4,opt,name=paymentID,proto3" json:"paymentID,omitempty"`
+	RefundID       string                 `protobuf:"bytes,5,opt,name=refundID,proto3" json:"refundID,omitempty"`
+	Purpose        string                 `protobuf:"bytes,6,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	Reason         string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	Amount         int64                  `protobuf:"varint,8,opt,name=amount,proto3" json:"amount,omitempty"`                 // refunded amount in minor units (paise)
+	RefundedAmount int64                  `protobuf:"varint,9,opt,name=refundedAmount,proto3" json:"refundedAmount,omitempty"` // total refunded from the payment so far
+	Currency       string                 `protobuf:"bytes,10,opt,name=currency,proto3" json:"currency,omitempty"`
+	PaymentStatus  string                 `protobuf:"bytes,11,opt,name=paymentStatus,proto3" json:"paymentStatus,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PaymentRefunded) Reset() {
+	*x = PaymentRefunded{}
+	mi := &file_events_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentRefunded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentRefunded) ProtoMessage() {}
+
+func (x *PaymentRefunded) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentRefunded.ProtoReflect.Descriptor instead.
+func (*PaymentRefunded) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PaymentRefunded) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetRiderID() string {
+	if x != nil {
+		return x.RiderID
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetPaymentID() string {
+	if x != nil {
+		return x.PaymentID
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetRefundID() string {
+	if x != nil {
+		return x.RefundID
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *PaymentRefunded) GetRefundedAmount() int64 {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return 0
+}
+
+func (x *PaymentRefunded) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PaymentRefunded) GetPaymentStatus() string {
+	if x != nil {
+		return x.PaymentStatus
+	}
+	return ""
+}
+
+var File_events_proto protoreflect.FileDescriptor
+
+const file_events_proto_rawDesc = "" +
+	"\n" +
+	"\fevents.proto\x12\x06events\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\fdriver.proto\x1a\n" +
+	"trip.proto\"\xaf\x02\n" +
+	"\rEventEnvelope\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12$\n" +
+	"\rschemaVersion\x18\x03 \x01(\x05R\rschemaVersion\x12:\n" +
+	"\n" +
+	"occurredAt\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x1a\n" +
+	"\bproducer\x18\x05 \x01(\tR\bproducer\x12$\n" +
+	"\rcorrelationID\x18\x06 \x01(\tR\rcorrelationID\x12 \n" +
+	"\vcausationID\x18\a \x01(\tR\vcausationID\x12\x1a\n" +
+	"\bentityID\x18\b \x01(\tR\bentityID\x12\x18\n" +
+	"\apayload\x18\t \x01(\fR\apayload\"+\n" +
+	"\tTripEvent\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\"Q\n" +
+	"\x13DriverNotInterested\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\x12\x1a\n" +
+	"\bdriverID\x18\x02 \x01(\tR\bdriverID\"n\n" +
+	"\x12DriverTripResponse\x12&\n" +
+	"\x06driver\x18\x01 \x01(\v2\x0e.driver.DriverR\x06driver\x12\x18\n" +
+	"\ariderID\x18\x02 \x01(\tR\ariderID\x12\x16\n" +
+	"\x06tripID\x18\x03 \x01(\tR\x06tripID\"\x99\x01\n" +
+	"\x15DriverLocationUpdated\x12&\n" +
+	"\x06driver\x18\x01 \x01(\v2\x0e.driver.DriverR\x06driver\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\bR\tavailable\x12:\n" +
+	"\n" +
+	"recordedAt\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"recordedAt\"B\n" +
+	"\x0eDriverLocation\x120\n" +
+	"\adrivers\x18\x01 \x03(\v2\x16.events.DriverTrackingR\adrivers\"\x98\x01\n" +
+	"\x0eDriverTracking\x12&\n" +
+	"\x06driver\x18\x01 \x01(\v2\x0e.driver.DriverR\x06driver\x12\x16\n" +
+	"\x06tripID\x18\x02 \x01(\tR\x06tripID\x12&\n" +
+	"\x0edistanceMeters\x18\x03 \x01(\x01R\x0edistanceMeters\x12\x1e\n" +
+	"\n" +
+	"etaSeconds\x18\x04 \x01(\x01R\n" +
+	"etaSeconds\"x\n" +
+	"\fSurgeUpdated\x12\x18\n" +
+	"\ageohash\x18\x01 \x01(\tR\ageohash\x12\x1e\n" +
+	"\n" +
+	"multiplier\x18\x02 \x01(\x01R\n" +
+	"multiplier\x12\x16\n" +
+	"\x06demand\x18\x03 \x01(\x05R\x06demand\x12\x16\n" +
+	"\x06supply\x18\x04 \x01(\x05R\x06supply\"\x9b\x01\n" +
+	"\x15PaymentSessionCreated\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x1c\n" +
+	"\tsessionID\x18\x02 \x01(\tR\tsessionID\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x18\n" +
+	"\apurpose\x18\x05 \x01(\tR\apurpose\"\xb8\x01\n" +
+	"\x12PaymentTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x18\n" +
+	"\ariderID\x18\x02 \x01(\tR\ariderID\x12\x1a\n" +
+	"\bdriverID\x18\x03 \x01(\tR\bdriverID\x12 \n" +
+	"\vpackageSlug\x18\x04 \x01(\tR\vpackageSlug\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\"\xe7\x01\n" +
+	"\x13PaymentStatusUpdate\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x18\n" +
+	"\ariderID\x18\x02 \x01(\tR\ariderID\x12\x1a\n" +
+	"\bdriverID\x18\x03 \x01(\tR\bdriverID\x12\x1c\n" +
+	"\tsessionID\x18\x04 \x01(\tR\tsessionID\x12\x18\n" +
+	"\apurpose\x18\x05 \x01(\tR\apurpose\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x16\n" +
+	"\x06amount\x18\a \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\b \x01(\tR\bcurrency\"\xcd\x02\n" +
+	"\x0fPaymentRefunded\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x18\n" +
+	"\ariderID\x18\x02 \x01(\tR\ariderID\x12\x1a\n" +
+	"\bdriverID\x18\x03 \x01(\tR\bdriverID\x12\x1c\n" +
+	"\tpaymentID\x18\x04 \x01(\tR\tpaymentID\x12\x1a\n" +
+	"\brefundID\x18\x05 \x01(\tR\brefundID\x12\x18\n" +
+	"\apurpose\x18\x06 \x01(\tR\apurpose\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12\x16\n" +
+	"\x06amount\x18\b \x01(\x03R\x06amount\x12&\n" +
+	"\x0erefundedAmount\x18\t \x01(\x03R\x0erefundedAmount\x12\x1a\n" +
+	"\bcurrency\x18\n" +
+	" \x01(\tR\bcurrency\x12$\n" +
+	"\rpaymentStatus\x18\v \x01(\tR\rpaymentStatusB;Z9github.com/cprakhar/uber-clone/shared/proto/events;eventsb\x06proto3"
+
+var (
+	file_events_proto_rawDescOnce sync.Once
+	file_events_proto_rawDescData []byte
+)
+
+func file_events_proto_rawDescGZIP() []byte {
+	file_events_proto_rawDescOnce.Do(func() {
+		file_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_events_proto_rawDesc), len(file_events_proto_rawDesc)))
+	})
+	return file_events_proto_rawDescData
+}
+
+var file_events_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_events_proto_goTypes = []any{
+	(*EventEnvelope)(nil),         // 0: events.EventEnvelope
+	(*TripEvent)(nil),             // 1: events.TripEvent
+	(*DriverNotInterested)(nil),   // 2: events.DriverNotInterested
+	(*DriverTripResponse)(nil),    // 3: events.DriverTripResponse
+	(*DriverLocationUpdated)(nil), // 4: events.DriverLocationUpdated
+	(*DriverLocation)(nil),        // 5: events.DriverLocation
+	(*DriverTracking)(nil),        // 6: events.DriverTracking
+	(*SurgeUpdated)(nil),          // 7: events.SurgeUpdated
+	(*PaymentSessionCreated)(nil), // 8: events.PaymentSessionCreated
+	(*PaymentTripRequest)(nil),    // 9: events.PaymentTripRequest
+	(*PaymentStatusUpdate)(nil),   // 10: events.PaymentStatusUpdate
+	(*PaymentRefunded)(nil),       // 11: events.PaymentRefunded
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+	(*trip.Trip)(nil),             // 13: trip.Trip
+	(*driver.Driver)(nil),         // 14: driver.Driver
+}
+var file_events_proto_depIdxs = []int32{
+	12, // 0: events.EventEnvelope.occurredAt:type_name -> google.protobuf.Timestamp
+	13, // 1: events.TripEvent.trip:type_name -> trip.Trip
+	13, // 2: events.DriverNotInterested.trip:type_name -> trip.Trip
+	14, // 3: events.DriverTripResponse.driver:type_name -> driver.Driver
+	14, // 4: events.DriverLocationUpdated.driver:type_name -> driver.Driver
+	12, // 5: events.DriverLocationUpdated.recordedAt:type_name -> google.protobuf.Timestamp
+	6,  // 6: events.DriverLocation.drivers:type_name -> events.DriverTracking
+	14, // 7: events.DriverTracking.driver:type_name -> driver.Driver
+	8,  // [8:8] is the sub-list for method output_type
+	8,  // [8:8] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_events_proto_init() }
+func file_events_proto_init() {
+	if File_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_events_proto_rawDesc), len(file_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_events_proto_goTypes,
+		DependencyIndexes: file_events_proto_depIdxs,
+		MessageInfos:      file_events_proto_msgTypes,
+	}.Build()
+	File_events_proto = out.File
+	file_events_proto_goTypes = nil
+	file_events_proto_depIdxs = nil
+}
@@ -242,7 +242,9 @@ type RideFare struct {
 	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	RiderID          string                 `protobuf:"bytes,2,opt,name=riderID,proto3" json:"riderID,omitempty"`
 	PackageSlug      string                 `protobuf:"bytes,3,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
-	TotalFareInPaise float64                `protobuf:"fixed64,4,opt,name=totalFareInPaise,proto3" json:"totalFareInPaise,omitempty"`
+	TotalFareInPaise int64                  `protobuf:"varint,4,opt,name=totalFareInPaise,proto3" json:"totalFareInPaise,omitempty"`
+	SurgeMultiplier  float64                `protobuf:"fixed64,5,opt,name=surgeMultiplier,proto3" json:"surgeMultiplier,omitempty"`
+	ExpiresAt        string                 `protobuf:"bytes,6,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -298,13 +300,27 @@ func (x *RideFare) GetPackageSlug() string {
 	return ""
 }
 
-func (x *RideFare) GetTotalFareInPaise() float64 {
+func (x *RideFare) GetTotalFareInPaise() int64 {
 	if x != nil {
 		return x.TotalFareInPaise
 	}
 	return 0
 }
 
+func (x *RideFare) GetSurgeMultiplier() float64 {
+	if x != nil {
+		return x.SurgeMultiplier
+	}
+	return 0
+}
+
+func (x *RideFare) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
 type PreviewTripResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
@@ -425,6 +441,8 @@ type Trip struct {
 	Route         *Route                 `protobuf:"bytes,4,opt,name=route,proto3" json:"route,omitempty"`
 	SelectedFare  *RideFare              `protobuf:"bytes,5,opt,name=selectedFare,proto3" json:"selectedFare,omitempty"`
 	Driver        *TripDriver            `protobuf:"bytes,6,opt,name=driver,proto3" json:"driver,omitempty"`
+	Pickup        *Coordinate            `protobuf:"bytes,7,opt,name=pickup,proto3" json:"pickup,omitempty"`
+	FinalFare     *FinalFare             `protobuf:"bytes,8,opt,name=finalFare,proto3" json:"finalFare,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -501,6 +519,88 @@ func (x *Trip) GetDriver() *TripDriver {
 	return nil
 }
 
+func (x *Trip) GetPickup() *Coordinate {
+	if x != nil {
+		return x.Pickup
+	}
+	return nil
+}
+
+func (x *Trip) GetFinalFare() *FinalFare {
+	if x != nil {
+		return x.FinalFare
+	}
+	return nil
+}
+
+type FinalFare struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TotalFareInPaise int64                  `protobuf:"varint,1,opt,name=totalFareInPaise,proto3" json:"totalFareInPaise,omitempty"`
+	Currency         string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Distance         float64                `protobuf:"fixed64,3,opt,name=distance,proto3" json:"distance,omitempty"` // meters travelled
+	Duration         float64                `protobuf:"fixed64,4,opt,name=duration,proto3" json:"duration,omitempty"` // seconds travelled
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *FinalFare) Reset() {
+	*x = FinalFare{}
+	mi := &file_trip_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinalFare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalFare) ProtoMessage() {}
+
+func (x *FinalFare) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalFare.ProtoReflect.Descriptor instead.
+func (*FinalFare) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FinalFare) GetTotalFareInPaise() int64 {
+	if x != nil {
+		return x.TotalFareInPaise
+	}
+	return 0
+}
+
+func (x *FinalFare) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *FinalFare) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *FinalFare) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
 type CreateTripResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
@@ -511,7 +611,7 @@ type CreateTripResponse struct {
 
 func (x *CreateTripResponse) Reset() {
 	*x = CreateTripResponse{}
-	mi := &file_trip_proto_msgTypes[8]
+	mi := &file_trip_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -523,7 +623,7 @@ func (x *CreateTripResponse) String() string {
 func (*CreateTripResponse) ProtoMessage() {}
 
 func (x *CreateTripResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[8]
+	mi := &file_trip_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -536,7 +636,7 @@ func (x *CreateTripResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTripResponse.ProtoReflect.Descriptor instead.
 func (*CreateTripResponse) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{8}
+	return file_trip_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CreateTripResponse) GetTripID() string {
@@ -553,6 +653,230 @@ func (x *CreateTripResponse) GetTrip() *Trip {
 	return nil
 }
 
+type CancelTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	CancelledBy   string                 `protobuf:"bytes,2,opt,name=cancelledBy,proto3" json:"cancelledBy,omitempty"` // "rider" or "driver"
+	RequesterID   string                 `protobuf:"bytes,3,opt,name=requesterID,proto3" json:"requesterID,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTripRequest) Reset() {
+	*x = CancelTripRequest{}
+	mi := &file_trip_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTripRequest) ProtoMessage() {}
+
+func (x *CancelTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTripRequest.ProtoReflect.Descriptor instead.
+func (*CancelTripRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CancelTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *CancelTripRequest) GetCancelledBy() string {
+	if x != nil {
+		return x.CancelledBy
+	}
+	return ""
+}
+
+func (x *CancelTripRequest) GetRequesterID() string {
+	if x != nil {
+		return x.RequesterID
+	}
+	return ""
+}
+
+func (x *CancelTripRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CancelTripResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Trip                   *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	CancellationFeeInPaise int64                  `protobuf:"varint,2,opt,name=cancellationFeeInPaise,proto3" json:"cancellationFeeInPaise,omitempty"`
+	Currency               string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *CancelTripResponse) Reset() {
+	*x = CancelTripResponse{}
+	mi := &file_trip_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTripResponse) ProtoMessage() {}
+
+func (x *CancelTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTripResponse.ProtoReflect.Descriptor instead.
+func (*CancelTripResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CancelTripResponse) GetTrip() *Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+func (x *CancelTripResponse) GetCancellationFeeInPaise() int64 {
+	if x != nil {
+		return x.CancellationFeeInPaise
+	}
+	return 0
+}
+
+func (x *CancelTripResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type TripDriverActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,2,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripDriverActionRequest) Reset() {
+	*x = TripDriverActionRequest{}
+	mi := &file_trip_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripDriverActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripDriverActionRequest) ProtoMessage() {}
+
+func (x *TripDriverActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripDriverActionRequest.ProtoReflect.Descriptor instead.
+func (*TripDriverActionRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TripDriverActionRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *TripDriverActionRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+type TripDriverActionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripDriverActionResponse) Reset() {
+	*x = TripDriverActionResponse{}
+	mi := &file_trip_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripDriverActionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripDriverActionResponse) ProtoMessage() {}
+
+func (x *TripDriverActionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripDriverActionResponse.ProtoReflect.Descriptor instead.
+func (*TripDriverActionResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TripDriverActionResponse) GetTrip() *Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
 type TripDriver struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -565,7 +889,7 @@ type TripDriver struct {
 
 func (x *TripDriver) Reset() {
 	*x = TripDriver{}
-	mi := &file_trip_proto_msgTypes[9]
+	mi := &file_trip_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -577,7 +901,7 @@ func (x *TripDriver) String() string {
 func (*TripDriver) ProtoMessage() {}
 
 func (x *TripDriver) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[9]
+	mi := &file_trip_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -590,7 +914,7 @@ func (x *TripDriver) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TripDriver.ProtoReflect.Descriptor instead.
 func (*TripDriver) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{9}
+	return file_trip_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *TripDriver) GetId() string {
@@ -640,12 +964,14 @@ const file_trip_proto_rawDesc = "" +
 	"\x05Route\x12*\n" +
 	"\bgeometry\x18\x01 \x03(\v2\x0e.trip.GeometryR\bgeometry\x12\x1a\n" +
 	"\bdistance\x18\x02 \x01(\x01R\bdistance\x12\x1a\n" +
-	"\bduration\x18\x03 \x01(\x01R\bduration\"\x82\x01\n" +
+	"\bduration\x18\x03 \x01(\x01R\bduration\"\xca\x01\n" +
 	"\bRideFare\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
 	"\ariderID\x18\x02 \x01(\tR\ariderID\x12 \n" +
 	"\vpackageSlug\x18\x03 \x01(\tR\vpackageSlug\x12*\n" +
-	"\x10totalFareInPaise\x18\x04 \x01(\x01R\x10totalFareInPaise\"~\n" +
+	"\x10totalFareInPaise\x18\x04 \x01(\x03R\x10totalFareInPaise\x12(\n" +
+	"\x0fsurgeMultiplier\x18\x05 \x01(\x01R\x0fsurgeMultiplier\x12\x1c\n" +
+	"\texpiresAt\x18\x06 \x01(\tR\texpiresAt\"~\n" +
 	"\x13PreviewTripResponse\x12\x16\n" +
 	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12!\n" +
 	"\x05route\x18\x02 \x01(\v2\v.trip.RouteR\x05route\x12,\n" +
@@ -654,17 +980,40 @@ const file_trip_proto_rawDesc = "" +
 	"\n" +
 	"rideFareID\x18\x01 \x01(\tR\n" +
 	"rideFareID\x12\x18\n" +
-	"\ariderID\x18\x02 \x01(\tR\ariderID\"\xc9\x01\n" +
+	"\ariderID\x18\x02 \x01(\tR\ariderID\"\xa2\x02\n" +
 	"\x04Trip\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
 	"\ariderID\x18\x02 \x01(\tR\ariderID\x12\x16\n" +
 	"\x06status\x18\x03 \x01(\tR\x06status\x12!\n" +
 	"\x05route\x18\x04 \x01(\v2\v.trip.RouteR\x05route\x122\n" +
 	"\fselectedFare\x18\x05 \x01(\v2\x0e.trip.RideFareR\fselectedFare\x12(\n" +
-	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\"L\n" +
+	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\x12(\n" +
+	"\x06pickup\x18\a \x01(\v2\x10.trip.CoordinateR\x06pickup\x12-\n" +
+	"\tfinalFare\x18\b \x01(\v2\x0f.trip.FinalFareR\tfinalFare\"\x8b\x01\n" +
+	"\tFinalFare\x12*\n" +
+	"\x10totalFareInPaise\x18\x01 \x01(\x03R\x10totalFareInPaise\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x1a\n" +
+	"\bdistance\x18\x03 \x01(\x01R\bdistance\x12\x1a\n" +
+	"\bduration\x18\x04 \x01(\x01R\bduration\"L\n" +
 	"\x12CreateTripResponse\x12\x16\n" +
 	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x1e\n" +
 	"\x04trip\x18\x02 \x01(\v2\n" +
+	".trip.TripR\x04trip\"\x87\x01\n" +
+	"\x11CancelTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12 \n" +
+	"\vcancelledBy\x18\x02 \x01(\tR\vcancelledBy\x12 \n" +
+	"\vrequesterID\x18\x03 \x01(\tR\vrequesterID\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x88\x01\n" +
+	"\x12CancelTripResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\x126\n" +
+	"\x16cancellationFeeInPaise\x18\x02 \x01(\x03R\x16cancellationFeeInPaise\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\"M\n" +
+	"\x17TripDriverActionRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x1a\n" +
+	"\bdriverID\x18\x02 \x01(\tR\bdriverID\":\n" +
+	"\x18TripDriverActionResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
 	".trip.TripR\x04trip\"l\n" +
 	"\n" +
 	"TripDriver\x12\x0e\n" +
@@ -673,11 +1022,16 @@ const file_trip_proto_rawDesc = "" +
 	"\n" +
 	"profilePic\x18\x03 \x01(\tR\n" +
 	"profilePic\x12\x1a\n" +
-	"\bcarPlate\x18\x04 \x01(\tR\bcarPlate2\x92\x01\n" +
+	"\bcarPlate\x18\x04 \x01(\tR\bcarPlate2\xbe\x03\n" +
 	"\vTripService\x12B\n" +
 	"\vPreviewTrip\x12\x18.trip.PreviewTripRequest\x1a\x19.trip.PreviewTripResponse\x12?\n" +
 	"\n" +
-	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponseB\x18Z\x16shared/proto/trip;tripb\x06proto3"
+	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponse\x12?\n" +
+	"\n" +
+	"CancelTrip\x12\x17.trip.CancelTripRequest\x1a\x18.trip.CancelTripResponse\x12N\n" +
+	"\rDriverArrived\x12\x1d.trip.TripDriverActionRequest\x1a\x1e.trip.TripDriverActionResponse\x12J\n" +
+	"\tStartTrip\x12\x1d.trip.TripDriverActionRequest\x1a\x1e.trip.TripDriverActionResponse\x12M\n" +
+	"\fCompleteTrip\x12\x1d.trip.TripDriverActionRequest\x1a\x1e.trip.TripDriverActionResponseB7Z5github.com/cprakhar/uber-clone/shared/proto/trip;tripb\x06proto3"
 
 var (
 	file_trip_proto_rawDescOnce sync.Once
@@ -691,18 +1045,23 @@ func file_trip_proto_rawDescGZIP() []byte {
 	return file_trip_proto_rawDescData
 }
 
-var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_trip_proto_goTypes = []any{
-	(*Coordinate)(nil),          // 0: trip.Coordinate
-	(*PreviewTripRequest)(nil),  // 1: trip.PreviewTripRequest
-	(*Geometry)(nil),            // 2: trip.Geometry
-	(*Route)(nil),               // 3: trip.Route
-	(*RideFare)(nil),            // 4: trip.RideFare
-	(*PreviewTripResponse)(nil), // 5: trip.PreviewTripResponse
-	(*CreateTripRequest)(nil),   // 6: trip.CreateTripRequest
-	(*Trip)(nil),                // 7: trip.Trip
-	(*CreateTripResponse)(nil),  // 8: trip.CreateTripResponse
-	(*TripDriver)(nil),          // 9: trip.TripDriver
+	(*Coordinate)(nil),               // 0: trip.Coordinate
+	(*PreviewTripRequest)(nil),       // 1: trip.PreviewTripRequest
+	(*Geometry)(nil),                 // 2: trip.Geometry
+	(*Route)(nil),                    // 3: trip.Route
+	(*RideFare)(nil),                 // 4: trip.RideFare
+	(*PreviewTripResponse)(nil),      // 5: trip.PreviewTripResponse
+	(*CreateTripRequest)(nil),        // 6: trip.CreateTripRequest
+	(*Trip)(nil),                     // 7: trip.Trip
+	(*FinalFare)(nil),                // 8: trip.FinalFare
+	(*CreateTripResponse)(nil),       // 9: trip.CreateTripResponse
+	(*CancelTripRequest)(nil),        // 10: trip.CancelTripRequest
+	(*CancelTripResponse)(nil),       // 11: trip.CancelTripResponse
+	(*TripDriverActionRequest)(nil),  // 12: trip.TripDriverActionRequest
+	(*TripDriverActionResponse)(nil), // 13: trip.TripDriverActionResponse
+	(*TripDriver)(nil),               // 14: trip.TripDriver
 }
 var file_trip_proto_depIdxs = []int32{
 	0,  // 0: trip.PreviewTripRequest.pickup:type_name -> trip.Coordinate
@@ -713,17 +1072,29 @@ var file_trip_proto_depIdxs = []int32{
 	4,  // 5: trip.PreviewTripResponse.rideFares:type_name -> trip.RideFare
 	3,  // 6: trip.Trip.route:type_name -> trip.Route
 	4,  // 7: trip.Trip.selectedFare:type_name -> trip.RideFare
-	9,  // 8: trip.Trip.driver:type_name -> trip.TripDriver
-	7,  // 9: trip.CreateTripResponse.trip:type_name -> trip.Trip
-	1,  // 10: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
-	6,  // 11: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
-	5,  // 12: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
-	8,  // 13: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
-	12, // [12:14] is the sub-list for method output_type
-	10, // [10:12] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	14, // 8: trip.Trip.driver:type_name -> trip.TripDriver
+	0,  // 9: trip.Trip.pickup:type_name -> trip.Coordinate
+	8,  // 10: trip.Trip.finalFare:type_name -> trip.FinalFare
+	7,  // 11: trip.CreateTripResponse.trip:type_name -> trip.Trip
+	7,  // 12: trip.CancelTripResponse.trip:type_name -> trip.Trip
+	7,  // 13: trip.TripDriverActionResponse.trip:type_name -> trip.Trip
+	1,  // 14: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
+	6,  // 15: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
+	10, // 16: trip.TripService.CancelTrip:input_type -> trip.CancelTripRequest
+	12, // 17: trip.TripService.DriverArrived:input_type -> trip.TripDriverActionRequest
+	12, // 18: trip.TripService.StartTrip:input_type -> trip.TripDriverActionRequest
+	12, // 19: trip.TripService.CompleteTrip:input_type -> trip.TripDriverActionRequest
+	5,  // 20: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
+	9,  // 21: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
+	11, // 22: trip.TripService.CancelTrip:output_type -> trip.CancelTripResponse
+	13, // 23: trip.TripService.DriverArrived:output_type -> trip.TripDriverActionResponse
+	13, // 24: trip.TripService.StartTrip:output_type -> trip.TripDriverActionResponse
+	13, // 25: trip.TripService.CompleteTrip:output_type -> trip.TripDriverActionResponse
+	20, // [20:26] is the sub-list for method output_type
+	14, // [14:20] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_trip_proto_init() }
@@ -737,7 +1108,7 @@ func file_trip_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trip_proto_rawDesc), len(file_trip_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"math"
+
+	"github.com/cprakhar/uber-clone/shared/types"
+)
+
+const earthRadiusMeters = 6371000.0
+
+// HaversineDistance returns the great-circle distance in meters between two coordinates
+func HaversineDistance(a, b *types.Coordinate) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cprakhar/uber-clone/shared/types"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *types.Coordinate
+		want float64 // meters
+		tol  float64
+	}{
+		{name: "same point", a: &types.Coordinate{Latitude: 12.97, Longitude: 77.59}, b: &types.Coordinate{Latitude: 12.97, Longitude: 77.59}, want: 0, tol: 1e-9},
+		{name: "one degree of latitude", a: &types.Coordinate{Latitude: 0, Longitude: 0}, b: &types.Coordinate{Latitude: 1, Longitude: 0}, want: 111195, tol: 1},
+		{name: "one degree of longitude at the equator", a: &types.Coordinate{Latitude: 0, Longitude: 0}, b: &types.Coordinate{Latitude: 0, Longitude: 1}, want: 111195, tol: 1},
+		{name: "across the antimeridian", a: &types.Coordinate{Latitude: 0, Longitude: 179.5}, b: &types.Coordinate{Latitude: 0, Longitude: -179.5}, want: 111195, tol: 1},
+		{name: "bangalore to delhi", a: &types.Coordinate{Latitude: 12.9716, Longitude: 77.5946}, b: &types.Coordinate{Latitude: 28.6139, Longitude: 77.2090}, want: 1740000, tol: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HaversineDistance(tt.a, tt.b)
+			if math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("HaversineDistance() = %.1f, want %.1f ± %.1f", got, tt.want, tt.tol)
+			}
+			if reverse := HaversineDistance(tt.b, tt.a); math.Abs(reverse-got) > 1e-6 {
+				t.Errorf("distance back = %.1f, want %.1f", reverse, got)
+			}
+		})
+	}
+}
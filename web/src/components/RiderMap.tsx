@@ -119,8 +119,10 @@ export default function RiderMap({ onRouteSelected }: RiderMapProps) {
             return
         }
 
+        // A fare starts at most one trip, so retries of the same fare reuse its ID as the key
         const response = await fetch(`${API_URL}${BackendEndpoints.START_TRIP}`, {
             method: 'POST',
+            headers: { 'Idempotency-Key': `trip-start-${fare.id}` },
             body: JSON.stringify(payload),
         })
         const data = await response.json() as HTTPTripStartResponse
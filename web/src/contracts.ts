@@ -4,6 +4,7 @@ import { Coordinate, Driver, Route, RouteFare, Trip } from "./types";
 export enum BackendEndpoints {
   PREVIEW_TRIP = "/trip/preview",
   START_TRIP = "/trip/start",
+  CANCEL_TRIP = "/trip/cancel",
   WS_DRIVERS = "/drivers",
   WS_RIDERS = "/riders",
 }
@@ -11,15 +12,25 @@ export enum BackendEndpoints {
 export enum TripEvents {
   NoDriversFound = "trip.event.no_drivers_found",
   DriverAssigned = "trip.event.driver_assigned",
+  DriverArrived = "trip.event.driver_arrived",
+  Started = "trip.event.started",
   Completed = "trip.event.completed",
   Cancelled = "trip.event.cancelled",
+  Paid = "trip.event.paid",
+  PaymentFailed = "trip.event.payment_failed",
   Created = "trip.event.created",
   DriverLocation = "driver.cmd.location",
   DriverTripRequest = "driver.cmd.trip_request",
   DriverTripAccept = "driver.cmd.trip_accept",
   DriverTripDecline = "driver.cmd.trip_decline",
+  DriverTripCancel = "driver.cmd.trip_cancel",
+  DriverTripArrived = "driver.cmd.trip_arrived",
+  DriverTripStart = "driver.cmd.trip_start",
+  DriverTripComplete = "driver.cmd.trip_complete",
   DriverRegister = "driver.cmd.register",
+  DriverAvailability = "driver.cmd.availability",
   PaymentSessionCreated = "payment.event.session_created",
+  PaymentRefunded = "payment.event.refunded",
 }
 
 // Messages sent from the server to the client via the websocket
@@ -33,7 +44,7 @@ export type ServerWsMessage =
   | NoDriversFoundRequest;
 
 // Messages sent from the client to the server via the websocket
-export type ClientWsMessage = DriverResponseToTripResponse
+export type ClientWsMessage = DriverResponseToTripResponse | DriverTripCancelRequest | DriverTripActionRequest | DriverAvailabilityRequest
 
 interface TripCreatedRequest {
   type: TripEvents.Created;
@@ -84,6 +95,34 @@ interface DriverResponseToTripResponse {
   };
 }
 
+interface DriverTripCancelRequest {
+  type: TripEvents.DriverTripCancel;
+  data: {
+    tripID: string;
+    reason?: string;
+  };
+}
+
+interface DriverAvailabilityRequest {
+  type: TripEvents.DriverAvailability;
+  data: {
+    availability: "available" | "on_break" | "offline";
+  };
+}
+
+interface DriverTripActionRequest {
+  type: TripEvents.DriverTripArrived | TripEvents.DriverTripStart | TripEvents.DriverTripComplete;
+  data: {
+    tripID: string;
+  };
+}
+
+export interface HTTPTripCancelRequestPayload {
+  tripID: string;
+  riderID: string;
+  reason?: string;
+}
+
 export interface HTTPTripPreviewResponse {
   route: Route;
   rideFares: RouteFare[];
@@ -39,6 +39,7 @@ export const useDriverStreamConnection = ({
           data: {
             location,
             geohash,
+            timestamp: Date.now(),
           }
         }));
       }
@@ -6,6 +6,14 @@ export interface Trip {
     selectedFare: RouteFare;
     route: Route;
     driver?: Driver;
+    finalFare?: FinalFare;
+}
+
+export interface FinalFare {
+    totalFareInPaise: number;
+    currency: string;
+    distance: number;
+    duration: number;
 }
 
 export interface RequestRideProps {
@@ -38,6 +46,7 @@ export interface RouteFare {
     packageSlug: CarPackageSlug,
     basePrice: number,
     totalFareInPaise?: number,
+    surgeMultiplier?: number,
     expiresAt: Date,
     route: Route,
 }
@@ -63,4 +72,7 @@ export interface Driver {
     name: string;
     profilePic: string;
     carPlate: string;
+    tripID?: string;
+    distanceMeters?: number;
+    etaSeconds?: number;
 }